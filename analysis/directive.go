@@ -0,0 +1,141 @@
+package analysis
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cheekybits/genny/paths"
+)
+
+// Directive is a //go:generate line that runs genny.
+type Directive struct {
+	// File is the file containing the directive.
+	File string
+	// Line is the line number of the directive in File.
+	Line int
+	// Text is the directive as written.
+	Text string
+	// Flags are the flags given to genny, with $GOFILE expanded.
+	Flags map[string]string
+	// Command is the genny command, usually "gen".
+	Command string
+	// Types is the type set argument.
+	Types string
+}
+
+// In gets the path of the template, or "" if it is read from stdin.
+func (d Directive) In() string {
+	return d.path("in")
+}
+
+// Out gets the path of the output file, or "" for stdout.
+func (d Directive) Out() string {
+	return d.path("out")
+}
+
+// path gets the value of a file flag relative to the directive's file.
+func (d Directive) path(flag string) string {
+	return paths.Resolve(filepath.Dir(d.File), d.Flags[flag])
+}
+
+const directivePrefix = "//go:generate "
+
+// valueFlags are the genny flags that take a value, so may be given as
+// "-flag value" as well as "-flag=value".
+var valueFlags = map[string]bool{
+	"in":  true,
+	"out": true,
+	"pkg": true,
+}
+
+// FindDirectives gets the genny directives in the file.
+func FindDirectives(filename string) ([]Directive, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var directives []Directive
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if !strings.HasPrefix(text, directivePrefix) {
+			continue
+		}
+		d, ok := ParseDirective(filename, text)
+		if !ok {
+			continue
+		}
+		d.Line = line
+		directives = append(directives, d)
+	}
+	return directives, sc.Err()
+}
+
+// ParseDirective parses a //go:generate line found in filename. ok is false
+// if the line does not run genny.
+func ParseDirective(filename, text string) (d Directive, ok bool) {
+	args := splitArgs(strings.TrimPrefix(text, directivePrefix))
+	if len(args) == 0 || filepath.Base(args[0]) != "genny" {
+		return d, false
+	}
+	d = Directive{File: filename, Text: text, Flags: make(map[string]string)}
+	gofile := filepath.Base(filename)
+	args = args[1:]
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		name := strings.TrimLeft(args[0], "-")
+		value := "true"
+		if i := strings.Index(name, "="); i >= 0 {
+			name, value = name[:i], name[i+1:]
+		} else if valueFlags[name] && len(args) > 1 {
+			value = args[1]
+			args = args[1:]
+		}
+		d.Flags[name] = strings.Replace(value, "$GOFILE", gofile, -1)
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		d.Command = args[0]
+	}
+	if len(args) > 1 {
+		d.Types = args[len(args)-1]
+	}
+	return d, true
+}
+
+// splitArgs splits the line into words the way go generate does: words are
+// separated by spaces unless they are inside double quotes.
+func splitArgs(line string) []string {
+	var (
+		args    []string
+		current strings.Builder
+		quoted  bool
+		inWord  bool
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && quoted && i+1 < len(line):
+			i++
+			current.WriteByte(line[i])
+		case c == '"':
+			quoted = !quoted
+			inWord = true
+		case (c == ' ' || c == '\t') && !quoted:
+			if inWord {
+				args = append(args, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, current.String())
+	}
+	return args
+}
@@ -0,0 +1,37 @@
+package analysis_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/analysis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDirective(t *testing.T) {
+
+	d, ok := analysis.ParseDirective(filepath.Join("pkg", "queue.go"), `//go:generate genny -in=$GOFILE -out gen-$GOFILE -strict gen "KeyType=string,int ValueType=string,int"`)
+	if assert.True(t, ok) {
+		assert.Equal(t, "queue.go", d.Flags["in"])
+		assert.Equal(t, "gen-queue.go", d.Flags["out"])
+		assert.Equal(t, "true", d.Flags["strict"])
+		assert.Equal(t, filepath.Join("pkg", "queue.go"), d.In())
+		assert.Equal(t, filepath.Join("pkg", "gen-queue.go"), d.Out())
+		assert.Equal(t, "gen", d.Command)
+		assert.Equal(t, "KeyType=string,int ValueType=string,int", d.Types)
+	}
+
+	_, ok = analysis.ParseDirective("queue.go", "//go:generate stringer -type=Pill")
+	assert.False(t, ok)
+
+}
+
+func TestFindDirectives(t *testing.T) {
+
+	ds, err := analysis.FindDirectives("test/unused/generic_queue.go")
+	if assert.NoError(t, err) && assert.Len(t, ds, 1) {
+		assert.Equal(t, 5, ds[0].Line)
+		assert.Equal(t, "Something=int,string", ds[0].Types)
+	}
+
+}
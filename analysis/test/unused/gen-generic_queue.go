@@ -0,0 +1,23 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package unused
+
+// IntQueue is a queue of Ints.
+type IntQueue struct {
+	items []int
+}
+
+func NewIntQueue() *IntQueue {
+	return &IntQueue{items: make([]int, 0)}
+}
+
+// StringQueue is a queue of Strings.
+type StringQueue struct {
+	items []string
+}
+
+func NewStringQueue() *StringQueue {
+	return &StringQueue{items: make([]string, 0)}
+}
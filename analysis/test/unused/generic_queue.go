@@ -0,0 +1,16 @@
+package unused
+
+import "github.com/cheekybits/genny/generic"
+
+//go:generate genny -in=$GOFILE -out=gen-$GOFILE gen "Something=int,string"
+
+type Something generic.Type
+
+// SomethingQueue is a queue of Somethings.
+type SomethingQueue struct {
+	items []Something
+}
+
+func NewSomethingQueue() *SomethingQueue {
+	return &SomethingQueue{items: make([]Something, 0)}
+}
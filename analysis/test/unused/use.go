@@ -0,0 +1,4 @@
+package unused
+
+// Ints is the only queue in use.
+var Ints = NewIntQueue()
@@ -0,0 +1,347 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/cheekybits/genny/parse"
+	"github.com/cheekybits/genny/paths"
+	"golang.org/x/tools/go/packages"
+)
+
+// UnusedTypeSet is a type set of a genny directive whose generated code is
+// never referenced outside of the file it was generated into.
+type UnusedTypeSet struct {
+	Directive Directive
+	TypeSet   map[string]string
+	// Names are the top level names generated for the type set.
+	Names []string
+}
+
+// UnusedFile is a genny generated file none of whose top level names are
+// referenced from elsewhere, and which no directive could be found for.
+type UnusedFile struct {
+	File  string
+	Names []string
+}
+
+// Unused is the result of FindUnused.
+type Unused struct {
+	TypeSets []UnusedTypeSet
+	Files    []UnusedFile
+}
+
+// generatedFile is a genny generated file and the top level names it
+// declares.
+type generatedFile struct {
+	pkgPath string
+	names   map[string]bool
+	used    map[string]bool
+}
+
+// FindUnused loads the packages matching the patterns (relative to dir)
+// and finds the genny instantiations that are never used. Files inside dir
+// are reported relative to it. Files ignored by the .gennyignore file in dir
+// are skipped.
+func FindUnused(dir string, patterns ...string) (*Unused, error) {
+	cfg := &packages.Config{Mode: packages.LoadFiles, Dir: dir, Tests: true}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	ignore, err := paths.LoadIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	files := make(map[string]*ast.File)
+	filePkg := make(map[string]string)
+	generated := make(map[string]*generatedFile)
+	for _, pkg := range pkgs {
+		pkgPath := strings.TrimSuffix(pkg.PkgPath, "_test")
+		for _, filename := range pkg.GoFiles {
+			if rel, err := filepath.Rel(base, filename); err == nil && !strings.HasPrefix(rel, "..") {
+				filename = filepath.Join(dir, rel)
+			}
+			if _, ok := files[filename]; ok || ignore.Match(filename, false) {
+				continue
+			}
+			src, err := ioutil.ReadFile(filename)
+			if err != nil {
+				return nil, err
+			}
+			file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			files[filename] = file
+			filePkg[filename] = pkg.PkgPath
+			if out.IsGenerated(src) {
+				generated[filename] = &generatedFile{pkgPath: pkgPath, names: topLevelNames(file), used: make(map[string]bool)}
+			}
+		}
+	}
+
+	// find every reference to a generated name from outside its file
+	byPkg := make(map[string][]*generatedFile)
+	for _, g := range generated {
+		byPkg[g.pkgPath] = append(byPkg[g.pkgPath], g)
+	}
+	for filename, file := range files {
+		imports := make(map[string]string)
+		for _, spec := range file.Imports {
+			path, _ := strconv.Unquote(spec.Path.Value)
+			name := filepath.Base(path)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			imports[name] = path
+		}
+		self := generated[filename]
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch t := n.(type) {
+			case *ast.SelectorExpr:
+				if x, ok := t.X.(*ast.Ident); ok {
+					if path, ok := imports[x.Name]; ok {
+						markUsed(byPkg[path], nil, t.Sel.Name)
+						return false
+					}
+				}
+			case *ast.Ident:
+				markUsed(byPkg[filePkg[filename]], self, t.Name)
+			}
+			return true
+		})
+	}
+
+	// attribute the unused names to the directives that generate them
+	unused := &Unused{}
+	explained := make(map[string]bool)
+	var dirs []string
+	seenDir := make(map[string]bool)
+	for filename := range files {
+		if dir := filepath.Dir(filename); !seenDir[dir] {
+			seenDir[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		directives, err := dirDirectives(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range directives {
+			g, ok := generated[d.Out()]
+			if !ok {
+				continue
+			}
+			explained[d.Out()] = true
+			sets, err := unusedTypeSets(d, g)
+			if err != nil {
+				return nil, err
+			}
+			unused.TypeSets = append(unused.TypeSets, sets...)
+		}
+	}
+	var filenames []string
+	for filename := range generated {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	for _, filename := range filenames {
+		g := generated[filename]
+		if explained[filename] || len(g.used) > 0 || len(g.names) == 0 {
+			continue
+		}
+		unused.Files = append(unused.Files, UnusedFile{File: filename, Names: sortedNames(g.names)})
+	}
+	return unused, nil
+}
+
+// markUsed marks name as used in the generated files (other than self)
+// that declare it.
+func markUsed(files []*generatedFile, self *generatedFile, name string) {
+	for _, g := range files {
+		if g != self && g.names[name] {
+			g.used[name] = true
+		}
+	}
+}
+
+// unusedTypeSets regenerates each type set of the directive on its own to
+// find the ones whose names are never used.
+func unusedTypeSets(d Directive, g *generatedFile) ([]UnusedTypeSet, error) {
+	typeSets, err := parse.TypeSet(d.Types)
+	if err != nil {
+		return nil, err
+	}
+	src, err := ioutil.ReadFile(d.In())
+	if err != nil {
+		return nil, err
+	}
+	var unused []UnusedTypeSet
+	for _, typeSet := range typeSets {
+		code, err := parse.Generics(d.In(), d.Out(), d.Flags["pkg"], bytes.NewReader(src), []map[string]string{typeSet})
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), d.Out(), code, 0)
+		if err != nil {
+			return nil, err
+		}
+		names := topLevelNames(file)
+		used := false
+		for name := range names {
+			if g.used[name] {
+				used = true
+				break
+			}
+		}
+		if !used && len(names) > 0 {
+			unused = append(unused, UnusedTypeSet{Directive: d, TypeSet: typeSet, Names: sortedNames(names)})
+		}
+	}
+	return unused, nil
+}
+
+// dirDirectives gets the genny directives in the .go files in dir.
+func dirDirectives(dir string) ([]Directive, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var directives []Directive
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") {
+			continue
+		}
+		ds, err := FindDirectives(filepath.Join(dir, info.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		directives = append(directives, ds...)
+	}
+	return directives, nil
+}
+
+// topLevelNames gets the names of the top level types, functions,
+// variables and constants declared in the file.
+func topLevelNames(file *ast.File) map[string]bool {
+	names := make(map[string]bool)
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				names[d.Name.Name] = true
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names[s.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.Name != "_" {
+							names[name.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+func sortedNames(names map[string]bool) []string {
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Write writes a human readable report of the unused instantiations,
+// suggesting new type arguments for the directives where possible.
+func (u *Unused) Write(w io.Writer) {
+	if len(u.TypeSets) == 0 && len(u.Files) == 0 {
+		fmt.Fprintln(w, "no unused instantiations found")
+		return
+	}
+	var last *Directive
+	var unusedSets []map[string]string
+	flush := func() {
+		if last == nil {
+			return
+		}
+		if suggestion, ok := suggestTypes(last.Types, unusedSets); ok {
+			fmt.Fprintf(w, "\tsuggestion: gen %q\n", suggestion)
+		}
+		unusedSets = nil
+	}
+	for i, set := range u.TypeSets {
+		d := set.Directive
+		if last == nil || last.File != d.File || last.Line != d.Line {
+			flush()
+			last = &u.TypeSets[i].Directive
+			fmt.Fprintf(w, "%s:%d: %s\n", d.File, d.Line, d.Text)
+		}
+		unusedSets = append(unusedSets, set.TypeSet)
+		fmt.Fprintf(w, "\ttype set %s is never used (%s)\n", typeSetString(set.TypeSet), strings.Join(set.Names, ", "))
+	}
+	flush()
+	for _, f := range u.Files {
+		fmt.Fprintf(w, "%s: generated file is never used (%s)\n", f.File, strings.Join(f.Names, ", "))
+	}
+}
+
+// suggestTypes suggests the type argument without the unused type sets.
+// This is only possible for directives with a single generic type.
+func suggestTypes(types string, unused []map[string]string) (string, bool) {
+	pair := strings.SplitN(types, "=", 2)
+	if len(pair) != 2 || strings.Contains(pair[1], " ") {
+		return "", false
+	}
+	drop := make(map[string]bool)
+	for _, set := range unused {
+		drop[set[pair[0]]] = true
+	}
+	var keep []string
+	for _, v := range strings.Split(pair[1], ",") {
+		if !drop[v] {
+			keep = append(keep, v)
+		}
+	}
+	if len(keep) == 0 {
+		return "", false
+	}
+	return pair[0] + "=" + strings.Join(keep, ","), true
+}
+
+// typeSetString gets the type set as sorted Generic=Specific pairs.
+func typeSetString(typeSet map[string]string) string {
+	var pairs []string
+	for k, v := range typeSet {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,43 @@
+package analysis_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/analysis"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindUnused(t *testing.T) {
+
+	unused, err := analysis.FindUnused(".", "./test/unused")
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, unused.TypeSets, 1) {
+		set := unused.TypeSets[0]
+		assert.Equal(t, map[string]string{"Something": "string"}, set.TypeSet)
+		assert.Equal(t, []string{"NewStringQueue", "StringQueue"}, set.Names)
+		assert.Equal(t, "gen-generic_queue.go", filepath.Base(set.Directive.Out()))
+	}
+	assert.Empty(t, unused.Files)
+
+	var buf bytes.Buffer
+	unused.Write(&buf)
+	assert.Contains(t, buf.String(), "type set Something=string is never used (NewStringQueue, StringQueue)")
+	assert.Contains(t, buf.String(), `suggestion: gen "Something=int"`)
+
+}
+
+func TestFindUnusedIgnore(t *testing.T) {
+
+	// test/.gennyignore ignores the generated files of the fixture
+	unused, err := analysis.FindUnused("test", "./unused")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, unused.TypeSets)
+	assert.Empty(t, unused.Files)
+
+}
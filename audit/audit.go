@@ -0,0 +1,346 @@
+// Package audit finds go:generate directives that run genny with
+// parameters that no longer match their templates, such as a template that
+// has gained a generic type since the directive was written.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cheekybits/genny/config"
+	"github.com/cheekybits/genny/parse"
+	"github.com/cheekybits/genny/paths"
+)
+
+// directivePrefix starts the go:generate lines that run genny.
+const directivePrefix = "//go:generate genny "
+
+// Directive is a go:generate line that runs genny.
+type Directive struct {
+	Pos token.Position
+	// Text is the line as written.
+	Text string
+	// Args are genny's arguments, split and expanded as go generate does.
+	Args []string
+}
+
+// Finding is a directive that needs updating.
+type Finding struct {
+	Directive Directive
+	// Problem describes what no longer matches.
+	Problem string
+}
+
+// Audit checks every genny directive in the Go files of dir and the
+// directories below it against the templates it generates, and the entries
+// of the config files run by genny build directives. boolFlags are the
+// names of genny's flags that take no value, to tell flags from arguments.
+// Directives that read stdin or the online library cannot be checked.
+func Audit(dir string, boolFlags map[string]bool) ([]Finding, error) {
+	directives, err := Find(dir)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, d := range directives {
+		for _, problem := range check(d, boolFlags) {
+			findings = append(findings, Finding{Directive: d, Problem: problem})
+		}
+	}
+	return findings, nil
+}
+
+// Find finds the genny directives in the Go files of dir and the
+// directories below it, skipping testdata, vendor and hidden directories.
+func Find(dir string) ([]Directive, error) {
+	var directives []Directive
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if p != dir && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(p, ".go") {
+			return nil
+		}
+		found, err := findInFile(p)
+		directives = append(directives, found...)
+		return err
+	})
+	return directives, err
+}
+
+// findInFile finds the genny directives in the Go file.
+func findInFile(filename string) ([]Directive, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(src, []byte(directivePrefix)) {
+		return nil, nil
+	}
+	pkg := ""
+	if file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.PackageClauseOnly); err == nil {
+		pkg = file.Name.Name
+	}
+	var directives []Directive
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if !strings.HasPrefix(line, directivePrefix) {
+			continue
+		}
+		env := map[string]string{
+			"GOFILE":    filepath.Base(filename),
+			"GOPACKAGE": pkg,
+			"GOLINE":    strconv.Itoa(n),
+			"DOLLAR":    "$",
+		}
+		args, err := Split(strings.TrimPrefix(line, directivePrefix), func(name string) string {
+			if v, ok := env[name]; ok {
+				return v
+			}
+			return os.Getenv(name)
+		})
+		d := Directive{Pos: token.Position{Filename: filename, Line: n, Column: 1}, Text: line, Args: args}
+		if err != nil {
+			d.Args = nil
+		}
+		directives = append(directives, d)
+	}
+	return directives, sc.Err()
+}
+
+// Split splits the arguments of a go:generate line as go generate does:
+// environment variables are expanded with env, and then the line is split
+// at spaces, except within double quoted strings, which are unquoted.
+func Split(line string, env func(string) string) ([]string, error) {
+	line = os.Expand(line, env)
+	var args []string
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return args, nil
+		}
+		if line[0] == '"' {
+			end := 1
+			for ; end < len(line); end++ {
+				if line[end] == '\\' {
+					end++
+				} else if line[end] == '"' {
+					break
+				}
+			}
+			if end >= len(line) {
+				return nil, fmt.Errorf("unterminated quoted string in %s", line)
+			}
+			arg, err := strconv.Unquote(line[:end+1])
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			line = line[end+1:]
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		args = append(args, line[:end])
+		line = line[end:]
+	}
+}
+
+// invocation is what a directive runs: the command, its arguments and the
+// flags given.
+type invocation struct {
+	Command string
+	Args    []string
+	Flags   map[string]string
+}
+
+// parseInvocation tells the flags of the directive from its arguments, as
+// genny does.
+func parseInvocation(args []string, boolFlags map[string]bool) invocation {
+	inv := invocation{Flags: make(map[string]string)}
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = append(positional, arg)
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		value := "true"
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value = name[:eq], name[eq+1:]
+		} else if !boolFlags[name] && i+1 < len(args) {
+			i++
+			value = args[i]
+		}
+		inv.Flags[name] = value
+	}
+	if len(positional) > 0 {
+		inv.Command, inv.Args = strings.ToLower(positional[0]), positional[1:]
+	}
+	return inv
+}
+
+// check gets the problems with the directive.
+func check(d Directive, boolFlags map[string]bool) []string {
+	if d.Args == nil {
+		return []string{"its arguments cannot be split (check the quotes)"}
+	}
+	dir := filepath.Dir(d.Pos.Filename)
+	inv := parseInvocation(d.Args, boolFlags)
+	switch inv.Command {
+	case "gen", "verify", "vet", "run":
+		if inv.Flags["in"] == "" || len(inv.Args) == 0 {
+			return nil
+		}
+		return checkTemplate(dir, inv.Flags["in"], inv.Args[0])
+	case "get":
+		if len(inv.Args) < 2 {
+			return nil
+		}
+		if _, err := os.Stat(paths.Resolve(dir, inv.Args[0])); err != nil {
+			// the online library
+			return nil
+		}
+		return checkTemplate(dir, inv.Args[0], inv.Args[1])
+	case "build":
+		filename := config.DefaultFilename
+		if len(inv.Args) > 0 {
+			filename = inv.Args[0]
+		}
+		if !config.IsPattern(filename) {
+			return checkConfig(paths.Resolve(dir, filename))
+		}
+		filenames, err := config.Discover(paths.Resolve(dir, config.PatternDir(filename)), nil)
+		if err != nil {
+			return []string{err.Error()}
+		}
+		var problems []string
+		for _, filename := range filenames {
+			problems = append(problems, checkConfig(filename)...)
+		}
+		return problems
+	}
+	return nil
+}
+
+// checkConfig gets the problems with the entries of the config file.
+func checkConfig(filename string) []string {
+	c, err := config.Load(filename)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	var problems []string
+	for _, e := range c.Entries {
+		for _, problem := range checkTemplate(c.Dir, e.Template, e.Types) {
+			problems = append(problems, filepath.Base(filename)+" entry "+e.Name+": "+problem)
+		}
+	}
+	return problems
+}
+
+// checkTemplate gets the problems with generating the template, relative
+// to dir, with the types.
+func checkTemplate(dir, template, types string) []string {
+	name, src, err := paths.ReadTemplate(dir, template)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	declared, err := genericTypes(name, src)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	typeSets, err := parse.TypeSet(types)
+	if err != nil {
+		return []string{fmt.Sprintf("the types %q are invalid: %s", types, err)}
+	}
+	given := make(map[string]bool)
+	for _, typeSet := range typeSets {
+		for generic := range typeSet {
+			given[generic] = true
+		}
+	}
+	var problems []string
+	for _, generic := range declared {
+		if !given[generic] {
+			problems = append(problems, fmt.Sprintf("%s declares the generic type %s, which the directive gives no type for", template, generic))
+		}
+	}
+	var unknown []string
+	for generic := range given {
+		if !contains(declared, generic) {
+			unknown = append(unknown, generic)
+		}
+	}
+	sort.Strings(unknown)
+	for _, generic := range unknown {
+		problems = append(problems, fmt.Sprintf("%s does not declare the generic type %s that the directive gives a type for (it declares %s)", template, generic, strings.Join(declared, ", ")))
+	}
+	return problems
+}
+
+// genericTypes gets the generic types the template declares, in order.
+func genericTypes(filename string, src []byte) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	var generics []string
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if sel, ok := ts.Type.(*ast.SelectorExpr); ok {
+				if x, ok := sel.X.(*ast.Ident); ok && x.Name == "generic" {
+					generics = append(generics, ts.Name.Name)
+				}
+			}
+		}
+	}
+	return generics, nil
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes each finding with where its directive is, followed by the
+// directive itself.
+func Write(w io.Writer, findings []Finding) {
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s: %s\n\t%s\n", f.Directive.Pos, f.Problem, f.Directive.Text)
+	}
+}
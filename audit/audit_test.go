@@ -0,0 +1,137 @@
+package audit_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/audit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const template = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Key generic.Type
+
+type Value generic.Type
+
+type KeyValueMap map[Key]Value
+`
+
+func write(t *testing.T, dir string, files map[string]string) {
+	for name, src := range files {
+		filename := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		require.NoError(t, ioutil.WriteFile(filename, []byte(src), 0644))
+	}
+}
+
+func TestAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write(t, dir, map[string]string{
+		"queue.go": template,
+		"current.go": "package queue\n\n" +
+			"//go:generate genny -in=queue.go -out=gen-$GOFILE gen \"Key=string Value=int,bool\"\n",
+		"stale.go": "package queue\n\n" +
+			"//go:generate genny -in queue.go -out=gen-$GOFILE gen \"Key=string Item=int\"\n",
+		"vendor/v.go": "package v\n\n" +
+			"//go:generate genny -in=missing.go gen \"Key=string\"\n",
+	})
+
+	findings, err := audit.Audit(dir, nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 2)
+	for _, f := range findings {
+		assert.Equal(t, filepath.Join(dir, "stale.go"), f.Directive.Pos.Filename)
+		assert.Equal(t, 3, f.Directive.Pos.Line)
+	}
+	assert.Equal(t, "queue.go declares the generic type Value, which the directive gives no type for", findings[0].Problem)
+	assert.Equal(t, "queue.go does not declare the generic type Item that the directive gives a type for (it declares Key, Value)", findings[1].Problem)
+
+	var buf bytes.Buffer
+	audit.Write(&buf, findings[:1])
+	assert.Equal(t, filepath.Join(dir, "stale.go")+":3:1: "+findings[0].Problem+"\n\t"+findings[0].Directive.Text+"\n", buf.String())
+}
+
+func TestAuditMissingTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write(t, dir, map[string]string{
+		"use.go": "package queue\n\n//go:generate genny -in=queue.go gen \"Key=string\"\n",
+	})
+
+	findings, err := audit.Audit(dir, nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Contains(t, findings[0].Problem, "queue.go")
+}
+
+func TestAuditBoolFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write(t, dir, map[string]string{
+		"queue.go": template,
+		"use.go":   "package queue\n\n//go:generate genny -force -in=queue.go gen \"Key=string\"\n",
+	})
+
+	// without -force known to be a bool flag, gen is taken as its value
+	findings, err := audit.Audit(dir, nil)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	findings, err = audit.Audit(dir, map[string]bool{"force": true})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Contains(t, findings[0].Problem, "Value")
+}
+
+func TestAuditBuild(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-audit")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	write(t, dir, map[string]string{
+		"queue.go":   template,
+		"genny.json": `{"entries": [{"name": "maps", "template": "queue.go", "out": "gen.go", "types": "Key=string"}]}`,
+		"gen.go":     "package queue\n\n//go:generate genny build\n",
+	})
+
+	findings, err := audit.Audit(dir, nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "genny.json entry maps: queue.go declares the generic type Value, which the directive gives no type for", findings[0].Problem)
+}
+
+func TestAuditBuildTree(t *testing.T) {
+	dir := t.TempDir()
+	write(t, dir, map[string]string{
+		"maps/queue.go":   template,
+		"maps/genny.json": `{"entries": [{"name": "maps", "template": "queue.go", "out": "gen.go", "types": "Key=string"}]}`,
+		"gen.go":          "package root\n\n//go:generate genny build ./...\n",
+	})
+
+	findings, err := audit.Audit(dir, nil)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "genny.json entry maps: queue.go declares the generic type Value, which the directive gives no type for", findings[0].Problem)
+}
+
+func TestSplit(t *testing.T) {
+	env := func(name string) string {
+		return map[string]string{"GOFILE": "queue.go", "DOLLAR": "$"}[name]
+	}
+	args, err := audit.Split(`-in=$GOFILE gen "Key=string Value=int" "a\"b" $DOLLAR`, env)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"-in=queue.go", "gen", "Key=string Value=int", `a"b`, "$"}, args)
+
+	_, err = audit.Split(`gen "Key=string`, env)
+	assert.Error(t, err)
+}
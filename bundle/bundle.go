@@ -0,0 +1,166 @@
+// Package bundle packages a directory of templates into a versioned
+// archive, which genny reads templates from like any other template
+// archive (archive.tar.gz#template.go).
+//
+// Besides the files of the directory, a bundle holds a manifest
+// (genny-bundle.json) listing its name, version and templates with their
+// generic types and metadata, and a SHA256SUMS file with the checksum of
+// every other file, in the format of sha256sum. Bundles are reproducible:
+// the same files and version always give the same archive.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cheekybits/genny/docs"
+	"github.com/cheekybits/genny/out"
+	"github.com/cheekybits/genny/paths"
+)
+
+// ManifestFilename is the name of the manifest in a bundle.
+const ManifestFilename = "genny-bundle.json"
+
+// Manifest describes a bundle.
+type Manifest struct {
+	Name      string     `json:"name"`
+	Version   string     `json:"version"`
+	Templates []Template `json:"templates"`
+}
+
+// Template is a template in a bundle.
+type Template struct {
+	// Path is the slash separated path of the template in the bundle.
+	Path    string `json:"path"`
+	Package string `json:"package"`
+	// Params are the names of the generic types.
+	Params []string `json:"params"`
+	// Meta holds the //genny:<key> <value> metadata directives, as
+	// "<key> <value>".
+	Meta []string `json:"meta,omitempty"`
+}
+
+// Create writes a bundle of the templates in dir, as a gzipped tar archive,
+// to w. It bundles every file in dir and its subdirectories except tests,
+// genny generated files, hidden files and the files .gennyignore ignores.
+// Every template must generate its example (see docs.Load).
+func Create(w io.Writer, dir, name, version string) (*Manifest, error) {
+	files, err := collect(dir)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{Name: name, Version: version, Templates: []Template{}}
+	var names []string
+	for n := range files {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if !strings.HasSuffix(n, ".go") || !paths.IsTemplate(files[n], nil) {
+			continue
+		}
+		t, err := docs.Load(filepath.Join(dir, filepath.FromSlash(n)))
+		if err != nil {
+			return nil, err
+		}
+		bt := Template{Path: n, Package: t.Package}
+		for _, p := range t.Params {
+			bt.Params = append(bt.Params, p.Name)
+		}
+		for _, meta := range t.Meta {
+			bt.Meta = append(bt.Meta, meta.Key+" "+meta.Value)
+		}
+		m.Templates = append(m.Templates, bt)
+	}
+	if len(m.Templates) == 0 {
+		return nil, &errNoTemplates{Dir: dir}
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files[ManifestFilename] = append(manifest, '\n')
+	names = append(names, ManifestFilename)
+	sort.Strings(names)
+	var sums bytes.Buffer
+	for _, n := range names {
+		fmt.Fprintf(&sums, "%x  %s\n", sha256.Sum256(files[n]), n)
+	}
+	files[paths.ChecksumsFilename] = sums.Bytes()
+	names = append(names, paths.ChecksumsFilename)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, n := range names {
+		h := &tar.Header{
+			Name:     n,
+			Mode:     0644,
+			Size:     int64(len(files[n])),
+			ModTime:  time.Unix(0, 0),
+			Typeflag: tar.TypeReg,
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[n]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	return m, gz.Close()
+}
+
+// collect reads the files to bundle, by their slash separated paths
+// relative to dir.
+func collect(dir string) (map[string][]byte, error) {
+	ignore, err := paths.LoadIgnore(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && (strings.HasPrefix(info.Name(), ".") || ignore.Match(path, info.IsDir())) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		src, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.HasSuffix(path, ".go") && out.IsGenerated(src) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); rel == ManifestFilename || rel == paths.ChecksumsFilename {
+			return nil
+		}
+		files[rel] = src
+		return nil
+	})
+	return files, err
+}
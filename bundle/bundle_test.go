@@ -0,0 +1,107 @@
+package bundle_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/bundle"
+	"github.com/cheekybits/genny/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+const template = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+// Item is the type of the items in the queue.
+type Item generic.Type
+
+//genny:owner storage-team
+
+// ItemQueue is a queue of Items.
+type ItemQueue struct {
+	items []Item
+}
+`
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filename), 0755))
+		assert.NoError(t, ioutil.WriteFile(filename, []byte(content), 0644))
+	}
+}
+
+// members lists the files in the bundle.
+func members(t *testing.T, b []byte) []string {
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	if !assert.NoError(t, err) {
+		return nil
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		if !assert.NoError(t, err) {
+			return nil
+		}
+		names = append(names, h.Name)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"queue/generic_queue.go":      template,
+		"queue/generic_queue_test.go": "package queue\n",
+		"queue/gen_queue.go":          "// This file was automatically generated by genny.\npackage queue\n",
+		"queue/helpers.go":            "package queue\n",
+		"experimental/generic_x.go":   template,
+		".genny/backup/gen_queue.go":  "package queue\n",
+		"LICENSE":                     "MIT\n",
+		paths.IgnoreFilename:          "experimental/\n",
+	})
+
+	var buf bytes.Buffer
+	m, err := bundle.Create(&buf, dir, "queues", "v1.2.0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &bundle.Manifest{Name: "queues", Version: "v1.2.0", Templates: []bundle.Template{
+		{Path: "queue/generic_queue.go", Package: "queue", Params: []string{"Item"}, Meta: []string{"owner storage-team"}},
+	}}, m)
+	assert.Equal(t, []string{"LICENSE", bundle.ManifestFilename, "queue/generic_queue.go", "queue/helpers.go", paths.ChecksumsFilename}, members(t, buf.Bytes()))
+
+	// bundles are reproducible
+	var again bytes.Buffer
+	_, err = bundle.Create(&again, dir, "queues", "v1.2.0")
+	assert.NoError(t, err)
+	assert.Equal(t, buf.Bytes(), again.Bytes())
+
+	// and can be generated from
+	archive := filepath.Join(dir, "queues-v1.2.0.tar.gz")
+	assert.NoError(t, ioutil.WriteFile(archive, buf.Bytes(), 0644))
+	name, src, err := paths.ReadTemplate(dir, "queues-v1.2.0.tar.gz")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "queues-v1.2.0.tar.gz#queue/generic_queue.go", name)
+		assert.Equal(t, template, string(src))
+	}
+}
+
+func TestCreateWithoutTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"queue.go": "package queue\n"})
+	_, err := bundle.Create(ioutil.Discard, dir, "queues", "v1.0.0")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no genny templates to bundle")
+	}
+}
@@ -0,0 +1,12 @@
+package bundle
+
+// errNoTemplates represents an error when a directory has no templates to
+// bundle.
+type errNoTemplates struct {
+	Dir string
+}
+
+// Error gets a human readable string describing this error.
+func (e errNoTemplates) Error() string {
+	return e.Dir + ": no genny templates to bundle"
+}
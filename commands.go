@@ -0,0 +1,724 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/cheekybits/genny/analysis"
+	"github.com/cheekybits/genny/audit"
+	"github.com/cheekybits/genny/config"
+	"github.com/cheekybits/genny/diag"
+	"github.com/cheekybits/genny/docs"
+	"github.com/cheekybits/genny/out"
+	"github.com/cheekybits/genny/parse"
+	"github.com/cheekybits/genny/paths"
+)
+
+// command is a genny command.
+type command struct {
+	name string
+	// usage is the command with its arguments.
+	usage string
+	// help describes the command, in lines that fit the usage.
+	help string
+	// minArgs is how many arguments the command needs.
+	minArgs int
+	// flags are the flags the command uses, besides globalFlags.
+	flags []string
+	run   func(args []string, opts parse.Options)
+}
+
+// globalFlags are the flags every command uses.
+var globalFlags = []string{"strict", "tags", "report", "timings", "crash-report"}
+
+// genFlags are the flags that set how code is generated, used by the
+// commands that generate it.
+var genFlags = []string{"encoding", "todo", "struct-tags", "string-words", "casing", "name-template", "import-map", "resolve", "require-docs", "interfaces", "fakes", "annotate", "header-file", "constraint", "typecheck", "verify", "line-directives", "owners", "script", "defer-format", "engine", "generic-packages", "workers"}
+
+// commands are the genny commands, in the order of the usage.
+var commands = []*command{
+	{name: "gen", usage: `gen "{types}"`, run: genCommand,
+		help:  "generates type specific code from generic code.",
+		flags: withFlags(genFlags, "mode", "in", "out", "pkg", "max-lines", "max-bytes", "const", "coverage", "explain", "preview", "diff", "dry-run", "check", "split-build", "per-type-set", "force", "backup", "directive", "plugins", "placeholders", "test-package", "require", "cache")},
+	{name: "get", usage: `get <package/file> "{types}"`, minArgs: 2, run: getCommand,
+		help:  "fetch a generic template from the online library and gen it.",
+		flags: withFlags(genFlags, "out", "pkg", "max-lines", "max-bytes", "const", "coverage", "explain", "preview", "diff", "dry-run", "check", "split-build", "per-type-set", "force", "backup", "directive", "plugins", "placeholders", "test-package", "require", "cache")},
+	{name: "verify", usage: `verify "{types}"`, minArgs: 1, run: verifyCommand,
+		help:  "check that the code generated from -in compiles in the package of\n-out, without writing it.",
+		flags: withFlags(genFlags, "in", "out", "pkg", "directive", "placeholders", "test-package")},
+	{name: "vet", usage: `vet "{types}"`, minArgs: 1, run: vetCommand,
+		help:  "run go vet on the package of -out with the code generated from -in,\nin a sandbox module, without writing it.",
+		flags: withFlags(genFlags, "in", "out", "pkg", "directive", "placeholders", "test-package")},
+	{name: "run", usage: `run "{types}" [args]`, minArgs: 1, run: runCommand,
+		help:  "generate the template (-in) as a script and go run it with the args\n(after --, if they look like flags).",
+		flags: withFlags(genFlags, "in", "pkg", "directive", "placeholders")},
+	{name: "bundle", usage: "bundle <version> [dir]", minArgs: 1, run: bundleCommand,
+		help:  "package the templates in dir (default .) into a\nversioned archive (-out, default <dir>-<version>.tar.gz).",
+		flags: []string{"out"}},
+	{name: "hints", usage: "hints <profile> [dir]", minArgs: 1, run: hintsCommand,
+		help: "suggest instantiations for hot paths of the package in dir\nthat convert values to and from interfaces (pprof profile)."},
+	{name: "build", usage: "build [config]", run: buildCommand,
+		help:  "generate everything declared in a config file (default genny.json),\nor in every config file of a tree with dir/... (e.g. ./...).",
+		flags: withFlags(genFlags, "run", "skip", "backup", "check")},
+	{name: "watch", usage: "watch [config]", run: watchCommand,
+		help:  "build, then rebuild the entries whose templates change until\ninterrupted.",
+		flags: withFlags(genFlags, "run", "skip", "backup")},
+	{name: "shuffle", usage: "shuffle [config]", run: shuffleCommand,
+		help:  "generate every entry of a config twice, in different orders, without\nwriting anything, and fail if any output differs.",
+		flags: withFlags(genFlags, "run", "skip", "seed")},
+	{name: "config", usage: "config validate [config]", minArgs: 1, run: configCommand,
+		help: "check a config file (default genny.json), or those of dir/..., against\nits schema, reporting every problem; config schema prints the JSON Schema."},
+	{name: "minimize", usage: `minimize "{types}"`, minArgs: 1, run: minimizeCommand,
+		help:  "shrink a template (-in) that fails with the types to an\nanonymized reproducer for a bug report.",
+		flags: []string{"in", "out", "match"}},
+	{name: "unused", usage: "unused [packages]", run: unusedCommand,
+		help: "report type sets whose generated code is never referenced\nin the packages (default ./...)."},
+	{name: "audit", usage: "audit [dir]", run: auditCommand,
+		help: "list the go:generate directives in dir (default .) whose types no\nlonger match the generic types of their templates."},
+	{name: "list", usage: "list [dir]", run: listCommand,
+		help: "list the templates in dir (default .) with their generic types."},
+	{name: "describe", usage: "describe <template>", minArgs: 1, run: describeCommand,
+		help:  "show the documentation of a template as Markdown or HTML\n(-format).",
+		flags: []string{"format"}},
+	{name: "docs", usage: "docs [dir]", run: docsCommand,
+		help:  "document the templates in dir (default .) as Markdown or HTML\npages (-format), written to the -out directory or printed.",
+		flags: []string{"out", "format", "require-docs"}},
+	{name: "fmt", usage: "fmt [files or dirs]", run: fmtCommand,
+		help: "format files written with -defer-format, in parallel; in\ndirs (default .), the genny generated files."},
+	{name: "clean", usage: "clean [dirs]", run: cleanCommand,
+		help:  "remove the genny generated files in dirs (default .), backing\nthem up with -backup.",
+		flags: []string{"backup"}},
+	{name: "rollback", usage: "rollback [files]", run: rollbackCommand,
+		help:  "restore files (default -out) from the backups kept with\n-backup.",
+		flags: []string{"out"}},
+}
+
+// withFlags gets the flags followed by more.
+func withFlags(flags []string, more ...string) []string {
+	return append(append([]string(nil), flags...), more...)
+}
+
+// lookupCommand gets the command with the name, in any case, or nil if
+// there is none.
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if strings.EqualFold(cmd.name, name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// uses gets whether the command uses the flag.
+func (cmd *command) uses(flag string) bool {
+	for _, f := range globalFlags {
+		if f == flag {
+			return true
+		}
+	}
+	for _, f := range cmd.flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// commandList lists the commands with their help, for the usage.
+func commandList() string {
+	var b strings.Builder
+	for _, cmd := range commands {
+		indent := strings.Repeat(" ", len(cmd.usage)+3)
+		b.WriteString(cmd.usage + " - " + strings.Replace(cmd.help, "\n", "\n"+indent, -1) + "\n")
+	}
+	return b.String()
+}
+
+// commandUsage prints how to use the command and the flags it uses.
+func commandUsage(cmd *command) {
+	fmt.Fprintf(os.Stderr, "usage: genny [{flags}] %s [{flags}]\n\n%s\n\nFlags:\n", cmd.usage, strings.Replace(cmd.help, "\n", " ", -1))
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	flag.VisitAll(func(f *flag.Flag) {
+		if cmd.uses(f.Name) {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	fs.PrintDefaults()
+}
+
+// help prints how to use genny, or the command named in the arguments.
+func help(args []string) {
+	if len(args) == 0 {
+		usage()
+		return
+	}
+	cmd := lookupCommand(args[0])
+	if cmd == nil {
+		fmt.Fprintln(os.Stderr, "unknown command:", args[0])
+		usage()
+		os.Exit(exitcodeInvalidArgs)
+	}
+	commandUsage(cmd)
+}
+
+// parseArgs parses the flags among the arguments of a command, which can
+// come before, between or after them, and gets the other arguments. Every
+// argument after "--" is taken as it is.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for len(args) > 0 {
+		if args[0] == "--" {
+			return append(positional, args[1:]...)
+		}
+		if !strings.HasPrefix(args[0], "-") || args[0] == "-" {
+			positional = append(positional, args[0])
+			args = args[1:]
+			continue
+		}
+		fs.Parse(args)
+		rest := fs.Args()
+		if parsed := args[:len(args)-len(rest)]; parsed[len(parsed)-1] == "--" {
+			return append(positional, rest...)
+		}
+		args = rest
+	}
+	return positional
+}
+
+// genCommand generates code from the template given with -in, or stdin.
+func genCommand(args []string, opts parse.Options) {
+	var typeSets []map[string]string
+	switch {
+	case len(args) > 0 && opts.Mode == parse.ModeTypeParams:
+		warn("-mode=typeparams does not use the type sets")
+	case len(args) > 0:
+		typeSets = parseTypeSets(args[0])
+	case opts.Mode != parse.ModeTypeParams:
+		fatal(exitcodeInvalidArgs, "gen takes the types, unless -mode=typeparams")
+	}
+	checkOutput(*in, opts)
+	filename, source := readTemplate()
+	writeGenerated(filename, source, typeSets, opts)
+}
+
+// getCommand generates code from a template on disk or in the online
+// library.
+func getCommand(args []string, opts parse.Options) {
+	if len(args) != 2 {
+		fatal(exitcodeInvalidArgs, "get takes the template and the types")
+	}
+	typeSets := parseTypeSets(args[1])
+	filename, source := fetchTemplate(args[0])
+	checkOutput(filename, opts)
+	writeGenerated(filename, source, typeSets, opts)
+}
+
+// verifyCommand checks that the generated code compiles in the package of
+// the -out file, without writing it.
+func verifyCommand(args []string, opts parse.Options) {
+	if *outFile == "" {
+		fatal(exitcodeInvalidArgs, "verify needs the output file given with -out")
+	}
+	opts.Unformatted = false
+	typeSets := parseTypeSets(args[0])
+	filename, source := readTemplate()
+	output := generate(filename, *outFile, source, typeSets, opts)
+	if err := parse.VerifyWithOptions(*outFile, output, opts); err != nil {
+		fatal(exitcodeVerifyFailed, err)
+	}
+}
+
+// vetCommand vets the package of the -out file with the generated code,
+// in a sandbox module, without writing it.
+func vetCommand(args []string, opts parse.Options) {
+	if *outFile == "" {
+		fatal(exitcodeInvalidArgs, "vet needs the output file given with -out")
+	}
+	opts.Unformatted = false
+	typeSets := parseTypeSets(args[0])
+	filename, source := readTemplate()
+	output := generate(filename, *outFile, source, typeSets, opts)
+	if err := vetSandboxed(*outFile, output, opts); err != nil {
+		fatal(exitcodeVetFailed, err)
+	}
+}
+
+// runCommand generates the template given with -in, or stdin, as a script
+// next to it, and runs it with the arguments after the types. A script
+// that fails exits genny with its exit code.
+func runCommand(args []string, opts parse.Options) {
+	opts.Script, opts.Unformatted = true, false
+	typeSets := parseTypeSets(args[0])
+	filename, source := readTemplate()
+	outputFilename := filepath.Join(filepath.Dir(filename), "genny_run.go")
+	output := generate(filename, outputFilename, source, typeSets, opts)
+	if err := runScript(outputFilename, output, args[1:], opts); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			os.Exit(exit.ExitCode())
+		}
+		fatal(exitcodeRunFailed, err)
+	}
+}
+
+func bundleCommand(args []string, opts parse.Options) {
+	dir := "."
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	if err := writeBundle(dir, args[0], *outFile); err != nil {
+		fatal(exitcodeBundleFailed, err)
+	}
+}
+
+func hintsCommand(args []string, opts parse.Options) {
+	dir := "."
+	if len(args) > 1 {
+		dir = args[1]
+	}
+	if err := profileHints(args[0], dir); err != nil {
+		fatal(exitcodeHintsFailed, err)
+	}
+}
+
+func buildCommand(args []string, opts parse.Options) {
+	filename := config.DefaultFilename
+	if len(args) > 0 {
+		filename = args[0]
+	}
+	filenames, err := configFiles(filename)
+	if err != nil {
+		fatal(exitcodeBuildFailed, err)
+	}
+	if *check {
+		checkBuilds(filenames, opts)
+		return
+	}
+	if len(filenames) == 1 {
+		if err := build(filenames[0], opts, *backup, *run, *skip); err != nil {
+			fatal(exitcodeBuildFailed, err)
+		}
+		return
+	}
+	// with -run and -skip, only the configs with entries left are built
+	built := false
+	for _, filename := range filenames {
+		err := build(filename, opts, *backup, *run, *skip)
+		if config.IsNoEntries(err) {
+			continue
+		}
+		if err != nil {
+			fatal(exitcodeBuildFailed, filename+": "+err.Error())
+		}
+		built = true
+	}
+	if !built {
+		fatal(exitcodeBuildFailed, "no entries to build in "+strings.Join(filenames, ", "))
+	}
+}
+
+func watchCommand(args []string, opts parse.Options) {
+	filename := config.DefaultFilename
+	if len(args) > 0 {
+		filename = args[0]
+	}
+	if err := watch(filename, opts, *backup, *run, *skip); err != nil {
+		fatal(exitcodeBuildFailed, err)
+	}
+}
+
+// shuffleCommand checks that the entries of a config generate the same
+// code when generated again in a different order.
+func shuffleCommand(args []string, opts parse.Options) {
+	filename := config.DefaultFilename
+	if len(args) > 0 {
+		filename = args[0]
+	}
+	c, err := loadConfig(filename, false, *run, *skip)
+	if err != nil {
+		fatal(exitcodeConfigInvalid, err)
+	}
+	s := *seed
+	if s == 0 {
+		s = time.Now().UnixNano()
+	}
+	if err := c.CheckDeterminism(opts, s); err != nil {
+		fatal(exitcodeNondeterministic, err)
+	}
+}
+
+// configCommand validates a config file, or prints the schema of config
+// files.
+func configCommand(args []string, opts parse.Options) {
+	switch strings.ToLower(args[0]) {
+	case "validate":
+		filename := config.DefaultFilename
+		if len(args) > 1 {
+			filename = args[1]
+		}
+		filenames, err := configFiles(filename)
+		if err != nil {
+			fatal(exitcodeConfigInvalid, err)
+		}
+		for _, filename := range filenames {
+			if _, err := config.Load(filename); err != nil {
+				fatal(exitcodeConfigInvalid, err)
+			}
+		}
+	case "schema":
+		os.Stdout.Write(config.Schema)
+	default:
+		fatal(exitcodeInvalidArgs, "unknown config command: "+args[0]+" (validate or schema expected)")
+	}
+}
+
+func minimizeCommand(args []string, opts parse.Options) {
+	reproducer, err := minimizeTemplate(*in, args[0], *match)
+	if err != nil {
+		fatal(exitcodeMinimizeFailed, err)
+	}
+	writeOutput(reproducer)
+}
+
+func unusedCommand(args []string, opts parse.Options) {
+	patterns := args
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	unused, err := analysis.FindUnused(".", patterns...)
+	if err != nil {
+		fatal(exitcodeUnusedFailed, err)
+	}
+	unused.Write(os.Stdout)
+	if *strict && (len(unused.TypeSets) > 0 || len(unused.Files) > 0) {
+		os.Exit(exitcodeUnusedFailed)
+	}
+}
+
+// auditCommand lists the genny directives in the directory (default .)
+// that need updating, failing if there are any.
+func auditCommand(args []string, opts parse.Options) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	ignore, err := paths.LoadIgnore(".")
+	if err != nil {
+		fatal(exitcodeAuditFailed, err)
+	}
+	boolFlags := make(map[string]bool)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			boolFlags[f.Name] = true
+		}
+	})
+	findings, err := audit.Audit(dir, boolFlags)
+	if err != nil {
+		fatal(exitcodeAuditFailed, err)
+	}
+	var stale []audit.Finding
+	for _, f := range findings {
+		if !ignore.Match(f.Directive.Pos.Filename, false) {
+			stale = append(stale, f)
+		}
+	}
+	audit.Write(os.Stdout, stale)
+	if len(stale) > 0 {
+		os.Exit(exitcodeAuditFailed)
+	}
+}
+
+// listCommand lists the templates in the directory (default .) with their
+// generic types and summaries.
+func listCommand(args []string, opts parse.Options) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	templates, err := findTemplates(dir, false)
+	if err != nil {
+		fatal(exitcodeDocsFailed, err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, t := range templates {
+		var params []string
+		for _, p := range t.Params {
+			params = append(params, p.Name)
+		}
+		fmt.Fprintf(w, "%s\t%s", t.Filename, strings.Join(params, " "))
+		if summary := t.Summary(); summary != "" {
+			fmt.Fprintf(w, "\t%s", summary)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
+
+// describeCommand prints the documentation page of the template.
+func describeCommand(args []string, opts parse.Options) {
+	format, err := docs.ParseFormat(*format)
+	if err != nil {
+		fatal(exitcodeInvalidArgs, err)
+	}
+	t, err := docs.Load(args[0])
+	if err != nil {
+		fatal(exitcodeDocsFailed, err)
+	}
+	if err := format.Page(os.Stdout, t); err != nil {
+		fatal(exitcodeDocsFailed, err)
+	}
+}
+
+func docsCommand(args []string, opts parse.Options) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	if err := writeDocs(dir, *outFile, *format, *reqDocs); err != nil {
+		fatal(exitcodeDocsFailed, err)
+	}
+}
+
+func fmtCommand(args []string, opts parse.Options) {
+	targets := args
+	if len(targets) == 0 {
+		targets = []string{"."}
+	}
+	if err := formatGenerated(targets); err != nil {
+		fatal(exitcodeFormatFailed, err)
+	}
+}
+
+// cleanCommand removes the genny generated files in the directories
+// (default .), other than those .gennyignore ignores.
+func cleanCommand(args []string, opts parse.Options) {
+	dirs := args
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	if err := removeGenerated(dirs, *backup); err != nil {
+		fatal(exitcodeCleanFailed, err)
+	}
+}
+
+func rollbackCommand(args []string, opts parse.Options) {
+	files := args
+	if len(files) == 0 && *outFile != "" {
+		files = []string{*outFile}
+	}
+	if len(files) == 0 {
+		fatal(exitcodeInvalidArgs, "rollback needs the files to restore, or -out")
+	}
+	for _, f := range files {
+		if err := out.Rollback(paths.Resolve("", f)); err != nil {
+			fatal(exitcodeRollbackFailed, err)
+		}
+	}
+}
+
+// parseTypeSets parses the type sets argument.
+func parseTypeSets(arg string) []map[string]string {
+	typeSets, err := parse.TypeSet(arg)
+	if err != nil {
+		fatal(exitcodeInvalidTypeSet, err)
+	}
+	return typeSets
+}
+
+// checkOutput checks that the -out file can be written for the template,
+// recognizing templates by the generic packages of the options.
+func checkOutput(template string, opts parse.Options) {
+	if *outFile == "" {
+		return
+	}
+	checkOutputErr(paths.CheckOutput(template, *outFile, *force, opts.GenericPackages))
+}
+
+// checkOutputErr fails with the error from checking an output file, if
+// any.
+func checkOutputErr(err error) {
+	if err == nil {
+		return
+	}
+	if paths.IsOverwrite(err) {
+		fatal(exitcodeInvalidArgs, err, "(use -force to write it anyway)")
+	}
+	fatal(exitcodeInvalidArgs, err)
+}
+
+// checkOutputs checks that each of the files, generated for the template,
+// can be written, as checkOutput does for the -out file.
+func checkOutputs(template string, files []parse.File, force bool, genericPackages []string) error {
+	for _, f := range files {
+		if err := paths.CheckOutput(template, f.Name, force, genericPackages); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDirectives checks that the //go:build directives given with
+// -directive are split into files of their own with -split-build, without
+// which the //genny:build sections they add constrain nothing.
+func checkDirectives(directives []parse.Directive, split bool) error {
+	for _, d := range directives {
+		if d.IsConstraint() && !split {
+			return fmt.Errorf("-directive %s=%s only constrains the function with -split-build, which writes it to a file for the constraint", d.Func, d.Text)
+		}
+	}
+	return nil
+}
+
+// checkTags checks that the -tags given are build tags, and not a build
+// constraint meant for the generated file, which -constraint writes: the
+// -tags flag was already the tags packages are loaded with to verify the
+// output when the constraint was added, so it is named differently.
+func checkTags(tags string) error {
+	if strings.ContainsAny(tags, "&|!()") {
+		return fmt.Errorf("-tags %q is not a list of build tags; -tags gives the tags packages are loaded with to verify the output, and -constraint writes a build constraint into the generated file", tags)
+	}
+	return nil
+}
+
+// readTemplate reads the template given with -in, or stdin.
+func readTemplate() (string, io.ReadSeeker) {
+	if dir, ok := templateDir(*in); ok {
+		return readTemplatePackage(dir)
+	}
+	if len(*in) > 0 && *in != "-" {
+		name, b, err := paths.ReadTemplate("", *in)
+		if err != nil {
+			fatal(exitcodeSourceFileInvalid, err)
+		}
+		return name, decodeTemplate(name, b)
+	}
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		// waiting for a template typed in is never what was meant
+		fatal(exitcodeStdinFailed, "no template: give it with -in, or pipe it to stdin (cat queue.go | genny gen ...)")
+	}
+	b, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		fatal(exitcodeStdinFailed, err)
+	}
+	return "stdin", decodeTemplate("stdin", b)
+}
+
+// templateDir gets the directory of the template package -in names, as a
+// directory or an import path, if it names one rather than a file.
+func templateDir(in string) (string, bool) {
+	if in == "" || in == "-" {
+		return "", false
+	}
+	if info, err := os.Stat(in); err == nil {
+		return in, info.IsDir()
+	}
+	if strings.HasSuffix(in, ".go") || strings.Contains(in, "#") || !strings.Contains(in, "/") {
+		return "", false
+	}
+	dir, err := parse.PackageDir(in, nil)
+	return dir, err == nil
+}
+
+// readTemplatePackage reads the files of the template package in dir
+// and joins them into one template, known by the directory, which the
+// //line directives placing its lines in the files are resolved against.
+func readTemplatePackage(dir string) (string, io.ReadSeeker) {
+	dir = filepath.Clean(dir)
+	filenames, err := parse.TemplatePackage(dir)
+	if err != nil {
+		fatal(exitcodeSourceFileInvalid, err)
+	}
+	var srcs [][]byte
+	for _, filename := range filenames {
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			fatal(exitcodeSourceFileInvalid, err)
+		}
+		b, err = parse.DecodeTemplate(filename, b, *encoding)
+		if err != nil {
+			fatal(exitcodeSourceFileInvalid, err)
+		}
+		srcs = append(srcs, b)
+	}
+	joined, err := parse.JoinTemplates(filenames, srcs)
+	if err != nil {
+		fatal(exitcodeSourceFileInvalid, err)
+	}
+	return dir, bytes.NewReader(joined)
+}
+
+// decodeTemplate gets the template as UTF-8, transcoding it from the
+// -encoding encoding.
+func decodeTemplate(filename string, b []byte) io.ReadSeeker {
+	b, err := parse.DecodeTemplate(filename, b, *encoding)
+	if err != nil {
+		fatal(exitcodeSourceFileInvalid, err)
+	}
+	return bytes.NewReader(b)
+}
+
+// fetchTemplate reads the template from disk, or else from the online
+// library or the internet address.
+func fetchTemplate(location string) (string, io.ReadSeeker) {
+	// Try a location on disk first
+	b, err := ioutil.ReadFile(location)
+	if err != nil {
+		// Try the default location next
+		r, err := http.Get(prefix + location)
+		if err != nil || r.StatusCode != 200 {
+			// Finally, try the non-prefixed internet address
+			r, err = http.Get("https://" + location)
+			if err != nil || r.StatusCode != 200 {
+				fatal(exitcodeGetFailed, err)
+			}
+		}
+		b, err = ioutil.ReadAll(r.Body)
+		if err != nil {
+			fatal(exitcodeGetFailed, err)
+		}
+		r.Body.Close()
+	}
+	return location, decodeTemplate(location, b)
+}
+
+// generate generates the code, printing warnings about what -strict would
+// fail on.
+func generate(filename, outputFilename string, source io.ReadSeeker, typeSets []map[string]string, opts parse.Options) []byte {
+	var warnings diag.List
+	opts.Warnings = &warnings
+	output, err := gen(filename, outputFilename, *pkgName, source, typeSets, opts)
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, w)
+	}
+	if err != nil {
+		fatal(exitcodeGenFailed, err)
+	}
+	return output
+}
+
+// generatePerTypeSet generates a file for each type set, named by the
+// pattern, printing warnings like generate.
+func generatePerTypeSet(filename, pattern string, source io.ReadSeeker, typeSets []map[string]string, opts parse.Options) []parse.File {
+	var warnings diag.List
+	opts.Warnings = &warnings
+	files, err := parse.GenericsPerTypeSet(filename, pattern, *pkgName, source, typeSets, opts)
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, w)
+	}
+	if err != nil {
+		fatal(exitcodeGenFailed, err)
+	}
+	return files
+}
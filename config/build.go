@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/cheekybits/genny/parse"
+	"github.com/cheekybits/genny/paths"
+)
+
+// Build generates every entry of the config in order, stopping at the
+// first error. The output of hooks is written to stdout and stderr.
+//
+// With opts.Unformatted, the entries are written unformatted and then
+// formatted together in parallel, before any post hooks run, which is
+// faster for configs with many entries.
+func (c *Config) Build(opts parse.Options, stdout, stderr io.Writer) error {
+	if !opts.Unformatted {
+		for _, e := range c.Entries {
+			if err := c.BuildEntry(e, opts, stdout, stderr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var outs []string
+	for _, e := range c.Entries {
+		if err := c.generateEntry(e, opts, stdout, stderr); err != nil {
+			return err
+		}
+		outs = append(outs, c.path(e.Out))
+	}
+	if err := parse.FormatFiles(outs, runtime.NumCPU()); err != nil {
+		return err
+	}
+	for _, e := range c.Entries {
+		if err := c.runHooks(e, "post", e.Post, c.env(e), stdout, stderr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildEntry runs the pre hooks of the entry, generates it and then runs
+// its post hooks.
+//
+// Hooks are run by the shell in the directory of the config file, with
+// these environment variables set:
+//
+//	GENNY_ENTRY     the name of the entry
+//	GENNY_TEMPLATE  the path of the template
+//	GENNY_OUT       the path of the generated file
+//	GENNY_PKG       the package name given in the entry, if any
+//	GENNY_TYPES     the type sets, e.g. "Something=int,string"
+func (c *Config) BuildEntry(e Entry, opts parse.Options, stdout, stderr io.Writer) error {
+	if err := c.generateEntry(e, opts, stdout, stderr); err != nil {
+		return err
+	}
+	return c.runHooks(e, "post", e.Post, c.env(e), stdout, stderr)
+}
+
+// generateEntry runs the pre hooks of the entry and generates it.
+func (c *Config) generateEntry(e Entry, opts parse.Options, stdout, stderr io.Writer) (err error) {
+	if err := c.runHooks(e, "pre", e.Pre, c.env(e), stdout, stderr); err != nil {
+		return err
+	}
+
+	output, err := c.generate(e, opts)
+	if err != nil {
+		return err
+	}
+	if err := parse.CheckImportCycle(c.path(e.Out), output, opts); err != nil {
+		return err
+	}
+	if c.Backup {
+		if err := out.Backup(c.path(e.Out), output); err != nil {
+			return err
+		}
+	}
+	if opts.Tracer != nil {
+		span := opts.Tracer.StartSpan(parse.SpanWrite, map[string]string{"genny.output": c.path(e.Out)})
+		defer func() { span.End(err) }()
+	}
+	lf := &out.LazyFile{FileName: c.path(e.Out)}
+	if _, err := lf.Write(output); err != nil {
+		return err
+	}
+	return lf.Close()
+}
+
+// generate generates the code of the entry.
+func (c *Config) generate(e Entry, opts parse.Options) ([]byte, error) {
+	typeSets, err := parse.TypeSet(e.Types)
+	if err != nil {
+		return nil, err
+	}
+	name, template, err := paths.ReadTemplate(c.Dir, e.Template)
+	if err != nil {
+		return nil, err
+	}
+	opts.TestPackage = opts.TestPackage || e.TestPackage
+	if e.Constraint != "" {
+		opts.Constraint = e.Constraint
+	}
+	if c.GenericPackages != nil {
+		opts.GenericPackages = append(append([]string(nil), opts.GenericPackages...), c.GenericPackages...)
+	}
+	if e.StructTags != nil {
+		if opts.StructTags, err = e.structTags(); err != nil {
+			return nil, err
+		}
+	}
+	if e.Directives != nil {
+		if opts.Directives, err = e.directives(); err != nil {
+			return nil, err
+		}
+	}
+	if e.Casing != nil {
+		if opts.Casings, err = e.casings(); err != nil {
+			return nil, err
+		}
+	}
+	return parse.GenericsWithOptions(c.path(name), c.path(e.Out), e.Pkg, bytes.NewReader(template), typeSets, opts)
+}
+
+// env gets the environment of the hooks of the entry.
+func (c *Config) env(e Entry) []string {
+	return append(os.Environ(),
+		"GENNY_ENTRY="+e.Name,
+		"GENNY_TEMPLATE="+c.path(e.Template),
+		"GENNY_OUT="+c.path(e.Out),
+		"GENNY_PKG="+e.Pkg,
+		"GENNY_TYPES="+e.Types,
+	)
+}
+
+// runHooks runs the commands one after another, stopping at the first
+// that fails.
+func (c *Config) runHooks(e Entry, phase string, commands []string, env []string, stdout, stderr io.Writer) error {
+	for _, command := range commands {
+		cmd := shell(command)
+		cmd.Dir = c.Dir
+		cmd.Env = env
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+		if err := cmd.Run(); err != nil {
+			return &errHook{Entry: e.Name, Phase: phase, Command: command, Err: err}
+		}
+	}
+	return nil
+}
+
+// shell gets the command to run the command line with the system shell.
+func shell(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
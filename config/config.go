@@ -0,0 +1,235 @@
+// Package config reads genny.json files, which declare everything genny
+// generates for a package, and builds them.
+//
+// A config file lists entries, each generating one output file from a
+// template:
+//
+//	{
+//	  "entries": [
+//	    {
+//	      "name": "queues",
+//	      "template": "generic_queue.go",
+//	      "out": "gen_queue.go",
+//	      "types": "Something=int,string",
+//	      "post": ["go vet ."]
+//	    }
+//	  ]
+//	}
+//
+// Paths are relative to the directory of the config file, and may use
+// forward slashes on any platform.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/cheekybits/genny/paths"
+)
+
+// DefaultFilename is the name of the config file genny looks for.
+const DefaultFilename = "genny.json"
+
+// Config is a genny config file.
+type Config struct {
+	// Dir is the directory of the config file.
+	Dir string `json:"-"`
+	// Schema is the JSON Schema the file is written against, for editors.
+	Schema string `json:"$schema,omitempty"`
+	// Backup keeps a copy of each output before it is overwritten, which
+	// genny rollback restores.
+	Backup bool `json:"backup,omitempty"`
+	// GenericPackages are packages, besides genny's generic package, whose
+	// Type, Number and Interface declare generic types in the templates, as
+	// in parse.Options.
+	GenericPackages []string `json:"genericPackages,omitempty"`
+	Entries         []Entry  `json:"entries"`
+}
+
+// Entry generates one output file from a template.
+type Entry struct {
+	// Name identifies the entry in messages.
+	Name string `json:"name"`
+	// Template is the path of the template.
+	Template string `json:"template"`
+	// Out is the path of the generated file.
+	Out string `json:"out"`
+	// Pkg is the package name for the generated file, if it differs from the
+	// template's.
+	Pkg string `json:"pkg,omitempty"`
+	// Types are the type sets, in the same format as the gen command.
+	Types string `json:"types"`
+	// Pre are shell commands run before the entry is generated.
+	Pre []string `json:"pre,omitempty"`
+	// Post are shell commands run after the entry is generated.
+	Post []string `json:"post,omitempty"`
+	// Force allows Out to overwrite a template.
+	Force bool `json:"force,omitempty"`
+	// TestPackage generates the entry into the external test package of
+	// the package of Out, which must be a _test.go file.
+	TestPackage bool `json:"testPackage,omitempty"`
+	// StructTags, if set, are the struct tag keys whose values the types
+	// are substituted into, each with its casing: keep, upper, lower or
+	// snake. Other keys are left untouched.
+	StructTags map[string]string `json:"structTags,omitempty"`
+	// Casing, if set, is how the types are written into identifiers,
+	// strings and comments, keyed by generic type, or "*" for every
+	// generic type, e.g. {"*": {"strings": "verbatim"}}.
+	Casing map[string]map[string]string `json:"casing,omitempty"`
+	// Directives are compiler directives to add to generated functions,
+	// keyed by function (NewIntQueue, or IntQueue.Push for a method), e.g.
+	// {"IntQueue.Push": ["//go:noinline"]}. A //go:build constraint is set
+	// for the whole entry with Constraint instead.
+	Directives map[string][]string `json:"directives,omitempty"`
+	// Constraint is a build constraint expression written at the top of Out,
+	// e.g. "linux && amd64", for a specialization built only on some
+	// platforms.
+	Constraint string `json:"constraint,omitempty"`
+}
+
+// Load reads and checks the config file. It fails with every problem
+// Validate finds, if any, before the entries are checked further.
+func Load(filename string) (*Config, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(filename, b); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var c Config
+	if err := dec.Decode(&c); err != nil {
+		return nil, &errConfig{Filename: filename, Err: err}
+	}
+	c.Dir = filepath.Dir(filename)
+	names := make(map[string]bool)
+	for i, e := range c.Entries {
+		if names[e.Name] {
+			return nil, &errEntry{Filename: filename, Index: i, Name: e.Name, Message: "name is used by another entry"}
+		}
+		if err := paths.CheckOutput(c.path(e.Template), c.path(e.Out), e.Force, c.GenericPackages); err != nil {
+			msg := err.Error()
+			if paths.IsOverwrite(err) {
+				msg += `; set "force": true to write it anyway`
+			}
+			return nil, &errEntry{Filename: filename, Index: i, Name: e.Name, Message: msg}
+		}
+		if _, err := e.structTags(); err != nil {
+			return nil, &errEntry{Filename: filename, Index: i, Name: e.Name, Message: err.Error()}
+		}
+		directives, err := e.directives()
+		if err != nil {
+			return nil, &errEntry{Filename: filename, Index: i, Name: e.Name, Message: err.Error()}
+		}
+		for _, d := range directives {
+			if d.IsConstraint() {
+				return nil, &errEntry{Filename: filename, Index: i, Name: e.Name, Message: "the " + d.Text + " directive of " + d.Func + " would constrain nothing, since entries are not split into a file for each constraint; set the entry's \"constraint\" instead"}
+			}
+		}
+		if _, err := e.casings(); err != nil {
+			return nil, &errEntry{Filename: filename, Index: i, Name: e.Name, Message: err.Error()}
+		}
+		if e.Constraint != "" {
+			if _, err := parse.ParseConstraint(e.Constraint); err != nil {
+				return nil, &errEntry{Filename: filename, Index: i, Name: e.Name, Message: err.Error()}
+			}
+		}
+		names[e.Name] = true
+	}
+	return &c, nil
+}
+
+// Select gets the config with only the entries whose names match run and
+// do not match skip, for rebuilding part of a config while iterating on it.
+// Either may be nil to not filter by it. It is an error if no entries are
+// left.
+func (c *Config) Select(run, skip *regexp.Regexp) (*Config, error) {
+	selected := *c
+	selected.Entries = nil
+	for _, e := range c.Entries {
+		if (run == nil || run.MatchString(e.Name)) && (skip == nil || !skip.MatchString(e.Name)) {
+			selected.Entries = append(selected.Entries, e)
+		}
+	}
+	if len(selected.Entries) == 0 {
+		err := &errNoEntries{}
+		if run != nil {
+			err.Run = run.String()
+		}
+		if skip != nil {
+			err.Skip = skip.String()
+		}
+		return nil, err
+	}
+	return &selected, nil
+}
+
+// structTags gets the struct tag keys to substitute into with their
+// casings, or nil for every struct tag.
+func (e Entry) structTags() (map[string]parse.Casing, error) {
+	if e.StructTags == nil {
+		return nil, nil
+	}
+	tags := make(map[string]parse.Casing)
+	for key, casing := range e.StructTags {
+		c, err := parse.ParseCasing(casing)
+		if err != nil {
+			return nil, err
+		}
+		tags[key] = c
+	}
+	return tags, nil
+}
+
+// directives gets the directives to add to the generated functions, in
+// the order of the functions.
+func (e Entry) directives() ([]parse.Directive, error) {
+	var funcs []string
+	for fn := range e.Directives {
+		funcs = append(funcs, fn)
+	}
+	sort.Strings(funcs)
+	var directives []parse.Directive
+	for _, fn := range funcs {
+		for _, text := range e.Directives[fn] {
+			d, err := parse.ParseDirective(fn + "=" + text)
+			if err != nil {
+				return nil, err
+			}
+			directives = append(directives, d)
+		}
+	}
+	return directives, nil
+}
+
+// casings gets the casing of the types in each position, or nil to
+// substitute as genny always has.
+func (e Entry) casings() (map[string]parse.PositionCasing, error) {
+	if e.Casing == nil {
+		return nil, nil
+	}
+	var items []string
+	for generic, positions := range e.Casing {
+		for position, casing := range positions {
+			if generic != parse.AllParams {
+				position = generic + "." + position
+			}
+			items = append(items, position+":"+casing)
+		}
+	}
+	sort.Strings(items)
+	return parse.ParseCasings(strings.Join(items, ","))
+}
+
+// path gets the path relative to the config file.
+func (c *Config) path(p string) string {
+	return paths.Resolve(c.Dir, p)
+}
@@ -0,0 +1,443 @@
+package config_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cheekybits/genny/config"
+	"github.com/cheekybits/genny/diag"
+	"github.com/cheekybits/genny/parse"
+	"github.com/cheekybits/genny/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+const template = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Something generic.Type
+
+type SomethingQueue struct {
+	items []Something
+}
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+
+	for _, test := range []struct {
+		config string
+		err    string
+	}{
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int"}]}`, ""},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "typo": 1}]}`, `:1:81: entries[0]: unknown property "typo" (did you mean "types"?)`},
+		{`{"entries": [{"template": "t.go", "out": "o.go", "types": "T=int"}]}`, `:1:14: entries[0]: "name" is required`},
+		{`{"entries": [{"name": "a", "out": "o.go", "types": "T=int"}]}`, `entries[0]: "template" is required`},
+		{`{"entries": [{"name": "a", "template": "t.go", "types": "T=int"}]}`, `entries[0]: "out" is required`},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go"}]}`, `entries[0]: "types" is required`},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int"}, {"name": "a", "template": "t.go", "out": "p.go", "types": "T=int"}]}`, "entry 1 (a): name is used by another entry"},
+		{`{"entries": [{"name": "a", "template": "sub/t.go", "out": "sub\\t.go", "types": "T=int"}]}`, "entry 0 (a): output"},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "t.go", "types": "T=int"}]}`, "would overwrite the template"},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "./t.go", "types": "T=int"}]}`, `would overwrite the template; set "force": true to write it anyway`},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "./t.go", "types": "T=int", "force": true}]}`, ""},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "structTags": {"json": "lower"}}]}`, ""},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "directives": {"IntQueue.Push": ["//go:noinline"]}}]}`, ""},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "directives": {"IntQueue.Push": ["//go:linkname"]}}]}`, `entry 0 (a): "//go:linkname" is not a valid directive option`},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "directives": {"IntQueue.Push": "//go:noinline"}}]}`, `entries[0].directives.IntQueue.Push: expected an array, got a string`},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "directives": {"IntQueue.Push": ["//go:build linux"]}}]}`, `entry 0 (a): the //go:build linux directive of IntQueue.Push would constrain nothing`},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "constraint": "linux && amd64"}]}`, ""},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "constraint": "linux &&"}]}`, `entry 0 (a): invalid constraint "linux &&"`},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "structTags": {"json": "kebab"}}]}`, `entries[0].structTags.json: "kebab" is not one of keep, upper, lower, snake`},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "casing": {"*": {"strings": "verbatim"}, "T": {"comments": "lower"}}}]}`, ""},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "casing": {"T": {"identifiers": "verbatim"}}}]}`, `entries[0].casing.T.identifiers: "verbatim" is not one of keep, upper, lower, snake`},
+		{`{"entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int", "casing": {"T": {"types": "lower"}}}]}`, `entries[0].casing.T: unknown property "types"`},
+	} {
+		dir := writeFiles(t, map[string]string{config.DefaultFilename: test.config})
+		c, err := config.Load(filepath.Join(dir, config.DefaultFilename))
+		if test.err == "" {
+			if assert.NoError(t, err) {
+				assert.Equal(t, dir, c.Dir)
+				assert.Len(t, c.Entries, 1)
+			}
+			continue
+		}
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), test.err)
+		}
+	}
+
+}
+
+func TestBuildRunsHooks(t *testing.T) {
+
+	dir := writeFiles(t, map[string]string{
+		"generic_queue.go": template,
+		config.DefaultFilename: `{
+			"entries": [{
+				"name": "queues",
+				"template": "generic_queue.go",
+				"out": "gen/queue.go",
+				"pkg": "gen",
+				"types": "Something=int,string",
+				"pre": ["echo pre $GENNY_ENTRY"],
+				"post": ["echo post $GENNY_TYPES $GENNY_PKG", "test -f \"$GENNY_OUT\" && echo exists"]
+			}]
+		}`,
+	})
+	c, err := config.Load(filepath.Join(dir, config.DefaultFilename))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var stdout, stderr bytes.Buffer
+	if !assert.NoError(t, c.Build(parse.Options{}, &stdout, &stderr)) {
+		return
+	}
+	assert.Equal(t, "pre queues\npost Something=int,string gen\nexists\n", stdout.String())
+	output, err := ioutil.ReadFile(filepath.Join(dir, "gen", "queue.go"))
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), "package gen")
+		assert.Contains(t, string(output), "type IntQueue struct")
+		assert.Contains(t, string(output), "type StringQueue struct")
+	}
+
+}
+
+func TestBuildStopsWhenAHookFails(t *testing.T) {
+
+	dir := writeFiles(t, map[string]string{
+		"generic_queue.go": template,
+		config.DefaultFilename: `{
+			"entries": [{
+				"name": "queues",
+				"template": "generic_queue.go",
+				"out": "queue.go",
+				"types": "Something=int",
+				"pre": ["exit 3"]
+			}]
+		}`,
+	})
+	c, err := config.Load(filepath.Join(dir, config.DefaultFilename))
+	if !assert.NoError(t, err) {
+		return
+	}
+	err = c.Build(parse.Options{}, ioutil.Discard, ioutil.Discard)
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "pre hook of queues failed: exit 3"))
+	}
+	_, err = ioutil.ReadFile(filepath.Join(dir, "queue.go"))
+	assert.Error(t, err, "nothing is generated when a pre hook fails")
+
+}
+
+func TestBuildFormatsAtTheEnd(t *testing.T) {
+
+	dir := writeFiles(t, map[string]string{
+		"generic_queue.go": template,
+		config.DefaultFilename: `{
+			"entries": [
+				{"name": "a", "template": "generic_queue.go", "out": "a.go", "types": "Something=int", "pre": ["echo pre a"], "post": ["echo post a"]},
+				{"name": "b", "template": "generic_queue.go", "out": "b.go", "types": "Something=string", "pre": ["echo pre b"], "post": ["echo post b"]}
+			]
+		}`,
+	})
+	c, err := config.Load(filepath.Join(dir, config.DefaultFilename))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var stdout, stderr bytes.Buffer
+	if !assert.NoError(t, c.Build(parse.Options{Unformatted: true}, &stdout, &stderr)) {
+		return
+	}
+	// every entry is generated before any post hook runs
+	assert.Equal(t, "pre a\npre b\npost a\npost b\n", stdout.String())
+	for name, types := range map[string]string{"a.go": "Something=int", "b.go": "Something=string"} {
+		output, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		expected, err := parse.Generics(filepath.Join(dir, "generic_queue.go"), filepath.Join(dir, name), "", strings.NewReader(template), mustTypeSet(t, types))
+		if assert.NoError(t, err) {
+			assert.Equal(t, string(expected), string(output), name)
+		}
+	}
+
+}
+
+func mustTypeSet(t *testing.T, arg string) []map[string]string {
+	typeSets, err := parse.TypeSet(arg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return typeSets
+}
+
+func TestBuildBacksUpOutputs(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"generic_queue.go": template,
+		"gen_queue.go":     "package queue\n\n// curated\n",
+	})
+	c := &config.Config{Dir: dir, Backup: true, Entries: []config.Entry{
+		{Name: "queues", Template: "generic_queue.go", Out: "gen_queue.go", Types: "Something=int"},
+	}}
+	var stdout, stderr bytes.Buffer
+	if !assert.NoError(t, c.Build(parse.Options{}, &stdout, &stderr)) {
+		return
+	}
+	backup, err := ioutil.ReadFile(filepath.Join(dir, ".genny", "backup", "gen_queue.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package queue\n\n// curated\n", string(backup))
+}
+
+func TestBuildWithGenericPackages(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"generic_queue.go": "package queue\n\nimport \"example.com/lib/genny\"\n\ntype Something genny.Type\n\ntype SomethingQueue []Something\n",
+	})
+	c := &config.Config{Dir: dir, GenericPackages: []string{"genny"}, Entries: []config.Entry{
+		{Name: "queues", Template: "generic_queue.go", Out: "gen_queue.go", Types: "Something=int"},
+	}}
+	var stdout, stderr bytes.Buffer
+	if !assert.NoError(t, c.Build(parse.Options{}, &stdout, &stderr)) {
+		return
+	}
+	output, err := ioutil.ReadFile(filepath.Join(dir, "gen_queue.go"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "type IntQueue []int")
+}
+
+func TestSelect(t *testing.T) {
+
+	c := &config.Config{Entries: []config.Entry{{Name: "queues"}, {Name: "queues-test"}, {Name: "stacks"}}}
+	names := func(c *config.Config) []string {
+		var names []string
+		for _, e := range c.Entries {
+			names = append(names, e.Name)
+		}
+		return names
+	}
+	for _, test := range []struct {
+		run, skip string
+		names     []string
+		err       string
+	}{
+		{run: "^queues", names: []string{"queues", "queues-test"}},
+		{run: "^queues", skip: "test$", names: []string{"queues"}},
+		{skip: "queues", names: []string{"stacks"}},
+		{run: "maps", err: "no entries to build with -run maps"},
+		{run: "stacks", skip: "s", err: "no entries to build with -run stacks -skip s"},
+	} {
+		var run, skip *regexp.Regexp
+		if test.run != "" {
+			run = regexp.MustCompile(test.run)
+		}
+		if test.skip != "" {
+			skip = regexp.MustCompile(test.skip)
+		}
+		selected, err := c.Select(run, skip)
+		if test.err != "" {
+			if assert.Error(t, err) {
+				assert.Equal(t, test.err, err.Error())
+				assert.True(t, config.IsNoEntries(err))
+			}
+			continue
+		}
+		if assert.NoError(t, err) {
+			assert.Equal(t, test.names, names(selected))
+		}
+	}
+	assert.Len(t, c.Entries, 3, "the config itself is not changed")
+
+}
+
+func TestDiscover(t *testing.T) {
+
+	dir := t.TempDir()
+	for _, sub := range []string{"a", "b/c", "b/vendor", "testdata", ".hidden", "_old", "ignored", "none"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if sub == "none" {
+			continue
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, sub, config.DefaultFilename), []byte(`{"entries": []}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ignore, err := paths.ParseIgnore(dir, []byte("ignored/\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := config.Discover(dir, ignore)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{
+			filepath.Join(dir, "a", config.DefaultFilename),
+			filepath.Join(dir, "b", "c", config.DefaultFilename),
+		}, found)
+	}
+
+	assert.True(t, config.IsPattern("./..."))
+	assert.True(t, config.IsPattern("..."))
+	assert.True(t, config.IsPattern("pkg/..."))
+	assert.False(t, config.IsPattern("genny.json"))
+	assert.Equal(t, ".", config.PatternDir("./..."))
+	assert.Equal(t, ".", config.PatternDir("..."))
+	assert.Equal(t, filepath.FromSlash("pkg/sub"), config.PatternDir("pkg/sub/..."))
+
+}
+
+func TestValidate(t *testing.T) {
+
+	err := config.Validate("genny.json", []byte(`{
+  "entries": [
+    {"name": "a", "template": "t.go", "out": "o.go", "types": 1},
+    {"name": "", "tempalte": "t.go", "out": "o.go", "types": "T=int", "pre": "go vet"}
+  ],
+  "backup": "yes"
+}`))
+	var problems diag.List
+	if assert.True(t, errors.As(err, &problems)) {
+		assert.Equal(t, []string{
+			"genny.json:3:63: entries[0].types: expected a string, got a number",
+			`genny.json:4:5: entries[1]: "template" is required`,
+			"genny.json:4:14: entries[1].name: must not be empty",
+			`genny.json:4:18: entries[1]: unknown property "tempalte" (did you mean "template"?)`,
+			"genny.json:4:78: entries[1].pre: expected an array, got a string",
+			"genny.json:6:13: backup: expected a boolean, got a string",
+		}, strings.Split(err.Error(), "\n"))
+	}
+
+	assert.EqualError(t, config.Validate("genny.json", []byte(`{"entries": []}`)), "genny.json:1:13: entries: must have at least 1 item(s)")
+	assert.EqualError(t, config.Validate("genny.json", []byte(`{}`)), `genny.json:1:1: "entries" is required`)
+	assert.Contains(t, config.Validate("genny.json", []byte("{\n  \"entries\": [\n")).Error(), "genny.json:3:1: invalid JSON")
+	assert.NoError(t, config.Validate("genny.json", []byte(`{"$schema": "https://github.com/cheekybits/genny/config/schema.json", "entries": [{"name": "a", "template": "t.go", "out": "o.go", "types": "T=int"}]}`)))
+
+}
+
+func TestCheckDeterminism(t *testing.T) {
+
+	dir := writeFiles(t, map[string]string{
+		"generic_queue.go": template,
+		"generic_map.go": `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Key generic.Type
+type Value generic.Type
+
+type KeyValueMap map[Key]Value
+
+func (m KeyValueMap) Get(k Key) Value {
+	return m[k]
+}
+`,
+		config.DefaultFilename: `{
+			"entries": [
+				{"name": "queues", "template": "generic_queue.go", "out": "gen_queue.go", "types": "Something=int,string", "pre": ["touch ran"]},
+				{"name": "maps", "template": "generic_map.go", "out": "gen_map.go", "types": "Key=string,int Value=int,bool"}
+			]
+		}`,
+	})
+	c, err := config.Load(filepath.Join(dir, config.DefaultFilename))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for seed := int64(0); seed < 5; seed++ {
+		assert.NoError(t, c.CheckDeterminism(parse.Options{}, seed))
+	}
+	for _, name := range []string{"gen_queue.go", "gen_map.go", "ran"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.True(t, os.IsNotExist(err), "%s is not written", name)
+	}
+
+	// both runs generate every entry
+	tracer := &countingTracer{}
+	assert.NoError(t, c.CheckDeterminism(parse.Options{Tracer: tracer}, 0))
+	assert.Equal(t, 4, tracer.count(parse.SpanGenerate))
+
+}
+
+// countingTracer counts the spans it starts by name.
+type countingTracer struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *countingTracer) StartSpan(name string, attrs map[string]string) parse.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int)
+	}
+	c.counts[name]++
+	return c
+}
+
+func (c *countingTracer) End(err error) {}
+
+func (c *countingTracer) count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[name]
+}
+
+func TestStale(t *testing.T) {
+
+	dir := writeFiles(t, map[string]string{
+		"generic_queue.go": template,
+		config.DefaultFilename: `{
+			"entries": [
+				{"name": "ints", "template": "generic_queue.go", "out": "gen_int_queue.go", "types": "Something=int", "pre": ["touch ran"]},
+				{"name": "strings", "template": "generic_queue.go", "out": "gen_string_queue.go", "types": "Something=string"},
+				{"name": "bools", "template": "generic_queue.go", "out": "gen_bool_queue.go", "types": "Something=bool"}
+			]
+		}`,
+	})
+	c, err := config.Load(filepath.Join(dir, config.DefaultFilename))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, c.Build(parse.Options{}, ioutil.Discard, ioutil.Discard))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "ran")))
+
+	stale, err := c.Stale(parse.Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, stale)
+
+	// one output edited, and one never generated
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "gen_int_queue.go"), []byte("package queue\n"), 0644))
+	assert.NoError(t, os.Remove(filepath.Join(dir, "gen_bool_queue.go")))
+	stale, err = c.Stale(parse.Options{Unformatted: true})
+	assert.NoError(t, err)
+	if assert.Len(t, stale, 2) {
+		assert.Equal(t, "ints", stale[0].Entry)
+		assert.Equal(t, filepath.Join(dir, "gen_int_queue.go"), stale[0].Out)
+		assert.Contains(t, string(stale[0].Output), "type IntQueue struct")
+		assert.False(t, stale[0].Missing)
+		assert.Equal(t, "bools", stale[1].Entry)
+		assert.True(t, stale[1].Missing)
+	}
+
+	// nothing is written, and no hooks are run
+	b, err := ioutil.ReadFile(filepath.Join(dir, "gen_int_queue.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, "package queue\n", string(b))
+	for _, name := range []string{"gen_bool_queue.go", "ran"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		assert.True(t, os.IsNotExist(err), "%s is not written", name)
+	}
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"bytes"
+	"math/rand"
+
+	"github.com/cheekybits/genny/parse"
+)
+
+// CheckDeterminism generates every entry of the config twice, in memory,
+// and fails if any output differs between the runs, which would be a bug
+// in genny (or in a hook or tool it runs). Nothing is written and no hooks
+// are run.
+//
+// The first run generates the entries in order, parsing each template
+// afresh. The second generates them, and the type sets of each, in orders
+// shuffled with the seed, from the templates parsed by the first run but
+// not from its outputs. The output of each entry is still in the order of
+// its type sets (see parse.Options.Shuffle), so the outputs of the runs are
+// compared entry by entry.
+func (c *Config) CheckDeterminism(opts parse.Options, seed int64) error {
+	rnd := rand.New(rand.NewSource(seed))
+	// the outputs of the first run are not kept, or the second would get them
+	// rather than generating the entries again
+	opts.Cache = &parse.Cache{MaxResults: -1}
+	first := make([][]byte, len(c.Entries))
+	for i, e := range c.Entries {
+		output, err := c.generate(e, opts)
+		if err != nil {
+			return err
+		}
+		first[i] = output
+	}
+	opts.Shuffle = rnd.Shuffle
+	for _, i := range rnd.Perm(len(c.Entries)) {
+		e := c.Entries[i]
+		output, err := c.generate(e, opts)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(first[i], output) {
+			err := &errNondeterministic{Entry: e.Name, Seed: seed}
+			err.Line, err.First, err.Second = firstDifference(first[i], output)
+			return err
+		}
+	}
+	return nil
+}
+
+// firstDifference gets the number of the first line that differs between
+// the outputs, and the line in each.
+func firstDifference(a, b []byte) (int, string, string) {
+	as, bs := bytes.Split(a, []byte("\n")), bytes.Split(b, []byte("\n"))
+	for i := 0; ; i++ {
+		var aLine, bLine []byte
+		if i < len(as) {
+			aLine = as[i]
+		}
+		if i < len(bs) {
+			bLine = bs[i]
+		}
+		if !bytes.Equal(aLine, bLine) || i >= len(as) || i >= len(bs) {
+			return i + 1, string(aLine), string(bLine)
+		}
+	}
+}
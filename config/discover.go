@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cheekybits/genny/paths"
+)
+
+// Discover finds the config files in dir and the directories below it, for
+// building everything a tree of packages declares at once. Like the go
+// command, it skips vendor and testdata directories and those whose names
+// start with . or _, and it skips what ignore ignores, which may be nil.
+func Discover(dir string, ignore *paths.Ignore) ([]string, error) {
+	var found []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != dir && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			if path != dir && ignore.Match(path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() == DefaultFilename && !ignore.Match(path, false) {
+			found = append(found, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(found)
+	return found, nil
+}
+
+// IsPattern gets whether the argument names the config files of a tree of
+// packages, as dir/... (or ./...) does, rather than one config file.
+func IsPattern(arg string) bool {
+	return arg == "..." || strings.HasSuffix(filepath.ToSlash(arg), "/...")
+}
+
+// PatternDir gets the directory of the tree a pattern names.
+func PatternDir(pattern string) string {
+	dir := strings.TrimSuffix(strings.TrimSuffix(filepath.ToSlash(pattern), "..."), "/")
+	if dir == "" {
+		return "."
+	}
+	return filepath.FromSlash(dir)
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// errConfig represents an error reading a config file.
+type errConfig struct {
+	Filename string
+	Err      error
+}
+
+// Error gets a human readable string describing this error.
+func (e errConfig) Error() string {
+	return "Invalid config file " + e.Filename + ": " + e.Err.Error()
+}
+
+// errEntry represents an error with an entry of a config file.
+type errEntry struct {
+	Filename string
+	Index    int
+	Name     string
+	Message  string
+}
+
+// Error gets a human readable string describing this error.
+func (e errEntry) Error() string {
+	entry := fmt.Sprintf("entry %d", e.Index)
+	if e.Name != "" {
+		entry += " (" + e.Name + ")"
+	}
+	return "Invalid config file " + e.Filename + ": " + entry + ": " + e.Message
+}
+
+// errHook represents an error when a pre or post hook fails.
+type errHook struct {
+	Entry   string
+	Phase   string
+	Command string
+	Err     error
+}
+
+// Error gets a human readable string describing this error.
+func (e errHook) Error() string {
+	return e.Phase + " hook of " + e.Entry + " failed: " + e.Command + ": " + e.Err.Error()
+}
+
+// errNoEntries represents an error when the -run and -skip filters leave
+// no entries to build.
+type errNoEntries struct {
+	Run  string
+	Skip string
+}
+
+// Error gets a human readable string describing this error.
+func (e errNoEntries) Error() string {
+	var filters []string
+	if e.Run != "" {
+		filters = append(filters, "-run "+e.Run)
+	}
+	if e.Skip != "" {
+		filters = append(filters, "-skip "+e.Skip)
+	}
+	return "no entries to build with " + strings.Join(filters, " ")
+}
+
+// errNondeterministic represents an error when an entry generates
+// different code when it is generated again.
+type errNondeterministic struct {
+	Entry  string
+	Seed   int64
+	Line   int
+	First  string
+	Second string
+}
+
+// Error gets a human readable string describing this error.
+func (e errNondeterministic) Error() string {
+	return fmt.Sprintf("%s is not generated deterministically (seed %d): line %d is %q in the first run and %q in the second", e.Entry, e.Seed, e.Line, e.First, e.Second)
+}
+
+// IsNoEntries gets whether the error is from Select leaving no entries to
+// build.
+func IsNoEntries(err error) bool {
+	_, ok := err.(*errNoEntries)
+	return ok
+}
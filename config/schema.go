@@ -0,0 +1,315 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cheekybits/genny/diag"
+)
+
+// Schema is the JSON Schema of config files, which editors can use to
+// complete and check them: set "$schema" in a config file to its $id,
+// https://github.com/cheekybits/genny/config/schema.json.
+//
+//go:embed schema.json
+var Schema []byte
+
+// schema is the part of JSON Schema that Schema uses.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties json.RawMessage    `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	MinLength            int                `json:"minLength"`
+	MinItems             int                `json:"minItems"`
+	Enum                 []string           `json:"enum"`
+	Defs                 map[string]*schema `json:"$defs"`
+}
+
+// rootSchema is Schema, parsed.
+var rootSchema = func() *schema {
+	var s schema
+	if err := json.Unmarshal(Schema, &s); err != nil {
+		panic("config: invalid schema: " + err.Error())
+	}
+	return &s
+}()
+
+// Validate checks the config file, with the contents src, against Schema,
+// getting every problem it finds as a diag.List, or nil if there are none.
+// Each problem is at the position of the value it is about, and its message
+// starts with the path to the value, e.g. entries[1].types.
+func Validate(filename string, src []byte) error {
+	v := &validator{filename: filename, src: src}
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	root, err := v.parse(dec)
+	if err == nil {
+		if _, err := dec.Token(); err != io.EOF {
+			v.problems.Errorf(v.position(v.start(dec.InputOffset())), "unexpected data after the config")
+			return v.problems
+		}
+	}
+	if err != nil {
+		var syntax *json.SyntaxError
+		offset := len(src)
+		if errors.As(err, &syntax) {
+			offset = int(syntax.Offset)
+		}
+		v.problems.Errorf(v.position(offset), "invalid JSON: "+err.Error())
+		return v.problems
+	}
+	v.validate(rootSchema, root, "")
+	sort.SliceStable(v.problems, func(i, j int) bool {
+		return v.problems[i].Pos.Offset < v.problems[j].Pos.Offset
+	})
+	return v.problems.Err()
+}
+
+// value is a JSON value of a config file, with where it starts.
+type value struct {
+	offset int
+	kind   string
+	str    string
+	keys   []string
+	// keyOffsets are where the keys start, to report unknown properties.
+	keyOffsets []int
+	fields     map[string]*value
+	items      []*value
+}
+
+// validator checks a config file against the schema.
+type validator struct {
+	filename string
+	src      []byte
+	problems diag.List
+}
+
+// parse parses the next JSON value.
+func (v *validator) parse(dec *json.Decoder) (*value, error) {
+	val := &value{offset: v.start(dec.InputOffset())}
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '[' {
+			val.kind = "array"
+			for dec.More() {
+				item, err := v.parse(dec)
+				if err != nil {
+					return nil, err
+				}
+				val.items = append(val.items, item)
+			}
+		} else {
+			val.kind = "object"
+			val.fields = make(map[string]*value)
+			for dec.More() {
+				keyOffset := v.start(dec.InputOffset())
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				field, err := v.parse(dec)
+				if err != nil {
+					return nil, err
+				}
+				val.keys = append(val.keys, key.(string))
+				val.keyOffsets = append(val.keyOffsets, keyOffset)
+				val.fields[key.(string)] = field
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	case string:
+		val.kind, val.str = "string", t
+	case json.Number:
+		val.kind = "number"
+	case bool:
+		val.kind = "boolean"
+	case nil:
+		val.kind = "null"
+	}
+	return val, nil
+}
+
+// start gets the offset of the value after offset, skipping whitespace and
+// separators.
+func (v *validator) start(offset int64) int {
+	i := int(offset)
+	for i < len(v.src) && strings.IndexByte(" \t\r\n,:", v.src[i]) >= 0 {
+		i++
+	}
+	return i
+}
+
+// position gets the position of the offset in the config file.
+func (v *validator) position(offset int) token.Position {
+	if offset > len(v.src) {
+		offset = len(v.src)
+	}
+	before := v.src[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(before, '\n')
+	return token.Position{Filename: v.filename, Offset: offset, Line: line, Column: column}
+}
+
+// errorf adds a problem with the value at the path.
+func (v *validator) errorf(val *value, path, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if path != "" {
+		msg = path + ": " + msg
+	}
+	v.problems.Errorf(v.position(val.offset), msg)
+}
+
+// validate checks the value at the path against the schema.
+func (v *validator) validate(s *schema, val *value, path string) {
+	if s.Ref != "" {
+		s = rootSchema.Defs[strings.TrimPrefix(s.Ref, "#/$defs/")]
+	}
+	if s.Type != "" && s.Type != val.kind {
+		v.errorf(val, path, "expected %s, got %s", article(s.Type), article(val.kind))
+		return
+	}
+	if len(s.Enum) > 0 && (val.kind != "string" || indexOf(s.Enum, val.str) < 0) {
+		v.errorf(val, path, "%s is not one of %s", describe(val), strings.Join(s.Enum, ", "))
+		return
+	}
+	switch val.kind {
+	case "string":
+		if len(val.str) < s.MinLength {
+			v.errorf(val, path, "must not be empty")
+		}
+	case "array":
+		if len(val.items) < s.MinItems {
+			v.errorf(val, path, "must have at least %d item(s)", s.MinItems)
+		}
+		if s.Items != nil {
+			for i, item := range val.items {
+				v.validate(s.Items, item, path+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	case "object":
+		v.validateObject(s, val, path)
+	}
+}
+
+// validateObject checks the properties of the object at the path.
+func (v *validator) validateObject(s *schema, val *value, path string) {
+	var additional *schema
+	closed := string(s.AdditionalProperties) == "false"
+	if len(s.AdditionalProperties) > 0 && !closed {
+		additional = new(schema)
+		if err := json.Unmarshal(s.AdditionalProperties, additional); err != nil {
+			panic("config: invalid schema: " + err.Error())
+		}
+	}
+	for i, key := range val.keys {
+		field := val.fields[key]
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		switch prop := s.Properties[key]; {
+		case prop != nil:
+			v.validate(prop, field, fieldPath)
+		case additional != nil:
+			v.validate(additional, field, fieldPath)
+		case closed:
+			msg := fmt.Sprintf("unknown property %q", key)
+			if suggestion := closest(key, s.Properties); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			v.errorf(&value{offset: val.keyOffsets[i]}, path, "%s", msg)
+		}
+	}
+	for _, key := range s.Required {
+		if _, ok := val.fields[key]; !ok {
+			v.errorf(val, path, "%q is required", key)
+		}
+	}
+}
+
+// closest gets the property name closest to the key, if it is close
+// enough to be a typo of it.
+func closest(key string, properties map[string]*schema) string {
+	var names []string
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	best, bestDistance := "", 3
+	for _, name := range names {
+		if d := distance(strings.ToLower(key), strings.ToLower(name)); d < bestDistance {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// distance gets the Levenshtein distance between the strings.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// article gets the JSON type with its article, e.g. "an object".
+func article(kind string) string {
+	if strings.IndexByte("aeiou", kind[0]) >= 0 {
+		return "an " + kind
+	}
+	return "a " + kind
+}
+
+// describe gets the value as it is in messages.
+func describe(val *value) string {
+	if val.kind == "string" {
+		return strconv.Quote(val.str)
+	}
+	return article(val.kind)
+}
+
+// indexOf gets the index of s in ss, or -1 if it is not there.
+func indexOf(ss []string, s string) int {
+	for i, other := range ss {
+		if other == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/cheekybits/genny/parse"
+)
+
+// StaleOutput is an output file that does not hold the code its entry
+// generates.
+type StaleOutput struct {
+	// Entry is the name of the entry.
+	Entry string
+	// Out is the path of the output file.
+	Out string
+	// Output is the code the entry generates.
+	Output []byte
+	// Missing is whether the output file does not exist.
+	Missing bool
+}
+
+// Stale generates every entry of the config in memory, formatted, and gets
+// those whose output files are missing or hold other code, in order. It
+// writes nothing and runs no hooks, so that CI can check that the
+// generated code was regenerated after its templates changed.
+func (c *Config) Stale(opts parse.Options) ([]StaleOutput, error) {
+	opts.Unformatted = false
+	if opts.Cache == nil {
+		opts.Cache = &parse.Cache{}
+	}
+	var stale []StaleOutput
+	for _, e := range c.Entries {
+		output, err := c.generate(e, opts)
+		if err != nil {
+			return nil, err
+		}
+		path := c.path(e.Out)
+		current, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil && bytes.Equal(current, output) {
+			continue
+		}
+		stale = append(stale, StaleOutput{Entry: e.Name, Out: path, Output: output, Missing: err != nil})
+	}
+	return stale, nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cheekybits/genny/parse"
+)
+
+// DefaultInterval is how often Watch checks the templates for changes by
+// default.
+const DefaultInterval = 500 * time.Millisecond
+
+// Watch builds every entry of the config, then keeps checking the templates
+// every interval (DefaultInterval if it is 0) and rebuilds the entries of
+// the templates that change, until stop is closed. built is called after
+// each entry is built, with its error if it failed; errors do not stop
+// watching.
+//
+// When a template changes, its parsed versions are dropped from opts.Cache,
+// which is set to a new Cache if it is nil, so that edits are picked up at
+// once while unchanged templates are not parsed again. Templates in
+// archives are rebuilt when the archive changes.
+func (c *Config) Watch(opts parse.Options, interval time.Duration, stop <-chan struct{}, stdout, stderr io.Writer, built func(e Entry, err error)) {
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	if opts.Cache == nil {
+		opts.Cache = &parse.Cache{}
+	}
+	seen := make(map[string]fileState)
+	for _, e := range c.Entries {
+		seen[c.watched(e)] = stat(c.watched(e))
+		built(e, c.BuildEntry(e, opts, stdout, stderr))
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		changed := make(map[string]bool)
+		for filename, state := range seen {
+			if now := stat(filename); now != state {
+				seen[filename] = now
+				changed[filename] = true
+				opts.Cache.Invalidate(filename)
+			}
+		}
+		for _, e := range c.Entries {
+			if changed[c.watched(e)] {
+				built(e, c.BuildEntry(e, opts, stdout, stderr))
+			}
+		}
+	}
+}
+
+// watched gets the file Watch checks for changes to the entry's template:
+// the template, or the archive it is in.
+func (c *Config) watched(e Entry) string {
+	return c.path(strings.SplitN(e.Template, "#", 2)[0])
+}
+
+// fileState is what Watch compares to find changed files. A missing file
+// has the zero state.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// stat gets the state of the file.
+func stat(filename string) fileState {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{modTime: info.ModTime(), size: info.Size()}
+}
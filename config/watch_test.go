@@ -0,0 +1,70 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/genny/config"
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchRebuildsChangedTemplates(t *testing.T) {
+
+	dir := writeFiles(t, map[string]string{
+		"generic_queue.go": template,
+		"generic_stack.go": strings.Replace(template, "Queue", "Stack", -1),
+		config.DefaultFilename: `{
+			"entries": [
+				{"name": "queues", "template": "generic_queue.go", "out": "gen_queue.go", "types": "Something=int"},
+				{"name": "stacks", "template": "generic_stack.go", "out": "gen_stack.go", "types": "Something=int"}
+			]
+		}`,
+	})
+	c, err := config.Load(filepath.Join(dir, config.DefaultFilename))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cache := &parse.Cache{}
+	built := make(chan string, 10)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		c.Watch(parse.Options{Cache: cache}, 10*time.Millisecond, stop, ioutil.Discard, ioutil.Discard, func(e config.Entry, err error) {
+			assert.NoError(t, err)
+			built <- e.Name
+		})
+		close(done)
+	}()
+	next := func() string {
+		select {
+		case name := <-built:
+			return name
+		case <-time.After(5 * time.Second):
+			return "(timed out)"
+		}
+	}
+
+	assert.Equal(t, "queues", next())
+	assert.Equal(t, "stacks", next())
+
+	changed := strings.Replace(template, "items []Something", "items []Something\n\tsize  int", 1)
+	if err := ioutil.WriteFile(filepath.Join(dir, "generic_queue.go"), []byte(changed), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "queues", next(), "only the entry of the changed template is rebuilt")
+	output, err := ioutil.ReadFile(filepath.Join(dir, "gen_queue.go"))
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), "size  int")
+	}
+	assert.Equal(t, 2, cache.Stats().Templates, "the old version of the template is dropped")
+
+	close(stop)
+	<-done
+	assert.Empty(t, built)
+
+}
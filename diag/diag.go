@@ -0,0 +1,227 @@
+// Package diag collects the problems genny finds in a template or its
+// output as diagnostics: errors and warnings with the position they are
+// at, which can be reported together instead of one at a time, matched
+// with errors.Is and errors.As, and serialized as JSON for editors and
+// other tools.
+package diag
+
+import (
+	"encoding/json"
+	"errors"
+	"go/token"
+	"strings"
+)
+
+// Severity is how serious a Diagnostic is.
+type Severity int
+
+const (
+	// Error is a problem that fails generation.
+	Error Severity = iota
+	// Warning is a problem that is reported but does not fail generation.
+	Warning
+)
+
+// String gets "error" or "warning".
+func (s Severity) String() string {
+	if s == Warning {
+		return "warning"
+	}
+	return "error"
+}
+
+// MarshalJSON writes the severity as its string.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON reads the severity from its string.
+func (s *Severity) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	switch str {
+	case "error":
+		*s = Error
+	case "warning":
+		*s = Warning
+	default:
+		return errors.New("unknown severity " + str)
+	}
+	return nil
+}
+
+// Positioner is implemented by errors that know where in a file they are.
+type Positioner interface {
+	Position() token.Position
+}
+
+// Diagnostic is a problem at a position, which may wrap the error it was
+// made from.
+type Diagnostic struct {
+	Pos      token.Position
+	Severity Severity
+	// Message describes the problem, without its position.
+	Message string
+	// Err is the error the diagnostic was made from, if any.
+	Err error
+}
+
+// FromError makes a Diagnostic from the error, taking its position from it
+// if it is a Positioner.
+func FromError(err error, severity Severity) Diagnostic {
+	var d Diagnostic
+	if errors.As(err, &d) {
+		d.Severity = severity
+		return d
+	}
+	d = Diagnostic{Severity: severity, Message: err.Error(), Err: err}
+	var p Positioner
+	if errors.As(err, &p) {
+		d.Pos = p.Position()
+		d.Message = strings.TrimPrefix(d.Message, d.Pos.String()+": ")
+	}
+	return d
+}
+
+// Error gets the diagnostic as a human readable string, prefixed with its
+// position, and marked if it is a warning.
+func (d Diagnostic) Error() string {
+	msg := d.Message
+	if d.Pos.IsValid() {
+		msg = d.Pos.String() + ": " + msg
+	}
+	if d.Severity == Warning {
+		msg = "warning: " + msg
+	}
+	return msg
+}
+
+// Unwrap gets the error the diagnostic was made from.
+func (d Diagnostic) Unwrap() error {
+	return d.Err
+}
+
+// jsonDiagnostic is the JSON form of a Diagnostic.
+type jsonDiagnostic struct {
+	Filename string   `json:"filename,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// MarshalJSON writes the diagnostic as an object with its filename, line,
+// column, severity and message.
+func (d Diagnostic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonDiagnostic{
+		Filename: d.Pos.Filename,
+		Line:     d.Pos.Line,
+		Column:   d.Pos.Column,
+		Severity: d.Severity,
+		Message:  d.Message,
+	})
+}
+
+// UnmarshalJSON reads a diagnostic written by MarshalJSON. The error it
+// was made from is not kept.
+func (d *Diagnostic) UnmarshalJSON(b []byte) error {
+	var j jsonDiagnostic
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	*d = Diagnostic{
+		Pos:      token.Position{Filename: j.Filename, Line: j.Line, Column: j.Column},
+		Severity: j.Severity,
+		Message:  j.Message,
+	}
+	return nil
+}
+
+// List is a bundle of diagnostics. As an error it joins them like
+// errors.Join, so errors.Is and errors.As find the errors of any of them.
+type List []Diagnostic
+
+// Add adds the error to the list with the severity. Lists are flattened
+// into the list, keeping their own severities. Nil errors are ignored.
+func (l *List) Add(err error, severity Severity) {
+	if err == nil {
+		return
+	}
+	var other List
+	if errors.As(err, &other) {
+		*l = append(*l, other...)
+		return
+	}
+	*l = append(*l, FromError(err, severity))
+}
+
+// Errorf adds an error at the position.
+func (l *List) Errorf(pos token.Position, message string) {
+	*l = append(*l, Diagnostic{Pos: pos, Severity: Error, Message: message})
+}
+
+// Warnf adds a warning at the position.
+func (l *List) Warnf(pos token.Position, message string) {
+	*l = append(*l, Diagnostic{Pos: pos, Severity: Warning, Message: message})
+}
+
+// Has gets whether the list has the diagnostic, with the same position,
+// severity and message.
+func (l List) Has(d Diagnostic) bool {
+	for _, other := range l {
+		if other.Pos == d.Pos && other.Severity == d.Severity && other.Message == d.Message {
+			return true
+		}
+	}
+	return false
+}
+
+// Errors gets the diagnostics that are errors.
+func (l List) Errors() List {
+	return l.filter(Error)
+}
+
+// Warnings gets the diagnostics that are warnings.
+func (l List) Warnings() List {
+	return l.filter(Warning)
+}
+
+// filter gets the diagnostics with the severity.
+func (l List) filter(severity Severity) List {
+	var filtered List
+	for _, d := range l {
+		if d.Severity == severity {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
+// Err gets the list as an error if it has any errors, or nil if it only
+// has warnings (or nothing).
+func (l List) Err() error {
+	if len(l.Errors()) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Error gets the diagnostics as a human readable string, one per line.
+func (l List) Error() string {
+	msgs := make([]string, len(l))
+	for i, d := range l {
+		msgs[i] = d.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap gets the diagnostics as errors, for errors.Is and errors.As.
+func (l List) Unwrap() []error {
+	errs := make([]error, len(l))
+	for i, d := range l {
+		errs[i] = d
+	}
+	return errs
+}
@@ -0,0 +1,78 @@
+package diag_test
+
+import (
+	"encoding/json"
+	"errors"
+	"go/token"
+	"testing"
+
+	"github.com/cheekybits/genny/diag"
+	"github.com/stretchr/testify/assert"
+)
+
+// positioned is an error that knows its position.
+type positioned struct {
+	pos token.Position
+}
+
+func (e positioned) Error() string {
+	return e.pos.String() + ": something is wrong"
+}
+
+func (e positioned) Position() token.Position {
+	return e.pos
+}
+
+func TestList(t *testing.T) {
+
+	errPlain := errors.New("plain")
+	pos := token.Position{Filename: "queue.go", Line: 3, Column: 6}
+
+	var l diag.List
+	l.Add(nil, diag.Error)
+	assert.Nil(t, l.Err())
+	l.Warnf(pos, "looks odd")
+	assert.Nil(t, l.Err(), "warnings alone are not an error")
+
+	l.Add(errPlain, diag.Error)
+	l.Add(positioned{pos: pos}, diag.Error)
+	var other diag.List
+	other.Errorf(token.Position{}, "from another list")
+	l.Add(other, diag.Warning)
+
+	if assert.Len(t, l, 4) {
+		assert.Equal(t, "something is wrong", l[2].Message)
+		assert.Equal(t, pos, l[2].Pos)
+		assert.Equal(t, diag.Error, l[3].Severity, "added lists keep their severities")
+	}
+	assert.Len(t, l.Errors(), 3)
+	assert.Len(t, l.Warnings(), 1)
+
+	err := l.Err()
+	if assert.Error(t, err) {
+		assert.Equal(t, "warning: queue.go:3:6: looks odd\nplain\nqueue.go:3:6: something is wrong\nfrom another list", err.Error())
+		assert.True(t, errors.Is(err, errPlain))
+		var p positioned
+		assert.True(t, errors.As(err, &p))
+	}
+
+}
+
+func TestJSON(t *testing.T) {
+
+	l := diag.List{
+		{Pos: token.Position{Filename: "queue.go", Line: 3, Column: 6}, Severity: diag.Warning, Message: "looks odd"},
+		{Severity: diag.Error, Message: "plain"},
+	}
+	b, err := json.Marshal(l)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, `[{"filename":"queue.go","line":3,"column":6,"severity":"warning","message":"looks odd"},{"severity":"error","message":"plain"}]`, string(b))
+
+	var read diag.List
+	if assert.NoError(t, json.Unmarshal(b, &read)) {
+		assert.Equal(t, l, read)
+	}
+
+}
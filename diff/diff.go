@@ -0,0 +1,276 @@
+// Package diff shows how generated code would change, as a unified diff.
+//
+// Generated code often changes by a single identifier in a long line, so
+// when the diff is colored, the words that changed within a changed line
+// are highlighted too.
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Context is the number of unchanged lines shown around each change.
+const Context = 3
+
+const (
+	colorReset   = "\x1b[0m"
+	colorBold    = "\x1b[1m"
+	colorRed     = "\x1b[31m"
+	colorGreen   = "\x1b[32m"
+	colorCyan    = "\x1b[36m"
+	colorReverse = "\x1b[7m"
+	colorNormal  = "\x1b[27m"
+)
+
+// Color gets whether output to f should be colored: only when f is a
+// terminal, and NO_COLOR is not set.
+func Color(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Unified writes a unified diff from the old to the new content to w, and
+// gets whether they differ. With color, removed and added lines are colored
+// and the words that changed within them are highlighted.
+func Unified(w io.Writer, oldName, newName string, old, new []byte, color bool) (bool, error) {
+	a, b := lines(old), lines(new)
+	ops := edits(a, b)
+	hunks := hunks(ops)
+	if len(hunks) == 0 {
+		return false, nil
+	}
+	p := &printer{w: bufio.NewWriter(w), color: color}
+	p.line(colorBold, "--- "+oldName)
+	p.line(colorBold, "+++ "+newName)
+	for _, h := range hunks {
+		p.hunk(a, b, ops[h.from:h.to])
+	}
+	return true, p.w.Flush()
+}
+
+// lines splits the content into lines, without their line endings.
+func lines(content []byte) []string {
+	s := strings.TrimSuffix(string(content), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// op is an edit: a line that is kept, removed from a or added from b. A
+// and B are the indices of the line in a and b (or where it would be).
+type op struct {
+	Kind byte
+	A, B int
+}
+
+const (
+	keep   = ' '
+	remove = '-'
+	add    = '+'
+)
+
+// edits gets the shortest list of edits from a to b, using Myers' diff
+// algorithm.
+func edits(a, b []string) []op {
+	n, m := len(a), len(b)
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, offset, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrack follows the trace of the search back from the end of a and b
+// to get the edits.
+func backtrack(trace [][]int, offset, x, y int) []op {
+	var ops []op
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, op{keep, x, y})
+		}
+		if d > 0 {
+			if x == prevX {
+				ops = append(ops, op{add, x, prevY})
+			} else {
+				ops = append(ops, op{remove, prevX, y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunk is a range of edits shown together.
+type hunk struct {
+	from, to int
+}
+
+// hunks groups the changes, with Context unchanged lines around them,
+// merging changes that are close together.
+func hunks(ops []op) []hunk {
+	var hs []hunk
+	for i, o := range ops {
+		if o.Kind == keep {
+			continue
+		}
+		from, to := i-Context, i+1+Context
+		if from < 0 {
+			from = 0
+		}
+		if to > len(ops) {
+			to = len(ops)
+		}
+		if len(hs) > 0 && from <= hs[len(hs)-1].to {
+			hs[len(hs)-1].to = to
+			continue
+		}
+		hs = append(hs, hunk{from, to})
+	}
+	return hs
+}
+
+type printer struct {
+	w     *bufio.Writer
+	color bool
+}
+
+func (p *printer) line(color, text string) {
+	if p.color {
+		text = color + text + colorReset
+	}
+	p.w.WriteString(text + "\n")
+}
+
+func (p *printer) hunk(a, b []string, ops []op) {
+	first := ops[0]
+	var oldLines, newLines int
+	for _, o := range ops {
+		if o.Kind != add {
+			oldLines++
+		}
+		if o.Kind != remove {
+			newLines++
+		}
+	}
+	p.line(colorCyan, fmt.Sprintf("@@ -%s +%s @@", rangeString(first.A, oldLines), rangeString(first.B, newLines)))
+	for i := 0; i < len(ops); {
+		if ops[i].Kind == keep {
+			p.w.WriteString(" " + a[ops[i].A] + "\n")
+			i++
+			continue
+		}
+		var removed, added []string
+		for ; i < len(ops) && ops[i].Kind != keep; i++ {
+			if ops[i].Kind == remove {
+				removed = append(removed, a[ops[i].A])
+			} else {
+				added = append(added, b[ops[i].B])
+			}
+		}
+		p.changes(removed, added)
+	}
+}
+
+// changes prints lines that were removed and the lines added in their
+// place. Removed and added lines are paired up in order to highlight the
+// words that changed.
+func (p *printer) changes(removed, added []string) {
+	for i, line := range removed {
+		if p.color && i < len(added) {
+			p.words(remove, line, added[i])
+			continue
+		}
+		p.line(colorRed, "-"+line)
+	}
+	for i, line := range added {
+		if p.color && i < len(removed) {
+			p.words(add, removed[i], line)
+			continue
+		}
+		p.line(colorGreen, "+"+line)
+	}
+}
+
+// words prints the old line (for remove) or the new line (for add), with
+// the words that differ from the other one highlighted.
+func (p *printer) words(kind byte, old, new string) {
+	a, b := wordPattern.FindAllString(old, -1), wordPattern.FindAllString(new, -1)
+	color, words := colorRed, a
+	if kind == add {
+		color, words = colorGreen, b
+	}
+	p.w.WriteString(color + string(kind))
+	for _, o := range edits(a, b) {
+		switch {
+		case o.Kind == keep:
+			p.w.WriteString(words[indexOf(kind, o)])
+		case o.Kind == kind:
+			p.w.WriteString(colorReverse + words[indexOf(kind, o)] + colorNormal)
+		}
+	}
+	p.w.WriteString(colorReset + "\n")
+}
+
+func indexOf(kind byte, o op) int {
+	if kind == add {
+		return o.B
+	}
+	return o.A
+}
+
+// wordPattern splits a line into words, runs of space and single
+// punctuation characters.
+var wordPattern = regexp.MustCompile(`[\pL\pN_]+|\s+|.`)
+
+// rangeString gets the range of lines for a hunk header, counting from 1.
+func rangeString(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
@@ -0,0 +1,71 @@
+package diff_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+func numbered(from, to int) []string {
+	var lines []string
+	for i := from; i <= to; i++ {
+		lines = append(lines, "line "+string(rune('a'+i-1)))
+	}
+	return lines
+}
+
+func TestUnified(t *testing.T) {
+	old := strings.Join(numbered(1, 12), "\n") + "\n"
+	new := strings.Replace(old, "line b\n", "", 1)
+	new = strings.Replace(new, "line k\n", "line k\nline K\n", 1)
+
+	var buf bytes.Buffer
+	changed, err := diff.Unified(&buf, "a/gen.go", "b/gen.go", []byte(old), []byte(new), false)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, `--- a/gen.go
++++ b/gen.go
+@@ -1,5 +1,4 @@
+ line a
+-line b
+ line c
+ line d
+ line e
+@@ -9,4 +8,5 @@
+ line i
+ line j
+ line k
++line K
+ line l
+`, buf.String())
+}
+
+func TestUnifiedSame(t *testing.T) {
+	var buf bytes.Buffer
+	changed, err := diff.Unified(&buf, "a", "b", []byte("x\ny\n"), []byte("x\ny\n"), true)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Empty(t, buf.String())
+}
+
+func TestUnifiedNewFile(t *testing.T) {
+	var buf bytes.Buffer
+	changed, err := diff.Unified(&buf, "a", "b", nil, []byte("x\ny\n"), false)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n", buf.String())
+}
+
+func TestUnifiedHighlightsWords(t *testing.T) {
+	old := "func NewIntQueue() *IntQueue {\n"
+	new := "func NewStringQueue() *StringQueue {\n"
+	var buf bytes.Buffer
+	_, err := diff.Unified(&buf, "a", "b", []byte(old), []byte(new), true)
+	assert.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, "\x1b[31m-func \x1b[7mNewIntQueue\x1b[27m() *\x1b[7mIntQueue\x1b[27m {\x1b[0m\n")
+	assert.Contains(t, out, "\x1b[32m+func \x1b[7mNewStringQueue\x1b[27m() *\x1b[7mStringQueue\x1b[27m {\x1b[0m\n")
+}
@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// statWidth is the most + and - signs shown for a file, as git does for
+// an 80 column terminal.
+const statWidth = 50
+
+// FileStat is how much a file changed.
+type FileStat struct {
+	Name       string
+	Insertions int
+	Deletions  int
+}
+
+// Stat counts the lines inserted and deleted from the old to the new
+// content of the file.
+func Stat(name string, old, new []byte) FileStat {
+	s := FileStat{Name: name}
+	for _, o := range edits(lines(old), lines(new)) {
+		switch o.Kind {
+		case add:
+			s.Insertions++
+		case remove:
+			s.Deletions++
+		}
+	}
+	return s
+}
+
+// WriteStat writes a summary of the files that changed, like git diff
+// --stat: a line for each with its number of changed lines and a bar of +
+// and - signs, followed by the totals. Files that did not change are left
+// out, and nothing is written if none did. With color, the signs are
+// colored.
+func WriteStat(w io.Writer, stats []FileStat, color bool) error {
+	var changed []FileStat
+	nameWidth, countWidth, most := 0, 0, 0
+	for _, s := range stats {
+		n := s.Insertions + s.Deletions
+		if n == 0 {
+			continue
+		}
+		changed = append(changed, s)
+		if len(s.Name) > nameWidth {
+			nameWidth = len(s.Name)
+		}
+		if c := len(strconv.Itoa(n)); c > countWidth {
+			countWidth = c
+		}
+		if n > most {
+			most = n
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	bw := bufio.NewWriter(w)
+	insertions, deletions := 0, 0
+	for _, s := range changed {
+		insertions += s.Insertions
+		deletions += s.Deletions
+		plus, minus := s.Insertions, s.Deletions
+		if most > statWidth {
+			// scale the bar, keeping at least one sign for any change
+			plus, minus = scale(plus, most), scale(minus, most)
+		}
+		bar := paint(color, colorGreen, strings.Repeat("+", plus)) + paint(color, colorRed, strings.Repeat("-", minus))
+		fmt.Fprintf(bw, " %-*s | %*d %s\n", nameWidth, s.Name, countWidth, s.Insertions+s.Deletions, bar)
+	}
+	fmt.Fprintf(bw, " %d %s changed", len(changed), plural(len(changed), "file", "files"))
+	if insertions > 0 {
+		fmt.Fprintf(bw, ", %d %s(+)", insertions, plural(insertions, "insertion", "insertions"))
+	}
+	if deletions > 0 {
+		fmt.Fprintf(bw, ", %d %s(-)", deletions, plural(deletions, "deletion", "deletions"))
+	}
+	fmt.Fprintln(bw)
+	return bw.Flush()
+}
+
+// scale scales the count of signs so that most fits in statWidth.
+func scale(n, most int) int {
+	if n == 0 {
+		return 0
+	}
+	if scaled := n * statWidth / most; scaled > 0 {
+		return scaled
+	}
+	return 1
+}
+
+// paint colors the text if color is true and there is any.
+func paint(color bool, code, text string) string {
+	if !color || text == "" {
+		return text
+	}
+	return code + text + colorReset
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
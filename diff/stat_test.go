@@ -0,0 +1,51 @@
+package diff_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/diff"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStat(t *testing.T) {
+	old := strings.Join(numbered(1, 12), "\n") + "\n"
+	new := strings.Replace(old, "line b\n", "", 1)
+	new = strings.Replace(new, "line k\n", "line K\nline L\n", 1)
+
+	s := diff.Stat("gen.go", []byte(old), []byte(new))
+	assert.Equal(t, diff.FileStat{Name: "gen.go", Insertions: 2, Deletions: 2}, s)
+	assert.Equal(t, diff.FileStat{Name: "new.go", Insertions: 12}, diff.Stat("new.go", nil, []byte(old)))
+}
+
+func TestWriteStat(t *testing.T) {
+	var buf bytes.Buffer
+	err := diff.WriteStat(&buf, []diff.FileStat{
+		{Name: "gen_queue.go", Insertions: 3, Deletions: 1},
+		{Name: "same.go"},
+		{Name: "gen_map.go", Insertions: 12},
+	}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, ` gen_queue.go |  4 +++-
+ gen_map.go   | 12 ++++++++++++
+ 2 files changed, 15 insertions(+), 1 deletion(-)
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, diff.WriteStat(&buf, []diff.FileStat{{Name: "same.go"}}, false))
+	assert.Empty(t, buf.String())
+}
+
+func TestWriteStatScaled(t *testing.T) {
+	var buf bytes.Buffer
+	err := diff.WriteStat(&buf, []diff.FileStat{
+		{Name: "big.go", Insertions: 100, Deletions: 100},
+		{Name: "small.go", Deletions: 1},
+	}, false)
+	assert.NoError(t, err)
+	assert.Equal(t, ` big.go   | 200 `+strings.Repeat("+", 25)+strings.Repeat("-", 25)+`
+ small.go |   1 -
+ 2 files changed, 100 insertions(+), 101 deletions(-)
+`, buf.String())
+}
@@ -0,0 +1,298 @@
+// Package docs renders documentation for genny templates: what they are
+// for, their metadata and generic types, how to generate them and a sample
+// of the code they generate.
+//
+// Templates describe themselves with their package doc comment, the doc
+// comments of their generic types and //genny:<key> <value> metadata
+// directives, e.g.
+//
+//	//genny:example Something=string
+//	//genny:since v1.2
+//
+// The example key gives the types the sample is generated with; by default
+// every generic type is an int.
+package docs
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cheekybits/genny/parse"
+)
+
+// Template is the documentation of a template.
+type Template struct {
+	// Filename is the path of the template.
+	Filename string
+	// Package is the template's package name.
+	Package string
+	// Doc is the package doc comment.
+	Doc string
+	// Meta holds the //genny:<key> <value> metadata directives.
+	Meta []Meta
+	// Params are the generic types of the template.
+	Params []Param
+	// Decls are the exported top level declarations.
+	Decls []Decl
+	// Example is the type set the sample was generated with.
+	Example string
+	// Sample is the code generated with the example type set.
+	Sample []byte
+}
+
+// Meta is a metadata directive of a template.
+type Meta struct {
+	Key   string
+	Value string
+}
+
+// Param is a generic type of a template.
+type Param struct {
+	Name string
+	// Kind is generic.Type, generic.Number, generic.Interface or another
+	// of the generic package's kinds, e.g. generic.Integer.
+	Kind string
+	Doc  string
+}
+
+// Decl is an exported top level declaration of a template.
+type Decl struct {
+	Name string
+	Doc  string
+}
+
+// metaPattern matches a metadata directive. The build key guards
+// declarations (see parse.SplitSections), so it is not metadata.
+var metaPattern = regexp.MustCompile(`^//genny:([a-z][a-z0-9_-]*)\s+(.*)$`)
+
+// Load reads the documentation of the template, generating the sample.
+func Load(filename string) (*Template, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	t := &Template{
+		Filename: filename,
+		Package:  file.Name.Name,
+		Doc:      file.Doc.Text(),
+		Params:   params(file),
+		Decls:    decls(file),
+	}
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if m := metaPattern.FindStringSubmatch(c.Text); m != nil && m[1] != "build" {
+				t.Meta = append(t.Meta, Meta{Key: m[1], Value: strings.TrimSpace(m[2])})
+			}
+		}
+	}
+	if len(t.Params) == 0 {
+		return nil, &errNotTemplate{Filename: filename}
+	}
+
+	t.Example = t.example()
+	typeSets, err := parse.TypeSet(t.Example)
+	if err != nil {
+		return nil, &errExample{Filename: filename, Example: t.Example, Err: err}
+	}
+	t.Sample, err = parse.Generics(filename, "sample.go", "", bytes.NewReader(src), typeSets)
+	if err != nil {
+		return nil, &errExample{Filename: filename, Example: t.Example, Err: err}
+	}
+	return t, nil
+}
+
+// Find loads the templates in the directory: the Go files that declare
+// generic types.
+func Find(dir string) ([]*Template, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	var templates []*Template
+	for _, name := range names {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Contains(src, []byte("generic.")) {
+			continue
+		}
+		t, err := Load(name)
+		if _, ok := err.(*errNotTemplate); ok {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+	if len(templates) == 0 {
+		return nil, &errNotTemplate{Filename: dir}
+	}
+	return templates, nil
+}
+
+// Name gets the name of the template's page: its file name without .go.
+func (t *Template) Name() string {
+	return strings.TrimSuffix(filepath.Base(t.Filename), ".go")
+}
+
+// Summary gets the first sentence of the template's doc comment.
+func (t *Template) Summary() string {
+	return summary(t.Doc)
+}
+
+// Usage gets the command that generates the template with the example
+// types.
+func (t *Template) Usage() string {
+	return "genny -in=" + filepath.ToSlash(filepath.Base(t.Filename)) + " -out=gen-" + filepath.ToSlash(filepath.Base(t.Filename)) + ` gen "` + t.Example + `"`
+}
+
+// example gets the example type set: from the example metadata, or with
+// every generic type an int.
+func (t *Template) example() string {
+	for _, m := range t.Meta {
+		if m.Key == "example" {
+			return m.Value
+		}
+	}
+	var pairs []string
+	for _, p := range t.Params {
+		pairs = append(pairs, p.Name+"=int")
+	}
+	return strings.Join(pairs, " ")
+}
+
+// params gets the generic types declared by the template.
+func params(file *ast.File) []Param {
+	var ps []Param
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			ts := spec.(*ast.TypeSpec)
+			sel, ok := ts.Type.(*ast.SelectorExpr)
+			if !ok {
+				continue
+			}
+			if x, ok := sel.X.(*ast.Ident); !ok || x.Name != "generic" || !parse.IsGenericKind(sel.Sel.Name) {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+			ps = append(ps, Param{Name: ts.Name.Name, Kind: "generic." + sel.Sel.Name, Doc: doc.Text()})
+		}
+	}
+	return ps
+}
+
+// decls gets the exported top level declarations of the template, other
+// than its generic types.
+func decls(file *ast.File) []Decl {
+	var ds []Decl
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Name.IsExported() {
+				name := d.Name.Name
+				if d.Recv != nil && len(d.Recv.List) == 1 {
+					name = recvName(d.Recv.List[0].Type) + "." + name
+				}
+				ds = append(ds, Decl{Name: name, Doc: d.Doc.Text()})
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				doc := d.Doc
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if _, generic := s.Type.(*ast.SelectorExpr); generic || !s.Name.IsExported() {
+						continue
+					}
+					if s.Doc != nil {
+						doc = s.Doc
+					}
+					ds = append(ds, Decl{Name: s.Name.Name, Doc: doc.Text()})
+				case *ast.ValueSpec:
+					if s.Doc != nil {
+						doc = s.Doc
+					}
+					for _, name := range s.Names {
+						if name.IsExported() {
+							ds = append(ds, Decl{Name: name.Name, Doc: doc.Text()})
+						}
+					}
+				}
+			}
+		}
+	}
+	return ds
+}
+
+// recvName gets the name of the receiver's type.
+func recvName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return recvName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
+
+// summary gets the first sentence of the doc comment.
+func summary(doc string) string {
+	doc = strings.Join(strings.Fields(doc), " ")
+	if i := strings.Index(doc, ". "); i >= 0 {
+		return doc[:i+1]
+	}
+	return doc
+}
+
+// Write writes a page for each template to dir in the format, along with
+// an index page listing them, and gets the paths of the pages.
+func Write(dir string, templates []*Template, format Format) ([]string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var written []string
+	write := func(name string, render func(*bytes.Buffer) error) error {
+		var buf bytes.Buffer
+		if err := render(&buf); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, name+format.Ext())
+		written = append(written, path)
+		return ioutil.WriteFile(path, buf.Bytes(), 0644)
+	}
+	for _, t := range templates {
+		t := t
+		if err := write(t.Name(), func(buf *bytes.Buffer) error { return format.Page(buf, t) }); err != nil {
+			return nil, err
+		}
+	}
+	if err := write("index", func(buf *bytes.Buffer) error { return format.Index(buf, templates) }); err != nil {
+		return nil, err
+	}
+	return written, nil
+}
@@ -0,0 +1,77 @@
+package docs_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/docs"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	tmpl, err := docs.Load("test/queue/generic_queue.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "queue", tmpl.Package)
+	assert.Equal(t, "generic_queue", tmpl.Name())
+	assert.Equal(t, "Package queue is a first in, first out queue.", tmpl.Summary())
+	assert.Equal(t, []docs.Meta{{Key: "example", Value: "Item=string"}, {Key: "owner", Value: "storage-team"}}, tmpl.Meta)
+	assert.Equal(t, []docs.Param{{Name: "Item", Kind: "generic.Type", Doc: "Item is the type of the items in the queue.\n"}}, tmpl.Params)
+	assert.Equal(t, []docs.Decl{
+		{Name: "ItemQueue", Doc: "ItemQueue is a queue of Items.\n"},
+		{Name: "NewItemQueue", Doc: "NewItemQueue makes an empty queue.\n"},
+		{Name: "ItemQueue.Push", Doc: "Push adds an item to the back of the queue.\n"},
+	}, tmpl.Decls)
+	assert.Equal(t, "Item=string", tmpl.Example)
+	assert.Contains(t, string(tmpl.Sample), "type StringQueue struct")
+	assert.NotContains(t, string(tmpl.Sample), "//genny:")
+	assert.Equal(t, `genny -in=generic_queue.go -out=gen-generic_queue.go gen "Item=string"`, tmpl.Usage())
+}
+
+func TestFind(t *testing.T) {
+	templates, err := docs.Find("test/queue")
+	if assert.NoError(t, err) && assert.Len(t, templates, 1) {
+		assert.Equal(t, "generic_queue", templates[0].Name())
+	}
+	_, err = docs.Find(".")
+	assert.Error(t, err)
+}
+
+func TestFormats(t *testing.T) {
+	tmpl, err := docs.Load("test/queue/generic_queue.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	var md bytes.Buffer
+	assert.NoError(t, docs.Markdown.Page(&md, tmpl))
+	assert.Contains(t, md.String(), "# generic_queue\n")
+	assert.Contains(t, md.String(), "| owner | storage-team |\n")
+	assert.Contains(t, md.String(), "| `Item` | `generic.Type` | Item is the type of the items in the queue. |\n")
+	assert.Contains(t, md.String(), "```go\n// This file was automatically generated by genny.")
+
+	var html bytes.Buffer
+	assert.NoError(t, docs.HTML.Page(&html, tmpl))
+	assert.Contains(t, html.String(), "<h1>generic_queue</h1>")
+	assert.Contains(t, html.String(), "return &amp;StringQueue{}")
+
+	_, err = docs.ParseFormat("pdf")
+	assert.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	templates, err := docs.Find("test/queue")
+	if !assert.NoError(t, err) {
+		return
+	}
+	written, err := docs.Write(dir, templates, docs.HTML)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "generic_queue.html"), filepath.Join(dir, "index.html")}, written)
+	index, err := ioutil.ReadFile(filepath.Join(dir, "index.html"))
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(index), `<a href="generic_queue.html">generic_queue</a>`)
+	}
+}
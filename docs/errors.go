@@ -0,0 +1,37 @@
+package docs
+
+import "fmt"
+
+// errNotTemplate represents an error when a file or directory has no
+// generic types to document.
+type errNotTemplate struct {
+	Filename string
+}
+
+// Error gets a human readable string describing this error.
+func (e errNotTemplate) Error() string {
+	return e.Filename + ": no genny templates found"
+}
+
+// errExample represents an error when the sample of a template cannot be
+// generated.
+type errExample struct {
+	Filename string
+	Example  string
+	Err      error
+}
+
+// Error gets a human readable string describing this error.
+func (e errExample) Error() string {
+	return fmt.Sprintf("%s: cannot generate the example %q: %v", e.Filename, e.Example, e.Err)
+}
+
+// errFormat represents an error when a documentation format is unknown.
+type errFormat struct {
+	Format string
+}
+
+// Error gets a human readable string describing this error.
+func (e errFormat) Error() string {
+	return fmt.Sprintf("unknown docs format %q (want markdown or html)", e.Format)
+}
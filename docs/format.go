@@ -0,0 +1,152 @@
+package docs
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// Format renders documentation pages.
+type Format interface {
+	// Ext gets the extension of the pages, e.g. ".md".
+	Ext() string
+	// Page renders the page of a template.
+	Page(w io.Writer, t *Template) error
+	// Index renders the page listing the templates.
+	Index(w io.Writer, templates []*Template) error
+}
+
+// Markdown renders pages as Markdown.
+var Markdown Format = &textFormat{ext: ".md", templates: template.Must(template.New("").Funcs(template.FuncMap{
+	"cell": func(s string) string {
+		return strings.Replace(strings.Join(strings.Fields(s), " "), "|", `\|`, -1)
+	},
+}).Parse(markdownTemplates))}
+
+// HTML renders pages as HTML.
+var HTML Format = &htmlFormat{templates: htmltemplate.Must(htmltemplate.New("").Parse(htmlTemplates))}
+
+// ParseFormat gets the format with the name: markdown (or md) or html.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "markdown", "md", "":
+		return Markdown, nil
+	case "html":
+		return HTML, nil
+	}
+	return nil, &errFormat{Format: name}
+}
+
+type textFormat struct {
+	ext       string
+	templates *template.Template
+}
+
+func (f *textFormat) Ext() string { return f.ext }
+
+func (f *textFormat) Page(w io.Writer, t *Template) error {
+	return f.templates.ExecuteTemplate(w, "page", t)
+}
+
+func (f *textFormat) Index(w io.Writer, templates []*Template) error {
+	return f.templates.ExecuteTemplate(w, "index", templates)
+}
+
+type htmlFormat struct {
+	templates *htmltemplate.Template
+}
+
+func (f *htmlFormat) Ext() string { return ".html" }
+
+func (f *htmlFormat) Page(w io.Writer, t *Template) error {
+	return f.templates.ExecuteTemplate(w, "page", t)
+}
+
+func (f *htmlFormat) Index(w io.Writer, templates []*Template) error {
+	return f.templates.ExecuteTemplate(w, "index", templates)
+}
+
+const markdownTemplates = `{{define "page"}}# {{.Name}}
+
+{{if .Doc}}{{.Doc}}
+{{end}}Template: ` + "`{{.Filename}}`" + ` (package ` + "`{{.Package}}`" + `)
+{{if .Meta}}
+| Key | Value |
+| --- | --- |
+{{range .Meta}}| {{cell .Key}} | {{cell .Value}} |
+{{end}}{{end}}
+## Generic types
+
+| Name | Kind | Description |
+| --- | --- | --- |
+{{range .Params}}| ` + "`{{.Name}}`" + ` | ` + "`{{.Kind}}`" + ` | {{cell .Doc}} |
+{{end}}{{if .Decls}}
+## Declarations
+
+{{range .Decls}}- ` + "`{{.Name}}`" + `{{with cell .Doc}} - {{.}}{{end}}
+{{end}}{{end}}
+## Usage
+
+` + "```" + `
+{{.Usage}}
+` + "```" + `
+
+## Example
+
+Generated with ` + "`{{.Example}}`" + `:
+
+` + "```go" + `
+{{printf "%s" .Sample}}` + "```" + `
+{{end}}{{define "index"}}# Templates
+
+| Template | Generic types | Description |
+| --- | --- | --- |
+{{range .}}| [{{.Name}}]({{.Name}}.md) | {{range $i, $p := .Params}}{{if $i}}, {{end}}` + "`{{$p.Name}}`" + `{{end}} | {{cell .Summary}} |
+{{end}}{{end}}`
+
+const htmlTemplates = `{{define "head"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.}}</title>
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; padding: 0 1em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+pre { background: #f6f8fa; padding: 1em; overflow: auto; }
+</style>
+</head>
+<body>
+{{end}}{{define "page"}}{{template "head" .Name}}<p><a href="index.html">Templates</a></p>
+<h1>{{.Name}}</h1>
+{{if .Doc}}<p>{{.Doc}}</p>
+{{end}}<p>Template: <code>{{.Filename}}</code> (package <code>{{.Package}}</code>)</p>
+{{if .Meta}}<table>
+<tr><th>Key</th><th>Value</th></tr>
+{{range .Meta}}<tr><td>{{.Key}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+{{end}}<h2>Generic types</h2>
+<table>
+<tr><th>Name</th><th>Kind</th><th>Description</th></tr>
+{{range .Params}}<tr><td><code>{{.Name}}</code></td><td><code>{{.Kind}}</code></td><td>{{.Doc}}</td></tr>
+{{end}}</table>
+{{if .Decls}}<h2>Declarations</h2>
+<ul>
+{{range .Decls}}<li><code>{{.Name}}</code>{{with .Doc}} - {{.}}{{end}}</li>
+{{end}}</ul>
+{{end}}<h2>Usage</h2>
+<pre>{{.Usage}}</pre>
+<h2>Example</h2>
+<p>Generated with <code>{{.Example}}</code>:</p>
+<pre><code>{{printf "%s" .Sample}}</code></pre>
+</body>
+</html>
+{{end}}{{define "index"}}{{template "head" "Templates"}}<h1>Templates</h1>
+<table>
+<tr><th>Template</th><th>Generic types</th><th>Description</th></tr>
+{{range .}}<tr><td><a href="{{.Name}}.html">{{.Name}}</a></td><td>{{range $i, $p := .Params}}{{if $i}}, {{end}}<code>{{$p.Name}}</code>{{end}}</td><td>{{.Summary}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+{{end}}`
@@ -0,0 +1,28 @@
+// Package queue is a first in, first out queue. It is not safe for
+// concurrent use.
+//
+//genny:example Item=string
+//genny:owner storage-team
+package queue
+
+import "github.com/cheekybits/genny/generic"
+
+// Item is the type of the items in the queue.
+type Item generic.Type
+
+// ItemQueue is a queue of Items.
+type ItemQueue struct {
+	items []Item
+}
+
+// NewItemQueue makes an empty queue.
+func NewItemQueue() *ItemQueue {
+	return &ItemQueue{}
+}
+
+// Push adds an item to the back of the queue.
+func (q *ItemQueue) Push(item Item) {
+	q.items = append(q.items, item)
+}
+
+func (q *ItemQueue) grow() {}
@@ -11,3 +11,51 @@ type Type interface{}
 // references to the specific types.
 //      var GenericType generic.Number
 type Number float64
+
+// Integer is the placeholder type that indicates a generic integer value,
+// for templates that use integer operations such as % or bit shifts.
+// When genny is executed, variables of this type will be replaced with
+// references to the specific types, which must be integer types.
+//      var GenericType generic.Integer
+type Integer int
+
+// Unsigned is the placeholder type that indicates a generic unsigned
+// integer value. When genny is executed, variables of this type will be
+// replaced with references to the specific types, which must be unsigned
+// integer types.
+//      var GenericType generic.Unsigned
+type Unsigned uint
+
+// Float is the placeholder type that indicates a generic floating-point
+// value. When genny is executed, variables of this type will be replaced
+// with references to the specific types, which must be float32 or float64.
+//      var GenericType generic.Float
+type Float float64
+
+// Interface is the placeholder type that indicates a generic value of an
+// interface type. When genny is executed, variables of this type will be
+// replaced with references to the specific types, which must be interfaces.
+// genny refuses templates that take pointers to it, or use it as a map key
+// or compare it without a //genny:comparable directive.
+//      var GenericType generic.Interface
+type Interface interface{}
+
+// Comparable is the placeholder type that indicates a generic value that
+// can be compared and used as a map key, for set and map templates. When
+// genny is executed, variables of this type will be replaced with
+// references to the specific types, which must not be slices, maps or
+// functions.
+//      var GenericType generic.Comparable
+type Comparable interface{}
+
+// Index is the placeholder for the index of the type set the code is
+// generated for, counting from 0. When genny is executed, it is replaced
+// with the number, e.g. to register each instantiation in a shared array.
+//      constructors[generic.Index] = NewGenericTypeQueue
+const Index = 0
+
+// Count is the placeholder for the number of type sets genny is executed
+// with. When genny is executed, it is replaced with the number. Top level
+// declarations that use Count but no generic type are only generated once.
+//      var constructors [generic.Count]func() Queue
+const Count = 1
@@ -1,8 +1,13 @@
 module github.com/cheekybits/genny
 
-go 1.12
+go 1.20
 
 require (
 	github.com/stretchr/testify v1.3.0
 	golang.org/x/tools v0.0.0-20190328030505-8f05a32dce9f
 )
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
@@ -0,0 +1,276 @@
+package hints
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Hint suggests a genny instantiation to remove interface conversions or
+// dynamic dispatch from a hot function.
+type Hint struct {
+	// Function is the hot function, as named in the profile.
+	Function string
+	// Share is the fraction of the profile spent in interface conversions
+	// and dispatch called from Function.
+	Share float64
+	// Types are the specific types guessed from the conversions seen.
+	Types []string
+	// File is the file in the package declaring Function.
+	File string
+	// Placeholders are the generic types declared in File, if it is a genny
+	// template.
+	Placeholders []string
+	// Directive is a ready to use go:generate line, or empty if File is not a
+	// template or no types could be guessed.
+	Directive string
+}
+
+// dispatchPrefixes are the runtime functions that show interface
+// conversions, assertions and comparisons.
+var dispatchPrefixes = []string{
+	"runtime.convT",
+	"runtime.convI2I",
+	"runtime.assertE2I",
+	"runtime.assertI2I",
+	"runtime.typeAssert",
+	"runtime.getitab",
+	"runtime.efaceeq",
+	"runtime.ifaceeq",
+	"runtime.interhash",
+	"runtime.nilinterhash",
+}
+
+// conversionTypes are the specific types that the runtime conversion
+// functions most likely box.
+var conversionTypes = map[string]string{
+	"runtime.convT16":     "int16",
+	"runtime.convT32":     "int32",
+	"runtime.convT64":     "int64",
+	"runtime.convTstring": "string",
+	"runtime.convTslice":  "[]byte",
+}
+
+// Analyze reads a pprof profile and suggests genny instantiations for the
+// functions of the package in dir that spend time converting values to
+// and from interfaces.
+func Analyze(prof io.Reader, dir string) ([]Hint, error) {
+	p, err := readProfile(prof)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return nil, err
+	}
+	if pkg == nil {
+		return nil, nil
+	}
+
+	value := valueIndex(p.SampleTypes)
+	var total int64
+	hot := make(map[string]int64)
+	types := make(map[string]map[string]bool)
+	for _, s := range p.Samples {
+		if value >= len(s.Values) {
+			continue
+		}
+		v := s.Values[value]
+		total += v
+		dispatch := ""
+		for _, fn := range s.Stack {
+			if dispatch == "" {
+				if isDispatch(fn) {
+					dispatch = fn
+				}
+				continue
+			}
+			name, ok := pkg.funcName(fn)
+			if !ok {
+				continue
+			}
+			hot[name] += v
+			if types[name] == nil {
+				types[name] = make(map[string]bool)
+			}
+			if t, ok := conversionTypes[dispatch]; ok {
+				types[name][t] = true
+			}
+			break
+		}
+	}
+	if total == 0 {
+		return nil, nil
+	}
+
+	var hints []Hint
+	for name, v := range hot {
+		h := Hint{
+			Function: pkg.Name + "." + name,
+			Share:    float64(v) / float64(total),
+			File:     pkg.Funcs[name],
+		}
+		for t := range types[name] {
+			h.Types = append(h.Types, t)
+		}
+		sort.Strings(h.Types)
+		h.Placeholders = pkg.Templates[h.File]
+		if len(h.Placeholders) > 0 && len(h.Types) > 0 {
+			var sets []string
+			for _, placeholder := range h.Placeholders {
+				sets = append(sets, placeholder+"="+strings.Join(h.Types, ","))
+			}
+			base := filepath.Base(h.File)
+			h.Directive = fmt.Sprintf("//go:generate genny -in=%s -out=gen-%s gen %q", base, base, strings.Join(sets, " "))
+		}
+		hints = append(hints, h)
+	}
+	sort.Slice(hints, func(i, j int) bool {
+		if hints[i].Share != hints[j].Share {
+			return hints[i].Share > hints[j].Share
+		}
+		return hints[i].Function < hints[j].Function
+	})
+	return hints, nil
+}
+
+// Write writes the hints in a human readable form.
+func Write(w io.Writer, hints []Hint) {
+	if len(hints) == 0 {
+		fmt.Fprintln(w, "no interface conversions found in hot paths of the package")
+		return
+	}
+	for _, h := range hints {
+		fmt.Fprintf(w, "%5.1f%% %s (%s)", h.Share*100, h.Function, h.File)
+		if len(h.Types) > 0 {
+			fmt.Fprintf(w, " boxes %s", strings.Join(h.Types, ", "))
+		}
+		fmt.Fprintln(w)
+		switch {
+		case h.Directive != "":
+			fmt.Fprintln(w, "\t"+h.Directive)
+		case len(h.Placeholders) == 0:
+			fmt.Fprintln(w, "\tconsider turning it into a genny template")
+		}
+	}
+}
+
+// valueIndex gets the index of the sample value to use.
+func valueIndex(sampleTypes []string) int {
+	for _, preferred := range []string{"cpu", "alloc_space"} {
+		for i, t := range sampleTypes {
+			if t == preferred {
+				return i
+			}
+		}
+	}
+	return len(sampleTypes) - 1
+}
+
+func isDispatch(fn string) bool {
+	for _, prefix := range dispatchPrefixes {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// pkgInfo describes the functions of a package.
+type pkgInfo struct {
+	Name string
+	// Funcs maps function names ("F", "T.M" or "(*T).M") to their files.
+	Funcs map[string]string
+	// Templates maps the template files to their generic types.
+	Templates map[string][]string
+}
+
+// funcName gets the name of the function within the package, if the
+// profile function belongs to it.
+func (p *pkgInfo) funcName(fn string) (string, bool) {
+	if i := strings.LastIndex(fn, "/"); i >= 0 {
+		fn = fn[i+1:]
+	}
+	if !strings.HasPrefix(fn, p.Name+".") {
+		return "", false
+	}
+	fn = strings.TrimPrefix(fn, p.Name+".")
+	// closures are attributed to their enclosing function
+	for {
+		i := strings.LastIndex(fn, ".func")
+		if i < 0 {
+			break
+		}
+		if _, err := strconv.Atoi(strings.Split(fn[i+5:], ".")[0]); err != nil {
+			break
+		}
+		fn = fn[:i]
+	}
+	_, ok := p.Funcs[fn]
+	return fn, ok
+}
+
+// loadPackage parses the (non test) files of the package in dir.
+func loadPackage(dir string) (*pkgInfo, error) {
+	fs := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fs, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range pkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return nil, nil
+	}
+	pkg := pkgs[names[0]]
+	info := &pkgInfo{Name: pkg.Name, Funcs: make(map[string]string), Templates: make(map[string][]string)}
+	for filename, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				info.Funcs[funcDeclName(d)] = filename
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					ts, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					if sel, ok := ts.Type.(*ast.SelectorExpr); ok {
+						if x, ok := sel.X.(*ast.Ident); ok && x.Name == "generic" {
+							info.Templates[filename] = append(info.Templates[filename], ts.Name.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return info, nil
+}
+
+// funcDeclName gets the name of the function as it appears in profiles.
+func funcDeclName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	switch t := fn.Recv.List[0].Type.(type) {
+	case *ast.StarExpr:
+		if ident, ok := t.X.(*ast.Ident); ok {
+			return "(*" + ident.Name + ")." + fn.Name.Name
+		}
+	case *ast.Ident:
+		return t.Name + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
@@ -0,0 +1,109 @@
+package hints_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/cheekybits/genny/hints"
+	"github.com/stretchr/testify/assert"
+)
+
+// message encodes protobuf fields for building test profiles.
+type message struct{ bytes.Buffer }
+
+func (m *message) varint(num int, v uint64) *message {
+	m.uvarint(uint64(num)<<3 | 0)
+	m.uvarint(v)
+	return m
+}
+
+func (m *message) bytes(num int, b []byte) *message {
+	m.uvarint(uint64(num)<<3 | 2)
+	m.uvarint(uint64(len(b)))
+	m.Write(b)
+	return m
+}
+
+func (m *message) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	m.Write(buf[:binary.PutUvarint(buf[:], v)])
+}
+
+func packed(vals ...uint64) []byte {
+	var m message
+	for _, v := range vals {
+		m.uvarint(v)
+	}
+	return m.Bytes()
+}
+
+// testProfile builds a cpu profile with one sample per stack, where stacks
+// are lists of function names (leaf first).
+func testProfile(stacks map[int64][]string) []byte {
+	var p message
+	strs := []string{"", "samples", "count", "cpu", "nanoseconds"}
+	index := func(s string) uint64 {
+		for i, str := range strs {
+			if str == s {
+				return uint64(i)
+			}
+		}
+		strs = append(strs, s)
+		return uint64(len(strs) - 1)
+	}
+	p.bytes(1, new(message).varint(1, 1).varint(2, 2).Bytes())
+	p.bytes(1, new(message).varint(1, 3).varint(2, 4).Bytes())
+	ids := make(map[string]uint64)
+	for value, stack := range stacks {
+		var locs []uint64
+		for _, fn := range stack {
+			if _, ok := ids[fn]; !ok {
+				id := uint64(len(ids) + 1)
+				ids[fn] = id
+				p.bytes(5, new(message).varint(1, id).varint(2, index(fn)).Bytes())
+				line := new(message).varint(1, id).Bytes()
+				p.bytes(4, new(message).varint(1, id).bytes(4, line).Bytes())
+			}
+			locs = append(locs, ids[fn])
+		}
+		p.bytes(2, new(message).bytes(1, packed(locs...)).bytes(2, packed(1, uint64(value))).Bytes())
+	}
+	for _, s := range strs {
+		p.bytes(6, []byte(s))
+	}
+	return p.Bytes()
+}
+
+func TestAnalyze(t *testing.T) {
+
+	prof := testProfile(map[int64][]string{
+		60: {"runtime.convT64", "github.com/cheekybits/genny/hints/test/sum.SumNumbers", "main.main"},
+		30: {"runtime.convTstring", "github.com/cheekybits/genny/hints/test/sum.Describe", "main.main"},
+		10: {"github.com/cheekybits/genny/hints/test/sum.SumNumbers", "main.main"},
+	})
+
+	hs, err := hints.Analyze(bytes.NewReader(prof), "test/sum")
+	if assert.NoError(t, err) && assert.Len(t, hs, 2) {
+
+		assert.Equal(t, "sum.SumNumbers", hs[0].Function)
+		assert.InDelta(t, 0.6, hs[0].Share, 0.001)
+		assert.Equal(t, []string{"int64"}, hs[0].Types)
+		assert.Equal(t, `//go:generate genny -in=sum.go -out=gen-sum.go gen "Number=int64"`, hs[0].Directive)
+
+		assert.Equal(t, "sum.Describe", hs[1].Function)
+		assert.Equal(t, []string{"string"}, hs[1].Types)
+		assert.Empty(t, hs[1].Placeholders)
+		assert.Empty(t, hs[1].Directive)
+
+		var buf bytes.Buffer
+		hints.Write(&buf, hs)
+		assert.Contains(t, buf.String(), " 60.0% sum.SumNumbers")
+		assert.Contains(t, buf.String(), "consider turning it into a genny template")
+
+	}
+
+	_, err = hints.Analyze(bytes.NewReader([]byte("not a profile")), "test/sum")
+	assert.Error(t, err)
+
+}
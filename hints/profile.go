@@ -0,0 +1,208 @@
+package hints
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+// profile is the small part of a pprof profile that hints needs.
+type profile struct {
+	// SampleTypes are the names of the values of each sample, e.g.
+	// "samples" and "cpu".
+	SampleTypes []string
+	Samples     []sample
+}
+
+// sample is a stack of function names (leaf first) with its values.
+type sample struct {
+	Stack  []string
+	Values []int64
+}
+
+var errBadProfile = errors.New("not a valid pprof profile")
+
+// readProfile reads a (possibly gzipped) pprof protobuf profile.
+func readProfile(r io.Reader) (*profile, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = ioutil.ReadAll(gz); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		strs        []string
+		sampleTypes [][]int64 // type, unit string indexes
+		samples     [][2][]uint64
+		locations   = make(map[uint64][]uint64) // location id -> function ids
+		functions   = make(map[uint64]int64)    // function id -> name string index
+	)
+	err = fields(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case 1: // sample_type
+			var st []int64
+			err := fields(b, func(num int, v uint64, _ []byte) error {
+				if num == 1 {
+					st = append(st, int64(v))
+				}
+				return nil
+			})
+			sampleTypes = append(sampleTypes, st)
+			return err
+		case 2: // sample
+			var s [2][]uint64
+			err := fields(b, func(num int, v uint64, b []byte) error {
+				if num == 1 || num == 2 {
+					vals, err := varints(v, b)
+					s[num-1] = append(s[num-1], vals...)
+					return err
+				}
+				return nil
+			})
+			samples = append(samples, s)
+			return err
+		case 4: // location
+			var id uint64
+			var fns []uint64
+			err := fields(b, func(num int, v uint64, b []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 4: // line
+					return fields(b, func(num int, v uint64, _ []byte) error {
+						if num == 1 {
+							fns = append(fns, v)
+						}
+						return nil
+					})
+				}
+				return nil
+			})
+			locations[id] = fns
+			return err
+		case 5: // function
+			var id uint64
+			var name int64
+			err := fields(b, func(num int, v uint64, _ []byte) error {
+				switch num {
+				case 1:
+					id = v
+				case 2:
+					name = int64(v)
+				}
+				return nil
+			})
+			functions[id] = name
+			return err
+		case 6: // string_table
+			strs = append(strs, string(b))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	str := func(i int64) string {
+		if i < 0 || int(i) >= len(strs) {
+			return ""
+		}
+		return strs[i]
+	}
+	p := &profile{}
+	for _, st := range sampleTypes {
+		if len(st) > 0 {
+			p.SampleTypes = append(p.SampleTypes, str(st[0]))
+		}
+	}
+	for _, s := range samples {
+		var smp sample
+		for _, loc := range s[0] {
+			// inlined functions come first in a location
+			for _, fn := range locations[loc] {
+				smp.Stack = append(smp.Stack, str(functions[fn]))
+			}
+		}
+		for _, v := range s[1] {
+			smp.Values = append(smp.Values, int64(v))
+		}
+		p.Samples = append(p.Samples, smp)
+	}
+	return p, nil
+}
+
+// fields calls fn for each field of the protobuf message in data. For
+// varint fields v is the value, for length delimited fields b is the data.
+func fields(data []byte, fn func(num int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errBadProfile
+		}
+		data = data[n:]
+		num, wire := int(key>>3), key&7
+		switch wire {
+		case 0: // varint
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errBadProfile
+			}
+			data = data[n:]
+			if err := fn(num, v, nil); err != nil {
+				return err
+			}
+		case 1: // 64-bit
+			if len(data) < 8 {
+				return errBadProfile
+			}
+			data = data[8:]
+		case 2: // length delimited
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return errBadProfile
+			}
+			b := data[n : n+int(l)]
+			data = data[n+int(l):]
+			if err := fn(num, 0, b); err != nil {
+				return err
+			}
+		case 5: // 32-bit
+			if len(data) < 4 {
+				return errBadProfile
+			}
+			data = data[4:]
+		default:
+			return errBadProfile
+		}
+	}
+	return nil
+}
+
+// varints gets the values of a repeated varint field, which may or may not
+// be packed.
+func varints(v uint64, b []byte) ([]uint64, error) {
+	if b == nil {
+		return []uint64{v}, nil
+	}
+	var vals []uint64
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errBadProfile
+		}
+		vals = append(vals, v)
+		b = b[n:]
+	}
+	return vals, nil
+}
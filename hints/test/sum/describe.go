@@ -0,0 +1,8 @@
+package sum
+
+import "fmt"
+
+// Describe describes any value.
+func Describe(v interface{}) string {
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,14 @@
+package sum
+
+import "github.com/cheekybits/genny/generic"
+
+type Number generic.Number
+
+// SumNumbers adds up the Numbers.
+func SumNumbers(numbers ...Number) Number {
+	var total Number
+	for _, n := range numbers {
+		total += n
+	}
+	return total
+}
@@ -2,16 +2,35 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/cheekybits/genny/bundle"
+	"github.com/cheekybits/genny/config"
+	"github.com/cheekybits/genny/diff"
+	"github.com/cheekybits/genny/docs"
+	"github.com/cheekybits/genny/hints"
+	"github.com/cheekybits/genny/minimize"
 	"github.com/cheekybits/genny/out"
 	"github.com/cheekybits/genny/parse"
+	"github.com/cheekybits/genny/paths"
+	"github.com/cheekybits/genny/report"
 )
 
 /*
@@ -29,102 +48,284 @@ const (
 	exitcodeGetFailed
 	exitcodeSourceFileInvalid
 	exitcodeDestFileFailed
+	exitcodeHintsFailed
+	exitcodeBudgetExceeded
+	exitcodeUnusedFailed
+	exitcodeVerifyFailed
+	exitcodeVetFailed
+	exitcodeBuildFailed
+	exitcodeMinimizeFailed
+	exitcodeDocsFailed
+	exitcodeFormatFailed
+	exitcodeRollbackFailed
+	exitcodeBundleFailed
+	exitcodeCleanFailed
+	exitcodeRunFailed
+	exitcodeConfigInvalid
+	exitcodeNondeterministic
+	exitcodeAuditFailed
+	exitcodeStale
 )
 
+// prefix is where get finds templates from the online library.
+const prefix = "https://github.com/metabition/gennylib/raw/master/"
+
+// The flags are shared by every command, and can be given before the
+// command, as genny has always taken them, or after it, among its
+// arguments. Each command lists the flags it uses in commands.
+var (
+	in        = flag.String("in", "", "file to parse instead of stdin (or - for stdin), or a template in an archive (archive.tar.gz#template.go), or a directory or package path whose files make up one template")
+	outFile   = flag.String("out", "", "file to save output to instead of stdout, or a destination URI: file:path, git:path (written and staged), stdout: or an http(s):// URL to PUT it to")
+	pkgName   = flag.String("pkg", "", "package name for generated files")
+	todo      = flag.String("todo", "keep", "what to do with TODO and FIXME comments: keep, strip or tag")
+	maxLines  = flag.Int("max-lines", 0, "warn when the generated code in the output package exceeds this many lines")
+	maxBytes  = flag.Int("max-bytes", 0, "warn when the generated code in the output package exceeds this many bytes")
+	strict    = flag.Bool("strict", false, "enable all correctness checks and fail instead of warning when one does not pass")
+	constName = flag.String("const", "", "write the generated code as the value of a string constant with this name")
+	match     = flag.String("match", "", "with minimize, only count failures whose message matches this regular expression")
+	coverage  = flag.Bool("coverage", false, "report which template lines reach the output instead of generating code")
+	explain   = flag.Bool("explain", false, "report which template lines are dropped from the output, and why, instead of generating code")
+	preview   = flag.String("preview", "", "show how the template expands for the first type set instead of generating code: diff (interleaved) or side (side by side)")
+	ifaces    = flag.Bool("interfaces", false, "also generate an interface with the exported methods of each generated type")
+	fakes     = flag.Bool("fakes", false, "also generate the interfaces and a fake implementation of each for tests")
+	showDiff  = flag.Bool("diff", false, "show how the -out file would change instead of writing it")
+	check     = flag.Bool("check", envBool("GENNY_CHECK"), "compare the generated code with the -out file (or files), or with build those of the config, without writing anything, and fail listing those that are stale")
+	dryRun    = flag.Bool("dry-run", false, "print the code that would be written to the -out file, or files, instead of writing it")
+	annotate  = flag.Bool("annotate", false, "list the template, its hash and the type sets in the header of the generated file")
+	owners    = flag.Bool("owners", false, "name the template and its //genny:owner owners in the header of the generated file")
+	buildExpr = flag.String("constraint", "", "build constraint expression to write at the top of the generated file as //go:build and // +build lines, e.g. \"linux && amd64\"")
+	typeCheck = flag.Bool("typecheck", false, "type check the generated code with go/types in the package of -out before writing it, reporting each error at the template line and type set it comes from")
+	verifyBy  = flag.String("verify", "", "check the generated code before writing it, reporting each problem at the template line and type set it comes from: types (type check it with go/types, as -typecheck) or vet (run go vet on the -out package in a sandbox module)")
+	lineDirs  = flag.Bool("line-directives", false, "add //line directives to the generated code, so that compiler errors, panics and debuggers point at the template lines it comes from")
+	split     = flag.Bool("split-build", false, "write declarations guarded by //genny:build directives to a file for each constraint")
+	format    = flag.String("format", "markdown", "with docs and describe, the format of the pages: markdown or html")
+	deferFmt  = flag.Bool("defer-format", false, "write unformatted output, to be formatted later by genny fmt (build formats all its entries at the end)")
+	tags      = flag.String("tags", "", "comma separated build tags to load packages with when verifying the output (-strict, -typecheck)")
+	force     = flag.Bool("force", false, "write the output even if -out is the template or another template")
+	crash     = flag.String("crash-report", "", "file to write a crash report to if genny fails with an internal error")
+	tagKeys   = flag.String("struct-tags", "", "comma separated struct tag keys to substitute into, each with an optional casing, e.g. json:lower,db:snake, or * for every key (default all tags, as strings)")
+	strWords  = flag.Bool("string-words", false, "also substitute the specific types into the words of string literals that start with a generic type with a lower case first letter or in snake case, as in log and error messages")
+	reqDocs   = flag.Bool("require-docs", false, "fail when a generic type of the template has no doc comment describing it")
+	reportTo  = flag.String("report", os.Getenv("GENNY_REPORT"), "append a record of each generation (template, duration, outcome) to this local file, as CSV if it ends in .csv and JSON lines otherwise")
+	useCache  = flag.Bool("cache", false, "leave the -out file untouched, without generating it, when the template, type sets and flags are those it was last generated with and it has not changed since; the hashes are kept in .genny/cache")
+	backup    = flag.Bool("backup", false, "keep a copy of the files genny overwrites or removes in .genny/backup, to restore with genny rollback")
+	script    = flag.Bool("script", false, "generate a standalone program in package main, with a main stub if the template has no main function, built only with go run")
+	run       = flag.String("run", "", "with build and watch, only build the entries whose names match this regular expression")
+	skip      = flag.String("skip", "", "with build and watch, skip the entries whose names match this regular expression")
+	seed      = flag.Int64("seed", 0, "with shuffle, the seed of the order the entries and type sets are generated in the second time (default random)")
+	nameTmpl  = flag.String("name-template", "", "text/template composing the names of the generated declarations from {{.Generic}}, the name in the template without its generic types (Queue), and {{.Concrete}}, the words of the specific types (Int), e.g. {{.Generic}}Of{{.Concrete}}")
+	casing    = flag.String("casing", "", "comma separated casings of the specific types in identifiers, strings and comments, each optionally for one generic type, e.g. strings:verbatim,Key.comments:lower")
+	encoding  = flag.String("encoding", "", "the encoding of the template if it is not UTF-8: windows-1252 or iso-8859-1 (byte order marks are handled either way)")
+	importMap = flag.String("import-map", "", "file of package names and import paths (name path per line) to import the packages goimports cannot find from")
+	resolve   = flag.String("resolve", "", "command to run when goimports cannot find packages, with their names in $GENNY_MISSING; it prints name path lines of imports to add")
+	plugins   = flag.String("plugins", "", "write a plugin package for each type set to a directory in this one, with a plugin.json manifest, instead of generating one file")
+	generics  = flag.String("placeholders", "", "comma separated generic types the template does not declare itself, each with its kind, e.g. Item:Type,Num:Number")
+	testPkg   = flag.Bool("test-package", false, "generate into the external test package of the -out package, importing it, and name -out a _test.go file")
+	require   = flag.Bool("require", false, "add the modules of imports the -out module does not require yet to its go.mod, with go get")
+	timings   = flag.String("timings", "", "write a JSON breakdown of the time each phase of each generation took (parse, substitute, format, write; per template and type set) to this file, or - for stderr")
+	engine    = flag.String("engine", "lines", "how the specific types are substituted: lines (token by token, line by line) or ast (renaming identifiers and printing the parsed template)")
+	mode      = flag.String("mode", "copies", "what to generate: copies (of the template for each type set) or typeparams (the template converted to Go 1.18 type parameters, without type sets)")
+	hdrFile   = flag.String("header-file", "", "text/template file of a custom header for the generated file, in place of the one pointing to genny")
+	perSet    = flag.String("per-type-set", "", "write each type set to its own file, named by this pattern with %T replaced by the specific types, e.g. stack_%T.go, instead of one -out file")
+	genPkgs   = flag.String("generic-packages", "", "comma separated packages besides genny's generic package whose Type, Number and Interface declare generic types, by name (genny) or import path")
+	workers   = flag.Int("workers", 0, "how many type sets to generate at once, by default as many as there are CPUs; the output is the same however many")
+	directive directiveFlag
+)
+
+func init() {
+	flag.Var(&directive, "directive", "add a compiler directive to a generated function, as Func=//go:noinline (or nosplit, norace, nocheckptr, or //go:build <expr>); may be repeated")
+}
+
+// directiveFlag collects the directives of every -directive flag.
+type directiveFlag []parse.Directive
+
+func (f *directiveFlag) String() string {
+	var ds []string
+	for _, d := range *f {
+		ds = append(ds, d.Func+"="+d.Text)
+	}
+	return strings.Join(ds, " ")
+}
+
+func (f *directiveFlag) Set(s string) error {
+	d, err := parse.ParseDirective(s)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, d)
+	return nil
+}
+
 func main() {
-	var (
-		in      = flag.String("in", "", "file to parse instead of stdin")
-		out     = flag.String("out", "", "file to save output to instead of stdout")
-		pkgName = flag.String("pkg", "", "package name for generated files")
-		prefix  = "https://github.com/metabition/gennylib/raw/master/"
-	)
+	flag.Usage = usage
 	flag.Parse()
-	args := flag.Args()
-
-	if len(args) < 2 {
+	if flag.NArg() == 0 {
 		usage()
 		os.Exit(exitcodeInvalidArgs)
 	}
-
-	if strings.ToLower(args[0]) != "gen" && strings.ToLower(args[0]) != "get" {
+	if strings.ToLower(flag.Arg(0)) == "help" {
+		help(parseArgs(flag.CommandLine, flag.Args()[1:]))
+		return
+	}
+	cmd := lookupCommand(flag.Arg(0))
+	if cmd == nil {
+		fmt.Fprintln(os.Stderr, "unknown command:", flag.Arg(0))
 		usage()
 		os.Exit(exitcodeInvalidArgs)
 	}
-
-	// parse the typesets
-	var setsArg = args[1]
-	if strings.ToLower(args[0]) == "get" {
-		setsArg = args[2]
+	args := parseArgs(flag.CommandLine, flag.Args()[1:])
+	if len(args) < cmd.minArgs {
+		commandUsage(cmd)
+		os.Exit(exitcodeInvalidArgs)
 	}
-	typeSets, err := parse.TypeSet(setsArg)
-	if err != nil {
-		fatal(exitcodeInvalidTypeSet, err)
+	flag.Visit(func(f *flag.Flag) {
+		if !cmd.uses(f.Name) {
+			warn("-"+f.Name, "has no effect on", cmd.name)
+		}
+	})
+	dest := *outFile
+	if *testPkg {
+		dest = testFile(dest)
 	}
-
-	outWriter := newWriter(*out)
-	outputFilename := *out
-	if outputFilename == "" {
-		outputFilename = "stdout"
+	*in, *outFile = paths.Resolve("", *in), out.SinkPath(dest)
+	var err error
+	if outSink, err = out.OpenSink(dest); err != nil {
+		fatal(exitcodeInvalidArgs, err)
 	}
 
-	if strings.ToLower(args[0]) == "get" {
-		if len(args) != 3 {
-			fmt.Println("not enough arguments to get")
-			usage()
-			os.Exit(exitcodeInvalidArgs)
+	opts := parse.Options{Strict: *strict, Interfaces: *ifaces, Fakes: *fakes, Annotate: *annotate, Constraint: *buildExpr, Owners: *owners, LineDirectives: *lineDirs, Unformatted: *deferFmt, CrashReport: *crash, RequireDocs: *reqDocs, StringWords: *strWords, Script: *script, TestPackage: *testPkg, Directives: directive, Workers: *workers, NameTemplate: *nameTmpl}
+	opts.Loader = &parse.Loader{}
+	if *verifyBy != "" && *verifyBy != "types" && *verifyBy != "vet" {
+		fatal(exitcodeInvalidArgs, fmt.Sprintf("-verify must be types or vet, not %q", *verifyBy))
+	}
+	if *typeCheck || *verifyBy != "" {
+		if *outFile == "" && cmd.uses("out") {
+			warn("verifying the generated code needs -out")
+		} else {
+			opts.TypeCheck = *typeCheck || *verifyBy == "types"
+			opts.Vet = *verifyBy == "vet"
 		}
-		// Try a location on disk first
-		b, err := ioutil.ReadFile(args[1])
-		if err != nil {
-			// Try the default location next
-			r, err := http.Get(prefix + args[1])
-			if err != nil || r.StatusCode != 200 {
-				// Finally, try the non-prefixed internet address
-				r, err = http.Get("https://" + args[1])
-				if err != nil || r.StatusCode != 200 {
-					fatal(exitcodeGetFailed, err)
-				}
+	}
+	if *check {
+		if *dryRun {
+			fatal(exitcodeInvalidArgs, "-check writes nothing already, and -dry-run prints the output: give one of them")
+		}
+		if *outFile == "" && cmd.uses("out") {
+			fatal(exitcodeInvalidArgs, "-check needs the output file given with -out")
+		}
+		// the files are compared as they are written, formatted
+		opts.Unformatted = false
+	}
+	if *dryRun && *showDiff {
+		fatal(exitcodeInvalidArgs, "-dry-run prints the output and -diff how it changes the -out file: give one of them")
+	}
+	if cmd.uses("split-build") {
+		if err := checkDirectives(directive, *split); err != nil {
+			fatal(exitcodeInvalidArgs, err)
+		}
+	}
+	if *useCache && (*split || *perSet != "" || *plugins != "") {
+		warn("-cache only skips generating one -out file, so is not used with -split-build, -per-type-set or -plugins")
+	} else if *useCache && *outFile == "" && cmd.uses("out") {
+		warn("-cache needs -out")
+	}
+	if *reportTo != "" {
+		rw := report.New(*reportTo)
+		opts.Tracer = rw
+		defer func() {
+			if err := rw.Err(); err != nil {
+				warn("cannot write the report:", err)
 			}
-			b, err = ioutil.ReadAll(r.Body)
-			if err != nil {
-				fatal(exitcodeGetFailed, err)
+		}()
+	}
+	if *timings != "" {
+		tm := report.NewTimings()
+		opts.Tracer = parse.Tracers(opts.Tracer, tm)
+		defer func() {
+			if err := writeTimings(*timings, tm); err != nil {
+				warn("cannot write the timings:", err)
 			}
-			r.Body.Close()
+		}()
+	}
+	if *tags != "" {
+		if err := checkTags(*tags); err != nil {
+			fatal(exitcodeInvalidArgs, err)
 		}
-		br := bytes.NewReader(b)
-		err = gen(*in, outputFilename, *pkgName, br, typeSets, outWriter)
-	} else if len(*in) > 0 {
-		var file *os.File
-		file, err = os.Open(*in)
+		opts.Loader.BuildFlags = []string{"-tags=" + *tags}
+	}
+	if opts.Todos, err = parse.ParseTodoMode(*todo); err != nil {
+		fatal(exitcodeInvalidArgs, err)
+	}
+	if opts.Engine, err = parse.ParseEngine(*engine); err != nil {
+		fatal(exitcodeInvalidArgs, err)
+	}
+	if opts.Mode, err = parse.ParseMode(*mode); err != nil {
+		fatal(exitcodeInvalidArgs, err)
+	}
+	if *hdrFile != "" {
+		text, err := ioutil.ReadFile(*hdrFile)
 		if err != nil {
-			fatal(exitcodeSourceFileInvalid, err)
+			fatal(exitcodeInvalidArgs, err)
 		}
-		defer file.Close()
-		err = gen(*in, outputFilename, *pkgName, file, typeSets, outWriter)
-	} else {
-		var source []byte
-		source, err = ioutil.ReadAll(os.Stdin)
-		if err != nil {
-			fatal(exitcodeStdinFailed, err)
+		opts.Header = &parse.Header{Text: string(text), Command: append([]string{"genny"}, os.Args[1:]...)}
+	}
+	if *tagKeys != "" {
+		if opts.StructTags, err = parse.ParseStructTags(*tagKeys); err != nil {
+			fatal(exitcodeInvalidArgs, err)
+		}
+	}
+	if opts.Resolver, err = resolver(*importMap, *resolve); err != nil {
+		fatal(exitcodeInvalidArgs, err)
+	}
+	if *casing != "" {
+		if opts.Casings, err = parse.ParseCasings(*casing); err != nil {
+			fatal(exitcodeInvalidArgs, err)
+		}
+	}
+	if *generics != "" {
+		if opts.Placeholders, err = parse.ParsePlaceholders(*generics); err != nil {
+			fatal(exitcodeInvalidArgs, err)
 		}
-		reader := bytes.NewReader(source)
-		err = gen("stdin", outputFilename, *pkgName, reader, typeSets, outWriter)
 	}
 
-	// do the work
-	if err != nil {
-		fatal(exitcodeGenFailed, err)
+	if *genPkgs != "" {
+		if opts.GenericPackages, err = parse.ParseGenericPackages(*genPkgs); err != nil {
+			fatal(exitcodeInvalidArgs, err)
+		}
+	}
+
+	cmd.run(args, opts)
+}
+
+// checkRequirements adds the modules of the imports of the output that the
+// -out module does not require yet to its go.mod with -require, or reports
+// what to add.
+func checkRequirements(outFile string, output []byte, opts parse.Options) error {
+	missing, err := parse.MissingRequirements(outFile, output)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+	if *require {
+		return parse.Require(outFile, missing, opts)
 	}
+	return fmt.Errorf("the go.mod of %s does not require the modules of %s; add them with go get %s (or -require)", outFile, strings.Join(missing, ", "), strings.Join(missing, " "))
+}
 
+// testFile gets the name of the -out file as a test file, e.g.
+// gen_queue_test.go for gen_queue.go.
+func testFile(dest string) string {
+	if !strings.HasSuffix(dest, ".go") || strings.HasSuffix(dest, "_test.go") {
+		return dest
+	}
+	return strings.TrimSuffix(dest, ".go") + "_test.go"
 }
 
 func usage() {
-	fmt.Fprintln(os.Stderr, `usage: genny [{flags}] gen "{types}"
+	fmt.Fprintln(os.Stderr, `usage: genny [{flags}] <command> [{flags}] [{args}]
 
-gen - generates type specific code from generic code.
-get <package/file> - fetch a generic template from the online library and gen it.
+`+commandList()+`help [command] - show how to use genny, or a command and the flags it uses.
 
-{flags}  - (optional) Command line flags (see below)
+{flags}  - (optional) Command line flags (see below), before or after the command
 {types}  - (required) Specific types for each generic type in the source
 {types} format:  {generic}={specific}[,another][ {generic2}={specific2}]
 
@@ -137,31 +338,906 @@ Flags:`)
 	flag.PrintDefaults()
 }
 
-func newWriter(fileName string) io.Writer {
-	if fileName == "" {
-		return os.Stdout
+// writeGenerated generates the code and writes it to the -out file, or
+// stdout, or reports on the template instead with -coverage, -explain and
+// -preview,
+// or writes a plugin package per type set with -plugins.
+func writeGenerated(filename string, source io.ReadSeeker, typeSets []map[string]string, opts parse.Options) {
+	if opts.Mode == parse.ModeTypeParams && (*plugins != "" || *perSet != "" || *coverage || *explain || *preview != "") {
+		fatal(exitcodeInvalidArgs, "-plugins, -per-type-set, -coverage, -explain and -preview need type sets, which -mode=typeparams does not use")
+	}
+
+	if *plugins != "" {
+		if *check {
+			fatal(exitcodeInvalidArgs, "-check compares -out files, which -plugins does not write")
+		}
+		ps, err := parse.Plugins(filename, source, typeSets, opts)
+		if err != nil {
+			fatal(exitcodeGenFailed, err)
+		}
+		checkOutputErr(checkOutputs(filename, pluginFiles(*plugins, ps), *force, opts.GenericPackages))
+		if err := writePlugins(*plugins, filename, ps, *dryRun); err != nil {
+			fatal(exitcodeDestFileFailed, err)
+		}
+		return
+	}
+	if *perSet != "" {
+		files := generatePerTypeSet(filename, *perSet, source, typeSets, opts)
+		if *strict {
+			warn("compile verification and vet need -out, so are skipped with -per-type-set")
+		}
+		if *check {
+			checkStale(files, *showDiff)
+			return
+		}
+		checkOutputErr(checkOutputs(filename, files, *force, opts.GenericPackages))
+		if err := writeFiles(files, *showDiff, *dryRun, *backup); err != nil {
+			fatal(exitcodeDestFileFailed, err)
+		}
+		return
+	}
+	if *coverage {
+		c, err := parse.TemplateCoverage(filename, source, typeSets, opts)
+		if err != nil {
+			fatal(exitcodeGenFailed, err)
+		}
+		c.Write(os.Stdout)
+		return
+	}
+	if *explain {
+		e, err := parse.TemplateExplain(filename, source, typeSets, opts)
+		if err != nil {
+			fatal(exitcodeGenFailed, err)
+		}
+		e.Write(os.Stdout)
+		return
+	}
+	if *preview != "" {
+		if *preview != "diff" && *preview != "side" {
+			fatal(exitcodeInvalidArgs, fmt.Sprintf("-preview must be diff or side, not %q", *preview))
+		}
+		if len(typeSets) > 1 {
+			warn("previewing the first of", len(typeSets), "type sets")
+		}
+		p, err := parse.TemplatePreview(filename, source, typeSets[0], opts)
+		if err != nil {
+			fatal(exitcodeGenFailed, err)
+		}
+		if *preview == "side" {
+			p.WriteSideBySide(os.Stdout)
+		} else {
+			p.Write(os.Stdout)
+		}
+		return
+	}
+
+	key := ""
+	if *useCache && *outFile != "" && !*split && !*showDiff && !*dryRun && !*check {
+		var err error
+		if key, err = cacheKey(source, typeSets); err != nil {
+			fatal(exitcodeSourceFileInvalid, err)
+		}
+		if out.Cached(*outFile, key) {
+			return
+		}
+	}
+
+	outputFilename := *outFile
+	if outputFilename == "" {
+		outputFilename = "stdout"
+	}
+	output := generate(filename, outputFilename, source, typeSets, opts)
+
+	if *split {
+		if *outFile == "" {
+			fatal(exitcodeDestFileFailed, "-split-build needs the output file given with -out")
+		}
+		files, err := parse.SplitSections(*outFile, output)
+		if err != nil {
+			fatal(exitcodeGenFailed, err)
+		}
+		if *check {
+			checkStale(files, *showDiff)
+			return
+		}
+		checkOutputErr(checkOutputs(filename, files, *force, opts.GenericPackages))
+		if err := writeFiles(files, *showDiff, *dryRun, *backup); err != nil {
+			fatal(exitcodeDestFileFailed, err)
+		}
+		return
+	}
+	if parse.HasSections(output) {
+		err := errors.New("the template has //genny:build sections, which are only constrained with -split-build")
+		if *strict {
+			fatal(exitcodeGenFailed, err)
+		}
+		warn(err)
+	}
+
+	if *constName != "" {
+		pkg := *pkgName
+		if pkg == "" {
+			pkg = packageName(output)
+		}
+		var err error
+		if output, err = out.StringConst(pkg, *constName, output); err != nil {
+			fatal(exitcodeGenFailed, err)
+		}
+	}
+
+	budget := out.Budget{MaxLines: *maxLines, MaxBytes: *maxBytes}
+	if err := checkBudget(*outFile, output, budget); err != nil {
+		if *strict {
+			fatal(exitcodeBudgetExceeded, err)
+		}
+		warn(err)
+	}
+
+	if *outFile != "" && !*showDiff && !*dryRun && !*check {
+		if err := checkRequirements(*outFile, output, opts); err != nil {
+			if *strict || *require {
+				fatal(exitcodeVerifyFailed, err)
+			}
+			warn(err)
+		}
+	}
+
+	vetInPlace := false
+	if *strict {
+		if *outFile == "" {
+			warn("compile verification and vet need -out")
+		} else if *deferFmt {
+			warn("compile verification and vet need formatted output, so are skipped with -defer-format")
+		} else if err := parse.VerifyWithOptions(*outFile, output, opts); err != nil {
+			fatal(exitcodeVerifyFailed, err)
+		} else if err := vetSandboxed(*outFile, output, opts); parse.IsNoModule(err) {
+			warn(err, "- vetting the output in place once it is written")
+			vetInPlace = true
+		} else if err != nil {
+			fatal(exitcodeVetFailed, err)
+		}
+	} else if *outFile != "" {
+		// verification checks for import cycles along with everything else
+		if err := parse.CheckImportCycle(*outFile, output, opts); err != nil {
+			fatal(exitcodeVerifyFailed, err)
+		}
+	}
+
+	if *check {
+		checkStale([]parse.File{{Name: *outFile, Source: output}}, *showDiff)
+		return
+	}
+	if *showDiff {
+		if err := showChanges(*outFile, output); err != nil {
+			fatal(exitcodeDestFileFailed, err)
+		}
+		return
+	}
+	if *dryRun {
+		os.Stdout.Write(output)
+		return
+	}
+
+	if key == "" || !out.Unchanged(*outFile, output) {
+		if *backup && *outFile != "" {
+			if err := out.Backup(*outFile, output); err != nil {
+				fatal(exitcodeDestFileFailed, err)
+			}
+		}
+		writeSpan := startWrite(opts, *outFile)
+		writeOutput(output)
+		writeSpan.End(nil)
+	}
+	if key != "" {
+		if err := out.Cache(*outFile, key, output); err != nil {
+			warn("cannot cache the output:", err)
+		}
+	}
+
+	if vetInPlace {
+		if err := vet(filepath.Dir(*outFile), opts.Loader.BuildFlags); err != nil {
+			fatal(exitcodeVetFailed, err)
+		}
 	}
-	lf := &out.LazyFile{FileName: fileName}
-	defer lf.Close()
-	return lf
 }
 
-func fatal(code int, a ...interface{}) {
-	fmt.Println(a...)
-	os.Exit(code)
+// cacheKey gets the -cache key of the generated file: the hash of the
+// template, the type sets, the flags that are set, the files -header-file
+// and -import-map name and the build of genny. Packages resolved from
+// outside the template, such as those goimports finds, are not part of it.
+func cacheKey(source io.ReadSeeker, typeSets []map[string]string) (string, error) {
+	template, err := ioutil.ReadAll(source)
+	if err != nil {
+		return "", err
+	}
+	if _, err := source.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	var sets []string
+	for _, typeSet := range typeSets {
+		var pairs []string
+		for generic, specific := range typeSet {
+			pairs = append(pairs, generic+"="+specific)
+		}
+		sort.Strings(pairs)
+		sets = append(sets, strings.Join(pairs, ","))
+	}
+	var flags []string
+	flag.Visit(func(f *flag.Flag) {
+		flags = append(flags, "-"+f.Name+"="+f.Value.String())
+	})
+	parts := [][]byte{template, []byte(strings.Join(sets, "\n")), []byte(strings.Join(flags, "\n"))}
+	for _, name := range []string{*hdrFile, *importMap} {
+		var b []byte
+		if name != "" {
+			if b, err = ioutil.ReadFile(name); err != nil {
+				return "", err
+			}
+		}
+		parts = append(parts, b)
+	}
+	return out.CacheKey(append(parts, []byte(gennyBuild()))...), nil
 }
 
-// gen performs the generic generation.
-func gen(filename, outputFilename, pkgName string, in io.ReadSeeker, typesets []map[string]string, out io.Writer) error {
+// gennyBuild gets the version of genny, and the revision it was built from
+// if it was built from a checkout, so that upgrading genny misses the
+// cache.
+func gennyBuild() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	build := info.Main.Version + " " + info.Main.Sum
+	for _, setting := range info.Settings {
+		if strings.HasPrefix(setting.Key, "vcs.") {
+			build += " " + setting.Key + "=" + setting.Value
+		}
+	}
+	return build
+}
 
-	var output []byte
-	var err error
+// pluginFiles gets the files writePlugins writes for the plugins, with
+// their code but not their manifests, to check them before writing.
+func pluginFiles(dir string, ps []parse.Plugin) []parse.File {
+	var files []parse.File
+	for _, p := range ps {
+		pkgDir := filepath.Join(dir, p.Name)
+		files = append(files, parse.File{Name: filepath.Join(pkgDir, p.Name+".go"), Source: p.Source}, parse.File{Name: filepath.Join(pkgDir, "plugin.json")})
+	}
+	return files
+}
+
+// writePlugins writes a plugin package for each type set to its own
+// directory in dir, with the plugin.json manifest describing it, or with
+// dryRun prints them.
+func writePlugins(dir, template string, ps []parse.Plugin, dryRun bool) error {
+	for _, p := range ps {
+		pkgDir := filepath.Join(dir, p.Name)
+		manifest, err := json.MarshalIndent(p.Manifest(template, filepath.ToSlash(pkgDir)), "", "  ")
+		if err != nil {
+			return err
+		}
+		if dryRun {
+			printFile(filepath.Join(pkgDir, p.Name+".go"), p.Source)
+			printFile(filepath.Join(pkgDir, "plugin.json"), append(manifest, '\n'))
+			continue
+		}
+		if err := os.MkdirAll(pkgDir, 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(pkgDir, p.Name+".go"), p.Source, 0644); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(pkgDir, "plugin.json"), append(manifest, '\n'), 0644); err != nil {
+			return err
+		}
+		fmt.Fprintln(os.Stderr, "wrote plugin", pkgDir)
+	}
+	return nil
+}
+
+// formatGenerated formats the files, and the genny generated files in the
+// directories that .gennyignore does not ignore, in parallel.
+func formatGenerated(targets []string) error {
+	var files, dirs []string
+	for _, target := range targets {
+		info, err := os.Stat(target)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			dirs = append(dirs, target)
+		} else {
+			files = append(files, target)
+		}
+	}
+	generated, err := generatedFiles(dirs)
+	if err != nil {
+		return err
+	}
+	return parse.FormatFiles(append(files, generated...), runtime.NumCPU())
+}
+
+// generatedFiles gets the genny generated files in the directories, other
+// than those .gennyignore ignores.
+func generatedFiles(dirs []string) ([]string, error) {
+	ignore, err := paths.LoadIgnore(".")
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, dir := range dirs {
+		names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if ignore.Match(name, false) {
+				continue
+			}
+			src, err := ioutil.ReadFile(name)
+			if err != nil {
+				return nil, err
+			}
+			if out.IsGenerated(src) {
+				files = append(files, name)
+			}
+		}
+	}
+	return files, nil
+}
+
+// removeGenerated removes the genny generated files in the directories,
+// backing them up first with backup.
+func removeGenerated(dirs []string, backup bool) error {
+	files, err := generatedFiles(dirs)
+	if err != nil {
+		return err
+	}
+	for _, name := range files {
+		if backup {
+			if err := out.Backup(name, nil); err != nil {
+				return err
+			}
+		}
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+		fmt.Println("removed", name)
+	}
+	return nil
+}
+
+// writeDocs writes documentation pages for the templates in dir, other than
+// those .gennyignore ignores, to the outDir directory, or prints them if
+// outDir is empty. With requireDocs, it fails if a generic type has no doc
+// comment.
+func writeDocs(dir, outDir, formatName string, requireDocs bool) error {
+	format, err := docs.ParseFormat(formatName)
+	if err != nil {
+		return err
+	}
+	templates, err := findTemplates(dir, requireDocs)
+	if err != nil {
+		return err
+	}
+	if outDir != "" {
+		_, err := docs.Write(outDir, templates, format)
+		return err
+	}
+	for _, t := range templates {
+		if err := format.Page(os.Stdout, t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	output, err = parse.Generics(filename, outputFilename, pkgName, in, typesets)
+// findTemplates finds the templates in dir, other than those .gennyignore
+// ignores. With requireDocs, it fails if a generic type has no doc comment.
+func findTemplates(dir string, requireDocs bool) ([]*docs.Template, error) {
+	ignore, err := paths.LoadIgnore(".")
+	if err != nil {
+		return nil, err
+	}
+	found, err := docs.Find(dir)
+	if err != nil {
+		return nil, err
+	}
+	var templates []*docs.Template
+	for _, t := range found {
+		if ignore.Match(t.Filename, false) {
+			continue
+		}
+		if requireDocs {
+			for _, p := range t.Params {
+				if p.Doc == "" {
+					return nil, fmt.Errorf("%s: generic type '%s' has no doc comment describing it", t.Filename, p.Name)
+				}
+			}
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// writeBundle bundles the templates in dir with the version, to the archive
+// filename, or <name>-<version>.tar.gz where name is the name of dir.
+func writeBundle(dir, version, filename string) error {
+	abs, err := filepath.Abs(dir)
 	if err != nil {
 		return err
 	}
+	name := filepath.Base(abs)
+	if filename == "" {
+		filename = name + "-" + version + ".tar.gz"
+	}
+	var buf bytes.Buffer
+	m, err := bundle.Create(&buf, dir, name, version)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d template(s) of %s %s\n", filename, len(m.Templates), m.Name, m.Version)
+	return nil
+}
+
+// build generates the entries of the config file whose names match run and
+// not skip, backing up the files it overwrites if backup is set.
+func build(filename string, opts parse.Options, backup bool, run, skip string) error {
+	c, err := loadConfig(filename, backup, run, skip)
+	if err != nil {
+		return err
+	}
+	before := make(map[string][]byte)
+	for _, e := range c.Entries {
+		out := paths.Resolve(c.Dir, e.Out)
+		before[out], _ = ioutil.ReadFile(out)
+	}
+	err = c.Build(opts, os.Stdout, os.Stderr)
+	writeBuildStat(c, before)
+	return err
+}
+
+// checkBuilds compares the outputs of the entries of the configs with the
+// code they generate for -check, without writing anything, and fails
+// listing those that are stale. With -run and -skip, configs with no
+// entries left are skipped.
+func checkBuilds(filenames []string, opts parse.Options) {
+	var stale []string
+	total := 0
+	for _, filename := range filenames {
+		c, err := loadConfig(filename, false, *run, *skip)
+		if config.IsNoEntries(err) && len(filenames) > 1 {
+			continue
+		}
+		if err != nil {
+			fatal(exitcodeBuildFailed, err)
+		}
+		outputs, err := c.Stale(opts)
+		if err != nil {
+			fatal(exitcodeBuildFailed, filename+": "+err.Error())
+		}
+		for _, o := range outputs {
+			stale = append(stale, o.Out)
+		}
+		total += len(c.Entries)
+	}
+	if total == 0 {
+		fatal(exitcodeBuildFailed, "no entries to check in "+strings.Join(filenames, ", "))
+	}
+	reportStale(stale, total)
+}
 
-	out.Write(output)
+// writeBuildStat writes a diffstat of how the outputs of the config's
+// entries changed from before the build, so the effect of a template edit
+// can be seen at a glance.
+func writeBuildStat(c *config.Config, before map[string][]byte) {
+	var stats []diff.FileStat
+	seen := make(map[string]bool)
+	for _, e := range c.Entries {
+		out := paths.Resolve(c.Dir, e.Out)
+		if seen[out] {
+			continue
+		}
+		seen[out] = true
+		after, _ := ioutil.ReadFile(out)
+		stats = append(stats, diff.Stat(out, before[out], after))
+	}
+	diff.WriteStat(os.Stderr, stats, diff.Color(os.Stderr))
+}
+
+// watch builds the entries of the config file that build would, and
+// rebuilds them whenever their templates change, until interrupted.
+func watch(filename string, opts parse.Options, backup bool, run, skip string) error {
+	c, err := loadConfig(filename, backup, run, skip)
+	if err != nil {
+		return err
+	}
+	stop := make(chan struct{})
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		close(stop)
+	}()
+	c.Watch(opts, 0, stop, os.Stdout, os.Stderr, func(e config.Entry, err error) {
+		if err != nil {
+			warn(e.Name+":", err)
+			return
+		}
+		fmt.Fprintln(os.Stderr, "built", e.Name)
+	})
+	return nil
+}
+
+// configFiles gets the config files the argument names: the file itself,
+// or, for a pattern such as ./..., every config file in the tree other
+// than those .gennyignore ignores.
+func configFiles(arg string) ([]string, error) {
+	if !config.IsPattern(arg) {
+		return []string{arg}, nil
+	}
+	ignore, err := paths.LoadIgnore(".")
+	if err != nil {
+		return nil, err
+	}
+	dir := config.PatternDir(arg)
+	filenames, err := config.Discover(dir, ignore)
+	if err != nil {
+		return nil, err
+	}
+	if len(filenames) == 0 {
+		return nil, fmt.Errorf("no %s files in %s", config.DefaultFilename, dir)
+	}
+	return filenames, nil
+}
+
+// loadConfig loads the config file with the entries whose names match the
+// run and not the skip regular expressions (either may be empty).
+func loadConfig(filename string, backup bool, run, skip string) (*config.Config, error) {
+	c, err := config.Load(filename)
+	if err != nil {
+		return nil, err
+	}
+	c.Backup = c.Backup || backup
+	if run == "" && skip == "" {
+		return c, nil
+	}
+	var runRe, skipRe *regexp.Regexp
+	if run != "" {
+		if runRe, err = regexp.Compile(run); err != nil {
+			return nil, fmt.Errorf("invalid -run: %v", err)
+		}
+	}
+	if skip != "" {
+		if skipRe, err = regexp.Compile(skip); err != nil {
+			return nil, fmt.Errorf("invalid -skip: %v", err)
+		}
+	}
+	return c.Select(runRe, skipRe)
+}
+
+// minimizeTemplate shrinks the template to a small anonymized template
+// that still fails in the same way.
+func minimizeTemplate(filename, types, match string) ([]byte, error) {
+	if filename == "" {
+		return nil, errors.New("minimize needs the template given with -in")
+	}
+	typeSets, err := parse.TypeSet(types)
+	if err != nil {
+		return nil, err
+	}
+	var re *regexp.Regexp
+	if match != "" {
+		if re, err = regexp.Compile(match); err != nil {
+			return nil, err
+		}
+	}
+	template, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	failure := minimize.Generate(filename, template, typeSets)
+	if failure == nil || (re != nil && !re.MatchString(failure.Error())) {
+		return nil, errors.New("the template does not fail with these types")
+	}
+	var placeholders []string
+	for _, typeSet := range typeSets {
+		for placeholder := range typeSet {
+			placeholders = append(placeholders, placeholder)
+		}
+	}
+	fails := minimize.SameFailure(filename, typeSets, failure, re)
+	reproducer := minimize.Anonymize(minimize.Minimize(template, fails), placeholders, fails)
+	fmt.Fprintln(os.Stderr, "reproduces:", minimize.Generate(filename, reproducer, typeSets))
+	return reproducer, nil
+}
+
+// profileHints prints the hints from the pprof profile for the package
+// in dir.
+func profileHints(profile, dir string) error {
+	f, err := os.Open(profile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hs, err := hints.Analyze(f, dir)
+	if err != nil {
+		return err
+	}
+	hints.Write(os.Stdout, hs)
 	return nil
 }
+
+// writeFiles writes the generated files, or with showDiff prints how they
+// would change, or with dryRun prints them, each under its name. With
+// backup, the files are backed up first.
+func writeFiles(files []parse.File, showDiff, dryRun, backup bool) error {
+	for _, f := range files {
+		if showDiff {
+			if err := showChanges(f.Name, f.Source); err != nil {
+				return err
+			}
+			continue
+		}
+		if dryRun {
+			printFile(f.Name, f.Source)
+			continue
+		}
+		if backup {
+			if err := out.Backup(f.Name, f.Source); err != nil {
+				return err
+			}
+		}
+		lf := &out.LazyFile{FileName: f.Name}
+		if _, err := lf.Write(f.Source); err != nil {
+			return err
+		}
+		if err := lf.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkStale compares the generated files with those on disk for -check,
+// without writing them. It lists the files that are missing or hold other
+// code, showing how they would change with showDiff, and fails if there
+// are any.
+func checkStale(files []parse.File, showDiff bool) {
+	var stale []string
+	for _, f := range files {
+		if out.Unchanged(f.Name, f.Source) {
+			continue
+		}
+		stale = append(stale, f.Name)
+		if showDiff {
+			if err := showChanges(f.Name, f.Source); err != nil {
+				fatal(exitcodeDestFileFailed, err)
+			}
+		}
+	}
+	reportStale(stale, len(files))
+}
+
+// reportStale lists the stale generated files of the total checked, and
+// fails if there are any.
+func reportStale(stale []string, total int) {
+	if len(stale) == 0 {
+		return
+	}
+	for _, name := range stale {
+		fmt.Println("stale:", filepath.ToSlash(name))
+	}
+	fatal(exitcodeStale, fmt.Sprintf("%d of %d generated files are stale: regenerate them (go generate or genny build)", len(stale), total))
+}
+
+// envBool gets the environment variable as a flag default: true if it is
+// set to a true value such as 1 or true.
+func envBool(name string) bool {
+	b, _ := strconv.ParseBool(os.Getenv(name))
+	return b
+}
+
+// printFile prints the file that would be written with -dry-run, under a
+// line naming it.
+func printFile(filename string, src []byte) {
+	fmt.Printf("--- %s ---\n", filepath.ToSlash(filename))
+	os.Stdout.Write(src)
+}
+
+// showChanges prints a diff of the output file to the output that would be
+// written to it.
+func showChanges(outFile string, output []byte) error {
+	if outFile == "" {
+		return errors.New("-diff needs the output file given with -out")
+	}
+	current, err := ioutil.ReadFile(outFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	name := filepath.ToSlash(outFile)
+	_, err = diff.Unified(os.Stdout, "a/"+name, "b/"+name, current, output, diff.Color(os.Stdout))
+	return err
+}
+
+// resolver gets the resolver of the packages goimports cannot find: the
+// import map, and then the command for the packages it does not have.
+func resolver(importMap, command string) (parse.Resolver, error) {
+	var resolvers []parse.Resolver
+	if importMap != "" {
+		r, err := parse.ImportMap(importMap)
+		if err != nil {
+			return nil, err
+		}
+		resolvers = append(resolvers, r)
+	}
+	if command != "" {
+		resolvers = append(resolvers, parse.CommandResolver(command))
+	}
+	if len(resolvers) == 0 {
+		return nil, nil
+	}
+	return func(filename string, missing []string) (map[string]string, error) {
+		found := make(map[string]string)
+		for _, r := range resolvers {
+			var left []string
+			for _, name := range missing {
+				if _, ok := found[name]; !ok {
+					left = append(left, name)
+				}
+			}
+			if len(left) == 0 {
+				break
+			}
+			more, err := r(filename, left)
+			if err != nil {
+				return nil, err
+			}
+			for name, p := range more {
+				found[name] = p
+			}
+		}
+		return found, nil
+	}, nil
+}
+
+// outSink is where the generated code goes: the -out destination.
+var outSink out.Sink
+
+// writeOutput writes the generated code to the -out destination.
+func writeOutput(output []byte) {
+	if err := outSink.Write(output); err != nil {
+		fatal(exitcodeDestFileFailed, err)
+	}
+}
+
+// startWrite starts the span of writing the output file, if generation is
+// traced.
+func startWrite(opts parse.Options, outFile string) parse.Span {
+	if opts.Tracer == nil {
+		return noSpan{}
+	}
+	return opts.Tracer.StartSpan(parse.SpanWrite, map[string]string{"genny.output": outFile})
+}
+
+// noSpan is the span of writing when generation is not traced.
+type noSpan struct{}
+
+func (noSpan) StartSpan(string, map[string]string) parse.Span { return noSpan{} }
+func (noSpan) End(error)                                      {}
+
+// writeTimings writes the timings of the run as JSON to the file, or to
+// stderr if it is -.
+func writeTimings(filename string, tm *report.Timings) error {
+	if filename == "-" {
+		return tm.WriteJSON(os.Stderr)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := tm.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// checkBudget checks the size of the generated code in the output package,
+// including output, against the budget.
+func checkBudget(outFile string, output []byte, budget out.Budget) error {
+	if budget.MaxLines == 0 && budget.MaxBytes == 0 {
+		return nil
+	}
+	var size out.Size
+	if outFile != "" {
+		var err error
+		size, err = out.PackageSize(filepath.Dir(outFile), outFile)
+		if err != nil {
+			return err
+		}
+	}
+	return budget.Check(size.Add(output))
+}
+
+// packageName gets the package name of the Go source.
+func packageName(src []byte) string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly)
+	if err != nil {
+		return "main"
+	}
+	return file.Name.Name
+}
+
+// vetSandboxed runs go vet on the package the output is written to, with
+// the output, in a sandbox module before it is written.
+func vetSandboxed(outFile string, output []byte, opts parse.Options) error {
+	sandbox, err := parse.NewSandbox(outFile, output, opts)
+	if err != nil {
+		return err
+	}
+	defer sandbox.Close()
+	if opts.Script {
+		// scripts are left out of the package, so are vetted on their own
+		_, err := sandbox.Go("vet", filepath.Join(sandbox.Package, filepath.Base(outFile)))
+		return err
+	}
+	return sandbox.Vet()
+}
+
+// runScript runs the script with go run and the arguments, in a sandbox of
+// the module it would be written into, or on its own outside a module.
+func runScript(outputFilename string, output []byte, args []string, opts parse.Options) error {
+	var cmd *exec.Cmd
+	sandbox, err := parse.NewSandbox(outputFilename, output, opts)
+	switch {
+	case parse.IsNoModule(err):
+		dir, err := ioutil.TempDir("", "genny-run")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		script := filepath.Join(dir, filepath.Base(outputFilename))
+		if err := ioutil.WriteFile(script, output, 0644); err != nil {
+			return err
+		}
+		cmd = exec.Command("go", append(append(append([]string{"run"}, opts.Loader.BuildFlags...), script), args...)...)
+	case err != nil:
+		return err
+	default:
+		defer sandbox.Close()
+		script := filepath.Join(sandbox.Package, filepath.Base(outputFilename))
+		cmd = sandbox.Command(append(append(append([]string{"run"}, opts.Loader.BuildFlags...), script), args...)...)
+	}
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// vet runs go vet on the package in dir.
+func vet(dir string, buildFlags []string) error {
+	cmd := exec.Command("go", append(append([]string{"vet"}, buildFlags...), ".")...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("go vet failed: %v\n%s", err, output)
+	}
+	return nil
+}
+
+func warn(a ...interface{}) {
+	fmt.Fprintln(os.Stderr, append([]interface{}{"warning:"}, a...)...)
+}
+
+func fatal(code int, a ...interface{}) {
+	fmt.Println(a...)
+	os.Exit(code)
+}
+
+// gen performs the generic generation.
+func gen(filename, outputFilename, pkgName string, in io.ReadSeeker, typesets []map[string]string, opts parse.Options) ([]byte, error) {
+	return parse.GenericsWithOptions(filename, outputFilename, pkgName, in, typesets, opts)
+}
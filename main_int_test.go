@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOutputs(t *testing.T) {
+
+	dir := t.TempDir()
+	template := filepath.Join(dir, "int.go")
+	src := []byte("package p\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n")
+	if err := ioutil.WriteFile(template, src, 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := []parse.File{{Name: filepath.Join(dir, "string.go")}, {Name: template}}
+	err := checkOutputs(template, files, false, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "would overwrite the template")
+	}
+	assert.NoError(t, checkOutputs(template, files[:1], false, nil))
+	assert.NoError(t, checkOutputs(template, files, true, nil))
+
+}
+
+func TestPluginFiles(t *testing.T) {
+
+	dir := t.TempDir()
+	template := filepath.Join(dir, "int", "int.go")
+	ps := []parse.Plugin{{Name: "int"}, {Name: "string"}}
+	files := pluginFiles(dir, ps)
+	assert.Equal(t, []string{template, filepath.Join(dir, "int", "plugin.json"), filepath.Join(dir, "string", "string.go"), filepath.Join(dir, "string", "plugin.json")},
+		[]string{files[0].Name, files[1].Name, files[2].Name, files[3].Name})
+	err := checkOutputs(template, files, false, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "would overwrite the template")
+	}
+	assert.NoError(t, checkOutputs(template, files[2:], false, nil))
+
+}
+
+func TestCheckTags(t *testing.T) {
+
+	assert.NoError(t, checkTags("integration,linux"))
+	err := checkTags("linux && amd64")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "-constraint writes a build constraint into the generated file")
+	}
+
+}
+
+func TestCheckDirectives(t *testing.T) {
+
+	noinline, err := parse.ParseDirective("IntQueue.Push=//go:noinline")
+	assert.NoError(t, err)
+	build, err := parse.ParseDirective("IntQueue.Push=//go:build linux")
+	assert.NoError(t, err)
+	assert.NoError(t, checkDirectives([]parse.Directive{noinline}, false))
+	assert.NoError(t, checkDirectives([]parse.Directive{noinline, build}, true))
+	err = checkDirectives([]parse.Directive{noinline, build}, false)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "-directive IntQueue.Push=//go:build linux only constrains the function with -split-build")
+	}
+
+}
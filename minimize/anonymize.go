@@ -0,0 +1,151 @@
+package minimize
+
+import (
+	"fmt"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Anonymize renames the identifiers of the template and empties its string
+// literals and comments, so that a reproducer does not give away the code
+// it came from. Placeholders, the parts of names made of placeholders,
+// predeclared identifiers and imported packages are kept. Each kind of
+// change is only made if the template still fails with it.
+func Anonymize(template []byte, placeholders []string, fails Predicate) []byte {
+	a := &anonymizer{
+		placeholders: placeholders,
+		segments:     make(map[string]string),
+		keep:         map[string]bool{"_": true, "init": true, "main": true},
+		imports:      make(map[string]bool),
+	}
+	if file, err := parser.ParseFile(token.NewFileSet(), "", template, parser.ImportsOnly); err == nil {
+		for _, spec := range file.Imports {
+			p, _ := strconv.Unquote(spec.Path.Value)
+			name := path.Base(p)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			a.keep[name] = true
+			a.imports[spec.Path.Value] = true
+		}
+	}
+
+	for _, rewrite := range []func(token.Token, string) string{a.ident, a.str, a.comment} {
+		if anonymized := a.rewrite(template, rewrite); fails(anonymized) {
+			template = anonymized
+		}
+	}
+	return template
+}
+
+type anonymizer struct {
+	placeholders []string
+	// segments maps parts of names to their anonymous replacements.
+	segments map[string]string
+	keep     map[string]bool
+	// imports are the quoted import paths.
+	imports map[string]bool
+}
+
+// rewrite replaces the tokens of the source with the result of replace.
+// Selectors of imported packages are kept.
+func (a *anonymizer) rewrite(src []byte, replace func(token.Token, string) string) []byte {
+	var s scanner.Scanner
+	fs := token.NewFileSet()
+	s.Init(fs.AddFile("", fs.Base(), len(src)), src, nil, scanner.ScanComments)
+	var out []byte
+	last, prev, prevLit := 0, token.ILLEGAL, ""
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		offset := fs.Position(pos).Offset
+		replacement := lit
+		if lit != "" && !(prev == token.PERIOD && a.keep[prevLit]) {
+			replacement = replace(tok, lit)
+		}
+		if replacement != lit {
+			out = append(out, src[last:offset]...)
+			out = append(out, replacement...)
+			last = offset + len(lit)
+		}
+		if tok != token.PERIOD {
+			prevLit = lit
+		}
+		prev = tok
+	}
+	return append(out, src[last:]...)
+}
+
+// ident gets the anonymous name for an identifier.
+func (a *anonymizer) ident(tok token.Token, name string) string {
+	if tok != token.IDENT || a.keep[name] || types.Universe.Lookup(name) != nil {
+		return name
+	}
+	var b strings.Builder
+	for len(name) > 0 {
+		i, placeholder := a.nextPlaceholder(name)
+		if i < 0 {
+			b.WriteString(a.segment(name))
+			break
+		}
+		if i > 0 {
+			b.WriteString(a.segment(name[:i]))
+		}
+		b.WriteString(placeholder)
+		name = name[i+len(placeholder):]
+	}
+	return b.String()
+}
+
+// nextPlaceholder finds the first placeholder in the name.
+func (a *anonymizer) nextPlaceholder(name string) (int, string) {
+	first, found := -1, ""
+	for _, p := range a.placeholders {
+		if i := strings.Index(name, p); i >= 0 && (first < 0 || i < first) {
+			first, found = i, p
+		}
+	}
+	return first, found
+}
+
+// segment gets the replacement for a part of a name, keeping whether it
+// starts with an upper case letter, so exported names stay exported.
+func (a *anonymizer) segment(s string) string {
+	if r, ok := a.segments[s]; ok {
+		return r
+	}
+	r := fmt.Sprintf("x%d", len(a.segments)+1)
+	if unicode.IsUpper([]rune(s)[0]) {
+		r = strings.ToUpper(r[:1]) + r[1:]
+	}
+	a.segments[s] = r
+	return r
+}
+
+// str empties a string literal, unless it mentions a placeholder or is an
+// import path.
+func (a *anonymizer) str(tok token.Token, lit string) string {
+	if i, _ := a.nextPlaceholder(lit); tok != token.STRING || i >= 0 || a.imports[lit] {
+		return lit
+	}
+	return `""`
+}
+
+// comment empties a comment, unless it mentions a placeholder.
+func (a *anonymizer) comment(tok token.Token, lit string) string {
+	if i, _ := a.nextPlaceholder(lit); tok != token.COMMENT || i >= 0 {
+		return lit
+	}
+	if strings.HasPrefix(lit, "/*") {
+		return "/**/"
+	}
+	return "//"
+}
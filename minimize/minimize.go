@@ -0,0 +1,125 @@
+// Package minimize shrinks a template that genny fails to generate to a
+// small reproducer, with its identifiers anonymized, that can be attached
+// to a bug report.
+package minimize
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+
+	"github.com/cheekybits/genny/parse"
+)
+
+// Predicate reports whether the template still fails.
+type Predicate func(template []byte) bool
+
+// Generate generates the template with the type sets.
+func Generate(filename string, template []byte, typeSets []map[string]string) error {
+	_, err := parse.Generics(filename, "output.go", "", bytes.NewReader(template), typeSets)
+	return err
+}
+
+// SameFailure gets a predicate for templates that fail like the original
+// error did: with an error of the same type and, if match is not nil, a
+// message that matches it.
+func SameFailure(filename string, typeSets []map[string]string, original error, match *regexp.Regexp) Predicate {
+	kind := fmt.Sprintf("%T", original)
+	return func(template []byte) bool {
+		err := Generate(filename, template, typeSets)
+		if err == nil || fmt.Sprintf("%T", err) != kind {
+			return false
+		}
+		return match == nil || match.MatchString(err.Error())
+	}
+}
+
+// Minimize removes as much of the template as it can while it still fails:
+// first whole declarations, then lines, using the delta debugging
+// algorithm.
+func Minimize(template []byte, fails Predicate) []byte {
+	return minimizeLines(removeDecls(template, fails), fails)
+}
+
+// removeDecls removes the top level declarations, along with their doc
+// comments, that the failure does not need.
+func removeDecls(template []byte, fails Predicate) []byte {
+	for removed := true; removed; {
+		removed = false
+		fs := token.NewFileSet()
+		file, err := parser.ParseFile(fs, "", template, parser.ParseComments)
+		if err != nil {
+			return template
+		}
+		// try the last declarations first so the offsets stay valid
+		for i := len(file.Decls) - 1; i >= 0; i-- {
+			decl := file.Decls[i]
+			start, end := decl.Pos(), decl.End()
+			if doc := declDoc(decl); doc != nil {
+				start = doc.Pos()
+			}
+			from, to := fs.Position(start).Offset, fs.Position(end).Offset
+			if to < len(template) && template[to] == '\n' {
+				to++
+			}
+			reduced := append(append([]byte{}, template[:from]...), template[to:]...)
+			if fails(reduced) {
+				template = reduced
+				removed = true
+			}
+		}
+	}
+	return template
+}
+
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+// minimizeLines removes the lines the failure does not need.
+func minimizeLines(template []byte, fails Predicate) []byte {
+	lines := strings.SplitAfter(string(template), "\n")
+	join := func(lines []string) []byte {
+		return []byte(strings.Join(lines, ""))
+	}
+	n := 2
+	for len(lines) >= 2 {
+		chunk := (len(lines) + n - 1) / n
+		reduced := false
+		for start := 0; start < len(lines); start += chunk {
+			end := start + chunk
+			if end > len(lines) {
+				end = len(lines)
+			}
+			complement := append(append([]string{}, lines[:start]...), lines[end:]...)
+			if fails(join(complement)) {
+				lines = complement
+				if n > 2 {
+					n--
+				}
+				reduced = true
+				break
+			}
+		}
+		if !reduced {
+			if n >= len(lines) {
+				break
+			}
+			n *= 2
+			if n > len(lines) {
+				n = len(lines)
+			}
+		}
+	}
+	return join(lines)
+}
@@ -0,0 +1,109 @@
+package minimize_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/minimize"
+	"github.com/stretchr/testify/assert"
+)
+
+const failing = `package billing
+
+import (
+	"fmt"
+
+	"github.com/cheekybits/genny/generic"
+)
+
+// Amount is the type being billed.
+type Amount generic.Type
+
+// Invoice lists the amounts owed by a customer.
+type Invoice struct {
+	Customer string
+	Lines    []Amount
+}
+
+// Total adds up the invoice.
+func (inv *Invoice) Total(add func(a, b Amount) Amount) Amount {
+	var total Amount
+	for _, line := range inv.Lines {
+		total = add(total, line)
+	}
+	return total
+}
+
+// AmountLedger records invoices as they are sent.
+type AmountLedger struct {
+	*Amount
+	sent map[string]*Invoice
+}
+
+func (l *AmountLedger) Send(inv *Invoice) {
+	fmt.Println("sending invoice to", inv.Customer)
+	l.sent[inv.Customer] = inv
+}
+`
+
+func TestMinimize(t *testing.T) {
+
+	typeSets := []map[string]string{{"Amount": "[]int"}}
+	err := minimize.Generate("billing.go", []byte(failing), typeSets)
+	if !assert.Error(t, err) {
+		return
+	}
+	fails := minimize.SameFailure("billing.go", typeSets, err, regexp.MustCompile("cannot be embedded"))
+
+	minimal := minimize.Minimize([]byte(failing), fails)
+	assert.True(t, fails(minimal))
+	assert.True(t, len(strings.Split(string(minimal), "\n")) < 12, "minimal template is small:\n%s", minimal)
+
+	anonymous := minimize.Anonymize(minimal, []string{"Amount"}, fails)
+	assert.True(t, fails(anonymous))
+	assert.NotContains(t, string(anonymous), "Ledger")
+	assert.Contains(t, string(anonymous), "*Amount")
+
+}
+
+func TestAnonymize(t *testing.T) {
+
+	src := `package p
+
+import "fmt"
+
+// Secret sauce.
+type ItemRecipe struct {
+	secretSauce string
+}
+
+func (r ItemRecipe) Cook() {
+	fmt.Println("classified", r.secretSauce, "Item")
+}
+`
+	anonymous := minimize.Anonymize([]byte(src), []string{"Item"}, func([]byte) bool { return true })
+	assert.Equal(t, `package x1
+
+import "fmt"
+
+//
+type ItemX2 struct {
+	x3 string
+}
+
+func (x4 ItemX2) X5() {
+	fmt.Println("", x4.x3, "Item")
+}
+`, string(anonymous))
+
+}
+
+func TestGenerateReportsPanics(t *testing.T) {
+
+	err := minimize.Generate("p.go", []byte("package p\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype T generic.Type\n\nvar TZero T\n"), []map[string]string{{"T": ""}})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Internal error generating p.go")
+	}
+
+}
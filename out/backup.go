@@ -0,0 +1,71 @@
+package out
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// BackupDir is the directory, next to the files genny writes, that their
+// backups are kept in. The go command ignores directories starting with a
+// dot, so backups are never built.
+const BackupDir = ".genny"
+
+// BackupPath gets where the backup of the file is kept.
+func BackupPath(filename string) string {
+	return filepath.Join(filepath.Dir(filename), BackupDir, "backup", filepath.Base(filename))
+}
+
+// Backup keeps a copy of the file before it is overwritten with next, so
+// that it can be restored with Rollback. Only the last version is kept.
+// Nothing is kept if the file does not exist yet or next does not change
+// it.
+func Backup(filename string, next []byte) error {
+	current, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(current, next) {
+		return nil
+	}
+	backup := BackupPath(filename)
+	if err := os.MkdirAll(filepath.Dir(backup), 0755); err != nil {
+		return err
+	}
+	unlock, err := Lock(backup)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return ioutil.WriteFile(backup, current, 0644)
+}
+
+// Rollback restores the file from its backup, and removes the backup.
+func Rollback(filename string) error {
+	backup := BackupPath(filename)
+	unlock, err := Lock(backup)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	previous, err := ioutil.ReadFile(backup)
+	if os.IsNotExist(err) {
+		return &errNoBackup{Filename: filename, Backup: backup}
+	}
+	if err != nil {
+		return err
+	}
+	unlockFile, err := Lock(filename)
+	if err != nil {
+		return err
+	}
+	defer unlockFile()
+	if err := ioutil.WriteFile(filename, previous, 0644); err != nil {
+		return err
+	}
+	return os.Remove(backup)
+}
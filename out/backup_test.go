@@ -0,0 +1,45 @@
+package out_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupAndRollback(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "gen_queue.go")
+	backup := filepath.Join(dir, ".genny", "backup", "gen_queue.go")
+	assert.Equal(t, backup, out.BackupPath(name))
+
+	// nothing to back up yet
+	assert.NoError(t, out.Backup(name, []byte("v1")))
+	_, err := os.Stat(backup)
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, ioutil.WriteFile(name, []byte("v1"), 0644))
+
+	// unchanged output is not backed up
+	assert.NoError(t, out.Backup(name, []byte("v1")))
+	_, err = os.Stat(backup)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, out.Backup(name, []byte("v2")))
+	assert.NoError(t, ioutil.WriteFile(name, []byte("v2"), 0644))
+	assert.FileExists(t, backup)
+
+	assert.NoError(t, out.Rollback(name))
+	b, err := ioutil.ReadFile(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1", string(b))
+	_, err = os.Stat(backup)
+	assert.True(t, os.IsNotExist(err))
+
+	err = out.Rollback(name)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "no backup of "+name)
+	}
+}
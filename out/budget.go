@@ -0,0 +1,112 @@
+package out
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GeneratedMarker is the line genny writes at the top of every file it
+// generates.
+const GeneratedMarker = "// This file was automatically generated by genny."
+
+// Size is the size of some generated code.
+type Size struct {
+	Files int
+	Lines int
+	Bytes int
+}
+
+// Add gets the size with the generated file src added.
+func (s Size) Add(src []byte) Size {
+	s.Files++
+	s.Lines += bytes.Count(src, []byte("\n"))
+	s.Bytes += len(src)
+	return s
+}
+
+// Budget limits the size of the generated code in a package. Zero values
+// mean no limit.
+type Budget struct {
+	MaxLines int
+	MaxBytes int
+}
+
+// Check gets an error describing how the size exceeds the budget, or nil
+// if it is within the budget.
+func (b Budget) Check(s Size) error {
+	var over []string
+	if b.MaxLines > 0 && s.Lines > b.MaxLines {
+		over = append(over, fmt.Sprintf("%d lines (budget %d)", s.Lines, b.MaxLines))
+	}
+	if b.MaxBytes > 0 && s.Bytes > b.MaxBytes {
+		over = append(over, fmt.Sprintf("%d bytes (budget %d)", s.Bytes, b.MaxBytes))
+	}
+	if len(over) == 0 {
+		return nil
+	}
+	return fmt.Errorf("generated code in %d file(s) is %s; consider consolidating instantiations", s.Files, strings.Join(over, " and "))
+}
+
+// PackageSize gets the size of the genny generated .go files in dir,
+// ignoring the file named exclude (usually the one about to be replaced).
+func PackageSize(dir, exclude string) (Size, error) {
+	var size Size
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return size, nil
+		}
+		return size, err
+	}
+	for _, info := range infos {
+		name := filepath.Join(dir, info.Name())
+		if info.IsDir() || !strings.HasSuffix(name, ".go") || sameFile(name, exclude) {
+			continue
+		}
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return size, err
+		}
+		if IsGenerated(src) {
+			size = size.Add(src)
+		}
+	}
+	return size, nil
+}
+
+// generatedLine is the line a custom header can have in place of
+// GeneratedMarker for the file to be known to be generated by genny, as
+// Go's convention for generated files has it, e.g.
+// "// Code generated by genny from queue.go. DO NOT EDIT.".
+var generatedLine = regexp.MustCompile(`^// Code generated by genny\b.* DO NOT EDIT\.$`)
+
+// IsGenerated gets whether the source was generated by genny: whether its
+// header, up to the package clause, has GeneratedMarker or a generatedLine.
+func IsGenerated(src []byte) bool {
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == GeneratedMarker || generatedLine.MatchString(line) {
+			return true
+		}
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+	}
+	return false
+}
+
+func sameFile(a, b string) bool {
+	if b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
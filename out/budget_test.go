@@ -0,0 +1,45 @@
+package out_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageSize(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "genny-budget")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	generated := []byte(out.GeneratedMarker + "\n\npackage p\n")
+	ioutil.WriteFile(filepath.Join(dir, "gen-a.go"), generated, 0644)
+	ioutil.WriteFile(filepath.Join(dir, "gen-b.go"), generated, 0644)
+	ioutil.WriteFile(filepath.Join(dir, "handwritten.go"), []byte("package p\n"), 0644)
+
+	size, err := out.PackageSize(dir, filepath.Join(dir, "gen-b.go"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, out.Size{Files: 1, Lines: 3, Bytes: len(generated)}, size)
+	}
+
+	size, err = out.PackageSize(filepath.Join(dir, "missing"), "")
+	assert.NoError(t, err)
+	assert.Equal(t, out.Size{}, size)
+
+}
+
+func TestBudgetCheck(t *testing.T) {
+
+	size := out.Size{}.Add([]byte("line\nline\n"))
+	assert.NoError(t, out.Budget{}.Check(size))
+	assert.NoError(t, out.Budget{MaxLines: 2, MaxBytes: 10}.Check(size))
+	assert.EqualError(t, out.Budget{MaxLines: 1}.Check(size), "generated code in 1 file(s) is 2 lines (budget 1); consider consolidating instantiations")
+	assert.Error(t, out.Budget{MaxBytes: 9}.Check(size))
+
+}
@@ -0,0 +1,75 @@
+package out
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// CachePath gets where the record of what the file was generated from is
+// kept, next to its backup in BackupDir.
+func CachePath(filename string) string {
+	return filepath.Join(filepath.Dir(filename), BackupDir, "cache", filepath.Base(filename))
+}
+
+// CacheKey hashes what a file is generated from: the template, the type
+// sets and the options, each given as a part. Parts are hashed with their
+// lengths, so that moving bytes from one part to the next changes the key.
+func CacheKey(parts ...[]byte) string {
+	h := sha256.New()
+	var n [8]byte
+	for _, part := range parts {
+		binary.BigEndian.PutUint64(n[:], uint64(len(part)))
+		h.Write(n[:])
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Cached gets whether the file was last generated with the key, as
+// recorded by Cache, and has not changed since. Any file that cannot be
+// read counts as not cached.
+func Cached(filename, key string) bool {
+	record, err := ioutil.ReadFile(CachePath(filename))
+	if err != nil {
+		return false
+	}
+	current, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(record, cacheRecord(key, current))
+}
+
+// Cache records that the file was generated with the key as output, so
+// that Cached reports it until the key or the file changes.
+func Cache(filename, key string, output []byte) error {
+	path := CachePath(filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return ioutil.WriteFile(path, cacheRecord(key, output), 0644)
+}
+
+// Unchanged gets whether the file already holds the output, so that
+// writing it again would only touch its modification time.
+func Unchanged(filename string, output []byte) bool {
+	current, err := ioutil.ReadFile(filename)
+	return err == nil && bytes.Equal(current, output)
+}
+
+// cacheRecord gets the record of the file generated with the key as
+// output: the key and the hash of the output, a line each.
+func cacheRecord(key string, output []byte) []byte {
+	sum := sha256.Sum256(output)
+	return []byte(key + "\n" + hex.EncodeToString(sum[:]) + "\n")
+}
@@ -0,0 +1,37 @@
+package out_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "gen_queue.go")
+	assert.Equal(t, filepath.Join(dir, ".genny", "cache", "gen_queue.go"), out.CachePath(name))
+
+	key := out.CacheKey([]byte("template"), []byte("Item=int"))
+	assert.Equal(t, key, out.CacheKey([]byte("template"), []byte("Item=int")))
+	assert.NotEqual(t, key, out.CacheKey([]byte("template"), []byte("Item=string")))
+	// parts are hashed with their lengths
+	assert.NotEqual(t, key, out.CacheKey([]byte("templateItem"), []byte("=int")))
+
+	// nothing generated yet
+	assert.False(t, out.Cached(name, key))
+	assert.False(t, out.Unchanged(name, []byte("v1")))
+
+	assert.NoError(t, ioutil.WriteFile(name, []byte("v1"), 0644))
+	assert.NoError(t, out.Cache(name, key, []byte("v1")))
+	assert.True(t, out.Cached(name, key))
+	assert.True(t, out.Unchanged(name, []byte("v1")))
+	assert.False(t, out.Cached(name, out.CacheKey([]byte("template"), []byte("Item=string"))))
+
+	// an edited file is generated again
+	assert.NoError(t, ioutil.WriteFile(name, []byte("v1 edited"), 0644))
+	assert.False(t, out.Cached(name, key))
+	assert.False(t, out.Unchanged(name, []byte("v1")))
+}
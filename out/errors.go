@@ -0,0 +1,41 @@
+package out
+
+// errNoBackup represents an error when there is no backup to roll a file
+// back to.
+type errNoBackup struct {
+	Filename string
+	Backup   string
+}
+
+// Error gets a human readable string describing this error.
+func (e errNoBackup) Error() string {
+	return "no backup of " + e.Filename + " (looked for " + e.Backup + ")"
+}
+
+// errSink represents an error when generated code cannot be written to a
+// destination.
+type errSink struct {
+	Dest    string
+	Message string
+}
+
+// Error gets a human readable string describing this error.
+func (e errSink) Error() string {
+	return "cannot write to " + e.Dest + ": " + e.Message
+}
+
+// errLock represents an error when a file cannot be locked for writing.
+type errLock struct {
+	Filename string
+	Err      error
+}
+
+// Error gets a human readable string describing this error.
+func (e errLock) Error() string {
+	return "cannot lock " + e.Filename + ": " + e.Err.Error()
+}
+
+// Unwrap gets the error that made locking fail.
+func (e errLock) Unwrap() error {
+	return e.Err
+}
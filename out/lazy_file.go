@@ -2,20 +2,26 @@ package out
 
 import (
 	"os"
-	"path"
+	"path/filepath"
 )
 
 // LazyFile is an io.WriteCloser which defers creation of the file it is supposed to write in
 // till the first call to its write function in order to prevent creation of file, if no write
-// is supposed to happen.
+// is supposed to happen. The file is locked (see Lock) from the first write until it is
+// closed.
 type LazyFile struct {
 	// FileName is path to the file to which genny will write.
 	FileName string
 	file     *os.File
+	unlock   func()
 }
 
 // Close closes the file if it is created. Returns nil if no file is created.
 func (lw *LazyFile) Close() error {
+	if lw.unlock != nil {
+		defer lw.unlock()
+		lw.unlock = nil
+	}
 	if lw.file != nil {
 		return lw.file.Close()
 	}
@@ -25,12 +31,17 @@ func (lw *LazyFile) Close() error {
 // Write writes to the specified file and creates the file first time it is called.
 func (lw *LazyFile) Write(p []byte) (int, error) {
 	if lw.file == nil {
-		err := os.MkdirAll(path.Dir(lw.FileName), 0755)
+		err := os.MkdirAll(filepath.Dir(lw.FileName), 0755)
 		if err != nil {
 			return 0, err
 		}
+		if lw.unlock, err = Lock(lw.FileName); err != nil {
+			return 0, err
+		}
 		lw.file, err = os.Create(lw.FileName)
 		if err != nil {
+			lw.unlock()
+			lw.unlock = nil
 			return 0, err
 		}
 	}
@@ -0,0 +1,33 @@
+package out
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// LockDir is the directory of the lock files that Lock takes its locks on.
+// It is shared by every genny process of the user, wherever it runs.
+var LockDir = filepath.Join(os.TempDir(), "genny-locks")
+
+// Lock takes an advisory lock on the file, waiting while another genny
+// process (or goroutine) holds it, so that processes run at the same time,
+// such as by go generate -p in packages that share outputs, do not write
+// the file at once. The file itself is left alone: the lock is on a file in
+// LockDir named after its absolute path. Call unlock to release it.
+func Lock(filename string) (unlock func(), err error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(LockDir, 0777); err != nil {
+		return nil, &errLock{Filename: filename, Err: err}
+	}
+	sum := sha256.Sum256([]byte(abs))
+	unlock, err = lockFile(filepath.Join(LockDir, hex.EncodeToString(sum[:12])+".lock"))
+	if err != nil {
+		return nil, &errLock{Filename: filename, Err: err}
+	}
+	return unlock, nil
+}
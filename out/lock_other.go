@@ -0,0 +1,32 @@
+//go:build !unix
+
+package out
+
+import (
+	"os"
+	"time"
+)
+
+// staleLock is how long a lock file may be held before it is taken to be
+// left by a process that died holding it.
+const staleLock = time.Minute
+
+// lockFile takes the lock by creating the lock file, which only one process
+// can do, and waits while it exists. Unlocking removes it.
+func lockFile(name string) (func(), error) {
+	for {
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(name) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > staleLock {
+			os.Remove(name)
+			continue
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
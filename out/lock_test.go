@@ -0,0 +1,84 @@
+package out_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	lockDir := out.LockDir
+	out.LockDir = filepath.Join(dir, "locks")
+	defer func() { out.LockDir = lockDir }()
+
+	filename := filepath.Join(dir, "gen.go")
+	var mu sync.Mutex
+	holders, most := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := out.Lock(filename)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			holders++
+			if holders > most {
+				most = holders
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			holders--
+			mu.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, most, "the lock was held by more than one at once")
+
+	// other files are locked separately
+	unlock, err := out.Lock(filename)
+	require.NoError(t, err)
+	defer unlock()
+	unlockOther, err := out.Lock(filepath.Join(dir, "other.go"))
+	require.NoError(t, err)
+	unlockOther()
+}
+
+func TestLockedWrites(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-lock")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "gen.go")
+	unlock, err := out.Lock(filename)
+	require.NoError(t, err)
+	written := make(chan struct{})
+	go func() {
+		assert.NoError(t, (&out.FileSink{Path: filename}).Write([]byte("package gen\n")))
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("the file was written while it was locked")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	<-written
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Equal(t, "package gen\n", string(b))
+}
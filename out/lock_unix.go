@@ -0,0 +1,32 @@
+//go:build unix
+
+package out
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive flock on the lock file, which the system
+// releases if the process dies holding it. The file is never removed, as
+// another process may be waiting for a lock on it.
+func lockFile(name string) (func(), error) {
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0666)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+		if err != syscall.EINTR {
+			break
+		}
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,188 @@
+package out
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cheekybits/genny/paths"
+)
+
+// Sink is where generated code is written: a file, stdout, memory, a web
+// service or anything else a build service routes genny's output to.
+type Sink interface {
+	// Write writes the generated code, replacing anything written before.
+	Write(src []byte) error
+}
+
+// SinkOpener opens the Sink for a destination, given without its scheme.
+type SinkOpener func(dest string) (Sink, error)
+
+var (
+	sinksMu sync.Mutex
+	sinks   = map[string]SinkOpener{
+		"file":   func(dest string) (Sink, error) { return &FileSink{Path: paths.Resolve("", dest)}, nil },
+		"git":    func(dest string) (Sink, error) { return &GitSink{Path: paths.Resolve("", dest)}, nil },
+		"stdout": func(dest string) (Sink, error) { return &WriterSink{Writer: os.Stdout}, nil },
+		"http":   func(dest string) (Sink, error) { return &HTTPSink{URL: "http:" + dest}, nil },
+		"https":  func(dest string) (Sink, error) { return &HTTPSink{URL: "https:" + dest}, nil },
+	}
+)
+
+// localSchemes are the schemes of destinations that are files.
+var localSchemes = map[string]bool{"file": true, "git": true}
+
+// scheme matches the scheme of a destination. It is at least two letters
+// long, so that Windows paths such as C:\out.go are files.
+var scheme = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]+):`)
+
+// RegisterSink makes destinations starting with the scheme and a colon,
+// e.g. s3:bucket/key.go, open with the opener. It replaces any opener
+// already registered for the scheme.
+func RegisterSink(scheme string, open SinkOpener) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	sinks[scheme] = open
+}
+
+// OpenSink opens the Sink for the destination: a path, or a URI such as
+// file:out.go, git:out.go (written and staged with git add), stdout: or
+// https://example.com/out.go (written with an HTTP PUT), or one whose
+// scheme was registered with RegisterSink. An empty destination is stdout.
+func OpenSink(dest string) (Sink, error) {
+	if dest == "" {
+		return &WriterSink{Writer: os.Stdout}, nil
+	}
+	m := scheme.FindStringSubmatch(dest)
+	if m == nil {
+		return &FileSink{Path: paths.Resolve("", dest)}, nil
+	}
+	sinksMu.Lock()
+	open, ok := sinks[m[1]]
+	sinksMu.Unlock()
+	if !ok {
+		return nil, &errSink{Dest: dest, Message: "unknown scheme " + m[1] + " (" + strings.Join(schemes(), ", ") + " or a path expected)"}
+	}
+	return open(dest[len(m[0]):])
+}
+
+// SinkPath gets the path of the file the destination writes, or an empty
+// string if it is not a file.
+func SinkPath(dest string) string {
+	m := scheme.FindStringSubmatch(dest)
+	if m == nil {
+		return paths.Resolve("", dest)
+	}
+	if localSchemes[m[1]] {
+		return paths.Resolve("", dest[len(m[0]):])
+	}
+	return ""
+}
+
+// schemes gets the registered schemes, in order.
+func schemes() []string {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	var names []string
+	for name := range sinks {
+		names = append(names, name+":")
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FileSink writes to a file, creating its directory if needed.
+type FileSink struct {
+	Path string
+}
+
+// Write writes the file, holding its lock.
+func (s *FileSink) Write(src []byte) error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+	unlock, err := Lock(s.Path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return os.WriteFile(s.Path, src, 0644)
+}
+
+// GitSink writes to a file, like FileSink, and stages it with git add, for
+// build services that commit what genny generates.
+type GitSink struct {
+	Path string
+}
+
+// Write writes and stages the file.
+func (s *GitSink) Write(src []byte) error {
+	if err := (&FileSink{Path: s.Path}).Write(src); err != nil {
+		return err
+	}
+	cmd := exec.Command("git", "add", "--", filepath.Base(s.Path))
+	cmd.Dir = filepath.Dir(s.Path)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return &errSink{Dest: "git:" + s.Path, Message: "git add failed: " + strings.TrimSpace(string(output))}
+	}
+	return nil
+}
+
+// WriterSink writes to an io.Writer, such as stdout.
+type WriterSink struct {
+	Writer io.Writer
+}
+
+// Write writes to the writer.
+func (s *WriterSink) Write(src []byte) error {
+	_, err := s.Writer.Write(src)
+	return err
+}
+
+// MemorySink keeps what is written, for programs that embed genny.
+type MemorySink struct {
+	Output []byte
+}
+
+// Write keeps a copy of the code.
+func (s *MemorySink) Write(src []byte) error {
+	s.Output = append([]byte(nil), src...)
+	return nil
+}
+
+// HTTPSink writes with an HTTP PUT to the URL, failing unless the response
+// has a 2xx status.
+type HTTPSink struct {
+	URL string
+	// Client makes the request, or http.DefaultClient if it is nil.
+	Client *http.Client
+}
+
+// Write puts the code to the URL.
+func (s *HTTPSink) Write(src []byte) error {
+	req, err := http.NewRequest(http.MethodPut, s.URL, bytes.NewReader(src))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/x-go; charset=utf-8")
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return &errSink{Dest: s.URL, Message: "PUT failed with " + resp.Status}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package out_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenSink(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "genny-sink")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	for _, dest := range []string{filepath.Join(dir, "a", "out.go"), "file:" + filepath.Join(dir, "b", "out.go")} {
+		s, err := out.OpenSink(dest)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.NoError(t, s.Write([]byte("package a\n")))
+		b, err := ioutil.ReadFile(out.SinkPath(dest))
+		assert.NoError(t, err)
+		assert.Equal(t, "package a\n", string(b))
+	}
+
+	s, err := out.OpenSink("")
+	assert.NoError(t, err)
+	assert.IsType(t, &out.WriterSink{}, s)
+	assert.Equal(t, "", out.SinkPath("https://example.com/out.go"))
+	assert.Equal(t, filepath.Join("gen", "out.go"), out.SinkPath("git:gen/out.go"))
+	assert.Equal(t, filepath.Join("C:", "out.go"), out.SinkPath(`C:\out.go`), "drive letters are not schemes")
+
+	_, err = out.OpenSink("ftp://example.com/out.go")
+	assert.EqualError(t, err, "cannot write to ftp://example.com/out.go: unknown scheme ftp (file:, git:, http:, https:, stdout: or a path expected)")
+
+	mem := &out.MemorySink{}
+	out.RegisterSink("mem", func(dest string) (out.Sink, error) { return mem, nil })
+	s, err = out.OpenSink("mem:queue")
+	if assert.NoError(t, err) {
+		assert.NoError(t, s.Write([]byte("package queue\n")))
+		assert.Equal(t, "package queue\n", string(mem.Output))
+	}
+
+}
+
+func TestHTTPSink(t *testing.T) {
+
+	var put []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || r.URL.Path != "/gen/out.go" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		put, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	s, err := out.OpenSink(server.URL + "/gen/out.go")
+	if assert.NoError(t, err) {
+		assert.NoError(t, s.Write([]byte("package gen\n")))
+		assert.Equal(t, "package gen\n", string(put))
+	}
+	s, err = out.OpenSink(server.URL + "/other.go")
+	if assert.NoError(t, err) {
+		assert.EqualError(t, s.Write([]byte("package gen\n")), "cannot write to "+server.URL+"/other.go: PUT failed with 403 Forbidden")
+	}
+
+}
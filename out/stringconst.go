@@ -0,0 +1,50 @@
+package out
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// StringConst gets a Go file in package pkg that declares the constant
+// name with src as its value.
+//
+// src is written as a raw string literal where possible, with the
+// characters that raw strings cannot hold (back quotes and carriage
+// returns) concatenated as interpreted literals, so the constant holds src
+// exactly.
+func StringConst(pkg, name string, src []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n// Any changes will be lost if this file is regenerated.\n// see https://github.com/cheekybits/genny\n\n", GeneratedMarker)
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "// %s is generated Go source code.\n", name)
+	fmt.Fprintf(&buf, "const %s = %s\n", name, Literal(src))
+	return format.Source(buf.Bytes())
+}
+
+// Literal gets a Go string literal for s.
+func Literal(s []byte) string {
+	// raw strings cannot hold everything Go source cannot hold
+	if !utf8.Valid(s) || len(s) == 0 || bytes.IndexByte(s, 0) >= 0 || bytes.Contains(s, []byte("\uFEFF")) {
+		return strconv.Quote(string(s))
+	}
+	var parts []string
+	start := 0
+	for i, c := range s {
+		if c != '`' && c != '\r' {
+			continue
+		}
+		if i > start {
+			parts = append(parts, "`"+string(s[start:i])+"`")
+		}
+		parts = append(parts, strconv.Quote(string(c)))
+		start = i + 1
+	}
+	if start < len(s) {
+		parts = append(parts, "`"+string(s[start:])+"`")
+	}
+	return strings.Join(parts, " +\n\t")
+}
@@ -0,0 +1,50 @@
+package out_test
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringConst(t *testing.T) {
+
+	for _, src := range []string{
+		"package queue\n\ntype IntQueue struct{}\n",
+		"package tags\n\ntype T struct {\n\tA int `json:\"a\"`\n}\n",
+		"```\r\nwindows\r\n",
+		"",
+		"\xff not utf-8",
+		"nul \x00 and bom \uFEFF",
+	} {
+		code, err := out.StringConst("snippets", "Source", []byte(src))
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.True(t, out.IsGenerated(code))
+
+		// the constant holds src exactly
+		fs := token.NewFileSet()
+		file, err := parser.ParseFile(fs, "snippets.go", code, 0)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		assert.Equal(t, "snippets", file.Name.Name)
+		info := &types.Info{Defs: make(map[*ast.Ident]types.Object)}
+		_, err = (&types.Config{}).Check("snippets", fs, []*ast.File{file}, info)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		for ident, obj := range info.Defs {
+			if ident.Name == "Source" {
+				assert.Equal(t, src, constant.StringVal(obj.(*types.Const).Val()))
+			}
+		}
+	}
+
+}
@@ -0,0 +1,89 @@
+package parse
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"runtime/debug"
+)
+
+// modulePath is the path of genny's module, used to find its version.
+const modulePath = "github.com/cheekybits/genny"
+
+// annotatedHeader gets the header base followed by what the file was generated
+// from: the template and its SHA-256 hash, the version of genny (when it is
+// known), each type set and the doc comments of the generic types.
+//
+// Absolute template paths are reduced to the file name, so the header
+// does not depend on where the code was generated.
+func annotatedHeader(base []byte, filename string, in io.ReadSeeker, typeSets []map[string]string) ([]byte, error) {
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	name := filepath.ToSlash(filename)
+	if filepath.IsAbs(filename) {
+		name = filepath.Base(filename)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(base[:len(base)-1])
+	buf.WriteString("//\n")
+	fmt.Fprintf(&buf, "// Template: %s (sha256:%x)\n", name, sha256.Sum256(src))
+	if version := gennyVersion(); version != "" {
+		fmt.Fprintf(&buf, "// Genny: %s\n", version)
+	}
+	buf.WriteString("// Type sets:\n")
+	for _, typeSet := range typeSets {
+		fmt.Fprintf(&buf, "//   - %s\n", typeSetString(typeSet))
+	}
+	params, err := templateParams(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	var documented []param
+	for _, p := range params {
+		if p.Doc != "" {
+			documented = append(documented, p)
+		}
+	}
+	if len(documented) > 0 {
+		buf.WriteString("// Parameters:\n")
+		for _, p := range documented {
+			fmt.Fprintf(&buf, "//   - %s\n", p.describe())
+		}
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// gennyVersion gets the version of genny the program was built with, or
+// "" if it is not known (e.g. in a development build).
+func gennyVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	version := info.Main.Version
+	if info.Main.Path != modulePath {
+		version = ""
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				version = dep.Version
+				if dep.Replace != nil {
+					version = dep.Replace.Version
+				}
+			}
+		}
+	}
+	if version == "(devel)" {
+		return ""
+	}
+	return version
+}
@@ -0,0 +1,181 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// checkAssertions makes sure that type switches and type assertions in the
+// template are still valid once the specific types have been substituted.
+//
+// Every specific type used in an assertion position must be a valid type
+// expression, an assertion on a value of a generic type requires the specific
+// type to be an interface, and a type switch must not end up with the same
+// type in two of its cases.
+func checkAssertions(fs *token.FileSet, file *ast.File, typeSet map[string]string) error {
+	var err error
+	ast.Inspect(file, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch t := n.(type) {
+		case *ast.TypeAssertExpr:
+			if t.Type != nil {
+				if err = checkAssertTarget(fs, t.Type, typeSet); err != nil {
+					return false
+				}
+			}
+			err = checkAssertOperand(fs, t.X, typeSet)
+		case *ast.TypeSwitchStmt:
+			err = checkTypeSwitch(fs, t, typeSet)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// checkTypeSwitch checks the cases of a type switch for invalid or
+// duplicated types.
+func checkTypeSwitch(fs *token.FileSet, sw *ast.TypeSwitchStmt, typeSet map[string]string) error {
+	seen := make(map[string]string)
+	for _, stmt := range sw.Body.List {
+		clause, ok := stmt.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+		for _, expr := range clause.List {
+			if err := checkAssertTarget(fs, expr, typeSet); err != nil {
+				return err
+			}
+			specific := subIntoTypeExpr(expr, typeSet)
+			if other, ok := seen[specific]; ok {
+				return &errDuplicateCase{
+					Type:  specific,
+					Cases: []string{other, types.ExprString(expr)},
+					Pos:   fs.Position(expr.Pos()),
+				}
+			}
+			seen[specific] = types.ExprString(expr)
+		}
+	}
+	return nil
+}
+
+// checkAssertTarget makes sure every generic type in the expression has a
+// specific type that may be used as the target of a type assertion.
+func checkAssertTarget(fs *token.FileSet, expr ast.Expr, typeSet map[string]string) error {
+	var err error
+	ast.Inspect(expr, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok || err != nil {
+			return err == nil
+		}
+		specificType, ok := typeSet[ident.Name]
+		if !ok {
+			return true
+		}
+		if !isTypeExpr(specificType) {
+			err = &errInvalidAssertion{
+				GenericType:  ident.Name,
+				SpecificType: specificType,
+				Reason:       "it is not a valid type expression",
+				Pos:          fs.Position(ident.Pos()),
+			}
+		}
+		return false
+	})
+	return err
+}
+
+// checkAssertOperand makes sure that values of a generic type are only
+// asserted when the specific type is an interface.
+func checkAssertOperand(fs *token.FileSet, x ast.Expr, typeSet map[string]string) error {
+	ident, ok := x.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return nil
+	}
+	var declType ast.Expr
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		declType = decl.Type
+	case *ast.ValueSpec:
+		declType = decl.Type
+	}
+	typeIdent, ok := declType.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	specificType, ok := typeSet[typeIdent.Name]
+	if !ok || mayBeInterface(specificType) {
+		return nil
+	}
+	return &errInvalidAssertion{
+		GenericType:  typeIdent.Name,
+		SpecificType: specificType,
+		Reason:       "'" + ident.Name + "' is asserted but '" + specificType + "' is not an interface",
+		Pos:          fs.Position(x.Pos()),
+	}
+}
+
+// subIntoTypeExpr gets the (normalised) type expression with the specific
+// types substituted.
+func subIntoTypeExpr(expr ast.Expr, typeSet map[string]string) string {
+	s := types.ExprString(expr)
+	for _, t := range substitutionOrder(typeSet) {
+		if strings.Contains(s, t) {
+			s = subTypeIntoLine(s, t, typeSet[t])
+		}
+	}
+	s = strings.TrimSuffix(strings.TrimSpace(s), ";")
+	if specific, err := parser.ParseExpr(s); err == nil {
+		return types.ExprString(specific)
+	}
+	return strings.TrimSpace(s)
+}
+
+// isTypeExpr gets whether s is a valid type expression.
+func isTypeExpr(s string) bool {
+	expr, err := parser.ParseExpr(s)
+	if err != nil {
+		return false
+	}
+	for {
+		switch t := expr.(type) {
+		case *ast.StarExpr:
+			expr = t.X
+			continue
+		case *ast.ParenExpr:
+			expr = t.X
+			continue
+		case *ast.Ident, *ast.ArrayType, *ast.MapType, *ast.ChanType,
+			*ast.FuncType, *ast.InterfaceType, *ast.StructType:
+			return true
+		case *ast.SelectorExpr:
+			_, ok := t.X.(*ast.Ident)
+			return ok
+		}
+		return false
+	}
+}
+
+// mayBeInterface gets whether the specific type could be an interface
+// type. Named types are given the benefit of the doubt.
+func mayBeInterface(specificType string) bool {
+	expr, err := parser.ParseExpr(specificType)
+	if err != nil {
+		return false
+	}
+	switch t := expr.(type) {
+	case *ast.InterfaceType:
+		return true
+	case *ast.SelectorExpr:
+		_, ok := t.X.(*ast.Ident)
+		return ok
+	case *ast.Ident:
+		return t.Name == "error" || !isBuiltin(t.Name)
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package parse
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const assertionsSource = `package p
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+func DescribeItem(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case Item:
+		return "item"
+	}
+	return "unknown"
+}
+
+func UnwrapItem(i Item) error {
+	err, _ := i.(error)
+	return err
+}
+`
+
+func TestCheckAssertions(t *testing.T) {
+
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, "assertions.go", assertionsSource, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, checkAssertions(fs, file, map[string]string{"Item": "io.Reader"}))
+	assert.NoError(t, checkAssertions(fs, file, map[string]string{"Item": "interface{}"}))
+
+	err = checkAssertions(fs, file, map[string]string{"Item": "string"})
+	if assert.IsType(t, &errDuplicateCase{}, err) {
+		assert.Equal(t, "assertions.go:11:7: type switch cases 'string' and 'Item' would both become 'string'", err.Error())
+	}
+
+	err = checkAssertions(fs, file, map[string]string{"Item": "int"})
+	if assert.IsType(t, &errInvalidAssertion{}, err) {
+		assert.Equal(t, "assertions.go:18:12: invalid type assertion for 'Item=int': 'i' is asserted but 'int' is not an interface", err.Error())
+	}
+
+	err = checkAssertions(fs, file, map[string]string{"Item": "[]"})
+	assert.IsType(t, &errInvalidAssertion{}, err)
+
+}
+
+func TestIsTypeExpr(t *testing.T) {
+
+	for _, s := range []string{"int", "*MyType", "pkg.Type", "[]byte", "map[string]int", "chan error", "func() error", "interface{}", "struct{}"} {
+		assert.True(t, isTypeExpr(s), s)
+	}
+	for _, s := range []string{"", "1", "a+b", "f()", "[]"} {
+		assert.False(t, isTypeExpr(s), s)
+	}
+
+}
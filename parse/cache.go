@@ -0,0 +1,138 @@
+package parse
+
+import (
+	"crypto/sha256"
+	"go/ast"
+	"go/token"
+	"strings"
+	"sync"
+)
+
+// Cache holds parsed templates, so that generating a template again (with
+// other type sets, or from another goroutine) does not parse it again.
+//
+// A Cache is safe for concurrent use by multiple goroutines. Programs that
+// generate code repeatedly, such as editor servers and build daemons,
+// should create one Cache and share it by setting Options.Cache on every
+// call. The zero value is an empty cache ready to use.
+//
+// Cached syntax trees are never modified, so they are shared between
+// goroutines without copying.
+//
+// The cache also keeps the most recent generation results, keyed by the
+// template's content, the type sets and the options, so that generating
+// the same instantiation again, as an editor previewing it does, returns
+// at once. Results are not kept for calls with Validators or a Resolver,
+// whose behaviour the cache cannot see.
+type Cache struct {
+	// MaxResults is the number of generation results kept, the least
+	// recently used being dropped first: DefaultMaxResults if it is 0, and
+	// none if it is negative.
+	MaxResults int
+
+	mu        sync.Mutex
+	templates map[cacheKey]*cachedTemplate
+	hits      int
+	misses    int
+	results   *resultMemo
+}
+
+// DefaultMaxResults is the number of generation results a Cache keeps by
+// default.
+const DefaultMaxResults = 64
+
+// cacheKey identifies a template by its name and content.
+type cacheKey struct {
+	filename string
+	sum      [sha256.Size]byte
+}
+
+// cachedTemplate is a parsed template. ready is closed once fs, file and
+// err are set.
+type cachedTemplate struct {
+	ready chan struct{}
+	fs    *token.FileSet
+	file  *ast.File
+	err   error
+}
+
+// CacheStats describes how well a Cache is doing.
+type CacheStats struct {
+	Templates int
+	Hits      int
+	Misses    int
+	// Results is the number of generation results kept, and ResultHits
+	// and ResultMisses how often a result was looked for and found or not.
+	Results      int
+	ResultHits   int
+	ResultMisses int
+}
+
+// Stats gets the number of templates and results in the cache and how
+// often they were found in it.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := CacheStats{Templates: len(c.templates), Hits: c.hits, Misses: c.misses}
+	if c.results != nil {
+		stats.Results = c.results.order.Len()
+		stats.ResultHits, stats.ResultMisses = c.results.hits, c.results.misses
+	}
+	return stats
+}
+
+// Reset empties the cache.
+func (c *Cache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.templates = nil
+	c.hits, c.misses = 0, 0
+	c.results = nil
+}
+
+// Invalidate drops every version of the template from the cache, along
+// with the templates read from it if it is an archive (archive#member) and
+// the results generated from them, so that long running programs watching
+// templates for changes do not keep the trees of old versions. It gets the
+// number of templates dropped.
+func (c *Cache) Invalidate(filename string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for key := range c.templates {
+		if key.filename == filename || strings.HasPrefix(key.filename, filename+"#") {
+			delete(c.templates, key)
+			n++
+		}
+	}
+	if c.results != nil {
+		c.results.invalidate(filename)
+	}
+	return n
+}
+
+// parse gets the parsed template from the cache, calling parse (at most
+// once, even when called concurrently) if it is not there.
+func (c *Cache) parse(filename string, src []byte, parse func() (*token.FileSet, *ast.File, error)) (*token.FileSet, *ast.File, error) {
+	key := cacheKey{filename: filename, sum: sha256.Sum256(src)}
+	c.mu.Lock()
+	t, ok := c.templates[key]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+		if c.templates == nil {
+			c.templates = make(map[cacheKey]*cachedTemplate)
+		}
+		t = &cachedTemplate{ready: make(chan struct{})}
+		c.templates[key] = t
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		t.fs, t.file, t.err = parse()
+		close(t.ready)
+	}
+	<-t.ready
+	return t.fs, t.file, t.err
+}
@@ -0,0 +1,150 @@
+package parse_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/cheekybits/genny/diag"
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheConcurrentUse(t *testing.T) {
+
+	template, err := ioutil.ReadFile("test/queue/generic_queue.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	expected, err := ioutil.ReadFile("test/queue/int_queue.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	const goroutines = 8
+	// without results, every goroutine generates the parsed template
+	cache := &parse.Cache{MaxResults: -1}
+	outputs := make([][]byte, goroutines)
+	errs := make([]error, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			typeSets := []map[string]string{{"Something": "int"}}
+			outputs[i], errs[i] = parse.GenericsWithOptions("generic_queue.go", "int_queue.go", "", bytes.NewReader(template), typeSets, parse.Options{Cache: cache})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < goroutines; i++ {
+		if assert.NoError(t, errs[i]) {
+			assert.Equal(t, string(expected), string(outputs[i]))
+		}
+	}
+	assert.Equal(t, parse.CacheStats{Templates: 1, Hits: goroutines - 1, Misses: 1}, cache.Stats())
+
+	cache.Reset()
+	assert.Equal(t, parse.CacheStats{}, cache.Stats())
+
+}
+
+func TestCacheKeysOnContent(t *testing.T) {
+
+	cache := &parse.Cache{MaxResults: -1}
+	opts := parse.Options{Cache: cache}
+	typeSets := []map[string]string{{"Item": "int"}}
+	for _, src := range []string{
+		"package a\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\nvar ItemZero Item\n",
+		"package a\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\nvar ItemOne Item\n",
+	} {
+		output, err := parse.GenericsWithOptions("a.go", "b.go", "", bytes.NewReader([]byte(src)), typeSets, opts)
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(output), "var Int")
+		}
+	}
+	assert.Equal(t, 2, cache.Stats().Templates)
+
+	// a bad template is cached along with its error
+	for i := 0; i < 2; i++ {
+		_, err := parse.GenericsWithOptions("bad.go", "b.go", "", bytes.NewReader([]byte("package")), typeSets, opts)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, parse.CacheStats{Templates: 3, Hits: 1, Misses: 3}, cache.Stats())
+
+}
+
+func TestCacheInvalidate(t *testing.T) {
+
+	cache := &parse.Cache{}
+	opts := parse.Options{Cache: cache}
+	typeSets := []map[string]string{{"Item": "int"}}
+	src := "package a\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\nvar ItemZero Item\n"
+	for _, filename := range []string{"a.go", "lib.tar.gz#a.go", "lib.tar.gz#b.go", "lib.tar.gzip#a.go"} {
+		_, err := parse.GenericsWithOptions(filename, "out.go", "", bytes.NewReader([]byte(src)), typeSets, opts)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 2, cache.Invalidate("lib.tar.gz"))
+	assert.Equal(t, 1, cache.Invalidate("a.go"))
+	assert.Equal(t, 0, cache.Invalidate("a.go"))
+	assert.Equal(t, 1, cache.Stats().Templates)
+
+}
+
+func TestCacheResults(t *testing.T) {
+
+	cache := &parse.Cache{MaxResults: 2}
+	var warnings diag.List
+	opts := parse.Options{Cache: cache, Warnings: &warnings}
+	src := "package a\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\ntype Unused generic.Type\n\nvar ItemZero Item\n"
+	generate := func(types string) string {
+		typeSets, err := parse.TypeSet(types)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		output, err := parse.GenericsWithOptions("a.go", "out.go", "", bytes.NewReader([]byte(src)), typeSets, opts)
+		assert.NoError(t, err)
+		return string(output)
+	}
+
+	first := generate("Item=int Unused=bool")
+	assert.Len(t, warnings, 1)
+	warnings = nil
+	assert.Equal(t, first, generate("Item=int Unused=bool"))
+	assert.Len(t, warnings, 1, "the warnings of a kept result are given again")
+	stats := cache.Stats()
+	assert.Equal(t, 1, stats.Results)
+	assert.Equal(t, 1, stats.ResultHits)
+	assert.Equal(t, 1, stats.ResultMisses)
+
+	// other type sets and options are other results
+	assert.Contains(t, generate("Item=string Unused=bool"), "var StringZero string")
+	opts.Annotate = true
+	assert.Contains(t, generate("Item=int Unused=bool"), "Type sets:")
+	opts.Annotate = false
+	stats = cache.Stats()
+	assert.Equal(t, 2, stats.Results, "the least recently used result is dropped")
+	assert.Equal(t, 1, stats.ResultHits)
+
+	generate("Item=int Unused=bool")
+	assert.Equal(t, 1, cache.Stats().ResultHits, "the first result was dropped")
+
+	cache.Invalidate("a.go")
+	assert.Equal(t, 0, cache.Stats().Results)
+
+}
+
+func TestCacheResultsNotKept(t *testing.T) {
+
+	cache := &parse.Cache{}
+	typeSets := []map[string]string{{"Item": "int"}}
+	src := "package a\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\nvar ItemZero Item\n"
+	opts := parse.Options{Cache: cache, Validators: map[string][]parse.Validator{parse.AllParams: {func(name, concreteType string) error { return nil }}}}
+	for i := 0; i < 2; i++ {
+		_, err := parse.GenericsWithOptions("a.go", "out.go", "", bytes.NewReader([]byte(src)), typeSets, opts)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, parse.CacheStats{Templates: 1, Hits: 1, Misses: 1}, cache.Stats())
+
+}
@@ -0,0 +1,123 @@
+package parse
+
+import (
+	"sort"
+	"strings"
+)
+
+// PositionCasing controls how a specific type is written where the
+// template uses its generic type in an identifier, a string literal or a
+// comment. CasingKeep is how genny has always substituted: an identifier
+// or a word that starts with an unexported generic type gets a lower case
+// first letter, and every other use an upper case one.
+type PositionCasing struct {
+	// Identifiers is the casing of the specific type in identifiers, such
+	// as IntQueue or NewIntQueue. An identifier that is just the generic
+	// type is always replaced by the specific type, as it is a type.
+	// CasingVerbatim cannot be used here, as the result would not be an
+	// identifier.
+	Identifiers Casing
+	// Strings is the casing in string literals, e.g. CasingVerbatim to
+	// write "*bytes.Buffer" rather than "BytesBuffer" in messages.
+	Strings Casing
+	// Comments is the casing in comments.
+	Comments Casing
+}
+
+// positions are the names of the fields of PositionCasing, as used by
+// ParseCasings.
+var positions = map[string]func(*PositionCasing) *Casing{
+	"identifiers": func(c *PositionCasing) *Casing { return &c.Identifiers },
+	"strings":     func(c *PositionCasing) *Casing { return &c.Strings },
+	"comments":    func(c *PositionCasing) *Casing { return &c.Comments },
+}
+
+// ParsePositionCasing gets the Casing for "keep", "upper", "lower",
+// "snake" or "verbatim".
+func ParsePositionCasing(s string) (Casing, error) {
+	if s == "verbatim" {
+		return CasingVerbatim, nil
+	}
+	casing, ok := casings[s]
+	if !ok {
+		return CasingKeep, &errBadOption{Option: "casing", Value: s, Message: "keep, upper, lower, snake or verbatim expected"}
+	}
+	return casing, nil
+}
+
+// ParseCasings parses a comma separated list of positions (identifiers,
+// strings or comments), each followed by a colon and its casing, e.g.
+// "strings:verbatim,comments:lower". A position may be prefixed with a
+// generic type and a dot to only apply to it, e.g. "Key.strings:snake";
+// otherwise it applies to every generic type, under the AllParams key.
+func ParseCasings(s string) (map[string]PositionCasing, error) {
+	casings := make(map[string]PositionCasing)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		i := strings.Index(item, ":")
+		if i < 0 {
+			return nil, &errBadOption{Option: "casing", Value: item, Message: "position:casing expected, e.g. strings:verbatim"}
+		}
+		generic, position := AllParams, item[:i]
+		if dot := strings.LastIndex(position, "."); dot >= 0 {
+			generic, position = position[:dot], position[dot+1:]
+		}
+		field, ok := positions[position]
+		if !ok {
+			return nil, &errBadOption{Option: "casing", Value: item, Message: "identifiers, strings or comments expected before the colon"}
+		}
+		casing, err := ParsePositionCasing(item[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		c := casings[generic]
+		*field(&c) = casing
+		casings[generic] = c
+	}
+	if err := checkCasings(casings); err != nil {
+		return nil, err
+	}
+	return casings, nil
+}
+
+// checkCasings checks that no generic type is given a casing that cannot
+// be used in its position.
+func checkCasings(casings map[string]PositionCasing) error {
+	var generics []string
+	for generic := range casings {
+		generics = append(generics, generic)
+	}
+	sort.Strings(generics)
+	for _, generic := range generics {
+		if casings[generic].Identifiers == CasingVerbatim {
+			return &errBadOption{Option: "casing", Value: generic + ".identifiers:verbatim", Message: "identifiers cannot be verbatim"}
+		}
+	}
+	return nil
+}
+
+// casingFor gets the casing of the generic type in each position: its own
+// where it is not CasingKeep, and that of AllParams elsewhere.
+func casingFor(casings map[string]PositionCasing, generic string) PositionCasing {
+	c := casings[AllParams]
+	own := casings[generic]
+	for _, field := range positions {
+		if casing := *field(&own); casing != CasingKeep {
+			*field(&c) = casing
+		}
+	}
+	return c
+}
+
+// withPositionCasing substitutes the specific type for every use of the
+// generic type in the identifier, literal or comment text with the casing.
+func withPositionCasing(text, typeTemplate, specificType string, casing Casing) string {
+	specific := specificTypeOf(specificType)
+	if casing != CasingVerbatim {
+		specific = withCasing(wordify(specificType, true), casing)
+	}
+	return strings.Replace(text, typeTemplate, specific, -1)
+}
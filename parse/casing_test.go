@@ -0,0 +1,53 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCasings(t *testing.T) {
+
+	casings, err := ParseCasings("strings:verbatim, comments:lower,Key.strings:snake")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]PositionCasing{
+		AllParams: {Strings: CasingVerbatim, Comments: CasingLower},
+		"Key":     {Strings: CasingSnake},
+	}, casings)
+	assert.Equal(t, PositionCasing{Strings: CasingSnake, Comments: CasingLower}, casingFor(casings, "Key"))
+	assert.Equal(t, PositionCasing{Strings: CasingVerbatim, Comments: CasingLower}, casingFor(casings, "Value"))
+
+	_, err = ParseCasings("strings")
+	assert.Error(t, err)
+	_, err = ParseCasings("types:upper")
+	assert.Error(t, err)
+	_, err = ParseCasings("strings:kebab")
+	assert.Error(t, err)
+	_, err = ParseCasings("Key.identifiers:verbatim")
+	assert.EqualError(t, err, `"Key.identifiers:verbatim" is not a valid casing option: identifiers cannot be verbatim`)
+
+}
+
+func TestSubTypeIntoTokensWithCasing(t *testing.T) {
+
+	line := `func NewItemQueue() *ItemQueue { log("new ItemQueue of Item") } // NewItemQueue makes an ItemQueue`
+	for _, test := range []struct {
+		casing   PositionCasing
+		expected string
+	}{
+		{PositionCasing{}, `func NewBytesBufferQueue() *BytesBufferQueue { log("new BytesBufferQueue of BytesBuffer") } // NewBytesBufferQueue makes an BytesBufferQueue`},
+		{PositionCasing{Strings: CasingVerbatim}, `func NewBytesBufferQueue() *BytesBufferQueue { log("new *bytes.BufferQueue of *bytes.Buffer") } // NewBytesBufferQueue makes an BytesBufferQueue`},
+		{PositionCasing{Strings: CasingSnake, Comments: CasingLower}, `func NewBytesBufferQueue() *BytesBufferQueue { log("new bytes_bufferQueue of bytes_buffer") } // NewbytesBufferQueue makes an bytesBufferQueue`},
+		{PositionCasing{Identifiers: CasingSnake}, `func Newbytes_bufferQueue() *bytes_bufferQueue { log("new BytesBufferQueue of BytesBuffer") } // NewBytesBufferQueue makes an BytesBufferQueue`},
+	} {
+		assert.Equal(t, test.expected, subTypeIntoTokens(line, "Item", "*bytes.Buffer", false, test.casing, false))
+	}
+
+	// the generic type itself is always the specific type
+	assert.Equal(t, "var q []*bytes.Buffer", subTypeIntoTokens("var q []Item", "Item", "*bytes.Buffer", false, PositionCasing{Identifiers: CasingLower}, false))
+
+	_, err := GenericsWithOptions("queue.go", "queue.go", "", strings.NewReader("package queue\n"), nil, Options{Casings: map[string]PositionCasing{AllParams: {Identifiers: CasingVerbatim}}})
+	assert.Error(t, err)
+
+}
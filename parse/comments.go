@@ -0,0 +1,100 @@
+package parse
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"strings"
+)
+
+// blockComment is the part of a line that is in a /* */ comment spanning
+// more than one line.
+type blockComment struct {
+	// start and end are the offsets of the comment in the line.
+	start, end int
+	// doc is whether the comment starts a line and has nothing after it,
+	// as a doc comment does.
+	doc bool
+}
+
+// blockComments gets the lines of the template, numbered from 1, that are
+// in /* */ comments spanning lines. Scanned on its own, such a line is not
+// a comment, so it is substituted into as one with what blockComments
+// finds.
+func blockComments(src []byte) map[int]blockComment {
+	blocks := make(map[int]blockComment)
+	fs := token.NewFileSet()
+	file := fs.AddFile("", fs.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.COMMENT || !strings.HasPrefix(lit, "/*") {
+			continue
+		}
+		// the literal has no carriage returns, so the end is found in
+		// the source
+		start := file.Offset(pos)
+		end := len(src)
+		if i := bytes.Index(src[start:], []byte("*/")); i >= 0 {
+			end = start + i + len("*/")
+		}
+		first, last := file.PositionFor(pos, false).Line, file.PositionFor(file.Pos(end), false).Line
+		if first == last {
+			continue
+		}
+		rest := lineAt(src, file, last)[end-file.Offset(file.LineStart(last)):]
+		doc := file.PositionFor(pos, false).Column == 1 && len(bytes.TrimSpace(rest)) == 0
+		for n := first; n <= last; n++ {
+			lineStart := file.Offset(file.LineStart(n))
+			bc := blockComment{end: len(lineAt(src, file, n)), doc: doc}
+			if n == first {
+				bc.start = start - lineStart
+			}
+			if n == last {
+				bc.end = end - lineStart
+			}
+			blocks[n] = bc
+		}
+	}
+	return blocks
+}
+
+// lineAt gets the line n of the source, without its line feed.
+func lineAt(src []byte, file *token.File, n int) []byte {
+	line := src[file.Offset(file.LineStart(n)):]
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+	return line
+}
+
+// in gets the parts of the line before, in and after the comment, which
+// may be cut short of its end (by bufio.Scanner dropping a carriage
+// return).
+func (bc blockComment) in(line string) (before, comment, after string) {
+	start, end := bc.start, bc.end
+	if end > len(line) {
+		end = len(line)
+	}
+	if start > end {
+		start = end
+	}
+	return line[:start], line[start:end], line[end:]
+}
+
+// subTypesIntoComment substitutes the specific types of the type set into
+// the text of a comment, with the comment casing of each.
+func subTypesIntoComment(text string, generics []string, typeSet map[string]string, casings map[string]PositionCasing) string {
+	for _, t := range generics {
+		if casing := casingFor(casings, t); casing.Comments != CasingKeep {
+			text = withPositionCasing(text, t, typeSet[t], casing.Comments)
+		} else {
+			text = subTypeIntoComment(text, t, typeSet[t])
+		}
+	}
+	return text
+}
@@ -0,0 +1,78 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const blockCommentTemplate = `/*
+Copyright Item Corp. It's licensed.
+*/
+
+package queue
+
+import "github.com/cheekybits/genny/generic"
+
+/*
+Item is the type
+of the items.
+*/
+type Item generic.Type
+
+// ItemQueue is a queue
+// of Items.
+type ItemQueue struct {
+	items []Item /* the Items,
+	oldest first */
+}
+
+/*
+NewItemQueue makes an ItemQueue
+of "Items".
+*/
+func NewItemQueue() *ItemQueue { return &ItemQueue{} }
+`
+
+func TestBlockComments(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int")
+	require.NoError(t, err)
+	for _, engine := range []parse.Engine{parse.EngineLines, parse.EngineAST} {
+		output, err := parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(blockCommentTemplate), typeSets, parse.Options{Engine: engine})
+		require.NoError(t, err)
+		code := string(output)
+		assert.Contains(t, code, "/*\nCopyright int Corp. It's licensed.\n*/")
+		assert.Contains(t, code, "items []int /* the Ints,\n\toldest first */")
+		assert.Contains(t, code, "/*\nNewIntQueue makes an IntQueue\nof \"Ints\".\n*/\nfunc NewIntQueue() *IntQueue")
+		// the doc comments of the generic type go with it
+		assert.NotContains(t, code, "is the type")
+		assert.NotContains(t, code, "of the items.")
+	}
+}
+
+func TestBlockCommentsCasing(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int")
+	require.NoError(t, err)
+	casings, err := parse.ParseCasings("comments:lower")
+	require.NoError(t, err)
+	output, err := parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(blockCommentTemplate), typeSets, parse.Options{Casings: casings})
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "makes an intQueue\nof \"ints\".")
+}
+
+func TestExplainBlockComments(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int")
+	require.NoError(t, err)
+	e, err := parse.TemplateExplain("queue.go", strings.NewReader(blockCommentTemplate), typeSets, parse.Options{})
+	require.NoError(t, err)
+	var docs []int
+	for _, l := range e.Lines {
+		if strings.Contains(l.Reason, "documents a generic type") {
+			docs = append(docs, l.Line)
+		}
+	}
+	assert.Equal(t, []int{9, 10, 11, 12}, docs)
+}
@@ -0,0 +1,298 @@
+package parse
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// sectionDirective guards the top level declaration it comments with a
+// build constraint, e.g.
+//
+//	//genny:build linux
+//	func (q *SomethingQueue) fd() int { ... }
+const sectionDirective = metadataPrefix + "build "
+
+// metadataPrefix starts the //genny:<key> <value> directives that describe
+// a template. They are not copied to the generated code, except for
+// sectionDirective.
+const metadataPrefix = "//genny:"
+
+// File is a generated file.
+type File struct {
+	// Name is the path the file is written to.
+	Name string
+	// Source is the formatted Go code.
+	Source []byte
+}
+
+// HasSections gets whether the generated code has declarations guarded
+// by //genny:build directives, which should be split with GenerateFiles
+// rather than merged into one file.
+func HasSections(output []byte) bool {
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte(sectionDirective)) {
+			return true
+		}
+	}
+	return false
+}
+
+// section is the code of the declarations with the same constraint.
+type section struct {
+	expr  constraint.Expr
+	decls [][]byte
+}
+
+// SplitSections splits the generated code into one file per constraint of
+// the declarations guarded by //genny:build directives. The other
+// declarations are in the first file, named outputFilename; a constrained
+// file takes its name from outputFilename and the constraint
+// (gen_queue_linux.go) and starts with the matching //go:build line, and
+// with the template's own constraint if it has one.
+func SplitSections(outputFilename string, output []byte) ([]File, error) {
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, outputFilename, output, parser.ParseComments)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	offset := func(p token.Pos) int { return fs.Position(p).Offset }
+
+	// the file keeps everything up to the first declaration, which has the
+	// header, any file constraint and the package clause
+	var prelude []byte
+	var fileExpr constraint.Expr
+	for _, group := range file.Comments {
+		if group.Pos() > file.Package {
+			break
+		}
+		for _, c := range group.List {
+			if constraint.IsGoBuild(c.Text) {
+				if fileExpr, err = constraint.Parse(c.Text); err != nil {
+					return nil, &errConstraint{Constraint: c.Text, Err: err}
+				}
+			}
+		}
+	}
+	packageEnd := offset(file.Name.End())
+	prelude = append(prelude, output[:packageEnd]...)
+	prelude = append(prelude, '\n')
+
+	var sections []*section
+	found := make(map[string]*section)
+	base := &section{expr: fileExpr}
+	for _, decl := range file.Decls {
+		start, end := decl.Pos(), decl.End()
+		doc := declDoc(decl)
+		var expr constraint.Expr
+		if doc != nil {
+			start = doc.Pos()
+			for _, c := range doc.List {
+				if !strings.HasPrefix(c.Text, sectionDirective) {
+					continue
+				}
+				if expr, err = constraint.Parse("//go:build " + strings.TrimPrefix(c.Text, sectionDirective)); err != nil {
+					return nil, &errConstraint{Constraint: c.Text, Err: err}
+				}
+			}
+		}
+		code := withoutDirectives(output[offset(start):offset(end)])
+		if expr == nil {
+			base.decls = append(base.decls, code)
+			continue
+		}
+		key := expr.String()
+		s, ok := found[key]
+		if !ok {
+			s = &section{expr: expr}
+			if fileExpr != nil {
+				s.expr = &constraint.AndExpr{X: fileExpr, Y: expr}
+			}
+			found[key] = s
+			sections = append(sections, s)
+		}
+		s.decls = append(s.decls, code)
+	}
+	if len(sections) == 0 {
+		return []File{{Name: outputFilename, Source: output}}, nil
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].expr.String() < sections[j].expr.String()
+	})
+
+	files := []File{{Name: outputFilename}}
+	for _, s := range sections {
+		files = append(files, File{Name: sectionFilename(outputFilename, s.expr)})
+	}
+	for i, s := range append([]*section{base}, sections...) {
+		source := sectionSource(prelude, s)
+		formatted, err := Format(files[i].Name, source)
+		if err != nil {
+			return nil, err
+		}
+		files[i].Source = formatted
+	}
+	return files, nil
+}
+
+// sectionSource gets the code of the file for the section, with the
+// file's constraint replaced by the section's.
+func sectionSource(prelude []byte, s *section) []byte {
+	var buf bytes.Buffer
+	if s.expr != nil {
+		buf.WriteString("//go:build " + s.expr.String() + "\n\n")
+	}
+	for _, line := range bytes.SplitAfter(prelude, []byte("\n")) {
+		if constraint.IsGoBuild(string(bytes.TrimSpace(line))) || constraint.IsPlusBuild(string(bytes.TrimSpace(line))) {
+			continue
+		}
+		buf.Write(line)
+	}
+	for _, decl := range s.decls {
+		buf.WriteString("\n")
+		buf.Write(decl)
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
+
+// withoutDirectives removes the //genny:build lines from the code, along
+// with the empty comment line gofmt puts before them.
+func withoutDirectives(code []byte) []byte {
+	lines := bytes.SplitAfter(code, []byte("\n"))
+	isDirective := func(i int) bool {
+		return i < len(lines) && bytes.HasPrefix(bytes.TrimSpace(lines[i]), []byte(sectionDirective))
+	}
+	var out []byte
+	for i, line := range lines {
+		if isDirective(i) || (string(bytes.TrimSpace(line)) == "//" && isDirective(i+1)) {
+			continue
+		}
+		out = append(out, line...)
+	}
+	return out
+}
+
+// nonWord matches the parts of a constraint that are not tags.
+var nonWord = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// sectionFilename gets the name of the file for the constraint, e.g.
+// gen_queue_linux.go for linux. Go also constrains files by the operating
+// system and architecture at the end of their names, so a name that would
+// do that without the constraint saying so gets a _build suffix.
+func sectionFilename(outputFilename string, expr constraint.Expr) string {
+	base := strings.TrimSuffix(outputFilename, ".go")
+	test := strings.HasSuffix(base, "_test")
+	base = strings.TrimSuffix(base, "_test")
+
+	name := expr.String()
+	name = strings.NewReplacer("!", " not ", "&&", " and ", "||", " or ").Replace(name)
+	name = strings.ToLower(strings.Trim(nonWord.ReplaceAllString(name, "_"), "_"))
+	if tag, ok := expr.(*constraint.TagExpr); !ok || !knownOSArch[tag.Tag] {
+		parts := strings.Split(name, "_")
+		if knownOSArch[parts[len(parts)-1]] {
+			name += "_build"
+		}
+	}
+	name = base + "_" + name
+	if test {
+		name += "_test"
+	}
+	return filepath.Clean(name + ".go")
+}
+
+// knownOSArch are the values of GOOS and GOARCH, which constrain files
+// whose names end with them.
+var knownOSArch = make(map[string]bool)
+
+func init() {
+	for _, name := range strings.Fields(`aix android darwin dragonfly freebsd hurd illumos ios js linux nacl netbsd openbsd plan9 solaris wasip1 windows zos
+		386 amd64 amd64p32 arm armbe arm64 arm64be loong64 mips mipsle mips64 mips64le mips64p32 mips64p32le ppc ppc64 ppc64le riscv riscv64 s390 s390x sparc sparc64 wasm`) {
+		knownOSArch[name] = true
+	}
+}
+
+func declDoc(decl ast.Decl) *ast.CommentGroup {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		return d.Doc
+	case *ast.GenDecl:
+		return d.Doc
+	}
+	return nil
+}
+
+// ParseConstraint parses a build constraint expression given for the
+// generated file, e.g. "linux && amd64". The //go:build prefix may be
+// left out.
+func ParseConstraint(s string) (constraint.Expr, error) {
+	text := strings.TrimSpace(s)
+	if !constraint.IsGoBuild(text) {
+		text = "//go:build " + text
+	}
+	expr, err := constraint.Parse(text)
+	if err != nil {
+		return nil, &errConstraint{Constraint: s, Err: err}
+	}
+	return expr, nil
+}
+
+// addConstraint puts the build constraint expr at the top of the lines of
+// generated code, as //go:build and // +build lines. The template's own
+// file constraint is replaced, and is required along with expr.
+func addConstraint(lines []string, origins []origin, expr constraint.Expr) ([]string, []origin, error) {
+	var keptLines []string
+	var keptOrigins []origin
+	var goBuild, plusBuild constraint.Expr
+	inHeader := true
+	for i, line := range lines {
+		text := strings.TrimSpace(line)
+		if strings.HasPrefix(text, string(packageKeyword)+" ") {
+			inHeader = false
+		}
+		if inHeader && (constraint.IsGoBuild(text) || constraint.IsPlusBuild(text)) {
+			fileExpr, err := constraint.Parse(text)
+			if err != nil {
+				return nil, nil, &errConstraint{Constraint: text, Err: err}
+			}
+			switch {
+			case constraint.IsGoBuild(text):
+				goBuild = fileExpr
+			case plusBuild == nil:
+				plusBuild = fileExpr
+			default:
+				plusBuild = &constraint.AndExpr{X: plusBuild, Y: fileExpr}
+			}
+			continue
+		}
+		keptLines = append(keptLines, line)
+		keptOrigins = append(keptOrigins, origins[i])
+	}
+	if goBuild == nil {
+		goBuild = plusBuild
+	}
+	if goBuild != nil {
+		expr = &constraint.AndExpr{X: goBuild, Y: expr}
+	}
+
+	top := []string{"//go:build " + expr.String() + "\n"}
+	plusLines, err := constraint.PlusBuildLines(expr)
+	if err != nil {
+		return nil, nil, &errConstraint{Constraint: expr.String(), Err: err}
+	}
+	for _, line := range plusLines {
+		top = append(top, line+"\n")
+	}
+	top = append(top, "\n")
+	topOrigins := make([]origin, len(top))
+	for i := range topOrigins {
+		topOrigins[i] = origin{TypeSet: -1}
+	}
+	return append(top, keptLines...), append(topOrigins, keptOrigins...), nil
+}
@@ -0,0 +1,90 @@
+package parse_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSections(t *testing.T) {
+	output, err := parse.Generics("generic_handle.go", "test/sections/split/handles.go", "split", strings.NewReader(contents("test/sections/generic_handle.go")), mustTypeSet("Item=int,string"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, parse.HasSections(output))
+
+	files, err := parse.SplitSections("test/sections/split/handles.go", output)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.ToSlash(f.Name))
+		expected, err := ioutil.ReadFile(f.Name)
+		if assert.NoError(t, err) {
+			assert.Equal(t, string(expected), string(f.Source), f.Name)
+		}
+	}
+	assert.Equal(t, []string{
+		"test/sections/split/handles.go",
+		"test/sections/split/handles_not_windows_build.go",
+		"test/sections/split/handles_windows.go",
+	}, names)
+}
+
+func TestSplitSectionsWithoutSections(t *testing.T) {
+	output, err := parse.Generics("generic_queue.go", "int_queue.go", "", strings.NewReader(contents("test/queue/generic_queue.go")), mustTypeSet("Something=int"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.False(t, parse.HasSections(output))
+	files, err := parse.SplitSections("int_queue.go", output)
+	assert.NoError(t, err)
+	assert.Equal(t, []parse.File{{Name: "int_queue.go", Source: output}}, files)
+}
+
+func TestFileConstraintIsKeptOnce(t *testing.T) {
+	template := "//go:build linux\n\n" + contents("test/queue/generic_queue.go")
+	output, err := parse.Generics("generic_queue.go", "queue.go", "", strings.NewReader(template), mustTypeSet("Something=int,string"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, strings.Count(string(output), "//go:build linux"))
+	}
+}
+
+func TestFileConstraintIsAboveTheHeader(t *testing.T) {
+	template := "// Copyright Example Corp.\n\n//go:build linux || darwin\n// +build linux darwin\n\n// Package queue is a queue.\n" + contents("test/queue/generic_queue.go")
+	output, err := parse.Generics("generic_queue.go", "queue.go", "", strings.NewReader(template), mustTypeSet("Something=int,string"))
+	if assert.NoError(t, err) {
+		code := string(output)
+		assert.True(t, strings.HasPrefix(code, "//go:build linux || darwin\n// +build linux darwin\n\n// This file was automatically generated by genny."), code)
+		assert.Equal(t, 1, strings.Count(code, "// Copyright Example Corp."))
+		assert.Equal(t, 1, strings.Count(code, "// +build"))
+		assert.Contains(t, code, "// Package queue is a queue.\npackage queue\n")
+		assert.Equal(t, 1, strings.Count(code, "// Package queue"))
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	opts := parse.Options{Constraint: "linux && amd64"}
+	output, err := parse.GenericsWithOptions("generic_queue.go", "queue.go", "", strings.NewReader(contents("test/queue/generic_queue.go")), mustTypeSet("Something=int,string"), opts)
+	if assert.NoError(t, err) {
+		assert.True(t, strings.HasPrefix(string(output), "//go:build linux && amd64\n// +build linux,amd64\n\n"), string(output))
+	}
+
+	template := "//go:build !js\n// +build !js\n\n" + contents("test/queue/generic_queue.go")
+	output, err = parse.GenericsWithOptions("generic_queue.go", "queue.go", "", strings.NewReader(template), mustTypeSet("Something=int"), opts)
+	if assert.NoError(t, err) {
+		code := string(output)
+		assert.True(t, strings.HasPrefix(code, "//go:build !js && linux && amd64\n// +build !js,linux,amd64\n\n"), code)
+		assert.Equal(t, 1, strings.Count(code, "//go:build"))
+		assert.Equal(t, 1, strings.Count(code, "// +build"))
+	}
+
+	opts.Constraint = "linux &&"
+	_, err = parse.GenericsWithOptions("generic_queue.go", "queue.go", "", strings.NewReader(template), mustTypeSet("Something=int"), opts)
+	assert.Error(t, err)
+}
@@ -0,0 +1,212 @@
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+)
+
+// LineCoverage is how many type sets a template line reached the output
+// of.
+type LineCoverage struct {
+	// Filename is the file the line is in: the template, or one of the
+	// files of the template package it joins.
+	Filename string
+	// Line is the line number in the file.
+	Line int
+	// Text is the line as written in the template.
+	Text string
+	// Hits is the number of type sets whose output includes the line.
+	Hits int
+	// Ignored is true for lines that never generate code by design: blank
+	// lines, imports, generic type declarations and genny directives.
+	Ignored bool
+}
+
+// Dead gets whether the line should generate code but never does.
+func (l LineCoverage) Dead() bool {
+	return !l.Ignored && l.Hits == 0
+}
+
+// Coverage describes which lines of a template reach the generated code.
+type Coverage struct {
+	Filename string
+	// TypeSets is the number of type sets the template was generated with.
+	TypeSets int
+	Lines    []LineCoverage
+}
+
+// TemplateCoverage generates the template with each of the type sets and
+// reports which of its lines reach the output.
+func TemplateCoverage(filename string, r io.Reader, typeSets []map[string]string, opts Options) (*Coverage, error) {
+
+	in, typeSets, err := readInput(filename, r, typeSets, opts)
+	if err != nil {
+		return nil, err
+	}
+	_, origins, err := generate(filename, "", in, typeSets, opts, noopSpan{})
+	if err != nil {
+		return nil, err
+	}
+
+	// count each template line once per type set
+	seen := make(map[origin]bool)
+	hits := make(map[int]int)
+	for _, o := range origins {
+		if o.TypeSet < 0 || seen[o] {
+			continue
+		}
+		seen[o] = true
+		hits[o.Line]++
+	}
+
+	in.Seek(0, os.SEEK_SET)
+	src, err := readLines(in)
+	if err != nil {
+		return nil, err
+	}
+	ignored, err := ignoredLines(filename, strings.Join(src, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	at := templateLines(filename, []byte(strings.Join(src, "\n")))
+	c := &Coverage{Filename: filename, TypeSets: len(typeSets)}
+	for i, text := range src {
+		n := i + 1
+		if isLineDirective(text) {
+			continue
+		}
+		pos := at(n)
+		c.Lines = append(c.Lines, LineCoverage{
+			Filename: pos.Filename,
+			Line:     pos.Line,
+			Text:     text,
+			Hits:     hits[n],
+			Ignored:  ignored[n] || strings.TrimSpace(text) == "",
+		})
+	}
+	return c, nil
+}
+
+// ignoredLines gets the template lines that are removed from the output by
+// design.
+func ignoredLines(filename, src string) (map[int]bool, error) {
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, src, 0)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	ignored := make(map[int]bool)
+	for _, decl := range file.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			for n := fs.PositionFor(gd.Pos(), false).Line; n <= fs.PositionFor(gd.End(), false).Line; n++ {
+				ignored[n] = true
+			}
+		}
+	}
+	for i, line := range strings.Split(src, "\n") {
+		if declaresGeneric(line) {
+			ignored[i+1] = true
+		}
+		for _, prefix := range unwantedLinePrefixes {
+			if strings.HasPrefix(line, string(prefix)) {
+				ignored[i+1] = true
+			}
+		}
+	}
+	return ignored, nil
+}
+
+func readLines(r io.Reader) ([]string, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines, sc.Err()
+}
+
+// DeadRegions gets the first and last line numbers of each run of dead
+// lines. Ignored lines do not interrupt a run, but the end of a file of a
+// template package does.
+func (c *Coverage) DeadRegions() [][2]int {
+	var regions [][2]int
+	for _, r := range c.deadRegions() {
+		regions = append(regions, [2]int{r[0].Line, r[1].Line})
+	}
+	return regions
+}
+
+// deadRegions gets the first and last lines of each run of dead lines, as
+// DeadRegions does.
+func (c *Coverage) deadRegions() [][2]LineCoverage {
+	var regions [][2]LineCoverage
+	var run [2]LineCoverage
+	inRun := false
+	for _, l := range c.Lines {
+		if inRun && (l.Filename != run[0].Filename || !l.Dead() && !l.Ignored) {
+			regions = append(regions, run)
+			inRun = false
+		}
+		if l.Dead() {
+			if !inRun {
+				run[0], inRun = l, true
+			}
+			run[1] = l
+		}
+	}
+	if inRun {
+		regions = append(regions, run)
+	}
+	return regions
+}
+
+// Write writes the template annotated with the number of type sets each
+// line reached, followed by a summary of the dead regions. Dead lines are
+// marked with "!" and ignored lines with "-". The lines of each file of a
+// template package follow its name.
+func (c *Coverage) Write(w io.Writer) {
+	covered, total := 0, 0
+	for _, l := range c.Lines {
+		if l.Ignored {
+			continue
+		}
+		total++
+		if l.Hits > 0 {
+			covered++
+		}
+	}
+	percent := 100.0
+	if total > 0 {
+		percent = float64(covered) * 100 / float64(total)
+	}
+	fmt.Fprintf(w, "%s: %d of %d lines reach the output of %d type set(s) (%.1f%%)\n", c.Filename, covered, total, c.TypeSets, percent)
+	file := c.Filename
+	for _, l := range c.Lines {
+		if l.Filename != file {
+			fmt.Fprintf(w, "%s:\n", l.Filename)
+			file = l.Filename
+		}
+		switch {
+		case l.Ignored:
+			fmt.Fprintf(w, "%5d %7s  %s\n", l.Line, "-", l.Text)
+		case l.Dead():
+			fmt.Fprintf(w, "%5d %7s  %s\n", l.Line, "! 0/"+fmt.Sprint(c.TypeSets), l.Text)
+		default:
+			fmt.Fprintf(w, "%5d %7s  %s\n", l.Line, fmt.Sprintf("%d/%d", l.Hits, c.TypeSets), l.Text)
+		}
+	}
+	for _, r := range c.deadRegions() {
+		if r[0].Line == r[1].Line {
+			fmt.Fprintf(w, "dead: %s:%d\n", r[0].Filename, r[0].Line)
+		} else {
+			fmt.Fprintf(w, "dead: %s:%d-%d\n", r[0].Filename, r[0].Line, r[1].Line)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package parse_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+const coverageTemplate = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+// Item is the type of thing in the queue.
+type Item generic.Type
+
+// ItemQueue is a queue of Items.
+type ItemQueue struct {
+	items []Item
+}
+
+// TODO: make this
+// concurrent safe.
+func (q *ItemQueue) Push(item Item) {
+	q.items = append(q.items, item)
+}
+`
+
+func TestTemplateCoverage(t *testing.T) {
+
+	typeSets := []map[string]string{{"Item": "int"}, {"Item": "string"}}
+	c, err := parse.TemplateCoverage("queue.go", strings.NewReader(coverageTemplate), typeSets, parse.Options{Todos: parse.TodoStrip})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, c.TypeSets)
+	assert.Len(t, c.Lines, 17)
+
+	// the package clause is only written once
+	assert.Equal(t, 1, c.Lines[0].Hits)
+	assert.True(t, c.Lines[2].Ignored)
+	assert.True(t, c.Lines[5].Ignored)
+	assert.Equal(t, 2, c.Lines[8].Hits)
+	assert.Equal(t, 2, c.Lines[14].Hits)
+
+	// the comment on the generic type and the stripped TODO never reach
+	// the output
+	assert.True(t, c.Lines[4].Dead())
+	assert.True(t, c.Lines[12].Dead())
+	assert.True(t, c.Lines[13].Dead())
+	assert.Equal(t, [][2]int{{5, 5}, {13, 14}}, c.DeadRegions())
+
+	var buf bytes.Buffer
+	c.Write(&buf)
+	assert.Contains(t, buf.String(), "queue.go: 8 of 11 lines reach the output of 2 type set(s) (72.7%)")
+	assert.Contains(t, buf.String(), "   13   ! 0/2  // TODO: make this")
+	assert.Contains(t, buf.String(), "dead: queue.go:13-14")
+
+}
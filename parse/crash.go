@@ -0,0 +1,32 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+)
+
+// writeCrashReport writes what is needed to reproduce the internal error
+// to filename: the error, the stack, the type sets and the template.
+func writeCrashReport(filename string, e *errInternal, in io.ReadSeeker, typeSets []map[string]string) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "genny crash report\n\n")
+	fmt.Fprintf(&buf, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&buf, "template: %s\n", e.Filename)
+	for _, typeSet := range typeSets {
+		fmt.Fprintf(&buf, "type set: %s\n", typeSetString(typeSet))
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&buf, "generating: %s line %d: %s\n", e.TypeSet, e.Line, e.Text)
+	}
+	fmt.Fprintf(&buf, "panic: %v\n\n%s\n", e.Value, e.Stack)
+
+	fmt.Fprintf(&buf, "--- %s ---\n", e.Filename)
+	if _, err := in.Seek(0, os.SEEK_SET); err == nil {
+		io.Copy(&buf, in)
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
@@ -0,0 +1,56 @@
+package parse_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+// an empty specific type makes wordify index out of range
+const panicTemplate = `package p
+
+import "github.com/cheekybits/genny/generic"
+
+type T generic.Type
+
+var TZero T
+`
+
+func TestInternalErrorsAreReturned(t *testing.T) {
+
+	output, err := parse.Generics("p.go", "out.go", "", strings.NewReader(panicTemplate), []map[string]string{{"T": ""}})
+	assert.Nil(t, output)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Internal error generating p.go with T= at line 7 (var TZero T): runtime error: index out of range")
+		assert.Contains(t, err.Error(), "This is a bug in genny")
+	}
+
+}
+
+func TestCrashReport(t *testing.T) {
+
+	report := filepath.Join(t.TempDir(), "crash.txt")
+	_, err := parse.GenericsWithOptions("p.go", "out.go", "", strings.NewReader(panicTemplate), []map[string]string{{"T": ""}}, parse.Options{CrashReport: report})
+	assert.Error(t, err)
+	b, err := ioutil.ReadFile(report)
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(b), "template: p.go\n")
+		assert.Contains(t, string(b), "type set: T=\n")
+		assert.Contains(t, string(b), "generating: T= line 7: var TZero T\n")
+		assert.Contains(t, string(b), "panic: runtime error: index out of range")
+		assert.Contains(t, string(b), "parse.wordify")
+		assert.Contains(t, string(b), "--- p.go ---\n"+panicTemplate)
+	}
+
+	// nothing is written when there is no internal error
+	other := filepath.Join(t.TempDir(), "crash.txt")
+	_, err = parse.GenericsWithOptions("p.go", "out.go", "", strings.NewReader(panicTemplate), []map[string]string{{"T": "int"}}, parse.Options{CrashReport: other})
+	assert.NoError(t, err)
+	_, err = ioutil.ReadFile(other)
+	assert.Error(t, err)
+
+}
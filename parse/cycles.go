@@ -0,0 +1,97 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// CheckImportCycle checks that the generated code, when saved to
+// outputFilename, would not create an import cycle: that none of the
+// packages it imports (such as the package of a specific type) imports the
+// output package, directly or through others. The packages are found with
+// opts.Loader's build flags and environment.
+//
+// Only imports from the module the output is written into can lead back to
+// it, so the build system is only asked when the code has such imports;
+// outside a module, nothing is checked.
+func CheckImportCycle(outputFilename string, output []byte, opts Options) error {
+	if opts.Script || opts.TestPackage {
+		// nothing can import a script or an external test package
+		return nil
+	}
+	loader := opts.Loader
+	if loader == nil {
+		loader = &Loader{}
+	}
+	dir, err := filepath.Abs(filepath.Dir(outputFilename))
+	if err != nil {
+		return err
+	}
+	host, err := findHostModule(dir)
+	if err != nil || host.Path == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(host.Root, dir)
+	if err != nil {
+		return nil
+	}
+	pkgPath := host.Path
+	if rel != "." {
+		pkgPath += "/" + filepath.ToSlash(rel)
+	}
+
+	generated, err := parser.ParseFile(token.NewFileSet(), outputFilename, output, parser.ImportsOnly)
+	if err != nil {
+		// syntax errors are left for verification to report
+		return nil
+	}
+	var imports []string
+	for _, path := range fileImports([]*ast.File{generated}) {
+		if path == host.Path || strings.HasPrefix(path, host.Path+"/") {
+			imports = append(imports, path)
+		}
+	}
+	if len(imports) == 0 {
+		return nil
+	}
+
+	pkgs, err := packages.Load(loader.config(dir), imports...)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range pkgs {
+		if chain := importChain(pkg, pkgPath, make(map[string]bool)); chain != nil {
+			return &errImportCycle{Package: pkgPath, Cycle: append([]string{pkgPath}, chain...)}
+		}
+	}
+	return nil
+}
+
+// importChain gets the packages from pkg to the package with the import
+// path, following imports, or nil if pkg does not lead to it.
+func importChain(pkg *packages.Package, path string, visited map[string]bool) []string {
+	if pkg.PkgPath == path {
+		return []string{path}
+	}
+	if visited[pkg.PkgPath] {
+		return nil
+	}
+	visited[pkg.PkgPath] = true
+	var imports []string
+	for importPath := range pkg.Imports {
+		imports = append(imports, importPath)
+	}
+	sort.Strings(imports)
+	for _, importPath := range imports {
+		if chain := importChain(pkg.Imports[importPath], path, visited); chain != nil {
+			return append([]string{pkg.PkgPath}, chain...)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package parse
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// compilerDirectives are the compiler directives a Directive may add.
+var compilerDirectives = []string{"//go:noinline", "//go:nosplit", "//go:norace", "//go:nocheckptr"}
+
+// Directive adds a compiler directive to a generated function, for a
+// performance sensitive instantiation that needs it when the others do
+// not, without forking the template.
+type Directive struct {
+	// Func is the generated function, e.g. NewIntQueue, or the method with
+	// its receiver type, e.g. IntQueue.Push.
+	Func string
+	// Text is the directive: //go:noinline, //go:nosplit, //go:norace or
+	// //go:nocheckptr, or a //go:build constraint. A constraint is added as
+	// a //genny:build section, so the function is written to a file for
+	// the constraint by SplitSections.
+	Text string
+}
+
+// ParseDirective parses a directive given as Func=Text, e.g.
+// "IntQueue.Push=//go:noinline". The slashes of the directive may be left
+// out.
+func ParseDirective(s string) (Directive, error) {
+	i := strings.Index(s, "=")
+	if i < 0 {
+		return Directive{}, &errBadOption{Option: "directive", Value: s, Message: "Func=//go:directive expected"}
+	}
+	d := Directive{Func: strings.TrimSpace(s[:i]), Text: strings.TrimSpace(s[i+1:])}
+	if !strings.HasPrefix(d.Text, "//") {
+		d.Text = "//" + d.Text
+	}
+	if d.Func == "" {
+		return Directive{}, &errBadOption{Option: "directive", Value: s, Message: "the function is missing"}
+	}
+	return d, d.check()
+}
+
+// check checks that the directive is one that can be added.
+func (d Directive) check() error {
+	if constraint.IsGoBuild(d.Text) {
+		if _, err := constraint.Parse(d.Text); err != nil {
+			return &errConstraint{Constraint: d.Text, Err: err}
+		}
+		return nil
+	}
+	for _, allowed := range compilerDirectives {
+		if d.Text == allowed {
+			return nil
+		}
+	}
+	return &errBadOption{Option: "directive", Value: d.Text, Message: strings.Join(compilerDirectives, ", ") + " or //go:build expected"}
+}
+
+// IsConstraint gets whether the directive is a //go:build constraint,
+// which only constrains the function once the output is split with
+// SplitSections.
+func (d Directive) IsConstraint() bool {
+	return constraint.IsGoBuild(d.Text)
+}
+
+// line gets the line the directive adds to the function's doc comment.
+func (d Directive) line() string {
+	if d.IsConstraint() {
+		return sectionDirective + strings.TrimSpace(strings.TrimPrefix(d.Text, "//go:build"))
+	}
+	return d.Text
+}
+
+// addDirectives adds the directives to the generated functions, just
+// before each function, after its doc comment. It is an error if a
+// directive names a function that was not generated.
+func addDirectives(filename string, output []byte, directives []Directive) ([]byte, error) {
+	if len(directives) == 0 {
+		return output, nil
+	}
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, output, parser.ParseComments)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	funcs := make(map[string]*ast.FuncDecl)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok {
+			funcs[funcName(fn)] = fn
+		}
+	}
+
+	lines := make(map[int][]string)
+	for _, d := range directives {
+		if err := d.check(); err != nil {
+			return nil, err
+		}
+		fn, ok := funcs[d.Func]
+		if !ok {
+			return nil, &errDirectiveFunc{Directive: d.Text, Func: d.Func}
+		}
+		pos := fs.PositionFor(fn.Type.Func, false)
+		start := pos.Offset - pos.Column + 1
+		lines[start] = append(lines[start], d.line())
+	}
+	var starts []int
+	for start := range lines {
+		starts = append(starts, start)
+	}
+	sort.Ints(starts)
+
+	var buf bytes.Buffer
+	last := 0
+	for _, start := range starts {
+		buf.Write(output[last:start])
+		for _, line := range lines[start] {
+			buf.WriteString(line + "\n")
+		}
+		last = start
+	}
+	buf.Write(output[last:])
+	return buf.Bytes(), nil
+}
+
+// funcName gets the name of the function as a Directive names it: Name,
+// or Recv.Name for a method.
+func funcName(fn *ast.FuncDecl) string {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return fn.Name.Name
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	if ident, ok := recv.(*ast.Ident); ok {
+		return ident.Name + "." + fn.Name.Name
+	}
+	return fn.Name.Name
+}
@@ -0,0 +1,72 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDirective(t *testing.T) {
+
+	d, err := parse.ParseDirective("IntQueue.Push=//go:noinline")
+	assert.NoError(t, err)
+	assert.Equal(t, parse.Directive{Func: "IntQueue.Push", Text: "//go:noinline"}, d)
+
+	d, err = parse.ParseDirective("NewIntQueue=go:build linux && amd64")
+	assert.NoError(t, err)
+	assert.Equal(t, parse.Directive{Func: "NewIntQueue", Text: "//go:build linux && amd64"}, d)
+
+	_, err = parse.ParseDirective("IntQueue.Push")
+	assert.Error(t, err)
+	_, err = parse.ParseDirective("IntQueue.Push=//go:linkname")
+	assert.Contains(t, err.Error(), "//go:noinline, //go:nosplit, //go:norace, //go:nocheckptr or //go:build expected")
+	_, err = parse.ParseDirective("IntQueue.Push=//go:build linux &&")
+	assert.Error(t, err)
+
+}
+
+func TestDirectives(t *testing.T) {
+
+	typeSets := []map[string]string{{"Item": "int"}, {"Item": "string"}}
+	opts := parse.Options{Directives: []parse.Directive{
+		{Func: "IntQueue.Push", Text: "//go:noinline"},
+		{Func: "IntQueue.Push", Text: "//go:nosplit"},
+		{Func: "NewStringQueue", Text: "//go:build linux"},
+	}}
+	output, err := parse.GenericsWithOptions("queue.go", "gen_queue.go", "", strings.NewReader(directivesTemplate), typeSets, opts)
+	if !assert.NoError(t, err) {
+		return
+	}
+	code := string(output)
+	assert.Contains(t, code, "// Push adds an item.\n//\n//go:noinline\n//go:nosplit\nfunc (q *IntQueue) Push(item int) {")
+	assert.Contains(t, code, "// Push adds an item.\nfunc (q *StringQueue) Push(item string) {")
+	assert.Contains(t, code, "//genny:build linux\nfunc NewStringQueue() *StringQueue {")
+	assert.True(t, parse.HasSections(output))
+
+	opts.Directives = []parse.Directive{{Func: "IntQueue.Pop", Text: "//go:noinline"}}
+	_, err = parse.GenericsWithOptions("queue.go", "gen_queue.go", "", strings.NewReader(directivesTemplate), typeSets, opts)
+	assert.EqualError(t, err, "Cannot add //go:noinline to 'IntQueue.Pop': no such function was generated (name methods as Type.Method, e.g. IntQueue.Push)")
+
+}
+
+const directivesTemplate = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemQueue struct {
+	items []Item
+}
+
+func NewItemQueue() *ItemQueue {
+	return &ItemQueue{}
+}
+
+// Push adds an item.
+func (q *ItemQueue) Push(item Item) {
+	q.items = append(q.items, item)
+}
+`
@@ -11,4 +11,22 @@
 //       Generic=Specific
 //       Generic1=Specific1 Generic2=Specific2
 //       Generic1=Specific1,Specific2 Generic2=Specific3,Specific4
+//
+// Output order
+//
+// The generated code is always in the same order: the code for each type
+// set in the order the type sets are given (TypeSet varies the last
+// generic type fastest), and within each, the template's lines in their
+// order, followed by anything genny adds for that type set (such as the
+// interfaces of Options.Interfaces). Where the names of generic types
+// overlap (Item and ItemKey) the longest is substituted first.
+//
+// Concurrency
+//
+// Generics and GenericsWithOptions may be called from any number of
+// goroutines at once. The package keeps no mutable global state (Builtins
+// and Numbers must not be modified while generating); the only state
+// shared between calls is what the caller shares through Options, and
+// Cache is safe for concurrent use. A Tracer shared between goroutines must
+// be safe for concurrent use itself.
 package parse
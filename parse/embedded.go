@@ -0,0 +1,220 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// embeddedField is an anonymous field found in a struct or interface
+// type of the template.
+type embeddedField struct {
+	// Container is either "struct" or "interface".
+	Container string
+	// Name is the name of the embedded type, without any pointer or
+	// package qualifier.
+	Name string
+	// Pointer is true when the type was embedded as *Name.
+	Pointer bool
+	Pos     token.Pos
+}
+
+// embeddedFields gets every embedded field in the struct and interface
+// types of the file.
+func embeddedFields(file *ast.File) []embeddedField {
+	var fields []embeddedField
+	ast.Inspect(file, func(n ast.Node) bool {
+		var (
+			list      *ast.FieldList
+			container string
+		)
+		switch t := n.(type) {
+		case *ast.StructType:
+			list, container = t.Fields, "struct"
+		case *ast.InterfaceType:
+			list, container = t.Methods, "interface"
+		default:
+			return true
+		}
+		if list == nil {
+			return true
+		}
+		for _, field := range list.List {
+			if len(field.Names) > 0 {
+				continue
+			}
+			expr := field.Type
+			pointer := false
+			if star, ok := expr.(*ast.StarExpr); ok {
+				expr = star.X
+				pointer = true
+			}
+			ident, ok := expr.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			fields = append(fields, embeddedField{
+				Container: container,
+				Name:      ident.Name,
+				Pointer:   pointer,
+				Pos:       field.Pos(),
+			})
+		}
+		return true
+	})
+	return fields
+}
+
+// checkEmbedded makes sure every embedded generic type can still be
+// embedded once it has been replaced by its specific type.
+func checkEmbedded(fs *token.FileSet, file *ast.File, typeSet map[string]string) error {
+	for _, field := range embeddedFields(file) {
+		specificType, ok := typeSet[field.Name]
+		if !ok {
+			continue
+		}
+		if embeddable(field.Container, field.Pointer, specificType) {
+			continue
+		}
+		return &errInvalidEmbedding{
+			GenericType:  field.Name,
+			SpecificType: specificType,
+			Container:    field.Container,
+			Pos:          fs.Position(field.Pos),
+		}
+	}
+	return nil
+}
+
+// embeddable gets whether the specific type may be embedded in a
+// struct or interface.
+func embeddable(container string, pointer bool, specificType string) bool {
+	if container == "interface" {
+		return mayBeInterface(specificType)
+	}
+	expr, err := parser.ParseExpr(specificType)
+	if err != nil {
+		return false
+	}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		if pointer {
+			return false
+		}
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		_, ok := t.X.(*ast.Ident)
+		return ok
+	}
+	return false
+}
+
+// embeddedGenerics gets the generic types that are embedded in a struct
+// type of the template, whose fields and methods are promoted.
+func embeddedGenerics(file *ast.File, typeSet map[string]string) map[string]bool {
+	generics := make(map[string]bool)
+	for _, field := range embeddedFields(file) {
+		if _, isGeneric := typeSet[field.Name]; isGeneric && field.Container == "struct" {
+			generics[field.Name] = true
+		}
+	}
+	return generics
+}
+
+// embeddedFieldName gets the name of the field a specific type is embedded
+// as, which is its type name without any pointer or package qualifier:
+// *bytes.Buffer is embedded as Buffer.
+func embeddedFieldName(specificType string) string {
+	name := strings.TrimPrefix(strings.TrimSpace(specificType), "*")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// isFieldName gets whether the identifier at position i is used as a field
+// name: selected from a value (b.Item) or as a key in a composite literal
+// (ItemBox{Item: item}).
+func isFieldName(toks []token.Token, i int) bool {
+	if i > 0 && toks[i-1] == token.PERIOD {
+		return true
+	}
+	if i+1 >= len(toks) || toks[i+1] != token.COLON || toks[0] == token.CASE {
+		return false
+	}
+	return i == 0 || toks[i-1] == token.LBRACE || toks[i-1] == token.COMMA
+}
+
+// embeddedTypeNames gets the names of the (non generic) types declared in
+// the template that are embedded by other types in the template.
+func embeddedTypeNames(file *ast.File, typeSet map[string]string) []string {
+	declared := declaredTypes(file)
+	seen := make(map[string]bool)
+	var names []string
+	for _, field := range embeddedFields(file) {
+		if _, isGeneric := typeSet[field.Name]; isGeneric {
+			continue
+		}
+		if declared[field.Name] && !seen[field.Name] {
+			seen[field.Name] = true
+			names = append(names, field.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkEmbeddedRenames makes sure that every template type that is embedded
+// somewhere in the template is still declared under its substituted name in
+// the generated code, so embedding types keep the method sets they were
+// written against.
+func checkEmbeddedRenames(names []string, typeSet map[string]string, generated *ast.File) error {
+	declared := declaredTypes(generated)
+	for _, name := range names {
+		specificName := subIntoIdent(name, typeSet)
+		if !declared[specificName] {
+			return &errEmbeddedRename{TemplateType: name, SpecificType: specificName}
+		}
+	}
+	return nil
+}
+
+// declaredTypes gets the names of the top level types declared in the file.
+func declaredTypes(file *ast.File) map[string]bool {
+	declared := make(map[string]bool)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok {
+				declared[ts.Name.Name] = true
+			}
+		}
+	}
+	return declared
+}
+
+// subIntoIdent substitutes every type in the type set into the identifier.
+func subIntoIdent(ident string, typeSet map[string]string) string {
+	for _, t := range substitutionOrder(typeSet) {
+		ident = subIntoLiteral(ident, t, typeSet[t])
+	}
+	return ident
+}
+
+// isBuiltin gets whether the name is one of the built-in Go types.
+func isBuiltin(name string) bool {
+	for _, b := range Builtins {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
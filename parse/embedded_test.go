@@ -0,0 +1,101 @@
+package parse
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddable(t *testing.T) {
+
+	for _, test := range []struct {
+		container    string
+		pointer      bool
+		specificType string
+		ok           bool
+	}{
+		{"struct", false, "int", true},
+		{"struct", false, "*MyType", true},
+		{"struct", false, "pkg.Type", true},
+		{"struct", false, "*pkg.Type", true},
+		{"struct", true, "*MyType", false},
+		{"struct", false, "[]byte", false},
+		{"struct", false, "map[string]int", false},
+		{"struct", false, "interface{}", false},
+		{"interface", false, "io.Reader", true},
+		{"interface", false, "MyInterface", true},
+		{"interface", false, "error", true},
+		{"interface", false, "interface{}", true},
+		{"interface", false, "int", false},
+		{"interface", false, "*MyType", false},
+	} {
+		assert.Equal(t, test.ok, embeddable(test.container, test.pointer, test.specificType), "%s %s", test.container, test.specificType)
+	}
+
+}
+
+func TestCheckEmbedded(t *testing.T) {
+
+	src := `package p
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemBox struct {
+	Item
+}
+`
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, "box.go", src, 0)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.NoError(t, checkEmbedded(fs, file, map[string]string{"Item": "*MyType"}))
+
+	err = checkEmbedded(fs, file, map[string]string{"Item": "[]byte"})
+	if assert.Error(t, err) {
+		assert.IsType(t, &errInvalidEmbedding{}, err)
+		assert.Equal(t, "box.go:8:2: '[]byte' cannot replace 'Item': it cannot be embedded in struct types", err.Error())
+	}
+
+}
+
+func TestCheckEmbeddedRenames(t *testing.T) {
+
+	typeSet := map[string]string{"Item": "int"}
+	names := []string{"ItemReader"}
+
+	generated, _ := parser.ParseFile(token.NewFileSet(), "out.go", "package p\ntype IntReader interface{}\n", 0)
+	assert.NoError(t, checkEmbeddedRenames(names, typeSet, generated))
+
+	generated, _ = parser.ParseFile(token.NewFileSet(), "out.go", "package p\ntype ItemReader interface{}\n", 0)
+	err := checkEmbeddedRenames(names, typeSet, generated)
+	if assert.Error(t, err) {
+		assert.IsType(t, &errEmbeddedRename{}, err)
+	}
+
+}
+
+func TestSubEmbeddedTypeIntoLine(t *testing.T) {
+
+	for _, test := range []struct {
+		line, specificType, expected string
+	}{
+		{"return b.Item", "*bytes.Buffer", "return b.Buffer"},
+		{"return b.Item.Unix()", "time.Time", "return b.Time.Unix()"},
+		{"return &ItemBox{Item: item}", "*bytes.Buffer", "return &BytesBufferBox{Buffer: item}"},
+		{"return ItemBox{n, Item: item}", "int", "return IntBox{n, int: item}"},
+		{"	Item: item,", "*pkg.Thing", "	Thing: item,"},
+		{"func (b ItemBox) Get() Item {", "*pkg.Thing", "func (b PkgThingBox) Get() *pkg.Thing {"},
+		{"case string, Item:", "*pkg.Thing", "case string, *pkg.Thing:"},
+	} {
+		assert.Equal(t, test.expected, subEmbeddedTypeIntoLine(test.line, "Item", test.specificType))
+	}
+
+	assert.Equal(t, "return b.*bytes.Buffer", subTypeIntoLine("return b.Item", "Item", "*bytes.Buffer"), "only embedded types are field names")
+
+}
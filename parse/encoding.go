@@ -0,0 +1,150 @@
+package parse
+
+import (
+	"bytes"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// The encodings DecodeTemplate transcodes from.
+const (
+	// EncodingAuto reads UTF-8, with or without a byte order mark, and
+	// UTF-16 with a byte order mark.
+	EncodingAuto = ""
+	// EncodingWindows1252 is the Windows Western European code page,
+	// which editors on Windows often save files in.
+	EncodingWindows1252 = "windows-1252"
+	// EncodingLatin1 is ISO 8859-1.
+	EncodingLatin1 = "iso-8859-1"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// windows1252 are the characters of the bytes 0x80 to 0x9F in Windows-1252,
+// where it differs from ISO 8859-1. The bytes it leaves undefined are 0.
+var windows1252 = [32]rune{
+	0x20AC, 0, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0, 0x017D, 0,
+	0, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0, 0x017E, 0x0178,
+}
+
+// DecodeTemplate gets the template as UTF-8 without a byte order mark, as
+// genny and the go tools expect. With EncodingAuto, a UTF-8 byte order
+// mark is dropped and UTF-16 with a byte order mark is transcoded; any
+// other input must be UTF-8, and the first byte that is not is reported
+// with its position. Templates in EncodingWindows1252 or EncodingLatin1
+// are transcoded.
+func DecodeTemplate(filename string, src []byte, encoding string) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case EncodingAuto, "utf-8", "utf8":
+	case EncodingWindows1252, "cp1252":
+		return decodeSingleByte(filename, src, true)
+	case EncodingLatin1, "latin1":
+		return decodeSingleByte(filename, src, false)
+	default:
+		return nil, &errBadOption{Option: "encoding", Value: encoding, Message: "utf-8, windows-1252 or iso-8859-1 expected"}
+	}
+	switch {
+	case bytes.HasPrefix(src, bomUTF8):
+		src = src[len(bomUTF8):]
+	case bytes.HasPrefix(src, bomUTF16LE):
+		return decodeUTF16(filename, src[len(bomUTF16LE):], func(b []byte) uint16 { return uint16(b[0]) | uint16(b[1])<<8 })
+	case bytes.HasPrefix(src, bomUTF16BE):
+		return decodeUTF16(filename, src[len(bomUTF16BE):], func(b []byte) uint16 { return uint16(b[0])<<8 | uint16(b[1]) })
+	}
+	for offset := 0; offset < len(src); {
+		r, size := utf8.DecodeRune(src[offset:])
+		if r == utf8.RuneError && size <= 1 {
+			return nil, encodingError(filename, src, offset)
+		}
+		offset += size
+	}
+	return src, nil
+}
+
+// decodeSingleByte transcodes the Windows-1252 or ISO 8859-1 source to
+// UTF-8.
+func decodeSingleByte(filename string, src []byte, windows bool) ([]byte, error) {
+	var buf bytes.Buffer
+	for offset, b := range src {
+		r := rune(b)
+		if windows && b >= 0x80 && b <= 0x9F {
+			if r = windows1252[b-0x80]; r == 0 {
+				err := encodingError(filename, src, offset)
+				err.Encoding = EncodingWindows1252
+				return nil, err
+			}
+		}
+		buf.WriteRune(r)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeUTF16 transcodes the UTF-16 source, after its byte order mark, to
+// UTF-8.
+func decodeUTF16(filename string, src []byte, unit func([]byte) uint16) ([]byte, error) {
+	if len(src)%2 != 0 {
+		err := encodingError(filename, src, len(src)-1)
+		err.Encoding = "utf-16"
+		return nil, err
+	}
+	units := make([]uint16, len(src)/2)
+	for i := range units {
+		units[i] = unit(src[2*i:])
+	}
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+// encodingError gets the error for the byte at the offset of the source.
+func encodingError(filename string, src []byte, offset int) *errEncoding {
+	before := src[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(before, '\n')
+	return &errEncoding{
+		Pos:    token.Position{Filename: filename, Offset: offset, Line: line, Column: column},
+		Byte:   src[offset],
+		Offset: offset,
+	}
+}
+
+// seekable gets the reader as an io.ReadSeeker, which genny reads the
+// template from more than once, reading it into memory if it is not one.
+func seekable(in io.Reader) (io.ReadSeeker, error) {
+	if rs, ok := in.(io.ReadSeeker); ok {
+		return rs, nil
+	}
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	return bytes.NewReader(src), nil
+}
+
+// decodeInput gets the template as DecodeTemplate does with EncodingAuto,
+// for the functions that take the template as a reader.
+func decodeInput(filename string, in io.ReadSeeker) (io.ReadSeeker, error) {
+	in.Seek(0, os.SEEK_SET)
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	decoded, err := DecodeTemplate(filename, src, EncodingAuto)
+	if err != nil {
+		return nil, err
+	}
+	if len(decoded) == len(src) {
+		in.Seek(0, os.SEEK_SET)
+		return in, nil
+	}
+	return bytes.NewReader(decoded), nil
+}
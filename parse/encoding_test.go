@@ -0,0 +1,65 @@
+package parse_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTemplate(t *testing.T) {
+
+	src := "package queue\n\n// ItemQueue is a “queue”.\n"
+
+	b, err := parse.DecodeTemplate("queue.go", []byte(src), parse.EncodingAuto)
+	assert.NoError(t, err)
+	assert.Equal(t, src, string(b))
+
+	b, err = parse.DecodeTemplate("queue.go", append([]byte{0xEF, 0xBB, 0xBF}, src...), parse.EncodingAuto)
+	assert.NoError(t, err)
+	assert.Equal(t, src, string(b))
+
+	le := []byte{0xFF, 0xFE}
+	be := []byte{0xFE, 0xFF}
+	for _, r := range src {
+		le = append(le, byte(r), byte(r>>8))
+		be = append(be, byte(r>>8), byte(r))
+	}
+	b, err = parse.DecodeTemplate("queue.go", le, parse.EncodingAuto)
+	assert.NoError(t, err)
+	assert.Equal(t, src, string(b))
+	b, err = parse.DecodeTemplate("queue.go", be, parse.EncodingAuto)
+	assert.NoError(t, err)
+	assert.Equal(t, src, string(b))
+
+	// curly quotes saved by a Windows editor
+	windows := []byte("package queue\n\n// ItemQueue is a \x93queue\x94.\n")
+	_, err = parse.DecodeTemplate("queue.go", windows, parse.EncodingAuto)
+	assert.EqualError(t, err, "queue.go:3:19: byte 0x93 at offset 33 is not valid UTF-8; save the template as UTF-8, or give its encoding (windows-1252 or iso-8859-1) with -encoding")
+	b, err = parse.DecodeTemplate("queue.go", windows, parse.EncodingWindows1252)
+	assert.NoError(t, err)
+	assert.Equal(t, src, string(b))
+	_, err = parse.DecodeTemplate("queue.go", []byte("// \x81\n"), parse.EncodingWindows1252)
+	assert.EqualError(t, err, "queue.go:1:4: byte 0x81 at offset 3 is not valid windows-1252")
+
+	b, err = parse.DecodeTemplate("queue.go", []byte("// caf\xe9\n"), parse.EncodingLatin1)
+	assert.NoError(t, err)
+	assert.Equal(t, "// café\n", string(b))
+
+	_, err = parse.DecodeTemplate("queue.go", nil, "ebcdic")
+	assert.Error(t, err)
+
+}
+
+func TestGenericsWithBOM(t *testing.T) {
+
+	src := "\xEF\xBB\xBF" + coverageTemplate
+	output, err := parse.GenericsWithOptions("queue.go", "queue.go", "", strings.NewReader(src), []map[string]string{{"Item": "int"}, {"Item": "string"}}, parse.Options{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, bytes.Count(output, []byte("package queue")))
+		assert.False(t, bytes.Contains(output, []byte{0xEF, 0xBB, 0xBF}))
+	}
+
+}
@@ -0,0 +1,384 @@
+package parse
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// Engine is how the specific types are substituted into the template.
+type Engine int
+
+const (
+	// EngineLines substitutes the specific types line by line, token by
+	// token, keeping the rest of each line as it is in the template.
+	EngineLines Engine = iota
+	// EngineAST renames the identifiers of the parsed template and prints
+	// it with go/printer, so the output keeps the structure of the template
+	// however its statements are split across lines, e.g. chained calls
+	// and composite literals spanning lines.
+	EngineAST
+)
+
+var engines = map[string]Engine{
+	"lines": EngineLines,
+	"ast":   EngineAST,
+}
+
+// ParseEngine gets the Engine for "lines" or "ast".
+func ParseEngine(s string) (Engine, error) {
+	engine, ok := engines[s]
+	if !ok {
+		return EngineLines, &errBadOption{Option: "engine", Value: s, Message: "lines or ast expected"}
+	}
+	return engine, nil
+}
+
+// substituteAST generates the specific code for the type set, the index'th
+// of count, like substitute, by renaming the identifiers of a copy of the
+// parsed template and printing its declarations. lines holds the template
+// line number each output line came from, or 0 for lines genny added.
+func substituteAST(filename string, in io.ReadSeeker, templateFs *token.FileSet, template *ast.File, typeSet map[string]string, index, count int, opts Options) (output []byte, lines []int, err error) {
+
+	// the template is parsed again, with its comments, as the tree of
+	// templateFs is shared
+	in.Seek(0, os.SEEK_SET)
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, nil, &errSource{Err: err}
+	}
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, &errSource{Err: err}
+	}
+
+	types := specificTypes(typeSet)
+	s := &astSubstituter{
+		typeSet:  typeSet,
+		generics: substitutionOrder(typeSet),
+		promoted: embeddedGenerics(file, types),
+		index:    index,
+		count:    count,
+		opts:     opts,
+	}
+	shared := sharedLines(fs, file, types)
+
+	// the declarations of the generic types, and the imports, which are
+	// fixed once everything is generated, are left out along with their
+	// comments
+	var removed []ast.Node
+	var decls []ast.Decl
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if index > 0 && shared[fs.PositionFor(decl.Pos(), false).Line] {
+			removed = append(removed, decl)
+			continue
+		}
+		if !ok || (gd.Tok != token.IMPORT && gd.Tok != token.TYPE) {
+			decls = append(decls, decl)
+			continue
+		}
+		if gd.Tok == token.IMPORT {
+			removed = append(removed, decl)
+			continue
+		}
+		var specs []ast.Spec
+		for _, spec := range gd.Specs {
+			if declaresGenericType(spec.(*ast.TypeSpec)) {
+				removed = append(removed, spec)
+				continue
+			}
+			specs = append(specs, spec)
+		}
+		if len(specs) == 0 {
+			removed = append(removed, decl)
+			continue
+		}
+		gd.Specs = specs
+		decls = append(decls, decl)
+	}
+
+	var comments []*ast.CommentGroup
+	for _, cg := range file.Comments {
+		if !within(fs, cg, removed) {
+			comments = append(comments, cg)
+		}
+	}
+	comments = s.comments(comments)
+
+	for _, decl := range decls {
+		astutil.Apply(decl, s.pre, nil)
+	}
+
+	// the package clause, then each declaration and the comments outside
+	// them, in the order of the template
+	var buf bytes.Buffer
+	add := func(text string, line int) {
+		for _, l := range strings.Split(text, "\n") {
+			buf.WriteString(l)
+			buf.WriteByte('\n')
+			lines = append(lines, line)
+			if line > 0 {
+				line++
+			}
+		}
+	}
+	var nodes []ast.Node
+	for _, decl := range decls {
+		nodes = append(nodes, decl)
+	}
+	for _, cg := range comments {
+		if !within(fs, cg, nodes) {
+			nodes = append(nodes, cg)
+		}
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return start(nodes[i]) < start(nodes[j]) })
+	// blank lines between the nodes are kept as they are in the template
+	last := 0
+	gap := func(n ast.Node) {
+		if last > 0 && fs.PositionFor(start(n), false).Line > last+1 {
+			add("", 0)
+		}
+		last = fs.PositionFor(end(n), false).Line
+	}
+	clause := false
+	for _, n := range nodes {
+		if !clause && n.Pos() > file.Package {
+			gap(file.Name)
+			add("package "+file.Name.Name, fs.PositionFor(file.Package, false).Line)
+			clause = true
+		}
+		gap(n)
+		if cg, ok := n.(*ast.CommentGroup); ok {
+			for _, c := range cg.List {
+				add(c.Text, fs.PositionFor(c.Pos(), false).Line)
+			}
+			continue
+		}
+		var nodeComments []*ast.CommentGroup
+		for _, cg := range comments {
+			if within(fs, cg, []ast.Node{n}) {
+				nodeComments = append(nodeComments, cg)
+				if line := fs.PositionFor(cg.End(), false).Line; line > last {
+					last = line
+				}
+			}
+		}
+		if len(nodeComments) == 0 {
+			// without comments, the printer prints those the nodes refer
+			// to, which may have been stripped
+			clearComments(n)
+		}
+		var printed bytes.Buffer
+		cfg := printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+		if err := cfg.Fprint(&printed, fs, &printer.CommentedNode{Node: n, Comments: nodeComments}); err != nil {
+			return nil, nil, err
+		}
+		add(printed.String(), fs.PositionFor(start(n), false).Line)
+	}
+	if !clause {
+		add("package "+file.Name.Name, fs.PositionFor(file.Package, false).Line)
+	}
+	// the next type set follows a blank line
+	add("", 0)
+
+	return checkSpecific(filename, templateFs, template, typeSet, buf.Bytes(), lines, opts)
+}
+
+// clearComments removes the doc and line comments from the node and the
+// nodes in it.
+func clearComments(n ast.Node) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			n.Doc = nil
+		case *ast.GenDecl:
+			n.Doc = nil
+		case *ast.TypeSpec:
+			n.Doc, n.Comment = nil, nil
+		case *ast.ValueSpec:
+			n.Doc, n.Comment = nil, nil
+		case *ast.Field:
+			n.Doc, n.Comment = nil, nil
+		}
+		return true
+	})
+}
+
+// declaresGenericType gets whether the type spec declares a generic type,
+// as a generic.Type, generic.Number or generic.Interface.
+func declaresGenericType(ts *ast.TypeSpec) bool {
+	sel, ok := ts.Type.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && x.Name == genericPackage
+}
+
+// start gets where the node starts, including its doc comment.
+func start(n ast.Node) token.Pos {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		if n.Doc != nil {
+			return n.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if n.Doc != nil {
+			return n.Doc.Pos()
+		}
+	case *ast.TypeSpec:
+		if n.Doc != nil {
+			return n.Doc.Pos()
+		}
+	}
+	return n.Pos()
+}
+
+// end gets where the node ends, including its line comment.
+func end(n ast.Node) token.Pos {
+	if ts, ok := n.(*ast.TypeSpec); ok && ts.Comment != nil {
+		return ts.Comment.End()
+	}
+	return n.End()
+}
+
+// within gets whether the comment group is part of one of the nodes: inside
+// it, its doc comment, or its line comment. Only where the comments start is
+// looked at, as their text may have been substituted into.
+func within(fs *token.FileSet, cg *ast.CommentGroup, nodes []ast.Node) bool {
+	for _, n := range nodes {
+		if cg.Pos() >= start(n) && cg.Pos() < end(n) {
+			return true
+		}
+		// a comment on the last line of the node belongs to it
+		if cg.Pos() >= n.End() && fs.PositionFor(cg.Pos(), false).Line == fs.PositionFor(n.End(), false).Line {
+			return true
+		}
+	}
+	return false
+}
+
+// astSubstituter substitutes a type set into the nodes of a template.
+type astSubstituter struct {
+	typeSet  map[string]string
+	generics []string
+	promoted map[string]bool
+	index    int
+	count    int
+	opts     Options
+}
+
+// pre substitutes into the node, before its children are visited.
+func (s *astSubstituter) pre(c *astutil.Cursor) bool {
+	switch n := c.Node().(type) {
+	case *ast.SelectorExpr:
+		if x, ok := n.X.(*ast.Ident); ok && x.Name == genericPackage {
+			switch n.Sel.Name {
+			case genericIndex:
+				c.Replace(&ast.BasicLit{ValuePos: n.Pos(), Kind: token.INT, Value: strconv.Itoa(s.index)})
+				return false
+			case genericCount:
+				c.Replace(&ast.BasicLit{ValuePos: n.Pos(), Kind: token.INT, Value: strconv.Itoa(s.count)})
+				return false
+			}
+		}
+	case *ast.BasicLit:
+		if n.Kind != token.STRING {
+			return true
+		}
+		if _, ok := c.Parent().(*ast.Field); ok && c.Name() == "Tag" && s.opts.StructTags != nil && structTag.MatchString(n.Value) {
+			n.Value = subTypesIntoStructTag(n.Value, s.generics, s.typeSet, s.opts.StructTags)
+			return true
+		}
+		for _, t := range s.generics {
+			if casing := casingFor(s.opts.Casings, t); s.opts.StringWords {
+				n.Value = subTypeIntoWords(n.Value, t, s.typeSet[t], casing.Strings)
+			} else if casing.Strings != CasingKeep {
+				n.Value = withPositionCasing(n.Value, t, s.typeSet[t], casing.Strings)
+			} else {
+				n.Value = subIntoLiteral(n.Value, t, s.typeSet[t])
+			}
+		}
+	case *ast.Ident:
+		s.ident(c, n)
+	}
+	return true
+}
+
+// ident substitutes into the identifier, which is replaced by the specific
+// type where it is the generic type itself.
+func (s *astSubstituter) ident(c *astutil.Cursor, id *ast.Ident) {
+	fieldName := false
+	switch p := c.Parent().(type) {
+	case *ast.SelectorExpr:
+		fieldName = c.Name() == "Sel"
+	case *ast.KeyValueExpr:
+		_, fieldName = p.Key.(*ast.Ident)
+		fieldName = fieldName && c.Name() == "Key"
+	}
+	conversion := false
+	if call, ok := c.Parent().(*ast.CallExpr); ok && c.Name() == "Fun" && call.Fun == ast.Expr(id) {
+		conversion = true
+	}
+	name := id.Name
+	for _, t := range s.generics {
+		if !strings.Contains(name, t) {
+			continue
+		}
+		specific := s.typeSet[t]
+		switch {
+		case name == t && fieldName && s.promoted[t]:
+			name = embeddedFieldName(specificTypeOf(specific))
+		case name == t && conversion && needsParens(specificTypeOf(specific)):
+			name = "(" + subIntoLiteral(name, t, specific) + ")"
+		case name != t && casingFor(s.opts.Casings, t).Identifiers != CasingKeep:
+			name = withPositionCasing(name, t, specific, casingFor(s.opts.Casings, t).Identifiers)
+		default:
+			name = subIntoLiteral(name, t, specific)
+		}
+	}
+	// the printer writes the name as it is, so it may be any type
+	id.Name = name
+}
+
+// comments substitutes into the comments, handling TODO and FIXME
+// comments as opts.Todos says, and gets those that are kept.
+func (s *astSubstituter) comments(groups []*ast.CommentGroup) []*ast.CommentGroup {
+	var kept []*ast.CommentGroup
+	for _, cg := range groups {
+		var list []*ast.Comment
+		for _, c := range cg.List {
+			text := c.Text
+			if s.opts.Todos == TodoStrip {
+				// a stripped TODO takes the rest of its comment group with
+				// it
+				if _, keep := stripTodo(text); !keep {
+					break
+				}
+			}
+			text = subTypesIntoComment(text, s.generics, s.typeSet, s.opts.Casings)
+			if s.opts.Todos == TodoTag {
+				text = tagTodo(text, typeSetString(s.typeSet))
+			}
+			c.Text = text
+			list = append(list, c)
+		}
+		if len(list) > 0 {
+			cg.List = list
+			kept = append(kept, cg)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,117 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const engineTemplate = `// Package queue is a queue.
+package queue
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cheekybits/genny/generic"
+)
+
+// Item is the type of the items.
+type Item generic.Type
+
+// ItemQueue is a queue of Items.
+type ItemQueue struct {
+	items []Item // the Items, oldest first
+}
+
+// TODO: grow in chunks
+func (q *ItemQueue) Push(item Item) {
+	q.items = append(q.items, item)
+}
+
+func (q *ItemQueue) String() string {
+	return strings.NewReplacer("a", "b").
+		Replace(fmt.Sprint("ItemQueue", len(q.items)))
+}
+
+var defaultItems = map[string]int{
+	"Item":  generic.Index,
+	"count": generic.Count,
+}
+`
+
+func TestParseEngine(t *testing.T) {
+	engine, err := parse.ParseEngine("ast")
+	require.NoError(t, err)
+	assert.Equal(t, parse.EngineAST, engine)
+	engine, err = parse.ParseEngine("lines")
+	require.NoError(t, err)
+	assert.Equal(t, parse.EngineLines, engine)
+	_, err = parse.ParseEngine("tokens")
+	assert.Error(t, err)
+}
+
+func TestEngineAST(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int,*bytes.Buffer")
+	require.NoError(t, err)
+	output, err := parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(engineTemplate), typeSets, parse.Options{Engine: parse.EngineAST})
+	require.NoError(t, err)
+	code := string(output)
+
+	assert.Contains(t, code, "// Package queue is a queue.\npackage queue")
+	assert.Contains(t, code, "type IntQueue struct {\n\titems []int // the Ints, oldest first\n}")
+	assert.Contains(t, code, "func (q *BytesBufferQueue) Push(item *bytes.Buffer) {")
+	assert.Contains(t, code, "\treturn strings.NewReplacer(\"a\", \"b\").\n\t\tReplace(fmt.Sprint(\"BytesBufferQueue\", len(q.items)))")
+	assert.Contains(t, code, "var defaultInts = map[string]int{\n\t\"Int\":   0,\n\t\"count\": 2,\n}")
+	assert.Contains(t, code, "\"BytesBuffer\": 1,")
+	assert.NotContains(t, code, "generic.")
+}
+
+func TestEngineASTMatchesLines(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int,string")
+	require.NoError(t, err)
+	lines, err := parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(engineTemplate), typeSets, parse.Options{})
+	require.NoError(t, err)
+	ast, err := parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(engineTemplate), typeSets, parse.Options{Engine: parse.EngineAST})
+	require.NoError(t, err)
+	assert.Equal(t, string(lines), string(ast))
+}
+
+func TestEngineASTTodos(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int")
+	require.NoError(t, err)
+	output, err := parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(engineTemplate), typeSets, parse.Options{Engine: parse.EngineAST, Todos: parse.TodoStrip})
+	require.NoError(t, err)
+	assert.NotContains(t, string(output), "TODO")
+	assert.Contains(t, string(output), "func (q *IntQueue) Push(item int) {")
+
+	output, err = parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(engineTemplate), typeSets, parse.Options{Engine: parse.EngineAST, Todos: parse.TodoTag})
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "TODO(Item=int): grow in chunks")
+}
+
+func TestEngineASTEmbedded(t *testing.T) {
+	template := `package wrap
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemWrapper struct {
+	Item
+}
+
+func (w ItemWrapper) Unwrap() Item {
+	return w.Item
+}
+`
+	typeSets, err := parse.TypeSet("Item=*bytes.Buffer")
+	require.NoError(t, err)
+	output, err := parse.GenericsWithOptions("wrap.go", "", "", strings.NewReader(template), typeSets, parse.Options{Engine: parse.EngineAST})
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "\t*bytes.Buffer\n")
+	assert.Contains(t, string(output), "return w.Buffer")
+}
@@ -2,6 +2,10 @@ package parse
 
 import (
 	"errors"
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
 )
 
 // errMissingSpecificType represents an error when a generic type is not
@@ -12,7 +16,7 @@ type errMissingSpecificType struct {
 
 // Error gets a human readable string describing this error.
 func (e errMissingSpecificType) Error() string {
-	return "Missing specific type for '" + e.GenericType + "' generic type"
+	return "Missing specific type for '" + paramName(e.GenericType) + "' generic type"
 }
 
 // errImports represents an error from goimports.
@@ -45,3 +49,560 @@ func (e errBadTypeArgs) Error() string {
 }
 
 var errMissingTypeInformation = errors.New("No type arguments were specified and no \"// +gogen\" tag was found in the source.")
+
+// errInvalidEmbedding represents an error when a generic type is embedded
+// in a struct or interface but its specific type cannot be embedded.
+type errInvalidEmbedding struct {
+	GenericType  string
+	SpecificType string
+	Container    string
+	Pos          token.Position
+}
+
+// Error gets a human readable string describing this error.
+func (e errInvalidEmbedding) Error() string {
+	return e.Pos.String() + ": '" + e.SpecificType + "' cannot replace '" + paramName(e.GenericType) + "': it cannot be embedded in " + e.Container + " types"
+}
+
+// Position gets where in the template the error is.
+func (e errInvalidEmbedding) Position() token.Position {
+	return e.Pos
+}
+
+// errEmbeddedRename represents an error when an embedded template type is
+// no longer declared after substitution.
+type errEmbeddedRename struct {
+	TemplateType string
+	SpecificType string
+}
+
+// Error gets a human readable string describing this error.
+func (e errEmbeddedRename) Error() string {
+	return "Embedded type '" + e.TemplateType + "' became '" + e.SpecificType + "' but no such type was generated"
+}
+
+// errInvalidAssertion represents an error when a generic type is used in a
+// type assertion or type switch that its specific type cannot satisfy.
+type errInvalidAssertion struct {
+	GenericType  string
+	SpecificType string
+	Reason       string
+	Pos          token.Position
+}
+
+// Error gets a human readable string describing this error.
+func (e errInvalidAssertion) Error() string {
+	return e.Pos.String() + ": invalid type assertion for '" + paramName(e.GenericType) + "=" + e.SpecificType + "': " + e.Reason
+}
+
+// Position gets where in the template the error is.
+func (e errInvalidAssertion) Position() token.Position {
+	return e.Pos
+}
+
+// errDuplicateCase represents an error when two cases of a type switch end
+// up with the same specific type.
+type errDuplicateCase struct {
+	Type  string
+	Cases []string
+	Pos   token.Position
+}
+
+// Error gets a human readable string describing this error.
+func (e errDuplicateCase) Error() string {
+	return e.Pos.String() + ": type switch cases '" + strings.Join(e.Cases, "' and '") + "' would both become '" + e.Type + "'"
+}
+
+// orphanedMethod is a template method that no longer attaches to the
+// type it was declared on.
+type orphanedMethod struct {
+	Method       method
+	SpecificRecv string
+}
+
+// Position gets where in the template the error is.
+func (e errDuplicateCase) Position() token.Position {
+	return e.Pos
+}
+
+// errOrphanedMethods represents an error when template methods would no
+// longer attach to their generated types.
+type errOrphanedMethods struct {
+	Methods []orphanedMethod
+}
+
+// Error gets a human readable string describing this error.
+func (e errOrphanedMethods) Error() string {
+	lines := []string{"Methods no longer attach to a generated type:"}
+	for _, o := range e.Methods {
+		lines = append(lines, "  "+o.Method.Pos.String()+": "+o.Method.Recv+"."+o.Method.Name+" would be declared on '"+o.SpecificRecv+"'")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// errBadOption represents an error when an option has an invalid value.
+type errBadOption struct {
+	Option  string
+	Value   string
+	Message string
+}
+
+// Error gets a human readable string describing this error.
+func (e errBadOption) Error() string {
+	return "\"" + e.Value + "\" is not a valid " + e.Option + " option: " + e.Message
+}
+
+// errUnknownParam represents an error when a type set names a generic type
+// that the template does not declare.
+type errUnknownParam struct {
+	Param string
+	Known []string
+}
+
+// Error gets a human readable string describing this error.
+func (e errUnknownParam) Error() string {
+	known := make([]string, len(e.Known))
+	for i, generic := range e.Known {
+		known[i] = paramName(generic)
+	}
+	return "Unknown generic type '" + paramName(e.Param) + "' (the template declares: " + strings.Join(known, ", ") + ")"
+}
+
+// errUnusedPlaceholder represents an error when a generic type is declared
+// but never used by the template.
+type errUnusedPlaceholder struct {
+	GenericType string
+	Pos         token.Position
+}
+
+// Error gets a human readable string describing this error.
+func (e errUnusedPlaceholder) Error() string {
+	return e.Pos.String() + ": generic type '" + paramName(e.GenericType) + "' is declared but never used"
+}
+
+// Position gets where in the template the error is.
+func (e errUnusedPlaceholder) Position() token.Position {
+	return e.Pos
+}
+
+// errUndocumentedParam represents an error when a generic type has no doc
+// comment describing it.
+type errUndocumentedParam struct {
+	GenericType string
+	Pos         token.Position
+}
+
+// Error gets a human readable string describing this error.
+func (e errUndocumentedParam) Error() string {
+	return e.Pos.String() + ": generic type '" + paramName(e.GenericType) + "' has no doc comment describing it"
+}
+
+// Position gets where in the template the error is.
+func (e errUndocumentedParam) Position() token.Position {
+	return e.Pos
+}
+
+// errRiskyParam represents an error when a generic type is named like a
+// builtin or a declaration of the generic package.
+type errRiskyParam struct {
+	GenericType string
+	Pos         token.Position
+	Reason      string
+	Suggestion  string
+}
+
+// Error gets a human readable string describing this error.
+func (e errRiskyParam) Error() string {
+	return e.Pos.String() + ": generic type '" + paramName(e.GenericType) + "' " + e.Reason +
+		", so substituting it would also change every identifier, literal and comment containing it;" +
+		" rename it (e.g. " + e.Suggestion + ") or allow it with " + allowDirective + paramName(e.GenericType)
+}
+
+// Position gets where in the template the error is.
+func (e errRiskyParam) Position() token.Position {
+	return e.Pos
+}
+
+// errCollision represents an error when more than one type set generates
+// the same top level name.
+type errCollision struct {
+	Name     string
+	TypeSets []string
+}
+
+// Error gets a human readable string describing this error.
+func (e errCollision) Error() string {
+	return "'" + e.Name + "' is generated more than once (by " + strings.Join(e.TypeSets, " and ") + ")"
+}
+
+// errCompile represents an error when the generated code does not type
+// check.
+type errCompile struct {
+	Errors []string
+}
+
+// Error gets a human readable string describing this error.
+func (e errCompile) Error() string {
+	return "Generated code does not compile:\n  " + strings.Join(e.Errors, "\n  ")
+}
+
+// errVet represents an error when go vet finds problems with the
+// generated code.
+type errVet struct {
+	Findings []string
+}
+
+// Error gets a human readable string describing this error.
+func (e errVet) Error() string {
+	return "go vet reports problems with the generated code:\n  " + strings.Join(e.Findings, "\n  ")
+}
+
+// errImportCycle represents an error when the generated code imports a
+// package that imports the output package.
+type errImportCycle struct {
+	Package string
+	Cycle   []string
+}
+
+// Error gets a human readable string describing this error.
+func (e errImportCycle) Error() string {
+	imported := e.Cycle[1]
+	return "Generated code would create an import cycle: " + strings.Join(e.Cycle, " imports ") +
+		"\nThe specific types come from " + imported + ", which depends on " + e.Package + ", the package the code is generated into." +
+		" Generate the code into " + imported + " (or a package it does not depend on) instead, or move the types to a package that does not depend on " + e.Package
+}
+
+// errInternal represents a panic inside genny, which is a bug.
+type errInternal struct {
+	Value    interface{}
+	Stack    string
+	Filename string
+	// TypeSet, Line and Text describe what was being generated, if known.
+	TypeSet string
+	Line    int
+	Text    string
+	// ReportErr is the error writing the crash report, if one was asked for.
+	ReportErr error
+}
+
+// Error gets a human readable string describing this error.
+func (e errInternal) Error() string {
+	msg := "Internal error generating " + e.Filename
+	if e.TypeSet != "" {
+		msg += " with " + e.TypeSet
+	}
+	if e.Line > 0 {
+		msg += " at line " + strconv.Itoa(e.Line) + " (" + strings.TrimSpace(e.Text) + ")"
+	}
+	msg += ": " + fmt.Sprint(e.Value) + "\nThis is a bug in genny, please report it (genny minimize makes a small reproducer)"
+	if e.ReportErr != nil {
+		msg += "\nThe crash report could not be written: " + e.ReportErr.Error()
+	}
+	return msg
+}
+
+// errConstraint represents an error when a build constraint in the
+// template cannot be parsed.
+type errConstraint struct {
+	Constraint string
+	Err        error
+}
+
+// Error gets a human readable string describing this error.
+func (e errConstraint) Error() string {
+	return "invalid constraint " + strconv.Quote(e.Constraint) + ": " + e.Err.Error()
+}
+
+// errFormatFile represents an error when a generated file cannot be
+// formatted.
+type errFormatFile struct {
+	Filename string
+	Err      error
+}
+
+// Error gets a human readable string describing this error.
+func (e errFormatFile) Error() string {
+	return e.Filename + ": " + e.Err.Error()
+}
+
+// errNoModule represents an error when a directory is not in a module.
+type errNoModule struct {
+	Dir string
+}
+
+// Error gets a human readable string describing this error.
+func (e errNoModule) Error() string {
+	return e.Dir + " is not in a module (no go.mod found)"
+}
+
+// errGoCommand represents an error when the go command fails.
+type errGoCommand struct {
+	Args   []string
+	Output string
+	Err    error
+}
+
+// Error gets a human readable string describing this error.
+func (e errGoCommand) Error() string {
+	return "go " + strings.Join(e.Args, " ") + " failed: " + e.Err.Error() + "\n" + e.Output
+}
+
+// errInterfaceParam represents an error when a generic.Interface type is
+// replaced by a type that is not an interface, or used by the template in
+// a way that is a mistake for interfaces, or when a generic.Comparable type
+// is replaced by a type that cannot be compared.
+type errInterfaceParam struct {
+	GenericType  string
+	SpecificType string
+	Reason       string
+	Pos          token.Position
+}
+
+// Error gets a human readable string describing this error.
+func (e errInterfaceParam) Error() string {
+	msg := e.Pos.String() + ": "
+	if e.SpecificType != "" {
+		msg += "'" + e.SpecificType + "' cannot replace '" + paramName(e.GenericType) + "': "
+	} else {
+		msg += "generic.Interface type '" + paramName(e.GenericType) + "' is misused: "
+	}
+	return msg + e.Reason
+}
+
+// Position gets where in the template the error is.
+func (e errInterfaceParam) Position() token.Position {
+	return e.Pos
+}
+
+// errNumericParam represents an error when a generic.Integer,
+// generic.Unsigned or generic.Float type is replaced by a type of another
+// kind.
+type errNumericParam struct {
+	GenericType  string
+	SpecificType string
+	Kind         string
+	Types        []string
+	Pos          token.Position
+}
+
+// Error gets a human readable string describing this error.
+func (e errNumericParam) Error() string {
+	return e.Pos.String() + ": '" + e.SpecificType + "' cannot replace '" + paramName(e.GenericType) + "': it is a " + e.Kind + ", so it must be replaced by one of " + strings.Join(e.Types, ", ") + " or a named type of one"
+}
+
+// Position gets where in the template the error is.
+func (e errNumericParam) Position() token.Position {
+	return e.Pos
+}
+
+// errDirectiveFunc represents an error when a directive names a function
+// that was not generated.
+type errDirectiveFunc struct {
+	Directive string
+	Func      string
+}
+
+// Error gets a human readable string describing this error.
+func (e errDirectiveFunc) Error() string {
+	return "Cannot add " + e.Directive + " to '" + e.Func + "': no such function was generated (name methods as Type.Method, e.g. IntQueue.Push)"
+}
+
+// errPlugin represents an error when a type set cannot be generated as a
+// plugin.
+type errPlugin struct {
+	TypeSet string
+	Reason  string
+}
+
+// Error gets a human readable string describing this error.
+func (e errPlugin) Error() string {
+	return "Cannot generate a plugin for " + e.TypeSet + ": " + e.Reason
+}
+
+// errRefusedType represents an error when a validator refuses a specific
+// type.
+type errRefusedType struct {
+	GenericType  string
+	SpecificType string
+	Err          error
+}
+
+// Error gets a human readable string describing this error.
+func (e errRefusedType) Error() string {
+	return "'" + e.SpecificType + "' cannot replace '" + paramName(e.GenericType) + "': " + e.Err.Error()
+}
+
+// Unwrap gets the error the validator returned.
+func (e errRefusedType) Unwrap() error {
+	return e.Err
+}
+
+// errEncoding represents an error when the template is not in the encoding
+// it is read as.
+type errEncoding struct {
+	Pos    token.Position
+	Byte   byte
+	Offset int
+	// Encoding is the encoding the template was read as, or empty for
+	// UTF-8.
+	Encoding string
+}
+
+// Error gets a human readable string describing this error.
+func (e errEncoding) Error() string {
+	if e.Encoding != "" {
+		return fmt.Sprintf("%s: byte 0x%02X at offset %d is not valid %s", e.Pos, e.Byte, e.Offset, e.Encoding)
+	}
+	return fmt.Sprintf("%s: byte 0x%02X at offset %d is not valid UTF-8; save the template as UTF-8, or give its encoding (windows-1252 or iso-8859-1) with -encoding", e.Pos, e.Byte, e.Offset)
+}
+
+// Position gets where in the template the error is.
+func (e errEncoding) Position() token.Position {
+	return e.Pos
+}
+
+// errScope represents an error when the scope directives of the template
+// do not mark regions.
+type errScope struct {
+	Pos    token.Position
+	Reason string
+}
+
+// Error gets a human readable string describing this error.
+func (e errScope) Error() string {
+	return e.Pos.String() + ": invalid //genny:scope: " + e.Reason
+}
+
+// Position gets where in the template the error is.
+func (e errScope) Position() token.Position {
+	return e.Pos
+}
+
+// errResolver represents an error when the command that resolves missing
+// packages fails.
+type errResolver struct {
+	Command string
+	Err     error
+	Stderr  string
+}
+
+// Error gets a human readable string describing this error.
+func (e errResolver) Error() string {
+	msg := "Failed to resolve missing packages with '" + e.Command + "': " + e.Err.Error()
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+// errMissingPackages represents an error when the generated code uses
+// packages that neither goimports nor the resolver could find.
+type errMissingPackages struct {
+	Filename string
+	Names    []string
+}
+
+// Error gets a human readable string describing this error.
+func (e errMissingPackages) Error() string {
+	return e.Filename + ": cannot find the packages " + strings.Join(e.Names, ", ") + " to import"
+}
+
+// errSuspiciousLiteral represents a warning about a string literal in which
+// a generic type carries on in lower case, so that a longer word is
+// substituted along with it, as Key is in "Keyboard".
+type errSuspiciousLiteral struct {
+	GenericType string
+	Word        string
+	Pos         token.Position
+}
+
+// Error gets a human readable string describing this error.
+func (e errSuspiciousLiteral) Error() string {
+	return e.Pos.String() + ": generic type '" + paramName(e.GenericType) + "' is part of the word '" + e.Word + "' in a string literal, which is substituted too"
+}
+
+// Position gets where in the template the error is.
+func (e errSuspiciousLiteral) Position() token.Position {
+	return e.Pos
+}
+
+// errTestPackage represents an error when the code cannot be generated into
+// the external test package of the package under test.
+type errTestPackage struct {
+	Message string
+}
+
+// Error gets a human readable string describing this error.
+func (e errTestPackage) Error() string {
+	return "cannot generate the test package: " + e.Message
+}
+
+// errTemplatePackage represents an error when a directory or package is
+// not a template split across files.
+type errTemplatePackage struct {
+	Dir     string
+	Message string
+}
+
+// Error gets a human readable string describing this error.
+func (e errTemplatePackage) Error() string {
+	return "template package " + e.Dir + " " + e.Message
+}
+
+// errPerTypeSet represents an error when the type sets cannot be generated
+// into a file each.
+type errPerTypeSet struct {
+	Message string
+}
+
+// Error gets a human readable string describing this error.
+func (e errPerTypeSet) Error() string {
+	return "cannot generate a file per type set: " + e.Message
+}
+
+// errTypeParams represents an error when a template cannot be converted to
+// type parameters.
+type errTypeParams struct {
+	Pos     token.Position
+	Message string
+}
+
+// Error gets a human readable string describing this error.
+func (e errTypeParams) Error() string {
+	return e.Pos.String() + ": cannot convert to type parameters: " + e.Message
+}
+
+// Position gets where in the template the error is.
+func (e errTypeParams) Position() token.Position {
+	return e.Pos
+}
+
+// errNativeTemplate represents an error when a template written with type
+// parameters cannot be converted to one genny generates code from.
+type errNativeTemplate struct {
+	Pos     token.Position
+	Message string
+}
+
+// Error gets a human readable string describing this error.
+func (e errNativeTemplate) Error() string {
+	return e.Pos.String() + ": cannot instantiate the type parameters: " + e.Message
+}
+
+// Position gets where in the template the error is.
+func (e errNativeTemplate) Position() token.Position {
+	return e.Pos
+}
+
+// errImportName represents an error when specific types are imported from
+// packages with the same name, or from a package with the name of one the
+// template imports.
+type errImportName struct {
+	Name  string
+	Paths []string
+}
+
+// Error gets a human readable string describing this error.
+func (e errImportName) Error() string {
+	return "the packages " + strings.Join(e.Paths, " and ") + " are both named " + e.Name
+}
@@ -0,0 +1,188 @@
+package parse
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"os"
+	"strings"
+)
+
+// DroppedLine is a template line that did not reach the output of some or
+// all of the type sets.
+type DroppedLine struct {
+	// Filename is the file the line is in: the template, or one of the
+	// files of the template package it joins.
+	Filename string
+	// Line is the line number in the file.
+	Line int
+	// Text is the line as written in the template.
+	Text string
+	// TypeSets is the number of type sets whose output does not include
+	// the line.
+	TypeSets int
+	// Reason describes why the line was dropped.
+	Reason string
+}
+
+// Explanation describes the template lines that genny drops from the
+// generated code, and why.
+type Explanation struct {
+	Filename string
+	// TypeSets is the number of type sets the template was generated with.
+	TypeSets int
+	Lines    []DroppedLine
+}
+
+// The reasons a template line is dropped.
+const (
+	reasonImport     = "the imports are rebuilt from what the generated code uses"
+	reasonGeneric    = "it declares a generic type, which the specific types replace"
+	reasonGenericDoc = "it documents a generic type, whose declaration is dropped"
+	reasonGenerate   = "it is the go:generate line that runs genny on the template"
+	reasonMetadata   = "it is a genny directive, which describes the template rather than the code"
+	reasonPackage    = "the package clause is only written once"
+	reasonPrelude    = "it comes before the package clause, which is only written once"
+	reasonShared     = "it declares something shared by every type set (it uses generic.Count but no generic type), which is only generated once"
+	reasonTodo       = "it is a TODO or FIXME comment, stripped with -todo=strip"
+	reasonTodoBlock  = "it continues the comment of a stripped TODO or FIXME"
+	reasonUnknown    = "it does not reach the output"
+)
+
+// TemplateExplain generates the template with each of the type sets and
+// explains which of its lines are dropped, and why.
+func TemplateExplain(filename string, r io.Reader, typeSets []map[string]string, opts Options) (*Explanation, error) {
+
+	in, typeSets, err := readInput(filename, r, typeSets, opts)
+	if err != nil {
+		return nil, err
+	}
+	_, origins, err := generate(filename, "", in, typeSets, opts, noopSpan{})
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[origin]bool)
+	reached := make(map[int]int)
+	for _, o := range origins {
+		if o.TypeSet < 0 || seen[o] {
+			continue
+		}
+		seen[o] = true
+		reached[o.Line]++
+	}
+
+	fs, file, err := parseSource(filename, in, opts.Cache)
+	if err != nil {
+		return nil, err
+	}
+	in.Seek(0, os.SEEK_SET)
+	src, err := readLines(in)
+	if err != nil {
+		return nil, err
+	}
+	imports := make(map[int]bool)
+	for _, decl := range file.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			for n := fs.PositionFor(gd.Pos(), false).Line; n <= fs.PositionFor(gd.End(), false).Line; n++ {
+				imports[n] = true
+			}
+		}
+	}
+	packageLine := fs.PositionFor(file.Package, false).Line
+	var shared map[int]bool
+	if len(typeSets) > 0 {
+		shared = sharedLines(fs, file, typeSets[0])
+	}
+
+	// the comment lines before a generic type's declaration, including
+	// those of block comments, are dropped with it
+	joined := []byte(strings.Join(src, "\n"))
+	blocks := blockComments(joined)
+	genericDocs := make(map[int]bool)
+	for i := range src {
+		if !declaresGeneric(src[i]) {
+			continue
+		}
+		for n := i; n > 0 && (isCommentLine(src[n-1]) || blocks[n].doc); n-- {
+			genericDocs[n] = true
+		}
+	}
+
+	at := templateLines(filename, joined)
+	e := &Explanation{Filename: filename, TypeSets: len(typeSets)}
+	previous := ""
+	for i, text := range src {
+		n := i + 1
+		if reached[n] == len(typeSets) || isLineDirective(text) {
+			previous = ""
+			continue
+		}
+		reason := reasonUnknown
+		trimmed := strings.TrimSpace(text)
+		switch {
+		case imports[n]:
+			reason = reasonImport
+		case declaresGeneric(text):
+			reason = reasonGeneric
+		case genericDocs[n]:
+			reason = reasonGenericDoc
+		case isUnwanted(text):
+			reason = reasonGenerate
+		case strings.HasPrefix(trimmed, metadataPrefix) && !strings.HasPrefix(trimmed, sectionDirective):
+			reason = reasonMetadata
+		case n == packageLine:
+			reason = reasonPackage
+		case n < packageLine:
+			reason = reasonPrelude
+		case shared[n]:
+			reason = reasonShared
+		case opts.Todos == TodoStrip && isCommentLine(text) && !keepsTodo(text):
+			reason = reasonTodo
+		case opts.Todos == TodoStrip && isCommentLine(text) && (previous == reasonTodo || previous == reasonTodoBlock):
+			reason = reasonTodoBlock
+		}
+		pos := at(n)
+		e.Lines = append(e.Lines, DroppedLine{Filename: pos.Filename, Line: pos.Line, Text: text, TypeSets: len(typeSets) - reached[n], Reason: reason})
+		previous = reason
+	}
+	return e, nil
+}
+
+// isUnwanted gets whether the line starts with one of the
+// unwantedLinePrefixes.
+func isUnwanted(line string) bool {
+	for _, prefix := range unwantedLinePrefixes {
+		if strings.HasPrefix(line, string(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepsTodo gets whether anything is left of the line once its TODO or
+// FIXME comment is stripped.
+func keepsTodo(line string) bool {
+	_, keep := stripTodo(line)
+	return keep
+}
+
+// Write writes each dropped line with its reason, noting the type sets it
+// was dropped from when it reached the output of others. The lines of each
+// file of a template package follow its name.
+func (e *Explanation) Write(w io.Writer) {
+	fmt.Fprintf(w, "%s: %d line(s) dropped from the output of %d type set(s)\n", e.Filename, len(e.Lines), e.TypeSets)
+	file := e.Filename
+	for _, l := range e.Lines {
+		if l.Filename != file {
+			fmt.Fprintf(w, "%s:\n", l.Filename)
+			file = l.Filename
+		}
+		fmt.Fprintf(w, "%5d  %s\n", l.Line, l.Text)
+		if l.TypeSets < e.TypeSets {
+			fmt.Fprintf(w, "       dropped from %d of %d type sets: %s\n", l.TypeSets, e.TypeSets, l.Reason)
+		} else {
+			fmt.Fprintf(w, "       dropped: %s\n", l.Reason)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package parse_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplateExplain(t *testing.T) {
+
+	src := "//go:generate genny -in=$GOFILE gen \"Item=int\"\n" + coverageTemplate + "\nvar count = generic.Count\n"
+	typeSets := []map[string]string{{"Item": "int"}, {"Item": "string"}}
+	e, err := parse.TemplateExplain("queue.go", strings.NewReader(src), typeSets, parse.Options{Todos: parse.TodoStrip})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var lines []int
+	for _, l := range e.Lines {
+		lines = append(lines, l.Line)
+	}
+	assert.Equal(t, []int{1, 2, 4, 6, 7, 14, 15, 20}, lines)
+	assert.Equal(t, 2, e.Lines[0].TypeSets)
+	assert.Equal(t, 1, e.Lines[1].TypeSets)
+
+	var buf bytes.Buffer
+	e.Write(&buf)
+	assert.Contains(t, buf.String(), "queue.go: 8 line(s) dropped from the output of 2 type set(s)\n")
+	assert.Contains(t, buf.String(), "    2  package queue\n       dropped from 1 of 2 type sets: the package clause is only written once\n")
+	assert.Contains(t, buf.String(), "    4  import \"github.com/cheekybits/genny/generic\"\n       dropped: the imports are rebuilt from what the generated code uses\n")
+	assert.Contains(t, buf.String(), "    6  // Item is the type of thing in the queue.\n       dropped: it documents a generic type")
+	assert.Contains(t, buf.String(), "    7  type Item generic.Type\n       dropped: it declares a generic type")
+	assert.Contains(t, buf.String(), "   15  // concurrent safe.\n       dropped: it continues the comment of a stripped TODO or FIXME\n")
+	assert.Contains(t, buf.String(), "   20  var count = generic.Count\n       dropped from 1 of 2 type sets: it declares something shared by every type set")
+
+	// TODOs are kept by default
+	e, err = parse.TemplateExplain("queue.go", strings.NewReader(coverageTemplate), typeSets[:1], parse.Options{})
+	if assert.NoError(t, err) {
+		assert.Len(t, e.Lines, 3)
+	}
+}
+
+func TestTemplateExplainPrelude(t *testing.T) {
+	src := "// Copyright Example Corp.\n\n//go:build linux\n\n" + coverageTemplate
+	typeSets := []map[string]string{{"Item": "int"}, {"Item": "string"}}
+	e, err := parse.TemplateExplain("queue.go", strings.NewReader(src), typeSets, parse.Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	var buf bytes.Buffer
+	e.Write(&buf)
+	assert.Contains(t, buf.String(), "    1  // Copyright Example Corp.\n       dropped from 1 of 2 type sets: it comes before the package clause, which is only written once\n")
+	assert.Contains(t, buf.String(), "    3  //go:build linux\n       dropped from 1 of 2 type sets: it comes before the package clause")
+}
@@ -0,0 +1,74 @@
+package parse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"sync"
+
+	"github.com/cheekybits/genny/out"
+	"golang.org/x/tools/imports"
+)
+
+// Format formats generated code and fixes its imports, as genny does
+// unless Options.Unformatted is set.
+func Format(filename string, src []byte) ([]byte, error) {
+	output, err := imports.Process(filename, src, nil)
+	if err != nil {
+		return nil, &errImports{Err: err}
+	}
+	return output, nil
+}
+
+// FormatFiles formats the files, with up to workers of them at once, and
+// writes back the ones that change. It gets the error of the first file
+// (in the order given) that could not be formatted, after trying them all.
+func FormatFiles(filenames []string, workers int) error {
+	if workers < 1 {
+		workers = 1
+	}
+	errs := make([]error, len(filenames))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = formatFile(filenames[i])
+			}
+		}()
+	}
+	for i := range filenames {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatFile formats the file in place, holding its lock (see out.Lock)
+// from reading it until it is written.
+func formatFile(filename string) error {
+	unlock, err := out.Lock(filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	formatted, err := Format(filename, src)
+	if err != nil {
+		return &errFormatFile{Filename: filename, Err: err}
+	}
+	if bytes.Equal(src, formatted) {
+		return nil
+	}
+	return ioutil.WriteFile(filename, formatted, 0644)
+}
@@ -0,0 +1,54 @@
+package parse_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnformattedOutputFormatsTheSame(t *testing.T) {
+	dir := t.TempDir()
+	template := contents("test/queue/generic_queue.go")
+	var files, expected []string
+	for _, types := range []string{"Something=int", "Something=string", "Something=float32"} {
+		name := filepath.Join(dir, strings.ToLower(strings.TrimPrefix(types, "Something="))+"_queue.go")
+		formatted, err := parse.Generics("generic_queue.go", name, "", strings.NewReader(template), mustTypeSet(types))
+		if !assert.NoError(t, err) {
+			return
+		}
+		unformatted, err := parse.GenericsWithOptions("generic_queue.go", name, "", strings.NewReader(template), mustTypeSet(types), parse.Options{Unformatted: true})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NotEqual(t, string(formatted), string(unformatted))
+		assert.NoError(t, ioutil.WriteFile(name, unformatted, 0644))
+		files = append(files, name)
+		expected = append(expected, string(formatted))
+	}
+
+	assert.NoError(t, parse.FormatFiles(files, 2))
+	for i, name := range files {
+		output, err := ioutil.ReadFile(name)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected[i], string(output), name)
+		}
+	}
+}
+
+func TestFormatFilesReportsTheFile(t *testing.T) {
+	dir := t.TempDir()
+	good, bad := filepath.Join(dir, "good.go"), filepath.Join(dir, "bad.go")
+	assert.NoError(t, ioutil.WriteFile(good, []byte("package p\nvar X   = 1\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(bad, []byte("package p\nfunc {\n"), 0644))
+
+	err := parse.FormatFiles([]string{good, bad}, 4)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), bad)
+	}
+	output, _ := ioutil.ReadFile(good)
+	assert.Equal(t, "package p\n\nvar X = 1\n", string(output))
+}
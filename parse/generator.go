@@ -0,0 +1,190 @@
+package parse
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+
+	"github.com/cheekybits/genny/diag"
+)
+
+// Generator generates code from templates, for build tools that embed
+// genny. What it generates is set by the options it is made with, rather
+// than by the arguments of each call, so that new behaviour does not change
+// its methods. A Generator is safe for concurrent use once it is made,
+// unless it collects warnings (WithWarnings).
+type Generator struct {
+	opts       Options
+	pkgName    string
+	split      bool
+	perTypeSet string
+}
+
+// GeneratorOption sets how a Generator generates code.
+type GeneratorOption func(*Generator)
+
+// NewGenerator makes a Generator with the options, applied in order. With
+// none, it generates code as Generics does.
+func NewGenerator(options ...GeneratorOption) *Generator {
+	g := &Generator{}
+	for _, option := range options {
+		option(g)
+	}
+	return g
+}
+
+// WithOptions sets every option of Options at once, replacing those set
+// before it.
+func WithOptions(opts Options) GeneratorOption {
+	return func(g *Generator) { g.opts = opts }
+}
+
+// WithPackage generates the code into the named package rather than the
+// template's.
+func WithPackage(name string) GeneratorOption {
+	return func(g *Generator) { g.pkgName = name }
+}
+
+// WithStrict reports the problems Options.Strict does as errors.
+func WithStrict() GeneratorOption {
+	return func(g *Generator) { g.opts.Strict = true }
+}
+
+// WithWarnings collects the problems Options.Strict reports as warnings in
+// the list. The Generator is then not safe for concurrent use.
+func WithWarnings(warnings *diag.List) GeneratorOption {
+	return func(g *Generator) { g.opts.Warnings = warnings }
+}
+
+// WithEngine substitutes the specific types with the engine.
+func WithEngine(engine Engine) GeneratorOption {
+	return func(g *Generator) { g.opts.Engine = engine }
+}
+
+// WithTodos sets what happens to TODO and FIXME comments.
+func WithTodos(mode TodoMode) GeneratorOption {
+	return func(g *Generator) { g.opts.Todos = mode }
+}
+
+// WithNameTemplate composes the names of the generated declarations with
+// the name template, as Options.NameTemplate does.
+func WithNameTemplate(text string) GeneratorOption {
+	return func(g *Generator) { g.opts.NameTemplate = text }
+}
+
+// WithStringWords substitutes the specific types into the words of string
+// literals, as Options.StringWords does.
+func WithStringWords() GeneratorOption {
+	return func(g *Generator) { g.opts.StringWords = true }
+}
+
+// WithHeader replaces genny's header with a custom one.
+func WithHeader(header *Header) GeneratorOption {
+	return func(g *Generator) { g.opts.Header = header }
+}
+
+// WithConstraint writes the build constraint expression at the top of the
+// generated files.
+func WithConstraint(expr string) GeneratorOption {
+	return func(g *Generator) { g.opts.Constraint = expr }
+}
+
+// WithTypeCheck type checks the generated code, as Options.TypeCheck does.
+func WithTypeCheck() GeneratorOption {
+	return func(g *Generator) { g.opts.TypeCheck = true }
+}
+
+// WithVet runs go vet on the generated code, as Options.Vet does.
+func WithVet() GeneratorOption {
+	return func(g *Generator) { g.opts.Vet = true }
+}
+
+// WithLineDirectives adds //line directives pointing at the template.
+func WithLineDirectives() GeneratorOption {
+	return func(g *Generator) { g.opts.LineDirectives = true }
+}
+
+// WithWorkers generates up to n type sets at once, as Options.Workers does.
+func WithWorkers(n int) GeneratorOption {
+	return func(g *Generator) { g.opts.Workers = n }
+}
+
+// WithCache keeps the parsed templates in the cache, which may be shared
+// with other Generators.
+func WithCache(cache *Cache) GeneratorOption {
+	return func(g *Generator) { g.opts.Cache = cache }
+}
+
+// WithLoader resolves the packages of the generated code with the loader,
+// which may be shared with other Generators.
+func WithLoader(loader *Loader) GeneratorOption {
+	return func(g *Generator) { g.opts.Loader = loader }
+}
+
+// WithTracer sends spans for each phase of generation to the tracer.
+func WithTracer(tracer Tracer) GeneratorOption {
+	return func(g *Generator) { g.opts.Tracer = tracer }
+}
+
+// WithSplitSections writes the declarations guarded by //genny:build
+// directives to a file for each constraint, as SplitSections does.
+func WithSplitSections() GeneratorOption {
+	return func(g *Generator) { g.split = true }
+}
+
+// WithPerTypeSet writes each type set to its own file, named by the
+// pattern as GenericsPerTypeSet does, rather than to Source.Out.
+func WithPerTypeSet(pattern string) GeneratorOption {
+	return func(g *Generator) { g.perTypeSet = pattern }
+}
+
+// Source is a template and what to generate from it.
+type Source struct {
+	// Filename is the name of the template, which messages and headers
+	// give it by.
+	Filename string
+	// Template is the code of the template. If it is nil, it is read from
+	// Filename.
+	Template []byte
+	// Out is the name of the generated file.
+	Out string
+	// TypeSets are the type sets to generate, as TypeSet parses them.
+	TypeSets []map[string]string
+}
+
+// Generate generates the code of the source, in one file named src.Out
+// unless the Generator splits it. The context is checked before each step
+// and before each type set is generated; a type set already started is
+// not interrupted. Splitting sections needs formatted code, so it cannot
+// be combined with Options.Unformatted.
+func (g *Generator) Generate(ctx context.Context, src Source) ([]File, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if g.split && g.opts.Unformatted {
+		return nil, &errBadOption{Option: "split-build", Value: "true", Message: "the sections are only split once the code is formatted"}
+	}
+	opts := g.opts
+	opts.ctx = ctx
+	template := src.Template
+	if template == nil {
+		var err error
+		if template, err = ioutil.ReadFile(src.Filename); err != nil {
+			return nil, err
+		}
+	}
+	if g.perTypeSet != "" {
+		return GenericsPerTypeSet(src.Filename, g.perTypeSet, g.pkgName, bytes.NewReader(template), src.TypeSets, opts)
+	}
+	output, err := GenericsWithOptions(src.Filename, src.Out, g.pkgName, bytes.NewReader(template), src.TypeSets, opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if g.split && HasSections(output) {
+		return SplitSections(src.Out, output)
+	}
+	return []File{{Name: src.Out, Source: output}}, nil
+}
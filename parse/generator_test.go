@@ -0,0 +1,101 @@
+package parse_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const generatorTemplate = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemQueue struct {
+	items []Item
+}
+
+//genny:build linux
+func (q *ItemQueue) Fd() int { return len(q.items) }
+`
+
+func TestGenerator(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int,string")
+	require.NoError(t, err)
+	src := parse.Source{Filename: "queue.go", Template: []byte(generatorTemplate), Out: "gen_queue.go", TypeSets: typeSets}
+
+	files, err := parse.NewGenerator(parse.WithPackage("things")).Generate(context.Background(), src)
+	require.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, "gen_queue.go", files[0].Name)
+		assert.Contains(t, string(files[0].Source), "package things")
+		assert.Contains(t, string(files[0].Source), "type StringQueue struct")
+	}
+
+	files, err = parse.NewGenerator(parse.WithSplitSections()).Generate(context.Background(), src)
+	require.NoError(t, err)
+	if assert.Len(t, files, 2) {
+		assert.Equal(t, "gen_queue_linux.go", files[1].Name)
+		assert.True(t, strings.HasPrefix(string(files[1].Source), "//go:build linux\n"), string(files[1].Source))
+		assert.Contains(t, string(files[1].Source), "func (q *IntQueue) Fd() int")
+	}
+
+	files, err = parse.NewGenerator(parse.WithPerTypeSet("queue_%T.go")).Generate(context.Background(), src)
+	require.NoError(t, err)
+	if assert.Len(t, files, 2) {
+		assert.Equal(t, "queue_int.go", files[0].Name)
+		assert.Equal(t, "queue_string.go", files[1].Name)
+	}
+
+	// options given later replace those given before
+	g := parse.NewGenerator(parse.WithStrict(), parse.WithOptions(parse.Options{}))
+	_, err = g.Generate(context.Background(), parse.Source{Filename: "queue.go", Template: []byte(generatorTemplate), Out: "gen_queue.go", TypeSets: []map[string]string{{"Item": "int", "Unknown": "int"}}})
+	assert.NoError(t, err)
+	_, err = parse.NewGenerator(parse.WithStrict()).Generate(context.Background(), parse.Source{Filename: "queue.go", Template: []byte(generatorTemplate), Out: "gen_queue.go", TypeSets: []map[string]string{{"Item": "int", "Unknown": "int"}}})
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = parse.NewGenerator().Generate(ctx, src)
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = parse.NewGenerator(parse.WithSplitSections(), parse.WithOptions(parse.Options{Unformatted: true})).Generate(context.Background(), src)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the sections are only split once the code is formatted")
+	}
+}
+
+// cancelingTracer cancels the generation when the first type set has been
+// substituted, counting the type sets substituted.
+type cancelingTracer struct {
+	cancel      context.CancelFunc
+	substituted int
+}
+
+func (c *cancelingTracer) StartSpan(name string, attrs map[string]string) parse.Span {
+	if name == parse.SpanSubstitute {
+		c.substituted++
+		c.cancel()
+	}
+	return c
+}
+
+func (c *cancelingTracer) End(err error) {}
+
+func TestGeneratorCancel(t *testing.T) {
+	// the type sets after the context is done are not generated
+	typeSets, err := parse.TypeSet("Item=int,string,bool,float64")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tracer := &cancelingTracer{cancel: cancel}
+	g := parse.NewGenerator(parse.WithWorkers(1), parse.WithTracer(tracer))
+	_, err = g.Generate(ctx, parse.Source{Filename: "queue.go", Template: []byte(generatorTemplate), Out: "gen_queue.go", TypeSets: typeSets})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 1, tracer.substituted)
+}
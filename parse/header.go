@@ -0,0 +1,136 @@
+package parse
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Header is a custom header for generated files, in place of the one
+// pointing to genny.
+type Header struct {
+	// Text is a text/template executed with HeaderData. Lines of the
+	// result that are not comments are made comments, so the header can
+	// be plain text such as a license.
+	Text string
+
+	// Command is the genny invocation that generated the file, given to
+	// the template as HeaderData.Command.
+	Command []string
+}
+
+// HeaderData is what a custom header template is executed with.
+type HeaderData struct {
+	// Template is the name of the template the file was generated from,
+	// reduced to its file name if it is an absolute path.
+	Template string
+	// TypeSets are the type sets, e.g. "Item=int".
+	TypeSets []string
+	// Types are the specific types of each type set by generic type.
+	Types []map[string]string
+	// Command is the genny invocation, with the arguments quoted where the
+	// shell needs them to be, e.g. genny -in=queue.go gen "T=func() error".
+	Command string
+	// Version is the version of genny, or "" if it is not known.
+	Version string
+}
+
+// customHeader gets the header executing the template of h for the file
+// generated from the template in filename, in place of genny's. The file
+// is only known to be generated if the header has genny's marker or a
+// "// Code generated by genny ... DO NOT EDIT." line (see out.IsGenerated).
+func customHeader(h *Header, filename string, typeSets []map[string]string) ([]byte, error) {
+	tmpl, err := template.New("header").Parse(h.Text)
+	if err != nil {
+		return nil, &errBadOption{Option: "header", Value: firstLine(h.Text), Message: err.Error()}
+	}
+	data := HeaderData{
+		Template: filepath.ToSlash(filename),
+		Types:    typeSets,
+		Command:  commandLine(h.Command),
+		Version:  gennyVersion(),
+	}
+	if filepath.IsAbs(filename) {
+		data.Template = filepath.Base(filename)
+	}
+	for _, typeSet := range typeSets {
+		data.TypeSets = append(data.TypeSets, typeSetString(typeSet))
+	}
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, data); err != nil {
+		return nil, &errBadOption{Option: "header", Value: firstLine(h.Text), Message: err.Error()}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\n\n")
+	for _, line := range strings.Split(strings.TrimRight(text.String(), "\n"), "\n") {
+		line = strings.TrimRight(line, " \t")
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(line), "//"):
+			buf.WriteString(strings.TrimSpace(line))
+		case line == "":
+			buf.WriteString("//")
+		default:
+			buf.WriteString("// " + line)
+		}
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// commandLine gets the arguments as they would be typed in a shell, with
+// those that are empty or have anything but letters, digits and
+// punctuation the shell leaves alone quoted.
+func commandLine(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = arg
+		if arg == "" || strings.IndexFunc(arg, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune("-_=.,/:+@%", r)
+		}) >= 0 {
+			quoted[i] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+// fileHeader gets the header of the file generated from the template in
+// filename: genny's own, or the custom one of the options, with what
+// Annotate and Owners add to it.
+func fileHeader(filename string, in io.ReadSeeker, typeSets []map[string]string, opts Options) ([]byte, error) {
+	base := header
+	if opts.Header != nil {
+		var err error
+		if base, err = customHeader(opts.Header, filename, typeSets); err != nil {
+			return nil, err
+		}
+	}
+	// copy the header so that concurrent calls do not append to the same
+	// array
+	h := append([]byte(nil), base...)
+	if opts.Annotate {
+		var err error
+		if h, err = annotatedHeader(base, filename, in, typeSets); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Owners {
+		owners, err := ownersHeader(filename, in)
+		if err != nil {
+			return nil, err
+		}
+		h = append(append(h[:len(h)-1], owners...), '\n')
+	}
+	return h, nil
+}
+
+// firstLine gets the first line of text.
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
@@ -0,0 +1,66 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const headerTemplate = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemQueue struct{ items []Item }
+`
+
+func TestCustomHeader(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int,string")
+	require.NoError(t, err)
+	opts := parse.Options{
+		Header: &parse.Header{
+			Text: `// Code generated by genny from {{.Template}}. DO NOT EDIT.
+Copyright Example Corp.
+{{range .TypeSets}}// - {{.}}
+{{end}}
+Run: {{.Command}}
+{{(index .Types 0).Item}}
+`,
+			Command: []string{"genny", "-in=queue.go", "gen", "Item=int,string Item=func() error"},
+		},
+		Annotate: true,
+	}
+	output, err := parse.GenericsWithOptions("/src/queue.go", "", "", strings.NewReader(headerTemplate), typeSets, opts)
+	require.NoError(t, err)
+	code := string(output)
+	assert.True(t, out.IsGenerated(output))
+	assert.True(t, strings.HasPrefix(code, "// Code generated by genny from queue.go. DO NOT EDIT.\n// Copyright Example Corp.\n// - Item=int\n// - Item=string\n//\n// Run: genny -in=queue.go gen \"Item=int,string Item=func() error\"\n// int\n//\n// Template: queue.go"), code)
+	assert.NotContains(t, code, out.GeneratedMarker)
+	assert.NotContains(t, code, "cheekybits/genny\n")
+
+	// a header with neither genny's line nor a Code generated line leaves
+	// the file unknown to be generated
+	opts.Header.Text = "Copyright Example Corp."
+	output, err = parse.GenericsWithOptions("/src/queue.go", "", "", strings.NewReader(headerTemplate), typeSets, opts)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(output), "// Copyright Example Corp.\n"), string(output))
+	assert.False(t, out.IsGenerated(output))
+	assert.Contains(t, code, "type IntQueue struct{ items []int }")
+}
+
+func TestCustomHeaderErrors(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int")
+	require.NoError(t, err)
+	for _, text := range []string{"{{.Template", "{{.Missing}}"} {
+		opts := parse.Options{Header: &parse.Header{Text: text}}
+		_, err := parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(headerTemplate), typeSets, opts)
+		if assert.Error(t, err, text) {
+			assert.Contains(t, err.Error(), "is not a valid header option", text)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package parse
+
+import (
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// The constants of the generic package that are replaced with the index of
+// the type set being generated and the number of type sets.
+const (
+	genericIndex = "Index"
+	genericCount = "Count"
+)
+
+// subIndexIntoLine replaces generic.Index and generic.Count in the code of
+// the line with the index of the type set and the number of type sets.
+func subIndexIntoLine(line string, index, count int) string {
+	if !strings.Contains(line, genericPackage+".") {
+		return line
+	}
+	src := []byte(line)
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, src, nil, 0)
+	type tok struct {
+		tok    token.Token
+		lit    string
+		offset int
+	}
+	var toks []tok
+	for {
+		pos, t, lit := s.Scan()
+		if t == token.EOF {
+			break
+		}
+		toks = append(toks, tok{t, lit, file.Offset(pos)})
+	}
+	var output strings.Builder
+	last := 0
+	for i := 0; i+2 < len(toks); i++ {
+		if toks[i].lit != genericPackage || toks[i+1].tok != token.PERIOD || (i > 0 && toks[i-1].tok == token.PERIOD) {
+			continue
+		}
+		var value int
+		switch toks[i+2].lit {
+		case genericIndex:
+			value = index
+		case genericCount:
+			value = count
+		default:
+			continue
+		}
+		output.WriteString(line[last:toks[i].offset])
+		output.WriteString(strconv.Itoa(value))
+		last = toks[i+2].offset + len(toks[i+2].lit)
+	}
+	output.WriteString(line[last:])
+	return output.String()
+}
+
+// sharedLines gets the template lines of the top level declarations that
+// use generic.Count but none of the generic types. They are the same for
+// every type set, so are only generated with the first.
+func sharedLines(fs *token.FileSet, file *ast.File, typeSet map[string]string) map[int]bool {
+	shared := make(map[int]bool)
+	for _, decl := range file.Decls {
+		usesCount, usesGeneric := false, false
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if x, ok := n.X.(*ast.Ident); ok && x.Name == genericPackage && n.Sel.Name == genericCount {
+					usesCount = true
+					return false
+				}
+			case *ast.Ident:
+				for t := range typeSet {
+					if strings.Contains(n.Name, t) {
+						usesGeneric = true
+					}
+				}
+			}
+			return true
+		})
+		if !usesCount || usesGeneric {
+			continue
+		}
+		for line := fs.PositionFor(decl.Pos(), false).Line; line <= fs.PositionFor(decl.End(), false).Line; line++ {
+			shared[line] = true
+		}
+	}
+	return shared
+}
@@ -0,0 +1,145 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"strings"
+)
+
+// interfaceMethod is an exported method of a generated type.
+type interfaceMethod struct {
+	Name string
+	Type *ast.FuncType
+}
+
+// interfaceDecls gets the source of an interface for each type the
+// template declares, listing the exported methods of its generated type.
+// The interface of IntQueue is called IntQueueInterface. If fakes is true,
+// each interface is followed by a fake implementation, FakeIntQueue.
+// names are the names composed by Options.NameTemplate, if any, keyed by
+// the names they replace.
+func interfaceDecls(file *ast.File, typeSet map[string]string, names map[string]string, fs *token.FileSet, generated *ast.File, fakes bool) string {
+	generics := genericTypes(file)
+	inTemplate := make(map[string]bool)
+	for name := range declaredTypes(file) {
+		if generics[name] {
+			continue
+		}
+		name = subIntoIdent(name, typeSet)
+		if composed, ok := names[name]; ok {
+			name = composed
+		}
+		inTemplate[name] = true
+	}
+
+	type methodSet struct {
+		pointer bool
+		methods []interfaceMethod
+	}
+	sets := make(map[string]*methodSet)
+	var order []string
+	for _, decl := range generated.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && inTemplate[ts.Name.Name] && sets[ts.Name.Name] == nil {
+				sets[ts.Name.Name] = &methodSet{}
+				order = append(order, ts.Name.Name)
+			}
+		}
+	}
+	for _, decl := range generated.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 || !fn.Name.IsExported() {
+			continue
+		}
+		recv := fn.Recv.List[0].Type
+		star, pointer := recv.(*ast.StarExpr)
+		if pointer {
+			recv = star.X
+		}
+		ident, ok := recv.(*ast.Ident)
+		if !ok || sets[ident.Name] == nil {
+			continue
+		}
+		set := sets[ident.Name]
+		set.pointer = set.pointer || pointer
+		set.methods = append(set.methods, interfaceMethod{Name: fn.Name.Name, Type: fn.Type})
+	}
+
+	var buf bytes.Buffer
+	for _, name := range order {
+		set := sets[name]
+		if len(set.methods) == 0 {
+			continue
+		}
+		impl := name
+		if set.pointer {
+			impl = "*" + name
+		}
+		buf.WriteString("\n// " + name + "Interface is the method set of " + impl + ".\n")
+		buf.WriteString("type " + name + "Interface interface {\n")
+		for _, m := range set.methods {
+			buf.WriteString("\t" + m.Name + strings.TrimPrefix(node(fs, m.Type), "func") + "\n")
+		}
+		buf.WriteString("}\n")
+		if fakes {
+			writeFake(&buf, fs, name, set.methods)
+		}
+	}
+	return buf.String()
+}
+
+// writeFake writes a fake implementation of the interface of the named
+// type, whose methods call function fields set by the test.
+func writeFake(buf *bytes.Buffer, fs *token.FileSet, name string, methods []interfaceMethod) {
+	fake := "Fake" + name
+	fmt.Fprintf(buf, "\n// %s is a fake %sInterface for tests.\n// Each method calls the function in the matching field, which must be set.\n", fake, name)
+	fmt.Fprintf(buf, "type %s struct {\n", fake)
+	for _, m := range methods {
+		fmt.Fprintf(buf, "\t%sFunc %s\n", m.Name, node(fs, m.Type))
+	}
+	buf.WriteString("}\n")
+	for _, m := range methods {
+		var decls, args []string
+		if m.Type.Params != nil {
+			for i, field := range m.Type.Params.List {
+				names := field.Names
+				if len(names) == 0 {
+					names = []*ast.Ident{nil}
+				}
+				for _, n := range names {
+					arg := fmt.Sprintf("a%d", len(args))
+					if n != nil && n.Name != "_" && n.Name != "fake" {
+						arg = n.Name
+					}
+					decls = append(decls, arg+" "+node(fs, field.Type))
+					if _, variadic := field.Type.(*ast.Ellipsis); variadic && i == len(m.Type.Params.List)-1 {
+						arg += "..."
+					}
+					args = append(args, arg)
+				}
+			}
+		}
+		params := *m.Type
+		params.Results = nil
+		results := strings.TrimPrefix(node(fs, m.Type), node(fs, &params))
+		call := "fake." + m.Name + "Func(" + strings.Join(args, ", ") + ")"
+		if results != "" {
+			call = "return " + call
+		}
+		fmt.Fprintf(buf, "\nfunc (fake *%s) %s(%s)%s {\n\t%s\n}\n", fake, m.Name, strings.Join(decls, ", "), results, call)
+	}
+}
+
+// node prints the node as Go source.
+func node(fs *token.FileSet, n ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, fs, n)
+	return buf.String()
+}
@@ -0,0 +1,192 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+)
+
+// comparableDirective asserts that the specific types of generic.Interface
+// types are comparable, so that the template may use them as map keys and
+// compare them, e.g. //genny:comparable Item.
+const comparableDirective = metadataPrefix + "comparable "
+
+// interfaceParams gets the generic types of the template declared as
+// generic.Interface.
+func interfaceParams(file *ast.File) map[string]bool {
+	return kindParams(file, genericIface)
+}
+
+// kindParams gets the generic types of the template declared as the kind,
+// e.g. generic.Interface.
+func kindParams(file *ast.File, kind string) map[string]bool {
+	params := make(map[string]bool)
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			sel, ok := ts.Type.(*ast.SelectorExpr)
+			if !ok || genericPackage+"."+sel.Sel.Name != kind {
+				continue
+			}
+			if name, ok := sel.X.(*ast.Ident); ok && name.Name == genericPackage {
+				params[ts.Name.Name] = true
+			}
+		}
+	}
+	return params
+}
+
+// checkInterfaceParams checks the generic.Interface types of the template:
+// their specific types must be interfaces, and the template must not use
+// them in ways that are mistakes for interface types. It must not take
+// pointers to them, and may only use them as map keys or compare them if
+// it asserts they are comparable with a //genny:comparable directive, as
+// comparing interfaces holding uncomparable values panics.
+func checkInterfaceParams(fs *token.FileSet, file *ast.File, in io.ReadSeeker, typeSet map[string]string) error {
+	params := interfaceParams(file)
+	if len(params) == 0 {
+		return nil
+	}
+	var names []string
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if specificType, ok := typeSet[name]; ok && !mayBeInterface(specificType) {
+			return &errInterfaceParam{GenericType: name, SpecificType: specificType, Reason: "it is a generic.Interface, so it must be replaced by an interface type", Pos: declPos(fs, file, name)}
+		}
+	}
+	values, err := metadata(in, comparableDirective)
+	if err != nil {
+		return err
+	}
+	comparables := make(map[string]bool)
+	for _, v := range values {
+		comparables[v] = true
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch t := n.(type) {
+		case *ast.StarExpr:
+			if name := paramIdent(t.X, params); name != "" {
+				err = &errInterfaceParam{GenericType: name, Reason: "*" + name + " is a pointer to an interface", Pos: fs.Position(t.Pos())}
+			}
+		case *ast.UnaryExpr:
+			if name := paramValue(t.X, params); t.Op == token.AND && name != "" {
+				err = &errInterfaceParam{GenericType: name, Reason: "'" + types.ExprString(t.X) + "' holds an interface, so &" + types.ExprString(t.X) + " is a pointer to an interface", Pos: fs.Position(t.Pos())}
+			}
+		case *ast.MapType:
+			if name := paramIdent(t.Key, params); name != "" && !comparables[name] {
+				err = &errInterfaceParam{GenericType: name, Reason: "it is a map key, but interfaces holding uncomparable values panic as keys; assert it is comparable with " + comparableDirective + name, Pos: fs.Position(t.Key.Pos())}
+			}
+		case *ast.BinaryExpr:
+			if t.Op != token.EQL && t.Op != token.NEQ || isNil(t.X) || isNil(t.Y) {
+				return true
+			}
+			name := paramValue(t.X, params)
+			if name == "" {
+				name = paramValue(t.Y, params)
+			}
+			if name != "" && !comparables[name] {
+				err = &errInterfaceParam{GenericType: name, Reason: "it is compared with " + t.Op.String() + ", but comparing interfaces holding uncomparable values panics; assert it is comparable with " + comparableDirective + name, Pos: fs.Position(t.OpPos)}
+			}
+		}
+		return err == nil
+	})
+	return err
+}
+
+// checkComparableParams checks that the specific types of the
+// generic.Comparable types of the template can be map keys and compared.
+// Slices, maps and functions cannot, nor can arrays and structs of them.
+// Named types are taken to be comparable, as what they are is only known
+// once their packages are loaded.
+func checkComparableParams(fs *token.FileSet, file *ast.File, typeSet map[string]string) error {
+	params := kindParams(file, genericComparable)
+	var names []string
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		specificType, ok := typeSet[name]
+		if !ok {
+			continue
+		}
+		expr, err := parser.ParseExpr(specificType)
+		if err != nil {
+			continue
+		}
+		if reason := uncomparable(expr); reason != "" {
+			return &errInterfaceParam{GenericType: name, SpecificType: specificType, Reason: "it is a generic.Comparable, so it must be usable as a map key, but " + reason, Pos: declPos(fs, file, name)}
+		}
+	}
+	return nil
+}
+
+// uncomparable gets why values of the type cannot be compared, or "" if
+// they can be, as far as the type expression shows.
+func uncomparable(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.ParenExpr:
+		return uncomparable(t.X)
+	case *ast.ArrayType:
+		if t.Len == nil {
+			return types.ExprString(t) + " is a slice"
+		}
+		return uncomparable(t.Elt)
+	case *ast.MapType:
+		return types.ExprString(t) + " is a map"
+	case *ast.FuncType:
+		return types.ExprString(t) + " is a function"
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			if reason := uncomparable(field.Type); reason != "" {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// paramIdent gets the name of the generic type if the expression is one of
+// the params, or "".
+func paramIdent(expr ast.Expr, params map[string]bool) string {
+	if ident, ok := expr.(*ast.Ident); ok && params[ident.Name] {
+		return ident.Name
+	}
+	return ""
+}
+
+// paramValue gets the name of the generic type the expression is declared
+// as, if it is a variable, parameter or field of one of the params, or "".
+func paramValue(expr ast.Expr, params map[string]bool) string {
+	ident, ok := expr.(*ast.Ident)
+	if !ok || ident.Obj == nil {
+		return ""
+	}
+	switch decl := ident.Obj.Decl.(type) {
+	case *ast.Field:
+		return paramIdent(decl.Type, params)
+	case *ast.ValueSpec:
+		return paramIdent(decl.Type, params)
+	}
+	return ""
+}
+
+// isNil gets whether the expression is nil.
+func isNil(expr ast.Expr) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == "nil"
+}
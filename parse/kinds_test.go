@@ -0,0 +1,89 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterfaceParams(t *testing.T) {
+
+	const header = "package p\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Interface\n\n"
+	for _, test := range []struct {
+		src, specificType, err string
+	}{
+		{
+			src:          "func Call(item Item) { item.(interface{ Run() }).Run() }\n",
+			specificType: "io.Reader",
+		},
+		{
+			src:          "func Call(item Item) {}\n",
+			specificType: "[]byte",
+			err:          "p.go:5:6: '[]byte' cannot replace 'Item': it is a generic.Interface, so it must be replaced by an interface type",
+		},
+		{
+			src:          "func Get(item *Item) {}\n",
+			specificType: "io.Reader",
+			err:          "p.go:7:15: generic.Interface type 'Item' is misused: *Item is a pointer to an interface",
+		},
+		{
+			src:          "func Get(item Item) interface{} { return &item }\n",
+			specificType: "io.Reader",
+			err:          "p.go:7:42: generic.Interface type 'Item' is misused: 'item' holds an interface, so &item is a pointer to an interface",
+		},
+		{
+			src:          "var seen map[Item]bool\n",
+			specificType: "io.Reader",
+			err:          "p.go:7:14: generic.Interface type 'Item' is misused: it is a map key, but interfaces holding uncomparable values panic as keys; assert it is comparable with //genny:comparable Item",
+		},
+		{
+			src:          "func Same(a, b Item) bool { return a == b }\n",
+			specificType: "io.Reader",
+			err:          "p.go:7:38: generic.Interface type 'Item' is misused: it is compared with ==, but comparing interfaces holding uncomparable values panics; assert it is comparable with //genny:comparable Item",
+		},
+		{
+			src:          "//genny:comparable Item\n\nfunc Same(a, b Item) bool { return a == b }\n\nvar seen map[Item]bool\n",
+			specificType: "io.Reader",
+		},
+		{
+			src:          "func IsNil(a Item) bool { return a != nil }\n",
+			specificType: "io.Reader",
+		},
+	} {
+		_, err := parse.Generics("p.go", "out.go", "", strings.NewReader(header+test.src), []map[string]string{{"Item": test.specificType}})
+		if test.err == "" {
+			assert.NoError(t, err, test.src)
+		} else if assert.Error(t, err, test.src) {
+			assert.Equal(t, test.err, err.Error())
+		}
+	}
+
+}
+
+func TestComparableParams(t *testing.T) {
+
+	const src = "package p\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Key generic.Comparable\n\nvar members map[Key]bool\n"
+	for _, test := range []struct {
+		specificType, err string
+	}{
+		{specificType: "string"},
+		{specificType: "*bytes.Buffer"},
+		{specificType: "[4]int"},
+		{specificType: "time.Time"},
+		{specificType: "struct{ A, B int }"},
+		{specificType: "[]byte", err: "p.go:5:6: '[]byte' cannot replace 'Key': it is a generic.Comparable, so it must be usable as a map key, but []byte is a slice"},
+		{specificType: "map[string]int", err: "map[string]int is a map"},
+		{specificType: "func()", err: "func() is a function"},
+		{specificType: "[2][]int", err: "[]int is a slice"},
+		{specificType: "struct{ Tags []string }", err: "[]string is a slice"},
+	} {
+		_, err := parse.Generics("p.go", "out.go", "", strings.NewReader(src), []map[string]string{{"Key": test.specificType}})
+		if test.err == "" {
+			assert.NoError(t, err, test.specificType)
+		} else if assert.Error(t, err, test.specificType) {
+			assert.Contains(t, err.Error(), test.err)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package parse
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"path/filepath"
+	"strconv"
+)
+
+// lineDirectivePrefix starts the //line directives that tell the compiler,
+// debuggers and stack traces where code comes from.
+const lineDirectivePrefix = "//line "
+
+// addLineDirectives adds //line directives to the formatted output, so that
+// the lines generated from the template are reported at their lines in it,
+// and the lines that come from no template line, such as those genny adds,
+// at their own. A directive is only added where the lines stop following
+// on from each other, and never within a string literal or a comment that
+// spans lines. The template lines are given at the positions at gets for
+// them.
+func addLineDirectives(at linePositions, outputFilename string, output, unformatted []byte, origins []origin) []byte {
+	lineOrigin := outputOrigins(outputFilename, output, unformatted, origins)
+	self := filepath.Base(outputFilename)
+	if outputFilename == "" {
+		self = "generated.go"
+	}
+	inside := multilineTokens(output)
+
+	var buf bytes.Buffer
+	lines := bytes.SplitAfter(output, []byte("\n"))
+	written := 0
+	// next is the line of the template the next line is reported at, or
+	// the zero position if lines are reported at their own
+	var next token.Position
+	for i, line := range lines {
+		if len(line) == 0 {
+			continue
+		}
+		o, ok := lineOrigin(token.Position{Filename: outputFilename, Line: i + 1})
+		fromTemplate := ok && o.TypeSet >= 0 && o.Line > 0
+		var pos token.Position
+		if fromTemplate {
+			pos = at(o.Line)
+		}
+		blank := len(bytes.TrimSpace(line)) == 0
+		switch {
+		case inside[i+1] || blank:
+		case fromTemplate && pos != next:
+			buf.WriteString(lineDirectivePrefix + directiveFilename(pos.Filename, outputFilename) + ":" + strconv.Itoa(pos.Line) + "\n")
+			written++
+			next = pos
+		case !fromTemplate && next.Line != 0:
+			// the directive takes the line before this one
+			buf.WriteString(lineDirectivePrefix + self + ":" + strconv.Itoa(i+written+2) + "\n")
+			written++
+			next = token.Position{}
+		}
+		if next.Line != 0 {
+			next.Line++
+		}
+		buf.Write(line)
+	}
+	return buf.Bytes()
+}
+
+// directiveFilename gets the name of the template as a //line directive in
+// the output file gives it: relative to the directory of the output, which
+// the compiler resolves it against, or absolute if there is no output file.
+func directiveFilename(filename, outputFilename string) string {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	if outputFilename == "" {
+		return filepath.ToSlash(abs)
+	}
+	outDir, err := filepath.Abs(filepath.Dir(outputFilename))
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	rel, err := filepath.Rel(outDir, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// multilineTokens gets the lines of the code that start within a string
+// literal or comment spanning lines, before which nothing can be added.
+func multilineTokens(src []byte) map[int]bool {
+	inside := make(map[int]bool)
+	fs := token.NewFileSet()
+	file := fs.AddFile("", fs.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return inside
+		}
+		if tok != token.STRING && tok != token.COMMENT {
+			continue
+		}
+		start := fs.Position(pos).Line
+		end := start + bytes.Count([]byte(lit), []byte("\n"))
+		for line := start + 1; line <= end; line++ {
+			inside[line] = true
+		}
+	}
+}
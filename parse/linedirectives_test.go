@@ -0,0 +1,67 @@
+package parse_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const lineDirectivesTemplate = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+// ItemQueue is a queue of Items.
+type ItemQueue struct {
+	items []Item
+}
+
+// Push adds an item.
+func (q *ItemQueue) Push(item Item) {
+	q.items = append(q.items, item)
+	usage := ` + "`push an item\nto the queue`" + `
+	_ = usage
+}
+`
+
+func TestLineDirectives(t *testing.T) {
+	typeSets := []map[string]string{{"Item": "int"}, {"Item": "string"}}
+	output, err := parse.GenericsWithOptions("lib/queue.go", "lib/gen/queue.go", "", strings.NewReader(lineDirectivesTemplate), typeSets, parse.Options{LineDirectives: true})
+	require.NoError(t, err)
+	code := string(output)
+	assert.Contains(t, code, "\n//line ../queue.go:7\n// IntQueue is a queue of Ints.\n")
+	assert.Contains(t, code, "usage := `push an item\nto the queue`", "nothing is added within a string")
+
+	// the code is reported at the template lines
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, "lib/gen/queue.go", output, parser.ParseComments)
+	require.NoError(t, err)
+	var pushes []token.Position
+	ast.Inspect(file, func(n ast.Node) bool {
+		if fn, ok := n.(*ast.FuncDecl); ok && fn.Name.Name == "Push" {
+			pushes = append(pushes, fs.Position(fn.Pos()))
+			last := fn.Body.List[len(fn.Body.List)-1]
+			assert.Equal(t, 17, fs.Position(last.Pos()).Line)
+		}
+		return true
+	})
+	if assert.Len(t, pushes, 2) {
+		for _, pos := range pushes {
+			assert.Equal(t, "lib/queue.go", pos.Filename)
+			assert.Equal(t, 13, pos.Line)
+		}
+	}
+
+	// the header is reported where it is
+	assert.Equal(t, "lib/gen/queue.go", fs.Position(file.Package).Filename)
+
+	_, err = parse.GenericsWithOptions("lib/queue.go", "lib/gen/queue.go", "", strings.NewReader(lineDirectivesTemplate), typeSets, parse.Options{LineDirectives: true, Unformatted: true})
+	assert.Error(t, err)
+}
@@ -0,0 +1,206 @@
+package parse
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Loader resolves the packages imported by generated code with
+// go/packages, so that the build flags and environment of the build are
+// taken into account, e.g. the custom build tags of a monorepo. Compile
+// verification uses it to type check the concrete types of type sets, such
+// as time.Duration or mycorp.com/ids.UserID.
+//
+// Packages are loaded once and cached, so programs that verify code
+// repeatedly should share one Loader by setting Options.Loader. A Loader
+// is safe for concurrent use by multiple goroutines, but its configuration
+// must not change once it is used. The zero value loads packages as the go
+// command in the current directory would.
+type Loader struct {
+	// Dir is the directory the build system runs in. Empty means the
+	// current directory.
+	Dir string
+	// BuildFlags are passed to the build system, e.g. "-tags=integration".
+	BuildFlags []string
+	// Env is the environment the build system runs with, e.g. GOOS, GOARCH
+	// and GOFLAGS. Nil means the environment of the current process.
+	Env []string
+
+	mu       sync.Mutex
+	fset     *token.FileSet
+	packages map[string]*loadedPackage
+}
+
+// loadedPackage is a package type checked by a Loader. ready is closed
+// once types and err are set.
+type loadedPackage struct {
+	ready chan struct{}
+	types *types.Package
+	err   error
+}
+
+// config gets the go/packages configuration for loading the packages in
+// dir, or the Loader's directory.
+func (l *Loader) config(dir string) *packages.Config {
+	if dir == "" {
+		dir = l.Dir
+	}
+	return &packages.Config{Mode: packages.LoadImports, Dir: dir, BuildFlags: l.BuildFlags, Env: l.Env}
+}
+
+// fileSet gets the file set of the packages the loader has parsed.
+func (l *Loader) fileSet() *token.FileSet {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.fset == nil {
+		l.fset = token.NewFileSet()
+	}
+	return l.fset
+}
+
+// packageFiles gets the names of the files of the package in dir, as the
+// build flags select them, or nil if there is no package there yet.
+func (l *Loader) packageFiles(dir string) []string {
+	pkgs, err := packages.Load(l.config(dir), ".")
+	if err != nil || len(pkgs) != 1 || len(pkgs[0].Errors) > 0 {
+		return nil
+	}
+	return pkgs[0].GoFiles
+}
+
+// importer gets an importer for the import paths, resolved from dir. All
+// the packages and their dependencies are found with a single call to the
+// build system.
+func (l *Loader) importer(dir string, paths []string) (types.Importer, error) {
+	var missing []string
+	l.mu.Lock()
+	for _, path := range paths {
+		if _, ok := l.packages[path]; !ok && path != "unsafe" && path != "C" {
+			missing = append(missing, path)
+		}
+	}
+	l.mu.Unlock()
+
+	byPath := make(map[string]*packages.Package)
+	if len(missing) > 0 {
+		pkgs, err := packages.Load(l.config(dir), missing...)
+		if err != nil {
+			return nil, err
+		}
+		packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+			byPath[pkg.PkgPath] = pkg
+		})
+		for _, pkg := range pkgs {
+			if len(pkg.Errors) > 0 {
+				return nil, fmt.Errorf("%s: %v", pkg.PkgPath, pkg.Errors[0])
+			}
+		}
+	}
+	return importerFunc(func(path string) (*types.Package, error) {
+		return l.load(path, byPath)
+	}), nil
+}
+
+// load gets the type checked package with the import path, type checking
+// it from the loaded packages if it is not cached.
+func (l *Loader) load(path string, byPath map[string]*packages.Package) (*types.Package, error) {
+	if path == "unsafe" {
+		return types.Unsafe, nil
+	}
+	l.mu.Lock()
+	p, ok := l.packages[path]
+	if !ok {
+		if l.packages == nil {
+			l.packages = make(map[string]*loadedPackage)
+		}
+		p = &loadedPackage{ready: make(chan struct{})}
+		l.packages[path] = p
+	}
+	l.mu.Unlock()
+
+	if !ok {
+		p.types, p.err = l.check(path, byPath)
+		close(p.ready)
+	}
+	<-p.ready
+	return p.types, p.err
+}
+
+// check type checks the loaded package with the import path.
+func (l *Loader) check(path string, byPath map[string]*packages.Package) (*types.Package, error) {
+	pkg, ok := byPath[path]
+	if !ok {
+		return nil, fmt.Errorf("package %s was not loaded", path)
+	}
+	if len(pkg.Errors) > 0 {
+		return nil, fmt.Errorf("%s: %v", path, pkg.Errors[0])
+	}
+	// cgo packages are checked from the files cgo generates
+	filenames := pkg.CompiledGoFiles
+	if len(filenames) == 0 {
+		filenames = pkg.GoFiles
+	}
+	fset := l.fileSet()
+	var files []*ast.File
+	for _, filename := range filenames {
+		if !strings.HasSuffix(filename, ".go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, filename, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	imports := make(map[string]string)
+	for importPath, imported := range pkg.Imports {
+		imports[importPath] = imported.PkgPath
+	}
+	conf := types.Config{
+		Importer: importerFunc(func(importPath string) (*types.Package, error) {
+			if resolved, ok := imports[importPath]; ok {
+				importPath = resolved
+			}
+			return l.load(importPath, byPath)
+		}),
+		Sizes: types.SizesFor("gc", runtime.GOARCH),
+		// only the declarations are needed, and the standard library
+		// uses features genny's own checks do not
+		IgnoreFuncBodies: true,
+		Error:            func(error) {},
+	}
+	return conf.Check(path, fset, files, nil)
+}
+
+// importerFunc implements types.Importer with a function.
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// fileImports gets the import paths of the files, sorted.
+func fileImports(files []*ast.File) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			path := strings.Trim(spec.Path.Value, "`\"")
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
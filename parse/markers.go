@@ -0,0 +1,131 @@
+package parse
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// genericSelectors are the names in the generic package that templates use:
+// the kinds of generic types and the index and count of the type set.
+var genericSelectors = map[string]bool{
+	"Type":       true,
+	"Number":     true,
+	"Integer":    true,
+	"Unsigned":   true,
+	"Float":      true,
+	"Interface":  true,
+	"Comparable": true,
+	genericIndex: true,
+	genericCount: true,
+}
+
+// ParseGenericPackages parses a comma separated list of the packages, other
+// than genny's generic package, whose Type, Number and Interface declare
+// generic types, each given by the name templates use for it (genny) or
+// by its import path (example.com/lib/generic), for organizations that
+// fork the generic package or avoid importing it.
+func ParseGenericPackages(s string) ([]string, error) {
+	var pkgs []string
+	for _, pkg := range strings.Split(s, ",") {
+		pkg = strings.TrimSpace(pkg)
+		if pkg == "" {
+			continue
+		}
+		if !token.IsIdentifier(pkg) && !isImportPath(pkg) {
+			return nil, &errBadOption{Option: "generic-packages", Value: s, Message: "\"" + pkg + "\" is neither a package name nor an import path"}
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// isImportPath gets whether s looks like an import path with more than
+// one element.
+func isImportPath(s string) bool {
+	if !strings.Contains(s, "/") || strings.HasPrefix(s, "/") || strings.HasSuffix(s, "/") {
+		return false
+	}
+	return !strings.ContainsAny(s, " \t\"\\")
+}
+
+// useGenericPackages writes the uses of the generic packages in the
+// template as uses of genny's generic package, which the rest of genny
+// looks for. Line numbers are kept, though columns after the uses may
+// move.
+func useGenericPackages(filename string, in io.ReadSeeker, pkgs []string) (io.ReadSeeker, error) {
+	in.Seek(0, os.SEEK_SET)
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	names := genericPackageNames(file, pkgs)
+	// the uses by offset, with the length of the name used
+	uses := make(map[int]int)
+	var offsets []int
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok && names[x.Name] && genericSelectors[sel.Sel.Name] {
+			offset := fs.Position(x.Pos()).Offset
+			uses[offset] = len(x.Name)
+			offsets = append(offsets, offset)
+		}
+		return true
+	})
+	if len(offsets) == 0 {
+		in.Seek(0, os.SEEK_SET)
+		return in, nil
+	}
+
+	// replace from the end so that the offsets before stay where they are
+	sort.Sort(sort.Reverse(sort.IntSlice(offsets)))
+	out := append([]byte(nil), src...)
+	for _, offset := range offsets {
+		out = append(out[:offset], append([]byte(genericPackage), out[offset+uses[offset]:]...)...)
+	}
+	return bytes.NewReader(out), nil
+}
+
+// genericPackageNames gets the names the template uses for the generic
+// packages: those given by name, and those of the imports of the ones
+// given by import path.
+func genericPackageNames(file *ast.File, pkgs []string) map[string]bool {
+	names := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if token.IsIdentifier(pkg) {
+			names[pkg] = true
+			continue
+		}
+		for _, imp := range file.Imports {
+			importPath, err := strconv.Unquote(imp.Path.Value)
+			if err != nil || importPath != pkg {
+				continue
+			}
+			name := path.Base(importPath)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			if name != "_" && name != "." {
+				names[name] = true
+			}
+		}
+	}
+	delete(names, genericPackage)
+	return names
+}
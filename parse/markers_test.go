@@ -0,0 +1,50 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGenericPackages(t *testing.T) {
+	pkgs, err := parse.ParseGenericPackages("genny, example.com/lib/generic")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"genny", "example.com/lib/generic"}, pkgs)
+
+	for _, s := range []string{"gen-ny", "/abs/generic", "example.com/lib/"} {
+		_, err := parse.ParseGenericPackages(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestGenericsWithGenericPackages(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=string Num=int")
+	require.NoError(t, err)
+
+	// by the name the template uses
+	template := "package queue\n\nimport \"example.com/lib/genny\"\n\ntype Item genny.Type\n\ntype Num genny.Number\n\ntype ItemQueue []Item\n\nvar ItemWeight Num = genny.Count\n"
+	opts := parse.Options{Strict: true, GenericPackages: []string{"genny"}}
+	output, err := parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(template), typeSets, opts)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "type StringQueue []string")
+	assert.Contains(t, string(output), "var StringWeight int = 1")
+	assert.NotContains(t, string(output), "example.com")
+
+	// by import path, whatever the template names it
+	template = "package queue\n\nimport g \"example.com/lib/generic\"\n\ntype Item g.Type\n\ntype ItemQueue []Item\n"
+	typeSets, err = parse.TypeSet("Item=string")
+	require.NoError(t, err)
+	opts.GenericPackages = []string{"example.com/lib/generic"}
+	output, err = parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(template), typeSets, opts)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "type StringQueue []string")
+	assert.NotContains(t, string(output), "example.com")
+
+	// without them, the template declares no generic types
+	opts.GenericPackages = nil
+	_, err = parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(template), typeSets, opts)
+	assert.Error(t, err)
+}
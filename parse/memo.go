@@ -0,0 +1,51 @@
+package parse
+
+import "container/list"
+
+// memoSize is the number of substituted lines a lineMemo keeps.
+const memoSize = 1024
+
+// lineMemo remembers the most recently substituted lines of a template,
+// so that lines it repeats (error checks and other boilerplate) are only
+// scanned once per type set. It is not safe for concurrent use; each call
+// to substitute has its own.
+type lineMemo struct {
+	size  int
+	order *list.List
+	lines map[string]*list.Element
+}
+
+// memoEntry is a template line and the line substituted for it.
+type memoEntry struct {
+	line, subbed string
+}
+
+func newLineMemo(size int) *lineMemo {
+	return &lineMemo{size: size, order: list.New(), lines: make(map[string]*list.Element)}
+}
+
+// get gets the substituted line, if it is remembered.
+func (m *lineMemo) get(line string) (string, bool) {
+	e, ok := m.lines[line]
+	if !ok {
+		return "", false
+	}
+	m.order.MoveToFront(e)
+	return e.Value.(*memoEntry).subbed, true
+}
+
+// put remembers the substituted line, forgetting the least recently used
+// line if the memo is full.
+func (m *lineMemo) put(line, subbed string) {
+	if e, ok := m.lines[line]; ok {
+		e.Value.(*memoEntry).subbed = subbed
+		m.order.MoveToFront(e)
+		return
+	}
+	m.lines[line] = m.order.PushFront(&memoEntry{line: line, subbed: subbed})
+	if m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.lines, oldest.Value.(*memoEntry).line)
+	}
+}
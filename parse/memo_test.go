@@ -0,0 +1,48 @@
+package parse
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineMemo(t *testing.T) {
+	m := newLineMemo(2)
+	m.put("a", "A")
+	m.put("b", "B")
+	subbed, ok := m.get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "A", subbed)
+
+	// b is the least recently used, so it is forgotten
+	m.put("c", "C")
+	_, ok = m.get("b")
+	assert.False(t, ok)
+	for line, expected := range map[string]string{"a": "A", "c": "C"} {
+		subbed, ok := m.get(line)
+		assert.True(t, ok)
+		assert.Equal(t, expected, subbed)
+	}
+}
+
+// BenchmarkRepeatedLines generates a template that repeats the same
+// boilerplate in many functions.
+func BenchmarkRepeatedLines(b *testing.B) {
+	var template strings.Builder
+	template.WriteString("package p\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&template, "func ItemStep%d(v Item) (Item, error) {\n", i)
+		template.WriteString("\tvar zero Item\n\tif err := check(v); err != nil {\n\t\treturn zero, err\n\t}\n\treturn v, nil\n}\n\n")
+	}
+	template.WriteString("func check(v Item) error { return nil }\n")
+	src := template.String()
+	typeSets := []map[string]string{{"Item": "int"}, {"Item": "string"}}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Generics("template.go", "output.go", "", strings.NewReader(src), typeSets); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package parse
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"io"
+	"sort"
+	"strings"
+)
+
+// allowDirective names generic types the template opts in to despite
+// their risky names, e.g. //genny:allow Number.
+const allowDirective = metadataPrefix + "allow "
+
+// genericNames are the declarations of the generic package, which
+// templates refer to in lines that are substituted too.
+var genericNames = map[string]bool{"Type": true, "Number": true, "Integer": true, "Unsigned": true, "Float": true, "Interface": true, "Comparable": true, "Index": true, "Count": true}
+
+// checkParamNames checks that no generic type of the template is named like
+// a builtin or a declaration of the generic package, unless the template
+// allows it with a //genny:allow directive. Generic types are substituted
+// into every identifier, literal and comment that contains them, so such
+// names also change len, NewX, String methods and the like.
+func checkParamNames(fs *token.FileSet, file *ast.File, in io.ReadSeeker) error {
+	var names []string
+	for name := range genericTypes(file) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var allowed map[string]bool
+	for _, name := range names {
+		reason := riskyName(name)
+		if reason == "" {
+			continue
+		}
+		if allowed == nil {
+			values, err := metadata(in, allowDirective)
+			if err != nil {
+				return err
+			}
+			allowed = make(map[string]bool)
+			for _, v := range values {
+				allowed[v] = true
+			}
+		}
+		if !allowed[name] {
+			return &errRiskyParam{GenericType: name, Pos: declPos(fs, file, name), Reason: reason, Suggestion: saferName(name)}
+		}
+	}
+	return nil
+}
+
+// riskyName gets why the name is risky for a generic type, or "" if it is
+// not.
+func riskyName(name string) string {
+	if genericNames[name] {
+		return "is named like generic." + name
+	}
+	lower := strings.ToLower(name)
+	if types.Universe.Lookup(lower) != nil {
+		if lower == name {
+			return "is named like the builtin " + lower
+		}
+		return "is named like the builtin " + lower + " (ignoring case)"
+	}
+	return ""
+}
+
+// saferName suggests a name for the generic type that is not risky.
+func saferName(name string) string {
+	name = strings.ToUpper(name[:1]) + name[1:]
+	if name == "Type" {
+		return "ElemType"
+	}
+	return name + "Type"
+}
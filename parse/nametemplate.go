@@ -0,0 +1,169 @@
+package parse
+
+import (
+	"bytes"
+	"go/ast"
+	"go/token"
+	"strings"
+	"text/template"
+)
+
+// NameData is what a name template (Options.NameTemplate) composes the
+// name of a generated declaration from.
+type NameData struct {
+	// Generic is the name of the declaration in the template without its
+	// generic types, starting with a capital letter: Queue for ItemQueue,
+	// and NewQueue for NewItemQueue.
+	Generic string
+	// Concrete is the words of the specific types, in the order of their
+	// generic types in the name: Int for ItemQueue with Item=int, and
+	// StringInt for KeyValueMap with Key=string and Value=int.
+	Concrete string
+}
+
+// parseNameTemplate parses the name template of Options.NameTemplate.
+func parseNameTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("name").Parse(text)
+	if err != nil {
+		return nil, &errBadOption{Option: "name-template", Value: text, Message: err.Error()}
+	}
+	return tmpl, nil
+}
+
+// templateNames gets the names the top level declarations of the template
+// are generated with for the type set, composed by the name template, keyed
+// by the names the specific types are substituted into them as. Exported
+// names stay exported, and unexported ones unexported.
+func templateNames(file *ast.File, typeSet map[string]string, opts Options) (map[string]string, error) {
+	tmpl, err := parseNameTemplate(opts.NameTemplate)
+	if err != nil {
+		return nil, err
+	}
+	generics := substitutionOrder(typeSet)
+	names := make(map[string]string)
+	for _, name := range topLevelNames(file) {
+		if _, isGeneric := typeSet[name]; isGeneric {
+			continue
+		}
+		data, ok := nameData(name, generics, typeSet)
+		if !ok {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, &errBadOption{Option: "name-template", Value: opts.NameTemplate, Message: err.Error()}
+		}
+		composed := buf.String()
+		if isExported(name) {
+			composed = withCasing(composed, CasingUpper)
+		} else {
+			composed = lowerFirst(composed)
+		}
+		if !token.IsIdentifier(composed) {
+			return nil, &errBadOption{Option: "name-template", Value: opts.NameTemplate, Message: "it names " + name + " \"" + composed + "\", which is not an identifier"}
+		}
+		names[substitutedIdent(name, typeSet, opts.Casings)] = composed
+	}
+	return names, nil
+}
+
+// nameData splits the name into the words of the generic types it contains
+// and the rest, or gets false if it contains none. The longest generic
+// type is taken where several start at the same place.
+func nameData(name string, generics []string, typeSet map[string]string) (NameData, bool) {
+	var rest, concrete strings.Builder
+	found := false
+	for i := 0; i < len(name); {
+		matched := false
+		for _, t := range generics {
+			if strings.HasPrefix(name[i:], t) {
+				concrete.WriteString(wordify(typeSet[t], true))
+				i += len(t)
+				matched, found = true, true
+				break
+			}
+		}
+		if !matched {
+			rest.WriteByte(name[i])
+			i++
+		}
+	}
+	if !found || rest.Len() == 0 {
+		return NameData{}, false
+	}
+	return NameData{Generic: withCasing(rest.String(), CasingUpper), Concrete: concrete.String()}, true
+}
+
+// topLevelNames gets the names of the types, functions, variables and
+// constants the template declares at the top level; not its methods.
+func topLevelNames(file *ast.File) []string {
+	var names []string
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				names = append(names, d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					names = append(names, s.Name.Name)
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						names = append(names, n.Name)
+					}
+				}
+			}
+		}
+	}
+	return names
+}
+
+// substitutedIdent gets the identifier with the specific types substituted
+// into it as they are into the identifiers of the template, with their
+// casings.
+func substitutedIdent(ident string, typeSet map[string]string, casings map[string]PositionCasing) string {
+	for _, t := range substitutionOrder(typeSet) {
+		if !strings.Contains(ident, t) {
+			continue
+		}
+		if casing := casingFor(casings, t).Identifiers; casing != CasingKeep {
+			ident = withPositionCasing(ident, t, typeSet[t], casing)
+		} else {
+			ident = subIntoLiteral(ident, t, typeSet[t])
+		}
+	}
+	return ident
+}
+
+// renameWords renames the words of the generated code (its runs of letters,
+// digits and underscores) that are keys of names, wherever they are:
+// identifiers, strings and comments alike.
+func renameWords(src []byte, names map[string]string) []byte {
+	if len(names) == 0 {
+		return src
+	}
+	var buf bytes.Buffer
+	s := string(src)
+	for len(s) > 0 {
+		start := strings.IndexFunc(s, isAlphaNumeric)
+		if start < 0 {
+			buf.WriteString(s)
+			break
+		}
+		buf.WriteString(s[:start])
+		s = s[start:]
+		end := strings.IndexFunc(s, func(r rune) bool { return !isAlphaNumeric(r) })
+		if end < 0 {
+			end = len(s)
+		}
+		word := s[:end]
+		if renamed, ok := names[word]; ok {
+			word = renamed
+		}
+		buf.WriteString(word)
+		s = s[end:]
+	}
+	return buf.Bytes()
+}
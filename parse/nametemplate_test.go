@@ -0,0 +1,67 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nameTemplateTemplate = `package maps
+
+import "github.com/cheekybits/genny/generic"
+
+type Key generic.Type
+type Value generic.Type
+
+// KeyValueMap maps keys to values.
+type KeyValueMap map[Key]Value
+
+// NewKeyValueMap makes a KeyValueMap.
+func NewKeyValueMap() KeyValueMap { return KeyValueMap{} }
+
+func (m KeyValueMap) Get(k Key) Value {
+	lastKey := k
+	return m[lastKey]
+}
+
+func describeKeyValueMap() string { return "KeyValueMap" }
+`
+
+func TestNameTemplate(t *testing.T) {
+	typeSets, err := parse.TypeSet("Key=string Value=int,time.Time:Instant")
+	require.NoError(t, err)
+	opts := parse.Options{NameTemplate: "{{.Generic}}Of{{.Concrete}}", Interfaces: true}
+	output, err := parse.GenericsWithOptions("maps.go", "gen_maps.go", "", strings.NewReader(nameTemplateTemplate), typeSets, opts)
+	require.NoError(t, err)
+	for _, want := range []string{
+		"// MapOfStringInt maps keys to values.\ntype MapOfStringInt map[string]int",
+		"func NewMapOfStringInt() MapOfStringInt { return MapOfStringInt{} }",
+		"func (m MapOfStringInt) Get(k string) int {",
+		// only the names of top level declarations are composed
+		"lastString := k",
+		`func describeMapOfStringInt() string { return "MapOfStringInt" }`,
+		"type MapOfStringInstant map[string]time.Time",
+		"type MapOfStringIntInterface interface {",
+	} {
+		assert.Contains(t, string(output), want)
+	}
+	assert.NotContains(t, string(output), "StringIntMap")
+
+	for _, test := range []struct {
+		template string
+		err      string
+	}{
+		{"{{.Generic", "unclosed action"},
+		{"{{.Specific}}", "can't evaluate field Specific"},
+		{"{{.Generic}}-{{.Concrete}}", `it names KeyValueMap "Map-StringInt", which is not an identifier`},
+	} {
+		opts := parse.Options{NameTemplate: test.template}
+		_, err := parse.GenericsWithOptions("maps.go", "gen_maps.go", "", strings.NewReader(nameTemplateTemplate), typeSets, opts)
+		if assert.Error(t, err, test.template) {
+			assert.Contains(t, err.Error(), test.err)
+		}
+	}
+}
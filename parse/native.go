@@ -0,0 +1,558 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// nativeParamPrefix starts the names of the generic types the type
+// parameters of a native generic template are converted to, e.g.
+// TypeParam01T for the first, T.
+const nativeParamPrefix = "TypeParam"
+
+// The numeric terms of type parameter constraints, as a mask so that the
+// terms of unions can be combined.
+const (
+	termUnsigned = 1 << iota
+	termSigned
+	termFloat
+)
+
+// constraintMasks are the constraints of golang.org/x/exp/constraints
+// (or named alike) that only allow numeric types, with their terms.
+var constraintMasks = map[string]int{
+	"Unsigned": termUnsigned,
+	"Signed":   termSigned,
+	"Integer":  termUnsigned | termSigned,
+	"Float":    termFloat,
+}
+
+// nativeDecl is a top level type or function of a native generic template
+// that takes type parameters.
+type nativeDecl struct {
+	name *ast.Ident
+	// params are the generic types its type parameters are converted to,
+	// in the order they are listed.
+	params  []string
+	newName string
+}
+
+// nativeTemplate converts a template written with Go 1.18 type parameters
+// into one that genny generates code from, for old toolchains. Each type
+// parameter becomes a generic type of the kind its constraint allows, and
+// the types and functions that take type parameters lose them and are
+// named after them: Queue[T any] becomes TQueue (IntQueue for T=int) and
+// NewQueue becomes NewTQueue, while unexported names take the types after
+// them (queueInt). Type parameters of the same name are the same generic
+// type. The type sets, which name the type parameters, are returned naming
+// the generic types, and the declarations are generated by the types of
+// those they use (see nativeGroups). A template without type parameters is
+// returned as it is.
+//
+// Only instantiations with the declaration's own type parameters can be
+// generated, such as Queue[T] within Map[T any], or calls inferring them.
+// The template keeps its lines, so that positions in it are still right.
+func nativeTemplate(filename string, in io.ReadSeeker, typeSets []map[string]string) (io.ReadSeeker, []map[string]string, error) {
+	in.Seek(0, io.SeekStart)
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, nil, &errSource{Err: err}
+	}
+	in.Seek(0, io.SeekStart)
+	if !bytes.Contains(src, []byte("[")) {
+		return in, typeSets, nil
+	}
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, src, parser.ParseComments)
+	if err != nil {
+		// genny reports it when it parses the template
+		return in, typeSets, nil
+	}
+	offset := func(p token.Pos) int { return fs.Position(p).Offset }
+
+	// the declarations with type parameters, and the generic types their
+	// type parameters become, in the order they are listed
+	decls := make(map[interface{}]*nativeDecl)
+	var ordered []*nativeDecl
+	names := make(map[string]string)
+	var generics []string
+	kinds := make(map[string]string)
+	// where each generic type's type parameter is first declared
+	declared := make(map[string]token.Position)
+	addParams := func(d *nativeDecl, list *ast.FieldList) {
+		for _, field := range list.List {
+			kind := constraintKind(field.Type)
+			for _, name := range field.Names {
+				generic, ok := names[name.Name]
+				if !ok {
+					generic = fmt.Sprintf("%s%02d%s", nativeParamPrefix, len(generics)+1, name.Name)
+					names[name.Name] = generic
+					generics = append(generics, generic)
+					kinds[generic] = kind
+					declared[generic] = fs.Position(name.Pos())
+				} else if kinds[generic] == genericType {
+					kinds[generic] = kind
+				}
+				d.params = append(d.params, generic)
+			}
+		}
+		decls[d.name.Obj.Decl] = d
+		ordered = append(ordered, d)
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok && ts.TypeParams != nil {
+					addParams(&nativeDecl{name: ts.Name}, ts.TypeParams)
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv == nil && decl.Type.TypeParams != nil {
+				addParams(&nativeDecl{name: decl.Name}, decl.Type.TypeParams)
+			}
+		}
+	}
+	if len(ordered) == 0 {
+		return in, typeSets, nil
+	}
+	if bytes.Contains(src, []byte(nativeParamPrefix)) {
+		return nil, nil, &errNativeTemplate{Pos: fs.Position(file.Package), Message: "the template uses " + nativeParamPrefix + ", which the generic types of its type parameters are named after"}
+	}
+
+	for _, d := range ordered {
+		d.newName = nativeName(d, decls)
+	}
+
+	// edits that only remove code keep its line breaks, so that the
+	// template keeps its lines
+	var edits []typeParamEdit
+	remove := func(start, end token.Pos) {
+		s, e := offset(start), offset(end)
+		edits = append(edits, typeParamEdit{start: s, end: e, text: strings.Repeat("\n", bytes.Count(src[s:e], []byte("\n")))})
+	}
+	replace := func(n ast.Node, text string) {
+		edits = append(edits, typeParamEdit{start: offset(n.Pos()), end: offset(n.End()), text: text})
+	}
+
+	for _, decl := range file.Decls {
+		// the generic types of the type parameters in scope, by name
+		local := make(map[string]string)
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			if decl.Tok == token.TYPE && isConstraintDecl(decl) {
+				start := decl.Pos()
+				if decl.Doc != nil {
+					start = decl.Doc.Pos()
+				}
+				remove(start, decl.End())
+				continue
+			}
+			for _, spec := range decl.Specs {
+				ts, ok := spec.(*ast.TypeSpec)
+				if !ok || ts.TypeParams == nil {
+					continue
+				}
+				remove(ts.TypeParams.Opening, ts.TypeParams.Closing+1)
+				for _, field := range ts.TypeParams.List {
+					for _, name := range field.Names {
+						local[name.Name] = names[name.Name]
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Type.TypeParams != nil {
+				remove(decl.Type.TypeParams.Opening, decl.Type.TypeParams.Closing+1)
+				for _, field := range decl.Type.TypeParams.List {
+					for _, name := range field.Names {
+						local[name.Name] = names[name.Name]
+					}
+				}
+			}
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				// the receiver may name the type parameters of its type
+				// differently
+				recv := decl.Recv.List[0].Type
+				if star, ok := recv.(*ast.StarExpr); ok {
+					recv = star.X
+				}
+				if x, indices := indexed(recv); x != nil {
+					if d := genericDecl(x, decls); d != nil {
+						for i, index := range indices {
+							if id, ok := index.(*ast.Ident); ok && i < len(d.params) {
+								local[id.Name] = d.params[i]
+							}
+						}
+					}
+				}
+			}
+		}
+
+		var inspectErr error
+		var visit func(ast.Node) bool
+		visit = func(n ast.Node) bool {
+			if inspectErr != nil {
+				return false
+			}
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				// the selected name is a field or method
+				ast.Inspect(n.X, visit)
+				return false
+			case *ast.KeyValueExpr:
+				if key, ok := n.Key.(*ast.Ident); ok && key.Obj == nil {
+					// a field name
+					ast.Inspect(n.Value, visit)
+					return false
+				}
+			case *ast.IndexExpr, *ast.IndexListExpr:
+				x, indices := indexed(n.(ast.Expr))
+				d := genericDecl(x, decls)
+				if d == nil {
+					return true
+				}
+				if len(indices) != len(d.params) {
+					inspectErr = &errNativeTemplate{Pos: fs.Position(n.Pos()), Message: d.name.Name + " is instantiated with " + fmt.Sprint(len(indices)) + " of its " + fmt.Sprint(len(d.params)) + " type parameters"}
+					return false
+				}
+				for i, index := range indices {
+					id, ok := index.(*ast.Ident)
+					if !ok || local[id.Name] != d.params[i] {
+						inspectErr = &errNativeTemplate{Pos: fs.Position(index.Pos()), Message: d.name.Name + " can only be instantiated with the type parameters it is declared with"}
+						return false
+					}
+				}
+				replace(n, d.newName)
+				return false
+			case *ast.Ident:
+				inspectErr = nativeIdent(fs, n, local, decls, replace)
+			}
+			return true
+		}
+		ast.Inspect(decl, visit)
+		if inspectErr != nil {
+			return nil, nil, inspectErr
+		}
+	}
+
+	// the names of the declarations in comments, such as their docs
+	for _, d := range ordered {
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				for _, loc := range wordIndexes(c.Text, d.name.Name) {
+					start := offset(c.Pos()) + loc
+					edits = append(edits, typeParamEdit{start: start, end: start + len(d.name.Name), text: d.newName})
+				}
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			// within removed code
+			continue
+		}
+		out.Write(src[last:e.start])
+		out.WriteString(e.text)
+		last = e.end
+	}
+	out.Write(src[last:])
+
+	// the generic types go after the rest of the template, as placeholders
+	// do, each placed by a //line directive where its type parameter is
+	// declared, so that problems with it are reported there
+	byGeneric := make(map[string]string)
+	for name, generic := range names {
+		byGeneric[generic] = name
+	}
+	for _, generic := range generics {
+		out.WriteString("\n// " + generic + " is the type parameter " + byGeneric[generic] + ".\n")
+		out.WriteString(paramDirective(filename, declared[generic]))
+		out.WriteString("type " + generic + " " + kinds[generic] + "\n")
+	}
+
+	var specific []map[string]string
+	for _, typeSet := range typeSets {
+		converted := make(map[string]string, len(typeSet))
+		for name, specificType := range typeSet {
+			if generic, ok := names[name]; ok {
+				name = generic
+			}
+			converted[name] = specificType
+		}
+		specific = append(specific, converted)
+	}
+	return bytes.NewReader(out.Bytes()), specific, nil
+}
+
+// paramDirective gets the //line directive that puts the name of the
+// generic type declared on the next line (after "type ") at the position of
+// its type parameter. Relative files are given relative to the directory
+// of the template, which the directive is resolved against.
+func paramDirective(filename string, pos token.Position) string {
+	name := pos.Filename
+	if !filepath.IsAbs(name) {
+		if rel, err := filepath.Rel(filepath.Dir(filename), name); err == nil {
+			name = rel
+		}
+	}
+	column := pos.Column - len("type ")
+	if column < 1 {
+		column = 1
+	}
+	return lineDirectivePrefix + filepath.ToSlash(name) + ":" + strconv.Itoa(pos.Line) + ":" + strconv.Itoa(column) + "\n"
+}
+
+// paramName gets the name a generic type is reported by: the type
+// parameter of a native generic template it was converted from, as K for
+// TypeParam01K, or its own name.
+func paramName(generic string) string {
+	rest := strings.TrimPrefix(generic, nativeParamPrefix)
+	if rest == generic || len(rest) < 3 || !isDigit(rest[0]) || !isDigit(rest[1]) || !token.IsIdentifier(rest[2:]) {
+		return generic
+	}
+	return rest[2:]
+}
+
+// nativeIdent converts the identifier, if it is a type parameter or
+// names a declaration with type parameters, using it with the type
+// parameters in scope.
+func nativeIdent(fs *token.FileSet, id *ast.Ident, local map[string]string, decls map[interface{}]*nativeDecl, replace func(ast.Node, string)) error {
+	if id.Obj == nil {
+		switch {
+		case local[id.Name] != "":
+			// a type parameter named by a receiver
+			replace(id, local[id.Name])
+		case id.Name == "any":
+			replace(id, "interface{}")
+		}
+		return nil
+	}
+	if id.Obj.Kind == ast.Typ && local[id.Name] != "" {
+		if _, ok := id.Obj.Decl.(*ast.Field); ok {
+			replace(id, local[id.Name])
+			return nil
+		}
+	}
+	d := genericDecl(id, decls)
+	if d == nil {
+		return nil
+	}
+	if id != d.name {
+		// a call inferring the type parameters, which can only be those
+		// in scope
+		inScope := make(map[string]bool)
+		for _, generic := range local {
+			inScope[generic] = true
+		}
+		for _, generic := range d.params {
+			if !inScope[generic] {
+				return &errNativeTemplate{Pos: fs.Position(id.Pos()), Message: d.name.Name + " must be instantiated with the type parameters it is declared with"}
+			}
+		}
+	}
+	replace(id, d.newName)
+	return nil
+}
+
+// nativeName gets the name the declaration is generated under: its generic
+// types before its name if it is exported (TQueue), or after it if not
+// (queueT). A constructor of a declared type, such as NewQueue, takes them
+// after New.
+func nativeName(d *nativeDecl, decls map[interface{}]*nativeDecl) string {
+	generics := strings.Join(d.params, "")
+	name := d.name.Name
+	if !ast.IsExported(name) {
+		return name + generics
+	}
+	if rest := strings.TrimPrefix(name, "New"); rest != name {
+		for _, other := range decls {
+			if other.name.Name == rest {
+				return "New" + generics + rest
+			}
+		}
+	}
+	return generics + name
+}
+
+// indexed gets the indexed expression and its indices of an index
+// expression, as of an instantiation, or nil if it is not one.
+func indexed(expr ast.Expr) (ast.Expr, []ast.Expr) {
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		return e.X, []ast.Expr{e.Index}
+	case *ast.IndexListExpr:
+		return e.X, e.Indices
+	}
+	return nil, nil
+}
+
+// genericDecl gets the declaration with type parameters the expression
+// names, or nil if it names none.
+func genericDecl(expr ast.Expr, decls map[interface{}]*nativeDecl) *nativeDecl {
+	id, ok := expr.(*ast.Ident)
+	if !ok || id.Obj == nil {
+		return nil
+	}
+	return decls[id.Obj.Decl]
+}
+
+// constraintKind gets the kind of generic type a type parameter with the
+// constraint becomes: generic.Comparable for comparable, a numeric kind for
+// constraints of numeric types only, and generic.Type for any other.
+func constraintKind(expr ast.Expr) string {
+	if id, ok := expr.(*ast.Ident); ok && id.Name == "comparable" && id.Obj == nil {
+		return genericComparable
+	}
+	mask := constraintMask(expr)
+	switch {
+	case mask <= 0:
+		return genericType
+	case mask == termUnsigned:
+		return genericUnsigned
+	case mask&termFloat == 0:
+		return genericInteger
+	case mask == termFloat:
+		return genericFloat
+	}
+	return genericNumber
+}
+
+// constraintMask gets the numeric terms the constraint allows, or -1 if it
+// allows other types too, or 0 if it allows any.
+func constraintMask(expr ast.Expr) int {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if e.Obj == nil {
+			switch {
+			case e.Name == "any":
+				return 0
+			case isTerm(e.Name, numericKinds[genericUnsigned]):
+				return termUnsigned
+			case isTerm(e.Name, numericKinds[genericInteger]):
+				return termSigned
+			case isTerm(e.Name, numericKinds[genericFloat]):
+				return termFloat
+			}
+			return -1
+		}
+		if ts, ok := e.Obj.Decl.(*ast.TypeSpec); ok {
+			if iface, ok := ts.Type.(*ast.InterfaceType); ok {
+				return constraintMask(iface)
+			}
+		}
+		return -1
+	case *ast.SelectorExpr:
+		if mask, ok := constraintMasks[e.Sel.Name]; ok {
+			return mask
+		}
+		return -1
+	case *ast.UnaryExpr:
+		if e.Op == token.TILDE {
+			return constraintMask(e.X)
+		}
+	case *ast.BinaryExpr:
+		if e.Op == token.OR {
+			x, y := constraintMask(e.X), constraintMask(e.Y)
+			if x <= 0 || y <= 0 {
+				return -1
+			}
+			return x | y
+		}
+	case *ast.InterfaceType:
+		if len(e.Methods.List) == 0 {
+			return 0
+		}
+		if len(e.Methods.List) == 1 && len(e.Methods.List[0].Names) == 0 {
+			return constraintMask(e.Methods.List[0].Type)
+		}
+	case *ast.ParenExpr:
+		return constraintMask(e.X)
+	}
+	return -1
+}
+
+// isTerm gets whether the name is one of the terms.
+func isTerm(name string, terms []string) bool {
+	for _, term := range terms {
+		if name == term {
+			return true
+		}
+	}
+	return false
+}
+
+// isConstraintDecl gets whether the declaration only declares interfaces
+// with type terms, which are only constraints and cannot be built by old
+// toolchains.
+func isConstraintDecl(decl *ast.GenDecl) bool {
+	for _, spec := range decl.Specs {
+		ts, ok := spec.(*ast.TypeSpec)
+		if !ok {
+			return false
+		}
+		iface, ok := ts.Type.(*ast.InterfaceType)
+		if !ok || !hasTypeTerms(iface) {
+			return false
+		}
+	}
+	return len(decl.Specs) > 0
+}
+
+// hasTypeTerms gets whether the interface lists type terms, such as
+// ~int | ~float64, rather than only methods and embedded interfaces.
+func hasTypeTerms(iface *ast.InterfaceType) bool {
+	for _, field := range iface.Methods.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		switch t := field.Type.(type) {
+		case *ast.UnaryExpr, *ast.BinaryExpr:
+			return true
+		case *ast.Ident:
+			if t.Obj == nil && t.Name != "any" && t.Name != "error" && t.Name != "comparable" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wordIndexes gets the indexes of the word in the text, where it is not
+// part of a longer identifier.
+func wordIndexes(text, word string) []int {
+	var indexes []int
+	for i := 0; ; {
+		j := strings.Index(text[i:], word)
+		if j < 0 {
+			return indexes
+		}
+		start, end := i+j, i+j+len(word)
+		if (start == 0 || !isIdentByte(text[start-1])) && (end == len(text) || !isIdentByte(text[end])) {
+			indexes = append(indexes, start)
+		}
+		i = end
+	}
+}
+
+// isIdentByte gets whether the byte may be part of an ASCII identifier.
+func isIdentByte(b byte) bool {
+	return b == '_' || ('0' <= b && b <= '9') || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z')
+}
+
+// isDigit gets whether the byte is an ASCII digit.
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
@@ -0,0 +1,179 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/diag"
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const nativeTemplate = `package queue
+
+// Number is the constraint of Sum.
+type Number interface {
+	~int | ~int64 | ~float64
+}
+
+// Queue is a queue.
+type Queue[T any] struct {
+	items []T
+	next  *Queue[T]
+}
+
+// NewQueue makes a Queue.
+func NewQueue[T any]() *Queue[T] {
+	return &Queue[T]{items: make([]T, 0)}
+}
+
+func (q *Queue[E]) Push(v E) {
+	q.items = append(q.items, v)
+}
+
+func (q *Queue[T]) Any() []any {
+	var out []any
+	for _, v := range q.items {
+		out = append(out, v)
+	}
+	return out
+}
+
+func Sum[N Number](xs []N) N {
+	var total N
+	for _, x := range xs {
+		total += x
+	}
+	return total
+}
+
+func Max[I ~int | ~int8 | ~uint](a, b I) I {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func keys[K comparable, V any](m map[K]V) []K {
+	var out []K
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+func countKeys[K comparable, V any](m map[K]V) int {
+	return len(keys(m))
+}
+`
+
+func TestNativeTemplate(t *testing.T) {
+	typeSets, err := parse.TypeSet("T=int N=float64 I=uint K=string V=bool")
+	require.NoError(t, err)
+	output, err := parse.Generics("queue.go", "", "", strings.NewReader(nativeTemplate), typeSets)
+	require.NoError(t, err)
+	code := string(output)
+	assert.Contains(t, code, "// IntQueue is a queue.\ntype IntQueue struct {\n\titems []int\n\tnext  *IntQueue\n}")
+	assert.Contains(t, code, "// NewIntQueue makes a IntQueue.\nfunc NewIntQueue() *IntQueue {\n\treturn &IntQueue{items: make([]int, 0)}")
+	assert.Contains(t, code, "func (q *IntQueue) Push(v int) {")
+	assert.Contains(t, code, "func (q *IntQueue) Any() []interface{} {\n\tvar out []interface{}")
+	assert.Contains(t, code, "func Float64Sum(xs []float64) float64 {\n\tvar total float64")
+	assert.Contains(t, code, "func UintMax(a, b uint) uint {")
+	assert.Contains(t, code, "func keysStringBool(m map[string]bool) []string {")
+	assert.Contains(t, code, "return len(keysStringBool(m))")
+	assert.NotContains(t, code, "Number")
+	assert.NotContains(t, code, "TypeParam")
+	assert.NotContains(t, code, "[T]")
+}
+
+func TestNativeTemplateKinds(t *testing.T) {
+	// the type parameters are checked as generic types of the kinds their
+	// constraints allow
+	for _, test := range []struct {
+		typeSet string
+		err     string
+	}{
+		{"T=int N=int I=float64 K=string V=bool", "queue.go:39:10: 'float64' cannot replace 'I': it is a generic.Integer"},
+		{"T=int N=int I=int K=[]int V=bool", "queue.go:46:11: '[]int' cannot replace 'K'"},
+		{"T=int N=int I=int K=string", "Missing specific type for 'V' generic type"},
+	} {
+		typeSets, err := parse.TypeSet(test.typeSet)
+		require.NoError(t, err)
+		_, err = parse.Generics("queue.go", "", "", strings.NewReader(nativeTemplate), typeSets)
+		if assert.Error(t, err, test.typeSet) {
+			assert.Contains(t, err.Error(), test.err)
+		}
+	}
+}
+
+func TestNativeTemplateErrors(t *testing.T) {
+	for _, test := range []struct {
+		template string
+		err      string
+	}{
+		{
+			"package p\n\ntype Box[T any] struct{ v T }\n\nfunc Ints() Box[int] { return Box[int]{} }\n",
+			"p.go:5:17: cannot instantiate the type parameters: Box can only be instantiated with the type parameters it is declared with",
+		},
+		{
+			"package p\n\nfunc Id[T any](v T) T { return v }\n\nfunc One() int { return Id(1) }\n",
+			"p.go:5:25: cannot instantiate the type parameters: Id must be instantiated with the type parameters it is declared with",
+		},
+		{
+			"package p\n\ntype Pair[K comparable, V any] struct{ k K; v V }\n\nfunc Swap[K comparable, V any](p Pair[V, K]) {}\n",
+			"p.go:5:39: cannot instantiate the type parameters: Pair can only be instantiated",
+		},
+		{
+			"package p\n\ntype TypeParamBox[T any] struct{ v T }\n",
+			"p.go:1:1: cannot instantiate the type parameters: the template uses TypeParam",
+		},
+	} {
+		typeSets, err := parse.TypeSet("T=int K=string V=int")
+		require.NoError(t, err)
+		_, err = parse.Generics("p.go", "", "", strings.NewReader(test.template), typeSets)
+		if assert.Error(t, err, test.template) {
+			assert.Contains(t, err.Error(), test.err)
+		}
+	}
+}
+
+const nativeDeclsTemplate = `package p
+
+// Stack is a stack.
+type Stack[T any] struct {
+	items []T
+}
+
+// Push pushes v.
+func (s *Stack[T]) Push(v T) { s.items = append(s.items, v) }
+
+// Map is a map.
+type Map[K comparable, V any] struct {
+	m map[K]V
+}
+
+// NewMap makes a Map.
+func NewMap[K comparable, V any]() *Map[K, V] {
+	return &Map[K, V]{m: make(map[K]V)}
+}
+
+func size() int { return 1 }
+`
+
+func TestNativeTemplateDecls(t *testing.T) {
+	// each declaration is generated once for each combination of the type
+	// parameters it uses
+	typeSets, err := parse.TypeSet("T=int,string K=string V=float64")
+	require.NoError(t, err)
+	var warnings diag.List
+	output, err := parse.GenericsWithOptions("p.go", "", "", strings.NewReader(nativeDeclsTemplate), typeSets, parse.Options{Warnings: &warnings})
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	code := string(output)
+	assert.Equal(t, 1, strings.Count(code, "type IntStack struct {"))
+	assert.Equal(t, 1, strings.Count(code, "type StringStack struct {"))
+	assert.Equal(t, 1, strings.Count(code, "type StringFloat64Map struct {"))
+	assert.Equal(t, 1, strings.Count(code, "func NewStringFloat64Map() *StringFloat64Map {"))
+	assert.Equal(t, 1, strings.Count(code, "func size() int"))
+}
@@ -0,0 +1,107 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// genericKinds are the declarations of the generic package that declare
+// generic types, with the generic types they declare.
+var genericKinds = map[string]string{
+	"Type":       genericType,
+	"Number":     genericNumber,
+	"Integer":    genericInteger,
+	"Unsigned":   genericUnsigned,
+	"Float":      genericFloat,
+	"Interface":  genericIface,
+	"Comparable": genericComparable,
+}
+
+// numericKinds are the specific types the refined numeric kinds of generic
+// types may be replaced by, if they are predeclared types.
+var numericKinds = map[string][]string{
+	genericInteger:  {"int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte", "rune"},
+	genericUnsigned: {"uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte"},
+	genericFloat:    {"float32", "float64"},
+}
+
+// IsGenericKind gets whether the name of a declaration of the generic
+// package declares generic types, as Type, Number, Integer, Unsigned, Float,
+// Interface and Comparable do.
+func IsGenericKind(name string) bool {
+	_, ok := genericKinds[name]
+	return ok
+}
+
+// numericParams gets the generic types of the template declared as
+// generic.Integer, generic.Unsigned or generic.Float, with their kinds.
+func numericParams(file *ast.File) map[string]string {
+	params := make(map[string]string)
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			sel, ok := ts.Type.(*ast.SelectorExpr)
+			if !ok {
+				continue
+			}
+			kind := genericPackage + "." + sel.Sel.Name
+			if name, ok := sel.X.(*ast.Ident); ok && name.Name == genericPackage && numericKinds[kind] != nil {
+				params[ts.Name.Name] = kind
+			}
+		}
+	}
+	return params
+}
+
+// checkNumericParams checks that the specific types of the generic.Integer,
+// generic.Unsigned and generic.Float types of the template are of their
+// kinds. Named types, such as time.Duration, are taken to be, as their
+// underlying types are only known once their packages are loaded; the
+// generated code is checked with them by Verify.
+func checkNumericParams(fs *token.FileSet, file *ast.File, typeSet map[string]string) error {
+	params := numericParams(file)
+	var names []string
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		specificType, ok := typeSet[name]
+		if !ok || isNumericKind(specificType, params[name]) {
+			continue
+		}
+		kinds := numericKinds[params[name]]
+		return &errNumericParam{GenericType: name, SpecificType: specificType, Kind: params[name], Types: kinds, Pos: declPos(fs, file, name)}
+	}
+	return nil
+}
+
+// isNumericKind gets whether the specific type may be of the numeric kind:
+// one of its predeclared types, or a named type.
+func isNumericKind(specificType, kind string) bool {
+	expr, err := parser.ParseExpr(specificType)
+	if err != nil {
+		return false
+	}
+	switch t := expr.(type) {
+	case *ast.SelectorExpr:
+		_, ok := t.X.(*ast.Ident)
+		return ok
+	case *ast.Ident:
+		if !isBuiltin(t.Name) {
+			return true
+		}
+		for _, allowed := range numericKinds[kind] {
+			if t.Name == allowed {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const numericTemplate = `package bits
+
+import "github.com/cheekybits/genny/generic"
+
+type Num generic.Integer
+
+type Bits generic.Unsigned
+
+type Ratio generic.Float
+
+func NumMod(a, b Num) Num { return a % b }
+
+func BitsShift(w Bits, n uint) Bits { return w << n }
+
+func RatioHalf(r Ratio) Ratio { return r / 2 }
+`
+
+func TestNumericParams(t *testing.T) {
+	typeSets, err := parse.TypeSet("Num=int64 Bits=uint8 Ratio=float32")
+	require.NoError(t, err)
+	output, err := parse.Generics("bits.go", "", "", strings.NewReader(numericTemplate), typeSets)
+	require.NoError(t, err)
+	code := string(output)
+	assert.Contains(t, code, "func Int64Mod(a, b int64) int64 { return a % b }")
+	assert.Contains(t, code, "func Uint8Shift(w uint8, n uint) uint8 { return w << n }")
+	assert.Contains(t, code, "func Float32Half(r float32) float32 { return r / 2 }")
+	assert.NotContains(t, code, "generic.")
+
+	// named types are taken to be of the kind
+	typeSets, err = parse.TypeSet("Num=time.Duration Bits=uint16 Ratio=float64")
+	require.NoError(t, err)
+	output, err = parse.Generics("bits.go", "", "", strings.NewReader(numericTemplate), typeSets)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "func TimeDurationMod(a, b time.Duration) time.Duration { return a % b }")
+}
+
+func TestNumericParamsErrors(t *testing.T) {
+	for _, test := range []struct {
+		typeSet string
+		err     string
+	}{
+		{"Num=float64 Bits=uint Ratio=float64", "bits.go:5:6: 'float64' cannot replace 'Num': it is a generic.Integer, so it must be replaced by one of int, int8,"},
+		{"Num=int Bits=int Ratio=float64", "'int' cannot replace 'Bits': it is a generic.Unsigned"},
+		{"Num=int Bits=uint Ratio=int", "'int' cannot replace 'Ratio': it is a generic.Float, so it must be replaced by one of float32, float64 or a named type of one"},
+		{"Num=string Bits=uint Ratio=float64", "'string' cannot replace 'Num'"},
+		{"Num=*int Bits=uint Ratio=float64", "'*int' cannot replace 'Num'"},
+	} {
+		typeSets, err := parse.TypeSet(test.typeSet)
+		require.NoError(t, err)
+		_, err = parse.Generics("bits.go", "", "", strings.NewReader(numericTemplate), typeSets)
+		if assert.Error(t, err, test.typeSet) {
+			assert.Contains(t, err.Error(), test.err)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package parse
+
+import (
+	"context"
+
+	"github.com/cheekybits/genny/diag"
+)
+
+// Options control the optional behaviour of GenericsWithOptions.
+// The zero value gives the same behaviour as Generics.
+type Options struct {
+	// Todos controls what happens to TODO and FIXME comments
+	// in the generated code.
+	Todos TodoMode
+
+	// Strict reports type sets naming unknown generic types, generic types
+	// the template never uses and names generated by more than one type
+	// set as errors.
+	Strict bool
+
+	// Warnings, if set, collects the problems Strict reports as errors as
+	// warnings when Strict is off, so that callers can show them without
+	// failing. It must not be shared by concurrent calls.
+	Warnings *diag.List
+
+	// RequireDocs reports generic types without a doc comment describing
+	// them as errors, for shared template libraries.
+	RequireDocs bool
+
+	// StructTags, if set, lists the struct tag keys whose values the
+	// specific types are substituted into, with their casing. The values
+	// of other keys are left untouched, unless AllStructTags is listed with
+	// the casing for them. If it is nil, every struct tag is substituted
+	// into like any other string.
+	StructTags map[string]Casing
+
+	// StringWords substitutes the specific types into every word of a
+	// string literal that starts with a generic type, with a lower case
+	// first letter or in snake case too, as into the values of StructTags,
+	// so that messages such as "something not found" name the specific
+	// type. By default only the generic types as they are written are.
+	StringWords bool
+
+	// Casings, if set, controls how the specific types are written into
+	// identifiers, string literals and comments, keyed by the generic type,
+	// or by AllParams for every generic type. A generic type's own casings
+	// that are CasingKeep follow those of AllParams.
+	Casings map[string]PositionCasing
+
+	// NameTemplate, if set, is a text/template composing the names of the
+	// top level declarations generated from the template, from the fields
+	// of NameData, e.g. {{.Generic}}Of{{.Concrete}} for QueueOfInt rather
+	// than IntQueue. Without it, the specific types take the place of the
+	// generic types in the names.
+	NameTemplate string
+
+	// Interfaces adds an interface for each type the template declares,
+	// listing the exported methods of the generated type, e.g.
+	// IntQueueInterface for IntQueue.
+	Interfaces bool
+
+	// Fakes adds the interfaces as Interfaces does, each followed by a fake
+	// implementation for tests (FakeIntQueue) whose methods call function
+	// fields.
+	Fakes bool
+
+	// Annotate lists what the file was generated from in its header: the
+	// template and its hash, the version of genny, each type set and the
+	// descriptions of the generic types.
+	Annotate bool
+
+	// Header replaces the header pointing to genny with a custom one, e.g.
+	// an organization's own provenance text. Annotate and Owners add to it
+	// as they do to genny's.
+	Header *Header
+
+	// Constraint is a build constraint expression, e.g. "linux && amd64",
+	// written at the top of the generated file as //go:build and // +build
+	// lines, for specializations built only on some platforms. A file
+	// constraint of the template is required along with it.
+	Constraint string
+
+	// TypeCheck type checks the generated code with go/types before it is
+	// returned, as VerifyWithOptions does with the package of the output
+	// file, so that specific types that cannot work, such as []byte as a
+	// map key, are reported where genny runs rather than at the next build.
+	// Each error names the template line and the type set it comes from.
+	TypeCheck bool
+
+	// Vet runs go vet on the package of the output file with the generated
+	// code, in a sandbox module (see NewSandbox), before it is returned.
+	// Each finding names the template line and the type set it comes from,
+	// as TypeCheck does. The output file must be in a module.
+	Vet bool
+
+	// LineDirectives adds //line directives to the generated code, so that
+	// compiler errors, panics and debuggers report the lines generated from
+	// the template at their lines in the template.
+	LineDirectives bool
+
+	// Owners names the template the file is owned by in its header, along
+	// with the owners listed by the template's //genny:owner directives, so
+	// that code review tooling can route changes to them.
+	Owners bool
+
+	// Script generates a standalone program, such as a benchmark or
+	// comparison script: the code is in package main, with an empty main
+	// function if the template declares none, and is only built when named
+	// on the command line (go run script.go), so it can be written to any
+	// directory without joining the package there.
+	Script bool
+
+	// TestPackage generates the code into the external test package of the
+	// package under test, which is the package of the output file (named
+	// pkgName, if that is given), for templates of test suites. The output
+	// file must be a test file (_test.go). The specific types the package
+	// under test declares (Thing, *Thing) are qualified with its name and
+	// the package is imported, while identifiers keep the plain names
+	// (ThingTable).
+	TestPackage bool
+
+	// Directives are compiler directives to add to generated functions,
+	// e.g. //go:noinline on IntQueue.Push only.
+	Directives []Directive
+
+	// Validators are checks on the specific types, run for every type set
+	// before anything is generated, keyed by the generic type they check,
+	// or by AllParams for those that check every generic type. The error of
+	// each refused type is returned, wrapped so that errors.Is and
+	// errors.As find it.
+	Validators map[string][]Validator
+
+	// Engine is how the specific types are substituted into the template:
+	// line by line (EngineLines), or into its syntax tree (EngineAST).
+	Engine Engine
+
+	// Mode is what is generated: a copy of the template for each type set
+	// (ModeCopies), or the template converted to Go 1.18 type parameters
+	// (ModeTypeParams), for which the type sets are not used.
+	Mode Mode
+
+	// Placeholders are generic types declared outside the template, for
+	// templates that do not declare them, such as snippets piped from other
+	// tools. Those the template declares itself are left alone.
+	Placeholders []Placeholder
+
+	// GenericPackages are packages, other than genny's generic package,
+	// whose Type, Number and Interface declare generic types, each given by
+	// the name templates use for it (genny, for genny.Type) or by its import
+	// path. See ParseGenericPackages.
+	GenericPackages []string
+
+	// Resolver, if set, is asked for the import paths of the packages the
+	// generated code uses that goimports cannot find, which are then
+	// imported. Packages it cannot find either are reported as Strict
+	// problems.
+	Resolver Resolver
+
+	// Unformatted skips formatting the output and fixing its imports, so
+	// that a batch of generated files can be formatted together afterwards
+	// with FormatFiles. The output is valid Go only once it is formatted.
+	Unformatted bool
+
+	// Cache, if set, holds the parsed templates between calls. It may be
+	// shared by calls made from any number of goroutines.
+	Cache *Cache
+
+	// Loader, if set, resolves the packages imported by the generated code
+	// for VerifyWithOptions, with its build flags and environment. It may be
+	// shared by calls made from any number of goroutines.
+	Loader *Loader
+
+	// CrashReport, if set, is the path of a file to write a report to if
+	// genny fails with an internal error.
+	CrashReport string
+
+	// Tracer, if set, receives spans for each phase of generation. The
+	// spans of the type sets may be started from several goroutines at
+	// once (see Workers).
+	Tracer Tracer
+
+	// Workers is how many type sets are generated at once:
+	// runtime.GOMAXPROCS(0) if it is 0, and one after another if it is 1
+	// or less.
+	// The output is the same however many there are.
+	Workers int
+
+	// Shuffle, if set, shuffles the order the type sets are generated in,
+	// as rand.Shuffle does, to check that the output does not depend on it.
+	// The output is in the order of the type sets all the same.
+	Shuffle func(n int, swap func(i, j int))
+
+	// ctx, if set, stops the generation before the next type set once it
+	// is done. Generator.Generate sets it to its context.
+	ctx context.Context
+}
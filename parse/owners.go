@@ -0,0 +1,54 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// ownerDirective names the owners of a template, e.g.
+// //genny:owner storage-team @alice.
+const ownerDirective = metadataPrefix + "owner "
+
+// ownersHeader gets the lines of the header of the generated file naming
+// the template it is owned by, and the owners the template's
+// //genny:owner directives list, for code review tooling to route changes
+// to.
+func ownersHeader(filename string, in io.ReadSeeker) ([]byte, error) {
+	owners, err := metadata(in, ownerDirective)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.ToSlash(filename)
+	if filepath.IsAbs(filename) {
+		name = filepath.Base(filename)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("//\n")
+	buf.WriteString("// Owned by template: " + name + "\n")
+	if len(owners) > 0 {
+		buf.WriteString("// Owners: " + strings.Join(owners, ", ") + "\n")
+	}
+	return buf.Bytes(), nil
+}
+
+// metadata gets the values of the directive in the template, split at
+// spaces and commas, in order.
+func metadata(in io.ReadSeeker, directive string) ([]string, error) {
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	defer in.Seek(0, io.SeekStart)
+	var values []string
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, directive) {
+			continue
+		}
+		values = append(values, strings.FieldsFunc(line[len(directive):], func(r rune) bool { return r == ',' || r == ' ' })...)
+	}
+	return values, scanner.Err()
+}
@@ -0,0 +1,86 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// param is a generic type declared by a template, with its doc comment.
+type param struct {
+	Name string
+	Doc  string
+	Pos  token.Position
+}
+
+// templateParams gets the generic types the template declares, in order,
+// with their doc comments.
+func templateParams(filename string, src []byte) ([]param, error) {
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	var params []param
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			sel, ok := ts.Type.(*ast.SelectorExpr)
+			if !ok {
+				continue
+			}
+			if name, ok := sel.X.(*ast.Ident); !ok || name.Name != genericPackage {
+				continue
+			}
+			doc := ts.Doc
+			if doc == nil && len(gd.Specs) == 1 {
+				doc = gd.Doc
+			}
+			params = append(params, param{
+				Name: ts.Name.Name,
+				Doc:  strings.Join(strings.Fields(doc.Text()), " "),
+				Pos:  fs.Position(ts.Pos()),
+			})
+		}
+	}
+	return params, nil
+}
+
+// checkParamDocs checks that every generic type of the template has a doc
+// comment describing it.
+func checkParamDocs(filename string, in io.ReadSeeker) error {
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	params, err := templateParams(filename, src)
+	if err != nil {
+		return err
+	}
+	for _, p := range params {
+		if p.Doc == "" {
+			return &errUndocumentedParam{GenericType: p.Name, Pos: p.Pos}
+		}
+	}
+	return nil
+}
+
+// describe gets the description of the generic type for the header of the
+// generated file: its doc comment, which is prefixed with its name unless
+// it starts with it.
+func (p param) describe() string {
+	if strings.HasPrefix(p.Doc, p.Name+" ") {
+		return p.Doc
+	}
+	return p.Name + ": " + p.Doc
+}
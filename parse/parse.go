@@ -5,15 +5,20 @@ import (
 	"bytes"
 	"fmt"
 	"go/ast"
+	"go/build/constraint"
 	"go/parser"
 	"go/scanner"
 	"go/token"
 	"io"
+	"io/ioutil"
 	"os"
+	"runtime/debug"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 
-	"golang.org/x/tools/imports"
+	"github.com/cheekybits/genny/diag"
 )
 
 var header = []byte(`
@@ -25,14 +30,19 @@ var header = []byte(`
 `)
 
 var (
-	packageKeyword = []byte("package")
-	importKeyword  = []byte("import")
-	openBrace      = []byte("(")
-	closeBrace     = []byte(")")
-	genericPackage = "generic"
-	genericType    = "generic.Type"
-	genericNumber  = "generic.Number"
-	linefeed       = "\r\n"
+	packageKeyword    = []byte("package")
+	importKeyword     = []byte("import")
+	openBrace         = []byte("(")
+	closeBrace        = []byte(")")
+	genericPackage    = "generic"
+	genericType       = "generic.Type"
+	genericNumber     = "generic.Number"
+	genericInteger    = "generic.Integer"
+	genericUnsigned   = "generic.Unsigned"
+	genericFloat      = "generic.Float"
+	genericIface      = "generic.Interface"
+	genericComparable = "generic.Comparable"
+	linefeed          = "\r\n"
 )
 var unwantedLinePrefixes = [][]byte{
 	[]byte("//go:generate genny "),
@@ -40,7 +50,7 @@ var unwantedLinePrefixes = [][]byte{
 
 func subIntoLiteral(lit, typeTemplate, specificType string) string {
 	if lit == typeTemplate {
-		return specificType
+		return specificTypeOf(specificType)
 	}
 	if !strings.Contains(lit, typeTemplate) {
 		return lit
@@ -54,51 +64,175 @@ func subIntoLiteral(lit, typeTemplate, specificType string) string {
 	return result
 }
 
+// subTypeIntoComment substitutes the type into each word of the comment,
+// keeping the spacing between the words.
 func subTypeIntoComment(line, typeTemplate, specificType string) string {
-	var subbed string
-	for _, w := range strings.Fields(line) {
-		subbed = subbed + subIntoLiteral(w, typeTemplate, specificType) + " "
+	var subbed strings.Builder
+	for len(line) > 0 {
+		space := strings.IndexFunc(line, unicode.IsSpace)
+		if space < 0 {
+			space = len(line)
+		}
+		subbed.WriteString(subIntoLiteral(line[:space], typeTemplate, specificType))
+		line = line[space:]
+		word := strings.IndexFunc(line, func(r rune) bool { return !unicode.IsSpace(r) })
+		if word < 0 {
+			word = len(line)
+		}
+		subbed.WriteString(line[:word])
+		line = line[word:]
 	}
-	return subbed
+	return subbed.String()
 }
 
 // Does the heavy lifting of taking a line of our code and
-// sbustituting a type into there for our generic type
+// sbustituting a type into there for our generic type.
+// Only the identifiers, literals and comments that change are replaced, in
+// place, so the rest of the line (including its spacing and any trailing
+// comment) is kept as it was.
 func subTypeIntoLine(line, typeTemplate, specificType string) string {
+	return subTypeIntoTokens(line, typeTemplate, specificType, false, PositionCasing{}, false)
+}
+
+// subEmbeddedTypeIntoLine substitutes a generic type that the template
+// embeds into the line, like subTypeIntoLine, except that where it is used
+// as the name of the embedded field (b.Item, or ItemBox{Item: item}) the
+// field name of the specific type is substituted, e.g. Buffer for
+// *bytes.Buffer.
+func subEmbeddedTypeIntoLine(line, typeTemplate, specificType string) string {
+	return subTypeIntoTokens(line, typeTemplate, specificType, true, PositionCasing{}, false)
+}
+
+// subTypeIntoTokens substitutes the type into the identifiers, literals and
+// comments of the line, with the casing of each. If embedded is true, uses
+// of the generic type as a field name get the field name of the specific
+// type. If stringWords is true, the type is substituted into the words of
+// string literals as Options.StringWords says.
+func subTypeIntoTokens(line, typeTemplate, specificType string, embedded bool, casing PositionCasing, stringWords bool) string {
 	src := []byte(line)
 	var s scanner.Scanner
 	fset := token.NewFileSet()
 	file := fset.AddFile("", fset.Base(), len(src))
 	s.Init(file, src, nil, scanner.ScanComments)
-	output := ""
+	var toks []token.Token
+	var lits []string
+	var offsets []int
 	for {
-		_, tok, lit := s.Scan()
+		pos, tok, lit := s.Scan()
 		if tok == token.EOF {
 			break
+		}
+		toks = append(toks, tok)
+		lits = append(lits, lit)
+		offsets = append(offsets, file.Offset(pos))
+	}
+	var output strings.Builder
+	last := 0
+	for i, tok := range toks {
+		lit := lits[i]
+		var subbed string
+		if tok == token.COMMENT && casing.Comments != CasingKeep {
+			subbed = withPositionCasing(lit, typeTemplate, specificType, casing.Comments)
 		} else if tok == token.COMMENT {
-			subbed := subTypeIntoComment(lit, typeTemplate, specificType)
-			output = output + subbed + " "
+			subbed = subTypeIntoComment(lit, typeTemplate, specificType)
+		} else if tok == token.STRING && stringWords {
+			subbed = subTypeIntoWords(lit, typeTemplate, specificType, casing.Strings)
+		} else if tok == token.STRING && casing.Strings != CasingKeep {
+			subbed = withPositionCasing(lit, typeTemplate, specificType, casing.Strings)
 		} else if tok.IsLiteral() {
-			subbed := subIntoLiteral(lit, typeTemplate, specificType)
-			output = output + subbed + " "
+			subbed = subIntoLiteral(lit, typeTemplate, specificType)
+			if tok == token.IDENT && lit != typeTemplate && casing.Identifiers != CasingKeep {
+				subbed = withPositionCasing(lit, typeTemplate, specificType, casing.Identifiers)
+			}
+			if lit == typeTemplate && embedded && isFieldName(toks, i) {
+				subbed = embeddedFieldName(specificTypeOf(specificType))
+			} else if lit == typeTemplate && isConversion(toks, i) && needsParens(specificTypeOf(specificType)) {
+				subbed = "(" + subbed + ")"
+			}
 		} else {
-			output = output + tok.String() + " "
+			continue
+		}
+		if subbed == lit {
+			continue
+		}
+		output.WriteString(line[last:offsets[i]])
+		output.WriteString(subbed)
+		last = offsets[i] + len(lit)
+	}
+	output.WriteString(line[last:])
+	return output.String()
+}
+
+// substitutionOrder gets the generic types of the type set in the order
+// they are substituted: longest first, so that a generic type whose name
+// contains another (ItemKey and Item) is replaced whole, then
+// alphabetically.
+func substitutionOrder(typeSet map[string]string) []string {
+	var generics []string
+	for t := range typeSet {
+		generics = append(generics, t)
+	}
+	sort.Slice(generics, func(i, j int) bool {
+		if len(generics[i]) != len(generics[j]) {
+			return len(generics[i]) > len(generics[j])
+		}
+		return generics[i] < generics[j]
+	})
+	return generics
+}
+
+// isConversion gets whether the identifier at position i is being called,
+// which for a type name means it is a conversion.
+func isConversion(toks []token.Token, i int) bool {
+	if i+1 >= len(toks) || toks[i+1] != token.LPAREN {
+		return false
+	}
+	return i == 0 || (toks[i-1] != token.PERIOD && toks[i-1] != token.FUNC)
+}
+
+// needsParens gets whether the specific type must be wrapped in parentheses
+// when it is used in a conversion, e.g. (*T)(v) or (func())(v).
+func needsParens(specificType string) bool {
+	for _, prefix := range []string{"*", "<-", "func", "chan"} {
+		if strings.HasPrefix(specificType, prefix) {
+			return true
 		}
 	}
-	return output
+	return false
 }
 
 // typeSet looks like "KeyType: int, ValueType: string"
-func generateSpecific(filename string, in io.ReadSeeker, typeSet map[string]string) ([]byte, error) {
+func generateSpecific(filename string, in io.ReadSeeker, typeSet map[string]string, index, count int, opts Options, span Span) ([]byte, []int, error) {
 
-	// ensure we are at the beginning of the file
-	in.Seek(0, os.SEEK_SET)
+	attrs := map[string]string{"genny.typeset": typeSetString(typeSet)}
 
-	// parse the source file
-	fs := token.NewFileSet()
-	file, err := parser.ParseFile(fs, filename, in, 0)
+	parseSpan := span.StartSpan(SpanParse, attrs)
+	fs, file, err := parseTemplate(filename, in, specificTypes(typeSet), opts.Cache)
+	if err == nil && (opts.Strict || opts.Warnings != nil) {
+		err = strictError(checkParams(fs, file, specificTypes(typeSet)), opts)
+	}
+	parseSpan.End(err)
 	if err != nil {
-		return nil, &errSource{Err: err}
+		return nil, nil, err
+	}
+
+	substituteSpan := span.StartSpan(SpanSubstitute, attrs)
+	substitute := substitute
+	if opts.Engine == EngineAST {
+		substitute = substituteAST
+	}
+	output, lines, err := substitute(filename, in, fs, file, typeSet, index, count, opts)
+	substituteSpan.End(err)
+	return output, lines, err
+}
+
+// parseTemplate parses the template and checks that the type set can be
+// used with it.
+func parseTemplate(filename string, in io.ReadSeeker, typeSet map[string]string, cache *Cache) (*token.FileSet, *ast.File, error) {
+
+	fs, file, err := parseSource(filename, in, cache)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// make sure every generic.Type is represented in the types
@@ -116,7 +250,7 @@ func generateSpecific(filename string, in io.ReadSeeker, typeSet map[string]stri
 					if name, ok := tt.X.(*ast.Ident); ok {
 						if name.Name == genericPackage {
 							if _, ok := typeSet[ts.Name.Name]; !ok {
-								return nil, &errMissingSpecificType{GenericType: ts.Name.Name}
+								return nil, nil, &errMissingSpecificType{GenericType: ts.Name.Name}
 							}
 						}
 					}
@@ -125,74 +259,505 @@ func generateSpecific(filename string, in io.ReadSeeker, typeSet map[string]stri
 		}
 	}
 
+	if err := checkParamNames(fs, file, in); err != nil {
+		return nil, nil, err
+	}
+	if err := checkEmbedded(fs, file, typeSet); err != nil {
+		return nil, nil, err
+	}
+	if err := checkAssertions(fs, file, typeSet); err != nil {
+		return nil, nil, err
+	}
+	if err := checkInterfaceParams(fs, file, in, typeSet); err != nil {
+		return nil, nil, err
+	}
+	if err := checkNumericParams(fs, file, typeSet); err != nil {
+		return nil, nil, err
+	}
+	if err := checkComparableParams(fs, file, typeSet); err != nil {
+		return nil, nil, err
+	}
+	return fs, file, nil
+}
+
+// parseSource parses the template, using the cache if there is one.
+func parseSource(filename string, in io.ReadSeeker, cache *Cache) (*token.FileSet, *ast.File, error) {
+
+	// ensure we are at the beginning of the file
 	in.Seek(0, os.SEEK_SET)
 
+	parse := func(src interface{}) func() (*token.FileSet, *ast.File, error) {
+		return func() (*token.FileSet, *ast.File, error) {
+			fs := token.NewFileSet()
+			file, err := parser.ParseFile(fs, filename, src, 0)
+			if err != nil {
+				return nil, nil, &errSource{Err: err}
+			}
+			return fs, file, nil
+		}
+	}
+	if cache == nil {
+		return parse(in)()
+	}
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, nil, &errSource{Err: err}
+	}
+	return cache.parse(filename, src, parse(src))
+}
+
+// substitute generates the specific code for the type set, the index'th of
+// count, from the parsed template. lines holds the template line number
+// each output line came from, or 0 for lines genny added.
+func substitute(filename string, in io.ReadSeeker, fs *token.FileSet, file *ast.File, typeSet map[string]string, index, count int, opts Options) (output []byte, lines []int, err error) {
+
+	// typeSet keeps the names given to the types, for substituting them
+	// into identifiers, and types is what the checks look at
+	types := specificTypes(typeSet)
+	promoted := embeddedGenerics(file, types)
+	shared := sharedLines(fs, file, types)
+	memo := newLineMemo(memoSize)
+	generics := substitutionOrder(typeSet)
+
+	in.Seek(0, os.SEEK_SET)
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, nil, &errSource{Err: err}
+	}
+	// lines with the generic types only in other forms are substituted
+	// into too when they are substituted into the words of strings or
+	// struct tags
+	forms := map[string][]string{}
+	words := generics
+	if opts.StringWords || opts.StructTags != nil {
+		words = nil
+		for _, t := range generics {
+			words = append(words, wordForms(t)...)
+			if opts.StringWords {
+				forms[t] = wordForms(t)
+			}
+		}
+	}
+	static := staticLines(src, words, opts.Todos != TodoKeep)
+
 	var buf bytes.Buffer
 
-	comment := ""
-	scanner := bufio.NewScanner(in)
-	for scanner.Scan() {
+	// n and line are kept outside the loop so that a panic can be
+	// reported with the line being generated
+	var (
+		n    int
+		line string
+	)
+	defer func() {
+		if r := recover(); r != nil {
+			err = &errInternal{Value: r, Stack: string(debug.Stack()), TypeSet: typeSetString(typeSet), Line: n, Text: line}
+		}
+	}()
 
-		line := scanner.Text()
+	subTypes := func(line string) string {
+		for _, t := range generics {
+			if !strings.Contains(line, t) && !containsAny(line, forms[t]) {
+				continue
+			}
+			line = subTypeIntoTokens(line, t, typeSet[t], promoted[t], casingFor(opts.Casings, t), opts.StringWords)
+		}
+		return line
+	}
+	blocks := blockComments(src)
+
+	// the comment lines before a declaration are kept back until the
+	// declaration is, since they are dropped with it
+	var comment []string
+	var commentLines []int
+	writeComment := func() {
+		for i, c := range comment {
+			buf.WriteString(makeLine(c))
+			lines = append(lines, commentLines[i])
+		}
+		comment, commentLines = nil, nil
+	}
+
+	strippingTodo := false
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for n = 1; scanner.Scan(); n++ {
+		bc, inBlock := blocks[n]
+
+		// declarations shared by all the type sets are only generated once
+		if index > 0 && shared[n] {
+			comment, commentLines = nil, nil
+			continue
+		}
+
+		// lines without placeholders are copied as they are
+		if static.has(n) && !inBlock {
+			strippingTodo = false
+			writeComment()
+			buf.Write(bytes.TrimRight(scanner.Bytes(), linefeed))
+			buf.WriteByte('\n')
+			lines = append(lines, n)
+			continue
+		}
+
+		line = scanner.Text()
 
 		// does this line contain generic.Type?
-		if strings.Contains(line, genericType) || strings.Contains(line, genericNumber) {
-			comment = ""
+		if declaresGeneric(line) && !inBlock {
+			comment, commentLines = nil, nil
+			continue
+		}
+
+		// the lines of a block comment are not comments on their own, so
+		// only the code around the comment is scanned
+		if inBlock {
+			strippingTodo = false
+			before, text, after := bc.in(line)
+			line = subIndexIntoLine(subTypes(before), index, count) + subTypesIntoComment(text, generics, typeSet, opts.Casings) + subIndexIntoLine(subTypes(after), index, count)
+			if opts.Todos == TodoTag {
+				line = tagTodo(line, typeSetString(typeSet))
+			}
+			if bc.doc {
+				comment, commentLines = append(comment, line), append(commentLines, n)
+				continue
+			}
+			writeComment()
+			buf.WriteString(makeLine(line))
+			lines = append(lines, n)
 			continue
 		}
 
-		for t, specificType := range typeSet {
-			if strings.Contains(line, t) {
-				newLine := subTypeIntoLine(line, t, specificType)
-				line = newLine
+		if opts.Todos == TodoStrip {
+			// a stripped TODO takes the rest of its comment block with it
+			if strippingTodo && isCommentLine(line) {
+				continue
+			}
+			strippingTodo = false
+			var keep bool
+			if line, keep = stripTodo(line); !keep {
+				strippingTodo = true
+				continue
+			}
+		}
+
+		if subbed, ok := memo.get(line); ok {
+			line = subbed
+		} else {
+			original := line
+			if before, tag, after, ok := splitStructTag(line); ok && opts.StructTags != nil {
+				line = subTypes(before) + subTypesIntoStructTag(tag, generics, typeSet, opts.StructTags) + subTypes(after)
+			} else {
+				line = subTypes(line)
 			}
+			line = subIndexIntoLine(line, index, count)
+			memo.put(original, line)
 		}
 
-		if comment != "" {
-			buf.WriteString(makeLine(comment))
-			comment = ""
+		if opts.Todos == TodoTag {
+			line = tagTodo(line, typeSetString(typeSet))
 		}
 
 		// is this line a comment?
-		// TODO: should we handle /* */ comments?
 		if strings.HasPrefix(line, "//") {
 			// record this line to print later
-			comment = line
+			comment, commentLines = append(comment, line), append(commentLines, n)
 			continue
 		}
 
 		// write the line
+		writeComment()
 		buf.WriteString(makeLine(line))
+		lines = append(lines, n)
 	}
+	writeComment()
+
+	n = 0
 
 	// write it out
-	return buf.Bytes(), nil
+	return checkSpecific(filename, fs, file, typeSet, buf.Bytes(), lines, opts)
+}
+
+// checkSpecific checks the specific code generated from the parsed template
+// for the type set, and adds the interfaces of opts.Interfaces and
+// opts.Fakes to it, with their lines coming from no template line (0).
+func checkSpecific(filename string, fs *token.FileSet, file *ast.File, typeSet map[string]string, output []byte, lines []int, opts Options) ([]byte, []int, error) {
+
+	// syntax errors are left for goimports to report
+	generatedFs := token.NewFileSet()
+	generated, err := parser.ParseFile(generatedFs, filename, output, 0)
+	if err != nil {
+		return output, lines, nil
+	}
+	if err := checkEmbeddedRenames(embeddedTypeNames(file, specificTypes(typeSet)), typeSet, generated); err != nil {
+		return nil, nil, err
+	}
+	if err := checkReceivers(fs, file, typeSet, generated); err != nil {
+		return nil, nil, err
+	}
+	var names map[string]string
+	if opts.NameTemplate != "" {
+		if names, err = templateNames(file, typeSet, opts); err != nil {
+			return nil, nil, err
+		}
+		output = renameWords(output, names)
+		if generated, err = parser.ParseFile(generatedFs, filename, output, 0); err != nil {
+			return output, lines, nil
+		}
+	}
+	if opts.Interfaces || opts.Fakes {
+		for _, line := range strings.SplitAfter(interfaceDecls(file, typeSet, names, generatedFs, generated, opts.Fakes), "\n") {
+			if line != "" {
+				output = append(output, line...)
+				lines = append(lines, 0)
+			}
+		}
+	}
+	return output, lines, nil
 }
 
 // Generics parses the source file and generates the bytes replacing the
-// generic types for the keys map with the specific types (its value).
-func Generics(filename, outputFilename, pkgName string, in io.ReadSeeker, typeSets []map[string]string) ([]byte, error) {
+// generic types for the keys map with the specific types (its value). The
+// source is read into memory unless it is an io.ReadSeeker, so it may be
+// any reader, such as stdin or a network stream.
+func Generics(filename, outputFilename, pkgName string, in io.Reader, typeSets []map[string]string) ([]byte, error) {
+	return GenericsWithOptions(filename, outputFilename, pkgName, in, typeSets, Options{})
+}
 
-	totalOutput := header
+// GenericsWithOptions is like Generics but allows the optional behaviour
+// to be controlled with opts.
+func GenericsWithOptions(filename, outputFilename, pkgName string, r io.Reader, typeSets []map[string]string, opts Options) ([]byte, error) {
+	in, err := seekable(r)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Cache == nil {
+		return generics(filename, outputFilename, pkgName, in, typeSets, opts)
+	}
+	key, ok := resultKeyOf(filename, outputFilename, pkgName, in, typeSets, opts)
+	if !ok {
+		return generics(filename, outputFilename, pkgName, in, typeSets, opts)
+	}
+	if output, ok := opts.Cache.result(key, opts); ok {
+		return output, nil
+	}
+	before := 0
+	if opts.Warnings != nil {
+		before = len(*opts.Warnings)
+	}
+	output, err := generics(filename, outputFilename, pkgName, in, typeSets, opts)
+	if err == nil {
+		var warnings diag.List
+		if opts.Warnings != nil {
+			warnings = (*opts.Warnings)[before:]
+		}
+		opts.Cache.putResult(key, output, warnings)
+	}
+	return output, err
+}
 
-	for _, typeSet := range typeSets {
+// generics generates the code, without looking for a result kept by
+// opts.Cache.
+func generics(filename, outputFilename, pkgName string, in io.ReadSeeker, typeSets []map[string]string, opts Options) (output []byte, err error) {
+
+	span := startSpan(opts.Tracer, SpanGenerate, map[string]string{
+		"genny.filename": filename,
+		"genny.output":   outputFilename,
+		"genny.typesets": strconv.Itoa(len(typeSets)),
+	})
+	defer func() { span.End(err) }()
+
+	// panics are bugs in genny, but must not take down the programs that
+	// embed it
+	defer func() {
+		if r := recover(); r != nil {
+			err = &errInternal{Value: r, Stack: string(debug.Stack())}
+		}
+		if e, ok := err.(*errInternal); ok {
+			output = nil
+			e.Filename = filename
+			if opts.CrashReport != "" {
+				e.ReportErr = writeCrashReport(opts.CrashReport, e, in, typeSets)
+			}
+		}
+	}()
 
-		// generate the specifics
-		parsed, err := generateSpecific(filename, in, typeSet)
+	if in, typeSets, err = readInput(filename, in, typeSets, opts); err != nil {
+		return nil, err
+	}
+	if opts.Mode == ModeTypeParams {
+		if output, err = typeParams(filename, pkgName, in, opts); err != nil {
+			return nil, err
+		}
+		if opts.Unformatted {
+			return output, nil
+		}
+		formatSpan := span.StartSpan(SpanFormat, nil)
+		output, err = formatWithResolver(outputFilename, output, opts)
+		formatSpan.End(err)
 		if err != nil {
 			return nil, err
 		}
+		if err := verifyOutput(nil, outputFilename, output, nil, nil, nil, opts, span); err != nil {
+			return nil, err
+		}
+		return output, nil
+	}
+	if (opts.TypeCheck || opts.Vet) && opts.Unformatted {
+		return nil, &errBadOption{Option: "verify", Value: "true", Message: "the code can only be verified once it is formatted"}
+	}
+	if opts.LineDirectives && opts.Unformatted {
+		return nil, &errBadOption{Option: "line-directives", Value: "true", Message: "the lines are only known once the code is formatted"}
+	}
+	if opts.Script {
+		if pkgName, err = scriptPackage(pkgName); err != nil {
+			return nil, err
+		}
+		if opts.Constraint != "" {
+			return nil, &errBadOption{Option: "constraint", Value: opts.Constraint, Message: "scripts are only built when named on the command line"}
+		}
+	}
+	var test *testPackage
+	if opts.TestPackage {
+		if opts.Script {
+			return nil, &errTestPackage{Message: "a script is not a test"}
+		}
+		if test, err = findTestPackage(outputFilename, pkgName, in); err != nil {
+			return nil, err
+		}
+		typeSets, pkgName = testTypeSets(typeSets, test), test.Name+testSuffix
+	}
+	// problems and line directives are reported at the lines of the
+	// template, which are in its files if it joins a template package
+	at, err := readTemplateLines(filename, in)
+	if err != nil {
+		return nil, err
+	}
+	output, origins, err := generate(filename, pkgName, in, typeSets, opts, span)
+	if err != nil {
+		return nil, err
+	}
+	unformatted := output
+	if test != nil {
+		output = importTestPackage(output, test)
+	}
+	if opts.Script {
+		output = makeScript(output)
+	}
+	if output, err = addDirectives(outputFilename, output, opts.Directives); err != nil {
+		return nil, err
+	}
+	if opts.Unformatted {
+		return output, nil
+	}
 
-		totalOutput = append(totalOutput, parsed...)
+	// fix the imports
+	formatSpan := span.StartSpan(SpanFormat, nil)
+	output, err = formatWithResolver(outputFilename, output, opts)
+	formatSpan.End(err)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyOutput(at, outputFilename, output, unformatted, origins, typeSets, opts, span); err != nil {
+		return nil, err
+	}
+	if opts.LineDirectives {
+		output = addLineDirectives(at, outputFilename, output, unformatted, origins)
+	}
+	return output, nil
+}
 
+// origin is where a line of unformatted output came from: the index of
+// its type set and its line number in the template. Header lines have no
+// type set.
+type origin struct {
+	TypeSet int
+	Line    int
+}
+
+// generate generates the unformatted code for all the type sets, along
+// with the origin of each of its lines.
+func generate(filename, pkgName string, in io.ReadSeeker, typeSets []map[string]string, opts Options, span Span) ([]byte, []origin, error) {
+
+	// specific types given with the import paths of their packages are
+	// generated with the names of their packages, which are imported
+	typeSets, imports, err := importSpecificTypes(typeSets)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(imports) > 0 {
+		if err := checkImportNames(filename, in, imports, opts.Cache); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := validate(typeSets, opts.Validators); err != nil {
+		return nil, nil, err
+	}
+	if err := checkCasings(opts.Casings); err != nil {
+		return nil, nil, err
+	}
+	if opts.NameTemplate != "" {
+		if _, err := parseNameTemplate(opts.NameTemplate); err != nil {
+			return nil, nil, err
+		}
+	}
+	if opts.Warnings != nil {
+		// syntax errors are reported when the template is generated
+		if fs, file, err := parseSource(filename, in, opts.Cache); err == nil {
+			addWarnings(checkLiterals(fs, file), opts)
+		}
+		in.Seek(0, os.SEEK_SET)
+	}
+
+	if opts.RequireDocs {
+		if err := checkParamDocs(filename, in); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	totalOutput, err := fileHeader(filename, in, typeSets, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	var origins []origin
+	for range bytes.Split(totalOutput[:len(totalOutput)-1], []byte("\n")) {
+		origins = append(origins, origin{TypeSet: -1})
+	}
+
+	// each scope of the template is generated with its own type sets,
+	// after the rest of the template
+	groups, err := scopeGroups(filename, in, typeSets)
+	if err != nil {
+		return nil, nil, err
+	}
+	specifics, err := generateSpecifics(filename, groups, opts, span)
+	if err != nil {
+		return nil, nil, err
+	}
+	var generated []map[string]string
+	for _, s := range specifics {
+		totalOutput = append(totalOutput, s.output...)
+		for _, line := range s.lines {
+			origins = append(origins, origin{TypeSet: len(generated), Line: line})
+		}
+		generated = append(generated, s.typeSet)
 	}
 
 	// clean up the code line by line
 	packageFound := false
 	insideImportBlock := false
 	var cleanOutputLines []string
+	var cleanOrigins []origin
+	// the template's build constraints go at the top of the file, above the
+	// header, and what comes before the package clause is kept only once,
+	// not once per type set
+	var constraintLines []string
+	var constraintOrigins []origin
+	inPrelude := false
+	lastTypeSet := -1
 	scanner := bufio.NewScanner(bytes.NewReader(totalOutput))
-	for scanner.Scan() {
+	for n := 0; scanner.Scan(); n++ {
+		if o := origins[n]; o.TypeSet != lastTypeSet {
+			inPrelude, lastTypeSet = o.TypeSet >= 0, o.TypeSet
+		}
 
 		// end of imports block?
 		if insideImportBlock {
@@ -202,7 +767,14 @@ func Generics(filename, outputFilename, pkgName string, in io.ReadSeeker, typeSe
 			continue
 		}
 
+		// the //line directives of a joined template place its lines in
+		// the files, not the code generated from them
+		if isLineDirective(scanner.Text()) {
+			continue
+		}
+
 		if bytes.HasPrefix(scanner.Bytes(), packageKeyword) {
+			inPrelude = false
 			if packageFound {
 				continue
 			} else {
@@ -228,39 +800,111 @@ func Generics(filename, outputFilename, pkgName string, in io.ReadSeeker, typeSe
 			continue
 		}
 
+		// metadata directives describe the template, not the generated code
+		if text := strings.TrimSpace(scanner.Text()); strings.HasPrefix(text, metadataPrefix) && !strings.HasPrefix(text, sectionDirective) {
+			continue
+		}
+
+		if inPrelude {
+			if packageFound {
+				continue
+			}
+			if text := strings.TrimSpace(scanner.Text()); constraint.IsGoBuild(text) || constraint.IsPlusBuild(text) {
+				constraintLines = append(constraintLines, makeLine(text))
+				constraintOrigins = append(constraintOrigins, origins[n])
+				continue
+			}
+		}
+
 		cleanOutputLines = append(cleanOutputLines, makeLine(scanner.Text()))
+		cleanOrigins = append(cleanOrigins, origins[n])
+	}
+
+	cleanOutputLines, cleanOrigins = addImportLines(cleanOutputLines, cleanOrigins, imports)
+
+	if len(constraintLines) > 0 {
+		constraintLines = append(constraintLines, "\n")
+		constraintOrigins = append(constraintOrigins, origin{TypeSet: -1})
+		cleanOutputLines = append(constraintLines, cleanOutputLines...)
+		cleanOrigins = append(constraintOrigins, cleanOrigins...)
+	}
+
+	if opts.Constraint != "" {
+		expr, err := ParseConstraint(opts.Constraint)
+		if err != nil {
+			return nil, nil, err
+		}
+		if cleanOutputLines, cleanOrigins, err = addConstraint(cleanOutputLines, cleanOrigins, expr); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	cleanOutput := strings.Join(cleanOutputLines, "")
 
 	output := []byte(cleanOutput)
-	var err error
+
+	if opts.Strict || opts.Warnings != nil {
+		var problems diag.List
+		problems.Add(checkCollisions(filename, output, cleanOrigins, generated), diag.Error)
+		if err := strictError(problems, opts); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	// change package name
 	if pkgName != "" {
 		output = changePackage(bytes.NewReader([]byte(output)), pkgName)
 	}
-	// fix the imports
-	output, err = imports.Process(outputFilename, output, nil)
-	if err != nil {
-		return nil, &errImports{Err: err}
-	}
 
-	return output, nil
+	return output, cleanOrigins, nil
+}
+
+// declaresGeneric gets whether the line declares a generic type, as a
+// generic.Type, generic.Number, generic.Interface or one of the other
+// genericKinds.
+func declaresGeneric(line string) bool {
+	for _, kind := range genericKinds {
+		if strings.Contains(line, kind) {
+			return true
+		}
+	}
+	return false
 }
 
 func makeLine(s string) string {
 	return fmt.Sprintln(strings.TrimRight(s, linefeed))
 }
 
+// containsAny gets whether s contains any of the substrings.
+func containsAny(s string, substrs []string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
+
 // isAlphaNumeric gets whether the rune is alphanumeric or _.
 func isAlphaNumeric(r rune) bool {
 	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
 }
 
 // wordify turns a type into a nice word for function and type
-// names etc.
+// names etc., or gets the name it was given with a transformation.
 func wordify(s string, exported bool) string {
+	if _, name := splitTransforms(s); name != "" {
+		if exported {
+			return withCasing(name, CasingUpper)
+		}
+		return lowerFirst(name)
+	}
+	if word, ok := compositeWord(s); ok {
+		if exported {
+			return word
+		}
+		return lowerFirst(word)
+	}
 	s = strings.TrimRight(s, "{}")
 	s = strings.TrimLeft(s, "*&")
 	s = strings.Replace(s, ".", "", -1)
@@ -1,6 +1,9 @@
 package parse
 
 import (
+	"go/build/constraint"
+	"go/token"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,8 +31,134 @@ func TestWordify(t *testing.T) {
 		"interface{}": "Interface",
 		"pack.type":   "Packtype",
 		"*pack.type":  "Packtype",
+
+		"[]byte":                   "ByteSlice",
+		"*[]byte":                  "ByteSlice",
+		"[16]byte":                 "Byte16Array",
+		"[]*MyType":                "MyTypeSlice",
+		"map[string]int":           "StringIntMap",
+		"map[string][]time.Time":   "StringTimeTimeSliceMap",
+		"chan error":               "ErrorChan",
+		"<-chan int":               "IntRecvChan",
+		"chan<- int":               "IntSendChan",
+		"func() error":             "ErrorFunc",
+		"func(int) bool":           "IntToBoolFunc",
+		"func(a, b string)":        "StringStringFunc",
+		"func(...int) (int, bool)": "IntSliceToIntBoolFunc",
+		"func()":                   "Func",
+		"struct{ X int }":          "Struct",
+		"List[int]":                "ListInt",
+		"Pair[string, int]":        "PairStringInt",
+
+		"[]github.com/google/uuid.UUID":                      "UUIDSlice",
+		"map[string]github.com/google/uuid.UUID":             "StringUUIDMap",
+		"map[gopkg.in/yaml.v2.Kind][]*gopkg.in/yaml.v2.Node": "KindNodeSliceMap",
+
+		"time.Time|name=Timestamp": "Timestamp",
+		"*pack.type|name=thing":    "Thing",
 	} {
 		assert.Equal(t, wordified, wordify(word, true))
 	}
+	assert.Equal(t, "byteSlice", wordify("[]byte", false))
+	assert.Equal(t, "MyType", wordify("*MyType", false))
+
+}
+
+func TestSubTypeIntoLineNames(t *testing.T) {
+
+	for line, expected := range map[string]string{
+		"func NewItemQueue() *ItemQueue": "func NewTimestampQueue() *TimestampQueue",
+		"var item Item":                  "var item time.Time",
+		"lastItem := Item{}":             "lastTimestamp := time.Time{}",
+		`return "Item: " + Item(v)`:      `return "Timestamp: " + time.Time(v)`,
+		"// Item is an ItemQueue item":   "// time.Time is an TimestampQueue item",
+	} {
+		assert.Equal(t, expected, subTypeIntoLine(line, "Item", "time.Time|name=Timestamp"))
+	}
+	assert.Equal(t, `"time.Time"`, withPositionCasing(`"Item"`, "Item", "time.Time|name=Timestamp", CasingVerbatim))
+	assert.Equal(t, "timestamp", withPositionCasing("Item", "Item", "time.Time|name=Timestamp", CasingLower))
+
+}
+
+func TestSubTypeIntoLineConversions(t *testing.T) {
+
+	for specificType, expected := range map[string]string{
+		"int":          "return int(v)",
+		"*MyType":      "return (*MyType)(v)",
+		"func() error": "return (func() error)(v)",
+		"chan error":   "return (chan error)(v)",
+	} {
+		assert.Equal(t, expected, subTypeIntoLine("return Item(v)", "Item", specificType))
+	}
+
+}
+
+func TestSubTypeIntoLineKeepsTrailingComments(t *testing.T) {
+
+	for line, expected := range map[string]string{
+		"x := 0 // ItemType count":                     "x := 0 // IntType count",
+		"\titems []Item   // the Items,  oldest first": "\titems []int   // the Ints,  oldest first",
+		"var n Item /* an Item */ = 1":                 "var n int /* an int */ = 1",
+		"if ok { // no Item here":                      "if ok { // no int here",
+	} {
+		assert.Equal(t, expected, subTypeIntoLine(line, "Item", "int"))
+	}
+
+}
+
+func TestSubIndexIntoLine(t *testing.T) {
+	for _, test := range []struct{ line, expected string }{
+		{"var all [generic.Count]Item", "var all [3]Item"},
+		{"\tall[generic.Index] = x // generic.Index", "\tall[1] = x // generic.Index"},
+		{`s := "generic.Count"`, `s := "generic.Count"`},
+		{"x := other.generic.Index", "x := other.generic.Index"},
+		{"var t generic.Type", "var t generic.Type"},
+	} {
+		assert.Equal(t, test.expected, subIndexIntoLine(test.line, 1, 3), test.line)
+	}
+}
+
+func TestSectionFilename(t *testing.T) {
+	for _, test := range []struct{ expr, expected string }{
+		{"linux", "gen_linux.go"},
+		{"!windows", "gen_not_windows_build.go"},
+		{"linux && amd64", "gen_linux_and_amd64_build.go"},
+		{"linux || darwin", "gen_linux_or_darwin_build.go"},
+		{"cgo", "gen_cgo.go"},
+		{"(go1.18 && !purego)", "gen_go1_18_and_not_purego.go"},
+	} {
+		expr, err := constraint.Parse("//go:build " + test.expr)
+		if assert.NoError(t, err) {
+			assert.Equal(t, test.expected, sectionFilename("gen.go", expr), test.expr)
+		}
+	}
+	expr, _ := constraint.Parse("//go:build linux")
+	assert.Equal(t, filepath.Join("dir", "gen_linux_test.go"), sectionFilename(filepath.Join("dir", "gen_test.go"), expr))
+}
+
+func TestQualifySpecific(t *testing.T) {
+	for specific, qualified := range map[string]string{
+		"Thing":                "queue.Thing|name=Thing",
+		"*Thing":               "*queue.Thing|name=Thing",
+		"Thing|name=Entry":     "queue.Thing|name=Entry",
+		"map[string]Thing":     "map[string]queue.Thing|name=StringThingMap",
+		"func(Name Thing) int": "func(Name queue.Thing) int|name=ThingToIntFunc",
+		"int":                  "int",
+		"error":                "error",
+		"time.Time":            "time.Time",
+		"thing":                "thing",
+	} {
+		assert.Equal(t, qualified, qualifySpecific(specific, "queue"), specific)
+	}
+}
+
+func TestParamName(t *testing.T) {
+
+	assert.Equal(t, "K", paramName("TypeParam01K"))
+	assert.Equal(t, "Value", paramName("TypeParam12Value"))
+	assert.Equal(t, "TypeParam", paramName("TypeParam"))
+	assert.Equal(t, "TypeParamK", paramName("TypeParamK"))
+	assert.Equal(t, "KeyType", paramName("KeyType"))
+	assert.Equal(t, "p.go:3:11: generic type 'K' is declared but never used", errUnusedPlaceholder{GenericType: "TypeParam01K", Pos: token.Position{Filename: "p.go", Line: 3, Column: 11}}.Error())
 
 }
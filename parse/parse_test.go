@@ -5,6 +5,7 @@ import (
 	"log"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/cheekybits/genny/parse"
 	"github.com/stretchr/testify/assert"
@@ -17,6 +18,7 @@ var tests = []struct {
 	pkgName        string
 	in             string
 	types          []map[string]string
+	opts           parse.Options
 
 	// expectations
 	expectedOut string
@@ -41,6 +43,13 @@ var tests = []struct {
 		types:       []map[string]string{{"Something": "float32"}},
 		expectedOut: `test/queue/float32_queue.go`,
 	},
+	{
+		filename:    "generic_queue.go",
+		in:          `test/queue/generic_queue.go`,
+		types:       []map[string]string{{"Something": "int"}},
+		opts:        parse.Options{Script: true},
+		expectedOut: `test/queue/script_int_queue.go`,
+	},
 	{
 		filename:    "generic_simplemap.go",
 		in:          `test/multipletypes/generic_simplemap.go`,
@@ -110,6 +119,114 @@ var tests = []struct {
 		types:       []map[string]string{{"SomeThing": "string"}},
 		expectedOut: `test/bugreports/negation_string.go`,
 	},
+	{
+		filename:    "generic_embedded.go",
+		in:          `test/embedded/generic_embedded.go`,
+		types:       []map[string]string{{"Item": "*Thing"}},
+		expectedOut: `test/embedded/thing_embedded.go`,
+	},
+	{
+		filename:    "generic_embedded.go",
+		in:          `test/embedded/generic_embedded.go`,
+		types:       []map[string]string{{"Item": "*bytes.Buffer"}},
+		expectedOut: `test/embedded/buffer_embedded.go`,
+	},
+	{
+		filename:    "generic_handlers.go",
+		in:          `test/ifaces/generic_handlers.go`,
+		types:       []map[string]string{{"Handler": "fmt.Stringer"}},
+		expectedOut: `test/ifaces/stringer_handlers.go`,
+	},
+	{
+		filename:    "generic_assertions.go",
+		in:          `test/assertions/generic_assertions.go`,
+		types:       []map[string]string{{"Item": "int"}},
+		expectedOut: `test/assertions/int_assertions.go`,
+	},
+	{
+		filename:    "generic_funcs.go",
+		in:          `test/funcs/generic_funcs.go`,
+		types:       []map[string]string{{"Item": "*Thing"}},
+		expectedOut: `test/funcs/thing_funcs.go`,
+	},
+	{
+		filename:    "generic_todos.go",
+		in:          `test/todos/generic_todos.go`,
+		types:       []map[string]string{{"Item": "int"}},
+		opts:        parse.Options{Todos: parse.TodoStrip},
+		expectedOut: `test/todos/int_todos.go`,
+	},
+	{
+		filename:    "generic_todos.go",
+		in:          `test/todos/generic_todos.go`,
+		types:       []map[string]string{{"Item": "string"}},
+		opts:        parse.Options{Todos: parse.TodoTag},
+		expectedOut: `test/todos/string_todos.go`,
+	},
+	{
+		filename:    "generic_counter.go",
+		in:          `test/comments/generic_counter.go`,
+		types:       []map[string]string{{"ItemType": "string"}},
+		expectedOut: `test/comments/string_counter.go`,
+	},
+	{
+		filename:    "generic_queue.go",
+		pkgName:     "interfaces",
+		in:          `test/queue/generic_queue.go`,
+		types:       []map[string]string{{"Something": "int"}},
+		opts:        parse.Options{Interfaces: true},
+		expectedOut: `test/interfaces/int_queue.go`,
+	},
+	{
+		filename:    "generic_queue.go",
+		pkgName:     "annotate",
+		in:          `test/queue/generic_queue.go`,
+		types:       []map[string]string{{"Something": "int"}, {"Something": "string"}},
+		opts:        parse.Options{Annotate: true},
+		expectedOut: `test/annotate/queues.go`,
+	},
+	{
+		filename:    "generic_record.go",
+		in:          `test/tags/generic_record.go`,
+		types:       []map[string]string{{"KeyType": "UserID"}},
+		opts:        parse.Options{StructTags: map[string]parse.Casing{"json": parse.CasingLower, "db": parse.CasingSnake}},
+		expectedOut: `test/tags/user_record.go`,
+	},
+	{
+		filename:    "generic_stack.go",
+		in:          `test/owners/generic_stack.go`,
+		types:       []map[string]string{{"Element": "int"}},
+		opts:        parse.Options{Owners: true},
+		expectedOut: `test/owners/int_stack.go`,
+	},
+	{
+		filename:    "generic_registry.go",
+		pkgName:     "holders",
+		in:          `test/registry/generic_registry.go`,
+		types:       []map[string]string{{"Item": "int"}, {"Item": "string"}},
+		expectedOut: `test/registry/holders/holders.go`,
+	},
+	{
+		filename:    "generic_store.go",
+		in:          `test/fakes/generic_store.go`,
+		types:       []map[string]string{{"Item": "string"}},
+		opts:        parse.Options{Fakes: true},
+		expectedOut: `test/fakes/string_store.go`,
+	},
+	{
+		filename:    "generic_index.go",
+		in:          `test/ordering/generic_index.go`,
+		types:       mustTypeSet("ItemKey=string,int Item=bool,float64"),
+		expectedOut: `test/ordering/indexes.go`,
+	},
+}
+
+func mustTypeSet(arg string) []map[string]string {
+	typeSets, err := parse.TypeSet(arg)
+	if err != nil {
+		panic(err)
+	}
+	return typeSets
 }
 
 func TestParse(t *testing.T) {
@@ -119,7 +236,7 @@ func TestParse(t *testing.T) {
 		test.in = contents(test.in)
 		test.expectedOut = contents(test.expectedOut)
 
-		bytes, err := parse.Generics(test.filename, test.outputFilename, test.pkgName, strings.NewReader(test.in), test.types)
+		bytes, err := parse.GenericsWithOptions(test.filename, test.outputFilename, test.pkgName, strings.NewReader(test.in), test.types, test.opts)
 
 		// check the error
 		if test.expectedErr == nil {
@@ -149,3 +266,40 @@ func contents(s string) string {
 	}
 	return s
 }
+
+func TestOutputIsStable(t *testing.T) {
+
+	in := contents("test/ordering/generic_index.go")
+	typeSets := mustTypeSet("ItemKey=string,int Item=bool,float64")
+	first, err := parse.Generics("generic_index.go", "indexes.go", "", strings.NewReader(in), typeSets)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i := 0; i < 20; i++ {
+		output, err := parse.Generics("generic_index.go", "indexes.go", "", strings.NewReader(in), typeSets)
+		if assert.NoError(t, err) {
+			assert.Equal(t, string(first), string(output))
+		}
+	}
+
+}
+
+func TestGenericsFromReader(t *testing.T) {
+
+	in := contents("test/queue/generic_queue.go")
+	typeSets := mustTypeSet("Something=int")
+	expected, err := parse.Generics("generic_queue.go", "int_queue.go", "", strings.NewReader(in), typeSets)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// a reader that cannot seek, as a pipe
+	output, err := parse.Generics("generic_queue.go", "int_queue.go", "", iotest.OneByteReader(strings.NewReader(in)), typeSets)
+	if assert.NoError(t, err) {
+		assert.Equal(t, string(expected), string(output))
+	}
+	explanation, err := parse.TemplateExplain("generic_queue.go", iotest.HalfReader(strings.NewReader(in)), typeSets, parse.Options{})
+	if assert.NoError(t, err) {
+		assert.NotEmpty(t, explanation.Lines)
+	}
+
+}
@@ -0,0 +1,105 @@
+package parse
+
+import (
+	"go/build/constraint"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// typeSetVerb is replaced by the name of each type set in the file name
+// pattern of GenericsPerTypeSet.
+const typeSetVerb = "%T"
+
+// GenericsPerTypeSet generates the template for the type sets like
+// GenericsWithOptions, but into a file for each type set rather than one,
+// so that large instantiations are not one huge file. The file of a type
+// set is named by pattern with %T replaced by its specific types in snake
+// case, e.g. stack_%T.go names stack_int.go and stack_bytes_buffer.go.
+//
+// The type sets are generated together, as they are into one file, so
+// generic.Index and generic.Count are the same, and declarations shared by
+// every type set are in the first file. The files are in the order of the
+// type sets.
+func GenericsPerTypeSet(filename, pattern, pkgName string, r io.Reader, typeSets []map[string]string, opts Options) (files []File, err error) {
+	if !strings.Contains(pattern, typeSetVerb) {
+		return nil, &errPerTypeSet{Message: "the file name pattern " + pattern + " has no " + typeSetVerb + " for the name of the type set"}
+	}
+	switch {
+	case opts.Script:
+		return nil, &errPerTypeSet{Message: "a script is one file"}
+	case opts.TestPackage:
+		return nil, &errPerTypeSet{Message: "the test package is not supported"}
+	case len(opts.Directives) > 0:
+		return nil, &errPerTypeSet{Message: "directives are not supported"}
+	case opts.TypeCheck || opts.Vet:
+		return nil, &errPerTypeSet{Message: "verification is not supported"}
+	case opts.LineDirectives:
+		return nil, &errPerTypeSet{Message: "line directives are not supported"}
+	}
+
+	span := startSpan(opts.Tracer, SpanGenerate, map[string]string{
+		"genny.filename": filename,
+		"genny.output":   pattern,
+		"genny.typesets": strconv.Itoa(len(typeSets)),
+	})
+	defer func() { span.End(err) }()
+
+	in, typeSets, err := readInput(filename, r, typeSets, opts)
+	if err != nil {
+		return nil, err
+	}
+	output, origins, err := generate(filename, pkgName, in, typeSets, opts, span)
+	if err != nil {
+		return nil, err
+	}
+
+	// the first file has everything up to the package clause; the others
+	// only the header and the file constraint
+	var prelude, otherPrelude []byte
+	bodies := make([][]byte, len(typeSets))
+	lines := strings.SplitAfter(string(output), "\n")
+	inPrelude := true
+	for i, o := range origins {
+		line := lines[i]
+		if inPrelude {
+			prelude = append(prelude, line...)
+			text := strings.TrimSpace(line)
+			if o.TypeSet < 0 || text == "" || constraint.IsGoBuild(text) || constraint.IsPlusBuild(text) || strings.HasPrefix(line, string(packageKeyword)) {
+				otherPrelude = append(otherPrelude, line...)
+			}
+			inPrelude = !strings.HasPrefix(line, string(packageKeyword))
+			continue
+		}
+		// the lines of scopes, which have their own type sets, are in the
+		// first file
+		k := o.TypeSet
+		if k < 0 || k >= len(typeSets) {
+			k = 0
+		}
+		bodies[k] = append(bodies[k], line...)
+	}
+
+	names := make(map[string]bool)
+	for k, typeSet := range typeSets {
+		name := strings.Replace(pattern, typeSetVerb, pluginName(typeSet), -1)
+		if names[name] {
+			return nil, &errPerTypeSet{Message: "type set " + typeSetString(typeSet) + " has the same file name as another, " + name}
+		}
+		names[name] = true
+		source := append(append([]byte(nil), otherPrelude...), bodies[k]...)
+		if k == 0 {
+			source = append(append([]byte(nil), prelude...), bodies[k]...)
+		}
+		if !opts.Unformatted {
+			formatSpan := span.StartSpan(SpanFormat, map[string]string{"genny.output": name})
+			source, err = formatWithResolver(name, source, opts)
+			formatSpan.End(err)
+			if err != nil {
+				return nil, err
+			}
+		}
+		files = append(files, File{Name: name, Source: source})
+	}
+	return files, nil
+}
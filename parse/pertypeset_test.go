@@ -0,0 +1,71 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const perTypeSetTemplate = `//go:build !js
+
+package stack
+
+import (
+	"fmt"
+
+	"github.com/cheekybits/genny/generic"
+)
+
+type Item generic.Type
+
+var stacks = generic.Count
+
+type ItemStack struct{ items []Item }
+
+func (s *ItemStack) String() string { return fmt.Sprint(generic.Index, s.items) }
+`
+
+func TestGenericsPerTypeSet(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int,*bytes.Buffer")
+	require.NoError(t, err)
+	files, err := parse.GenericsPerTypeSet("stack.go", "stack_%T.go", "", strings.NewReader(perTypeSetTemplate), typeSets, parse.Options{})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	assert.Equal(t, "stack_int.go", files[0].Name)
+	first := string(files[0].Source)
+	assert.True(t, strings.HasPrefix(first, "//go:build !js\n\n"), first)
+	assert.Contains(t, first, "\npackage stack\n")
+	assert.Contains(t, first, "var stacks = 2")
+	assert.Contains(t, first, "type IntStack struct{ items []int }")
+	assert.Contains(t, first, "fmt.Sprint(0, s.items)")
+	assert.NotContains(t, first, "BytesBuffer")
+
+	assert.Equal(t, "stack_bytes_buffer.go", files[1].Name)
+	second := string(files[1].Source)
+	assert.True(t, strings.HasPrefix(second, "//go:build !js\n\n"), second)
+	assert.Contains(t, second, "\npackage stack\n")
+	assert.Contains(t, second, "\"bytes\"")
+	assert.Contains(t, second, "type BytesBufferStack struct{ items []*bytes.Buffer }")
+	assert.Contains(t, second, "fmt.Sprint(1, s.items)")
+	assert.NotContains(t, second, "var stacks", "shared declarations are only in the first file")
+	assert.NotContains(t, second, "IntStack")
+}
+
+func TestGenericsPerTypeSetErrors(t *testing.T) {
+	typeSets, err := parse.TypeSet("Item=int,int")
+	require.NoError(t, err)
+	_, err = parse.GenericsPerTypeSet("stack.go", "stack.go", "", strings.NewReader(perTypeSetTemplate), typeSets, parse.Options{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "has no %T")
+	}
+	_, err = parse.GenericsPerTypeSet("stack.go", "stack_%T.go", "", strings.NewReader(perTypeSetTemplate), typeSets, parse.Options{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the same file name as another, stack_int.go")
+	}
+	_, err = parse.GenericsPerTypeSet("stack.go", "stack_%T.go", "", strings.NewReader(perTypeSetTemplate), typeSets[:1], parse.Options{Script: true})
+	assert.Error(t, err)
+}
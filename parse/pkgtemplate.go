@@ -0,0 +1,238 @@
+package parse
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cheekybits/genny/out"
+	"golang.org/x/tools/go/packages"
+)
+
+// PackageDir gets the directory of the package with the import path, found
+// with the loader's build flags and environment.
+func PackageDir(pkgPath string, loader *Loader) (string, error) {
+	if loader == nil {
+		loader = &Loader{}
+	}
+	cfg := loader.config("")
+	cfg.Mode = packages.NeedName | packages.NeedFiles
+	pkgs, err := packages.Load(cfg, pkgPath)
+	if err != nil {
+		return "", err
+	}
+	if len(pkgs) != 1 || len(pkgs[0].Errors) > 0 || len(pkgs[0].GoFiles) == 0 {
+		return "", &errTemplatePackage{Dir: pkgPath, Message: "is not a package with Go files"}
+	}
+	return filepath.Dir(pkgs[0].GoFiles[0]), nil
+}
+
+// TemplatePackage gets the Go files of the package in dir that make up a
+// template split across files (types, methods, helpers and so on): those
+// the default build context matches, other than tests and files genny
+// generated. At least one of them must declare a generic type.
+func TemplatePackage(dir string) ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	var filenames []string
+	template := false
+	for _, name := range names {
+		if ok, err := build.Default.MatchFile(dir, filepath.Base(name)); err != nil || !ok {
+			continue
+		}
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if out.IsGenerated(src) {
+			continue
+		}
+		filenames = append(filenames, name)
+		template = template || declaresGeneric(string(src))
+	}
+	if !template {
+		return nil, &errTemplatePackage{Dir: dir, Message: "has no Go files declaring generic types"}
+	}
+	return filenames, nil
+}
+
+// JoinTemplates joins the files of a template package into one template,
+// to be generated as one: the package clause of the file with the package
+// doc comment (or else the first file), with what comes before it, the
+// imports of every file, and then the rest of each file, starting with
+// that one, as it is written. The lines of each file are preceded by a
+// //line directive, so that the joined template, known by the directory
+// of the files, reports positions in the files; the directives are left
+// out of the generated code.
+func JoinTemplates(filenames []string, srcs [][]byte) ([]byte, error) {
+	fs := token.NewFileSet()
+	files := make([]*ast.File, len(srcs))
+	first := 0
+	for i, src := range srcs {
+		file, err := parser.ParseFile(fs, filenames[i], src, parser.ImportsOnly|parser.ParseComments)
+		if err != nil {
+			return nil, &errSource{Err: err}
+		}
+		if i > 0 && file.Name.Name != files[0].Name.Name {
+			return nil, &errTemplatePackage{Dir: filepath.Dir(filenames[i]), Message: "has files in more than one package (" + files[0].Name.Name + " and " + file.Name.Name + ")"}
+		}
+		files[i] = file
+		if file.Doc != nil && files[first].Doc == nil {
+			first = i
+		}
+	}
+
+	// the file with the package clause comes first
+	order := []int{first}
+	for i := range files {
+		if i != first {
+			order = append(order, i)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(joinDirective(filenames[first], 1))
+	buf.Write(srcs[first][:fs.Position(files[first].Name.End()).Offset])
+	buf.WriteString("\n\n")
+	// the imports of every file are placed at those of the first that has
+	// any
+	for _, i := range order {
+		if len(files[i].Decls) > 0 {
+			buf.WriteString(joinDirective(filenames[i], fs.Position(files[i].Decls[0].Pos()).Line))
+			break
+		}
+	}
+	buf.WriteString("import (\n")
+	seen := make(map[string]bool)
+	for _, file := range files {
+		for _, spec := range file.Imports {
+			imp := spec.Path.Value
+			if spec.Name != nil {
+				imp = spec.Name.Name + " " + imp
+			}
+			if !seen[imp] {
+				seen[imp] = true
+				buf.WriteString("\t" + imp + "\n")
+			}
+		}
+	}
+	buf.WriteString(")\n")
+	for _, i := range order {
+		file := files[i]
+		// parsed with ImportsOnly, the file has only its imports
+		rest := fs.Position(file.Name.End()).Offset
+		for _, decl := range file.Decls {
+			rest = fs.Position(decl.End()).Offset
+		}
+		// the rest of the line the imports end on, then the lines after it
+		if nl := bytes.IndexByte(srcs[i][rest:], '\n'); nl >= 0 && rest+nl+1 < len(srcs[i]) {
+			buf.Write(srcs[i][rest : rest+nl+1])
+			rest += nl + 1
+			buf.WriteString(joinDirective(filenames[i], bytes.Count(srcs[i][:rest], []byte("\n"))+1))
+		}
+		buf.Write(srcs[i][rest:])
+		if !bytes.HasSuffix(srcs[i], []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// joinDirective gets the //line directive putting the next line of a
+// joined template at the line of the file. The file is named relative to
+// the parent of its directory, which the directive is resolved against
+// for the template known by the directory.
+func joinDirective(filename string, line int) string {
+	dir := filepath.Dir(filename)
+	name := filepath.Join(filepath.Base(dir), filepath.Base(filename))
+	return lineDirectivePrefix + filepath.ToSlash(name) + ":" + strconv.Itoa(line) + ":1\n"
+}
+
+// linePositions gets the position a line of the template is reported at.
+type linePositions func(line int) token.Position
+
+// templateLines gets the position each line of the template is reported
+// at: its own, or the file and line a //line directive gives it, as in a
+// template JoinTemplates joined from the files of a package. Relative
+// files are resolved against the directory of the template, as go/parser
+// resolves them.
+func templateLines(filename string, src []byte) linePositions {
+	fs := token.NewFileSet()
+	f := fs.AddFile(filename, -1, len(src))
+	f.SetLinesForContent(src)
+	for offset := 0; offset < len(src); {
+		end := bytes.IndexByte(src[offset:], '\n')
+		if end < 0 {
+			break
+		}
+		end += offset
+		if file, line, ok := lineDirective(string(src[offset:end])); ok && end+1 < len(src) {
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(filepath.Dir(filename), file)
+			}
+			f.AddLineColumnInfo(end+1, file, line, 1)
+		}
+		offset = end + 1
+	}
+	return func(line int) token.Position {
+		if line < 1 || line > f.LineCount() {
+			return token.Position{Filename: filename, Line: line}
+		}
+		pos := f.PositionFor(f.LineStart(line), true)
+		return token.Position{Filename: pos.Filename, Line: pos.Line}
+	}
+}
+
+// readTemplateLines reads the template for templateLines, and seeks back
+// to its start.
+func readTemplateLines(filename string, in io.ReadSeeker) (linePositions, error) {
+	src, err := ioutil.ReadAll(in)
+	in.Seek(0, os.SEEK_SET)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	return templateLines(filename, src), nil
+}
+
+// isLineDirective gets whether the template line is a //line directive.
+func isLineDirective(line string) bool {
+	return strings.HasPrefix(line, lineDirectivePrefix)
+}
+
+// lineDirective gets the file and line of a //line directive, written as
+// //line file:line or //line file:line:column.
+func lineDirective(text string) (filename string, line int, ok bool) {
+	if !isLineDirective(text) {
+		return "", 0, false
+	}
+	rest := strings.TrimSpace(text[len(lineDirectivePrefix):])
+	i := strings.LastIndex(rest, ":")
+	if i < 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(rest[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	if j := strings.LastIndex(rest[:i], ":"); j >= 0 {
+		if m, err := strconv.Atoi(rest[j+1 : i]); err == nil {
+			return rest[:j], m, true
+		}
+	}
+	return rest[:i], n, true
+}
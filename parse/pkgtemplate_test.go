@@ -0,0 +1,157 @@
+package parse_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatePackage(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"types.go": `// Package queue is a queue.
+package queue
+
+import "github.com/cheekybits/genny/generic"
+
+// Item is the type of the items.
+type Item generic.Type
+
+// ItemQueue is a queue of Items.
+type ItemQueue struct {
+	items []Item
+}
+`,
+		"methods.go": `package queue
+
+import (
+	"fmt"
+	"github.com/cheekybits/genny/generic"
+)
+
+// Push adds an Item.
+func (q *ItemQueue) Push(item Item) {
+	q.items = append(q.items, item)
+}
+
+func (q *ItemQueue) String() string {
+	return fmt.Sprint(generic.Index, q.items)
+}
+`,
+		"queue_test.go": "package queue\n",
+		"bench.go":      "//go:build ignore\n\npackage main\n",
+		"gen_queue.go":  "// This file was automatically generated by genny.\npackage queue\n",
+	}
+	for name, src := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+	}
+
+	filenames, err := parse.TemplatePackage(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "methods.go"), filepath.Join(dir, "types.go")}, filenames)
+
+	var srcs [][]byte
+	for _, filename := range filenames {
+		srcs = append(srcs, []byte(files[filepath.Base(filename)]))
+	}
+	template, err := parse.JoinTemplates(filenames, srcs)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(template), "//line "+filepath.Base(dir)+"/types.go:1:1\n// Package queue is a queue.\npackage queue\n\n//line "+filepath.Base(dir)+"/types.go:4:1\nimport (\n\t\"fmt\"\n\t\"github.com/cheekybits/genny/generic\"\n)\n"))
+
+	typeSets, err := parse.TypeSet("Item=int,string")
+	require.NoError(t, err)
+	output, err := parse.Generics(dir, "", "", strings.NewReader(string(template)), typeSets)
+	require.NoError(t, err)
+	code := string(output)
+	assert.Equal(t, 1, strings.Count(code, "package queue"))
+	assert.Contains(t, code, "type IntQueue struct {\n\titems []int\n}")
+	assert.Contains(t, code, "func (q *StringQueue) Push(item string) {")
+	assert.Contains(t, code, "fmt.Sprint(1, q.items)")
+	assert.NotContains(t, code, "//line")
+}
+
+func TestTemplatePackagePositions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"types.go": `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemQueue struct {
+	items []Item
+}
+`,
+		"methods.go": `package queue
+
+// Push adds an Item.
+func (q *ItemQueue) Push(item Item) {
+	q.items = append(q.items, item)
+}
+
+func (q *ItemQueue) Len() int {
+	return len(q.items)
+}
+`,
+	}
+	for name, src := range files {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644))
+	}
+	filenames, err := parse.TemplatePackage(dir)
+	require.NoError(t, err)
+	join := func() string {
+		var srcs [][]byte
+		for _, filename := range filenames {
+			srcs = append(srcs, []byte(files[filepath.Base(filename)]))
+		}
+		template, err := parse.JoinTemplates(filenames, srcs)
+		require.NoError(t, err)
+		return string(template)
+	}
+	typeSets, err := parse.TypeSet("Item=int")
+	require.NoError(t, err)
+	outputFilename := filepath.Join(dir, "gen_queue.go")
+
+	opts := parse.Options{LineDirectives: true}
+	output, err := parse.GenericsWithOptions(dir, outputFilename, "", strings.NewReader(join()), typeSets, opts)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "//line methods.go:3\n// Push adds an Int.\nfunc (q *IntQueue) Push(item int) {")
+	assert.Contains(t, string(output), "//line types.go:7\ntype IntQueue struct {")
+
+	coverage, err := parse.TemplateCoverage(dir, strings.NewReader(join()), typeSets, parse.Options{})
+	require.NoError(t, err)
+	var lens []parse.LineCoverage
+	for _, l := range coverage.Lines {
+		if l.Text == "\treturn len(q.items)" {
+			lens = append(lens, l)
+		}
+	}
+	if assert.Len(t, lens, 1) {
+		assert.Equal(t, filepath.Join(dir, "methods.go"), lens[0].Filename)
+		assert.Equal(t, 9, lens[0].Line)
+	}
+
+	files["methods.go"] = strings.Replace(files["methods.go"], "return len(q.items)", "return len(q.items", 1)
+	_, err = parse.GenericsWithOptions(dir, outputFilename, "", strings.NewReader(join()), typeSets, parse.Options{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), filepath.Join(dir, "methods.go")+":9:")
+	}
+}
+
+func TestTemplatePackageErrors(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "plain.go"), []byte("package plain\n"), 0644))
+	_, err := parse.TemplatePackage(dir)
+	assert.Error(t, err)
+
+	_, err = parse.JoinTemplates([]string{"a.go", "b.go"}, [][]byte{[]byte("package a\n"), []byte("package b\n")})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "more than one package (a and b)")
+	}
+}
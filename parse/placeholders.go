@@ -0,0 +1,106 @@
+package parse
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Placeholder is a generic type declared outside the template, for
+// templates that do not declare their generic types themselves, such as
+// snippets other tools pipe to genny.
+type Placeholder struct {
+	Name string
+	// Kind is the kind of generic type: Type, Number, Integer, Unsigned,
+	// Float, Interface or Comparable, as in generic.Type.
+	Kind string
+}
+
+// ParsePlaceholders parses a comma separated list of placeholders, each a
+// name and, after a colon, its kind, e.g. "Item:Type,Num:Number". A
+// placeholder without a kind is a Type.
+func ParsePlaceholders(s string) ([]Placeholder, error) {
+	var placeholders []Placeholder
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, kind, ok := strings.Cut(item, ":")
+		if !ok {
+			kind = "Type"
+		}
+		if !token.IsIdentifier(name) {
+			return nil, &errBadOption{Option: "placeholders", Value: s, Message: "\"" + name + "\" is not a valid name for a generic type"}
+		}
+		if _, ok := genericKinds[kind]; !ok {
+			return nil, &errBadOption{Option: "placeholders", Value: s, Message: "unknown kind \"" + kind + "\" of " + name + " (Type, Number, Integer, Unsigned, Float, Interface or Comparable expected)"}
+		}
+		if seen[name] {
+			return nil, &errBadOption{Option: "placeholders", Value: s, Message: name + " is given more than once"}
+		}
+		seen[name] = true
+		placeholders = append(placeholders, Placeholder{Name: name, Kind: kind})
+	}
+	return placeholders, nil
+}
+
+// readInput gets the template, decoded as UTF-8, with the generic packages
+// of the options used as genny's own and its placeholders declared in it.
+// A template written with type parameters is converted to one declaring
+// generic types, with the type sets converted along with it.
+func readInput(filename string, r io.Reader, typeSets []map[string]string, opts Options) (io.ReadSeeker, []map[string]string, error) {
+	in, err := seekable(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if in, err = decodeInput(filename, in); err != nil {
+		return nil, nil, err
+	}
+	if len(opts.GenericPackages) > 0 {
+		if in, err = useGenericPackages(filename, in, opts.GenericPackages); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(opts.Placeholders) > 0 {
+		if in, err = declarePlaceholders(filename, in, opts.Placeholders); err != nil {
+			return nil, nil, err
+		}
+	}
+	return nativeTemplate(filename, in, typeSets)
+}
+
+// declarePlaceholders declares the placeholders that the template does not
+// declare itself as generic types, after the rest of the template so that
+// its line numbers are kept. The generic package need not be imported.
+func declarePlaceholders(filename string, in io.ReadSeeker, placeholders []Placeholder) (io.ReadSeeker, error) {
+	in.Seek(0, os.SEEK_SET)
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	declared := genericTypes(file)
+	var decls bytes.Buffer
+	for _, p := range placeholders {
+		if !declared[p.Name] {
+			decls.WriteString("type " + p.Name + " " + genericKinds[p.Kind] + "\n")
+		}
+	}
+	if decls.Len() == 0 {
+		in.Seek(0, os.SEEK_SET)
+		return in, nil
+	}
+	if len(src) > 0 && src[len(src)-1] != '\n' {
+		src = append(src, '\n')
+	}
+	return bytes.NewReader(append(src, decls.Bytes()...)), nil
+}
@@ -0,0 +1,55 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlaceholders(t *testing.T) {
+	placeholders, err := parse.ParsePlaceholders("Item:Type, Num:Number,Value")
+	require.NoError(t, err)
+	assert.Equal(t, []parse.Placeholder{
+		{Name: "Item", Kind: "Type"},
+		{Name: "Num", Kind: "Number"},
+		{Name: "Value", Kind: "Type"},
+	}, placeholders)
+
+	for _, s := range []string{"Item:Any", "1tem:Type", "Item,Item:Number"} {
+		_, err := parse.ParsePlaceholders(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestGenericsWithPlaceholders(t *testing.T) {
+	// the template is a snippet that neither declares its generic types nor
+	// imports the generic package
+	template := "package snip\n\ntype ItemList []Item\n\nfunc SumItem(xs []Num) Num {\n\tvar s Num\n\tfor _, x := range xs {\n\t\ts += x\n\t}\n\treturn s\n}"
+	typeSets, err := parse.TypeSet("Item=string Num=float64")
+	require.NoError(t, err)
+	opts := parse.Options{Strict: true, Placeholders: []parse.Placeholder{{Name: "Item", Kind: "Type"}, {Name: "Num", Kind: "Number"}}}
+	output, err := parse.GenericsWithOptions("stdin", "", "", strings.NewReader(template), typeSets, opts)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "type StringList []string")
+	assert.Contains(t, string(output), "func SumString(xs []float64) float64 {")
+	assert.NotContains(t, string(output), "generic")
+
+	// without the placeholders, the types name generic types the template
+	// does not declare
+	opts.Placeholders = nil
+	_, err = parse.GenericsWithOptions("stdin", "", "", strings.NewReader(template), typeSets, opts)
+	assert.Error(t, err)
+}
+
+func TestPlaceholdersDeclaredByTemplate(t *testing.T) {
+	template := "package queue\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\ntype ItemQueue []Item\n"
+	typeSets, err := parse.TypeSet("Item=int")
+	require.NoError(t, err)
+	opts := parse.Options{Placeholders: []parse.Placeholder{{Name: "Item", Kind: "Type"}}}
+	output, err := parse.GenericsWithOptions("queue.go", "", "", strings.NewReader(template), typeSets, opts)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "type IntQueue []int")
+}
@@ -0,0 +1,146 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Plugin is the code generated for one type set as a package that builds
+// with go build -buildmode=plugin, for programs that load per type
+// implementations at runtime with the plugin package.
+type Plugin struct {
+	// Name is made from the specific types, e.g. string_int, and names the
+	// directory of the package.
+	Name string
+	// TypeSet is the type set the plugin is generated for.
+	TypeSet map[string]string
+	// Source is the formatted code, in package main.
+	Source []byte
+	// Symbols are the exported functions and variables that the plugin
+	// package can look up.
+	Symbols []Symbol
+}
+
+// Symbol is an exported symbol of a Plugin.
+type Symbol struct {
+	Name string `json:"name"`
+	// Kind is func or var.
+	Kind string `json:"kind"`
+}
+
+// PluginManifest describes a Plugin, for the program that loads it.
+type PluginManifest struct {
+	Template string            `json:"template"`
+	TypeSet  map[string]string `json:"typeSet"`
+	// Package is the directory of the plugin package.
+	Package string `json:"package"`
+	// Build is the command that builds the plugin.
+	Build   string   `json:"build"`
+	Symbols []Symbol `json:"symbols"`
+}
+
+// Plugins generates the template as a plugin package for each type set.
+// As plugins must be, the packages are main packages; only their exported
+// functions and variables (not types) can be looked up once it is loaded.
+// It is an error if a type set would export nothing, or two type sets
+// would have the same name.
+func Plugins(filename string, r io.Reader, typeSets []map[string]string, opts Options) ([]Plugin, error) {
+	in, err := seekable(r)
+	if err != nil {
+		return nil, err
+	}
+	opts.Script = false
+	var plugins []Plugin
+	names := make(map[string]bool)
+	for _, typeSet := range typeSets {
+		in.Seek(0, os.SEEK_SET)
+		source, err := GenericsWithOptions(filename, filename, "main", in, []map[string]string{typeSet}, opts)
+		if err != nil {
+			return nil, err
+		}
+		p := Plugin{Name: pluginName(typeSet), TypeSet: typeSet, Source: source}
+		if names[p.Name] {
+			return nil, &errPlugin{TypeSet: typeSetString(typeSet), Reason: "another type set has the same name, " + p.Name}
+		}
+		names[p.Name] = true
+		if p.Symbols, err = symbols(source); err != nil {
+			return nil, err
+		}
+		if len(p.Symbols) == 0 {
+			return nil, &errPlugin{TypeSet: typeSetString(typeSet), Reason: "it exports no functions or variables to look up"}
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, nil
+}
+
+// Manifest gets the manifest of the plugin generated from the template,
+// to be written to the package directory dir.
+func (p Plugin) Manifest(template, dir string) PluginManifest {
+	pkg := "./" + strings.TrimPrefix(dir, "./")
+	return PluginManifest{
+		Template: template,
+		TypeSet:  p.TypeSet,
+		Package:  pkg,
+		Build:    "go build -buildmode=plugin -o " + p.Name + ".so " + pkg,
+		Symbols:  p.Symbols,
+	}
+}
+
+// pluginName gets the name of the plugin for the type set: its specific
+// types, in the order of their generic types, in snake case.
+func pluginName(typeSet map[string]string) string {
+	var generics []string
+	for generic := range typeSet {
+		generics = append(generics, generic)
+	}
+	sort.Strings(generics)
+	var words []string
+	for _, generic := range generics {
+		var word strings.Builder
+		for _, r := range snakeCase(wordify(typeSet[generic], true)) {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				word.WriteRune(r)
+			} else {
+				word.WriteRune('_')
+			}
+		}
+		words = append(words, strings.Trim(word.String(), "_"))
+	}
+	return strings.Join(words, "_")
+}
+
+// symbols gets the exported functions and variables of the source.
+func symbols(source []byte) ([]Symbol, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), "", source, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	var syms []Symbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.IsExported() {
+				syms = append(syms, Symbol{Name: d.Name.Name, Kind: "func"})
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range d.Specs {
+				for _, name := range spec.(*ast.ValueSpec).Names {
+					if name.IsExported() {
+						syms = append(syms, Symbol{Name: name.Name, Kind: "var"})
+					}
+				}
+			}
+		}
+	}
+	return syms, nil
+}
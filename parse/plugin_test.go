@@ -0,0 +1,61 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+const pluginTemplate = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemQueue struct {
+	items []Item
+}
+
+var DefaultItemQueue = NewItemQueue()
+
+func NewItemQueue() *ItemQueue {
+	return &ItemQueue{}
+}
+
+func (q *ItemQueue) Push(item Item) {
+	q.items = append(q.items, item)
+}
+`
+
+func TestPlugins(t *testing.T) {
+
+	typeSets := []map[string]string{{"Item": "int"}, {"Item": "*bytes.Buffer"}}
+	ps, err := parse.Plugins("queue.go", strings.NewReader(pluginTemplate), typeSets, parse.Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if !assert.Len(t, ps, 2) {
+		return
+	}
+	assert.Equal(t, "int", ps[0].Name)
+	assert.Equal(t, "bytes_buffer", ps[1].Name)
+	assert.Contains(t, string(ps[0].Source), "package main\n")
+	assert.Contains(t, string(ps[0].Source), "func NewIntQueue() *IntQueue {")
+	assert.NotContains(t, string(ps[0].Source), "StringQueue")
+	assert.Equal(t, []parse.Symbol{{Name: "DefaultIntQueue", Kind: "var"}, {Name: "NewIntQueue", Kind: "func"}}, ps[0].Symbols)
+
+	m := ps[0].Manifest("queue.go", "plugins/int")
+	assert.Equal(t, "./plugins/int", m.Package)
+	assert.Equal(t, "go build -buildmode=plugin -o int.so ./plugins/int", m.Build)
+	assert.Equal(t, map[string]string{"Item": "int"}, m.TypeSet)
+
+	// nothing to look up
+	_, err = parse.Plugins("queue.go", strings.NewReader(coverageTemplate), typeSets[:1], parse.Options{})
+	assert.EqualError(t, err, "Cannot generate a plugin for Item=int: it exports no functions or variables to look up")
+
+	// two plugins with the same directory
+	_, err = parse.Plugins("queue.go", strings.NewReader(pluginTemplate), []map[string]string{{"Item": "*bytes.Buffer"}, {"Item": "bytes.Buffer"}}, parse.Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// PreviewLine is a line of a template with the lines it generates.
+type PreviewLine struct {
+	// Line is the line number in the template.
+	Line int
+	// Text is the line as written in the template.
+	Text string
+	// Generated are the lines generated from it, before formatting, or
+	// none if it is removed (as generic type declarations are).
+	Generated []string
+}
+
+// Changed gets whether the line generates anything other than itself.
+func (l PreviewLine) Changed() bool {
+	return len(l.Generated) != 1 || l.Generated[0] != l.Text
+}
+
+// Preview shows how a template expands for a type set, line by line.
+type Preview struct {
+	Filename string
+	TypeSet  map[string]string
+	Lines    []PreviewLine
+}
+
+// TemplatePreview generates the template with the type set and pairs each
+// template line with the lines it generates, to see how the substitutions
+// behave. The generated lines are not formatted, so that each keeps its
+// place; imports are fixed only when the code is formatted.
+func TemplatePreview(filename string, r io.Reader, typeSet map[string]string, opts Options) (*Preview, error) {
+
+	in, typeSets, err := readInput(filename, r, []map[string]string{typeSet}, opts)
+	if err != nil {
+		return nil, err
+	}
+	opts.Unformatted = true
+	output, origins, err := generate(filename, "", in, typeSets, opts, noopSpan{})
+	if err != nil {
+		return nil, err
+	}
+	generated := make(map[int][]string)
+	for i, line := range strings.Split(strings.TrimSuffix(string(output), "\n"), "\n") {
+		if i < len(origins) && origins[i].TypeSet == 0 {
+			generated[origins[i].Line] = append(generated[origins[i].Line], strings.TrimRight(line, linefeed))
+		}
+	}
+
+	in.Seek(0, os.SEEK_SET)
+	src, err := readLines(in)
+	if err != nil {
+		return nil, err
+	}
+	p := &Preview{Filename: filename, TypeSet: typeSet}
+	for i, text := range src {
+		p.Lines = append(p.Lines, PreviewLine{Line: i + 1, Text: text, Generated: generated[i+1]})
+	}
+	return p, nil
+}
+
+// Write writes the preview in the form of a diff against the template:
+// unchanged lines are written once, and changed lines are written from the
+// template (marked "-") followed by what they generate (marked "+").
+func (p *Preview) Write(w io.Writer) {
+	fmt.Fprintf(w, "%s with %s\n", p.Filename, typeSetString(p.TypeSet))
+	for _, l := range p.Lines {
+		if !l.Changed() {
+			fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("%5d   %s", l.Line, l.Text), " "))
+			continue
+		}
+		fmt.Fprintf(w, "%5d - %s\n", l.Line, l.Text)
+		for _, g := range l.Generated {
+			fmt.Fprintf(w, "%5s + %s\n", "", g)
+		}
+	}
+}
+
+// WriteSideBySide writes the template and what each line generates in two
+// columns, with changed lines marked "|" and removed lines marked "<".
+// Tabs are expanded so that the columns line up.
+func (p *Preview) WriteSideBySide(w io.Writer) {
+	width := 0
+	for _, l := range p.Lines {
+		if n := utf8.RuneCountInString(expandTabs(l.Text)); n > width {
+			width = n
+		}
+	}
+	fmt.Fprintf(w, "%s with %s\n", p.Filename, typeSetString(p.TypeSet))
+	for _, l := range p.Lines {
+		text := expandTabs(l.Text)
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(text))
+		switch {
+		case len(l.Generated) == 0:
+			fmt.Fprintf(w, "%5d %s%s <\n", l.Line, text, pad)
+		case !l.Changed():
+			fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf("%5d %s%s   %s", l.Line, text, pad, text), " "))
+		default:
+			for i, g := range l.Generated {
+				if i > 0 {
+					text, pad = "", strings.Repeat(" ", width)
+				}
+				fmt.Fprintf(w, "%5d %s%s | %s\n", l.Line, text, pad, expandTabs(g))
+			}
+		}
+	}
+}
+
+// expandTabs replaces the tabs in the line with spaces, up to the next
+// multiple of four columns.
+func expandTabs(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var buf bytes.Buffer
+	col := 0
+	for _, r := range line {
+		if r == '\t' {
+			n := 4 - col%4
+			buf.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		buf.WriteRune(r)
+		col++
+	}
+	return buf.String()
+}
@@ -0,0 +1,38 @@
+package parse_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplatePreview(t *testing.T) {
+
+	p, err := parse.TemplatePreview("queue.go", strings.NewReader(coverageTemplate), map[string]string{"Item": "int"}, parse.Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, p.Lines, 17)
+
+	// the package clause is unchanged, the generic type is removed and
+	// the queue is renamed
+	assert.False(t, p.Lines[0].Changed())
+	assert.Empty(t, p.Lines[5].Generated)
+	assert.Equal(t, []string{"type IntQueue struct {"}, p.Lines[8].Generated)
+	assert.Equal(t, []string{"\titems []int"}, p.Lines[9].Generated)
+
+	var buf bytes.Buffer
+	p.Write(&buf)
+	assert.Contains(t, buf.String(), "queue.go with Item=int\n")
+	assert.Contains(t, buf.String(), "    6 - type Item generic.Type\n    7\n")
+	assert.Contains(t, buf.String(), "    9 - type ItemQueue struct {\n      + type IntQueue struct {\n")
+
+	buf.Reset()
+	p.WriteSideBySide(&buf)
+	assert.Contains(t, buf.String(), "    6 type Item generic.Type")
+	assert.Contains(t, buf.String(), "   10     items []Item ")
+	assert.Contains(t, buf.String(), " |     items []int\n")
+}
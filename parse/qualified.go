@@ -0,0 +1,165 @@
+package parse
+
+import (
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// qualifiedType matches a type given with the import path of its package,
+// as in github.com/shopspring/decimal.Decimal, alone or within a specific
+// type such as []*github.com/shopspring/decimal.Decimal. The path has a
+// slash, which tells it from the name of a package, and the type is what
+// follows its last dot, so paths such as gopkg.in/yaml.v2 may have dots.
+var qualifiedType = regexp.MustCompile(`[A-Za-z0-9_.~-]+(?:/[A-Za-z0-9_.~-]+)+\.[A-Za-z_][A-Za-z0-9_]*`)
+
+// importSpecificTypes rewrites the specific types given with the import
+// paths of their packages with the names of their packages instead:
+// github.com/shopspring/decimal.Decimal is decimal.Decimal, known by the
+// name of the type (Decimal) in identifiers unless it is given a name, as
+// the types a composite type is made of are (UUIDSlice for
+// []github.com/google/uuid.UUID). It gets the imports the rewritten types
+// need, as the names the packages are imported as keyed by their paths, or
+// nil if there are none.
+func importSpecificTypes(typeSets []map[string]string) ([]map[string]string, map[string]string, error) {
+	var rewritten []map[string]string
+	var imports map[string]string
+	for i, typeSet := range typeSets {
+		for generic, specific := range typeSet {
+			specificType, name := splitTransforms(specific)
+			// a path in a struct tag is not an import
+			if strings.ContainsAny(specificType, "\"`") || !qualifiedType.MatchString(specificType) {
+				continue
+			}
+			if imports == nil {
+				imports = make(map[string]string)
+				rewritten = copyTypeSets(typeSets)
+			}
+			var err error
+			qualified := qualifiedType.ReplaceAllStringFunc(specificType, func(match string) string {
+				dot := strings.LastIndex(match, ".")
+				importPath, typeName := match[:dot], match[dot+1:]
+				pkgName := importName(importPath)
+				for p, n := range imports {
+					if n == pkgName && p != importPath && err == nil {
+						err = &errImportName{Name: pkgName, Paths: []string{p, importPath}}
+					}
+				}
+				imports[importPath] = pkgName
+				return pkgName + "." + typeName
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+			rewritten[i][generic] = qualified + specific[len(specificType):]
+			if name == "" {
+				// composite types are named after the types they are made
+				// of, which are known by their names alone
+				if name = typeNameOf(qualified); name == "" {
+					name, _ = compositeWord(specificType)
+				}
+				if name != "" {
+					rewritten[i][generic] += transformSep + transformName + keyValueSep + name
+				}
+			}
+		}
+	}
+	if imports == nil {
+		return typeSets, nil, nil
+	}
+	return rewritten, imports, nil
+}
+
+// typeNameOf gets the name of the type of a package the specific type is,
+// or points to, as Decimal for decimal.Decimal and *decimal.Decimal, or ""
+// for any other type, which is named as usual.
+func typeNameOf(specificType string) string {
+	pkgName, typeName, ok := strings.Cut(strings.TrimLeft(specificType, "*"), ".")
+	if !ok || !token.IsIdentifier(pkgName) || !token.IsIdentifier(typeName) {
+		return ""
+	}
+	return typeName
+}
+
+// copyTypeSets gets a copy of the type sets that can be changed without
+// changing them.
+func copyTypeSets(typeSets []map[string]string) []map[string]string {
+	copies := make([]map[string]string, len(typeSets))
+	for i, typeSet := range typeSets {
+		copies[i] = make(map[string]string, len(typeSet))
+		for generic, specific := range typeSet {
+			copies[i][generic] = specific
+		}
+	}
+	return copies
+}
+
+// checkImportNames checks that no import of the template has the name of
+// a package the specific types are imported from, but another path, which
+// the generated code could not tell apart.
+func checkImportNames(filename string, in io.ReadSeeker, imports map[string]string, cache *Cache) error {
+	_, file, err := parseSource(filename, in, cache)
+	in.Seek(0, os.SEEK_SET)
+	if err != nil {
+		// syntax errors are reported when the template is generated
+		return nil
+	}
+	for _, spec := range file.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := importName(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		for importPath, pkgName := range imports {
+			if pkgName == name && importPath != p {
+				return &errImportName{Name: name, Paths: []string{p, importPath}}
+			}
+		}
+	}
+	return nil
+}
+
+// addImportLines adds an import declaration for each of the imports after
+// the package clause of the lines, in the order of their paths. Packages whose
+// names are not the last elements of their paths are imported with their
+// names, so that the code builds whatever the packages call themselves.
+func addImportLines(lines []string, origins []origin, imports map[string]string) ([]string, []origin) {
+	clause := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "package ") {
+			clause = i
+			break
+		}
+	}
+	if clause < 0 || len(imports) == 0 {
+		return lines, origins
+	}
+	var paths []string
+	for p := range imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	decls := []string{"\n"}
+	for _, p := range paths {
+		if name := imports[p]; name != path.Base(p) {
+			decls = append(decls, makeLine("import "+name+" "+strconv.Quote(p)))
+		} else {
+			decls = append(decls, makeLine("import "+strconv.Quote(p)))
+		}
+	}
+	declOrigins := make([]origin, len(decls))
+	for i := range declOrigins {
+		declOrigins[i] = origin{TypeSet: -1}
+	}
+	lines = append(lines[:clause+1], append(decls, lines[clause+1:]...)...)
+	origins = append(origins[:clause+1], append(declOrigins, origins[clause+1:]...)...)
+	return lines, origins
+}
@@ -0,0 +1,65 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const qualifiedTemplate = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type ValueType generic.Type
+
+// ValueTypeQueue is a queue of ValueType values.
+type ValueTypeQueue struct {
+	items []ValueType
+}
+
+func (q *ValueTypeQueue) Put(v ValueType) {
+	q.items = append(q.items, v)
+}
+`
+
+func TestQualifiedTypes(t *testing.T) {
+	typeSets, err := parse.TypeSet("ValueType=github.com/shopspring/decimal.Decimal,*gopkg.in/yaml.v2.Node:YAML,[]github.com/google/uuid.UUID:UUIDs,[]github.com/google/uuid.UUID,map[string]github.com/google/uuid.UUID")
+	require.NoError(t, err)
+	opts := parse.Options{Unformatted: true}
+	output, err := parse.GenericsWithOptions("queue.go", "gen_queue.go", "", strings.NewReader(qualifiedTemplate), typeSets, opts)
+	require.NoError(t, err)
+	for _, want := range []string{
+		"import \"github.com/google/uuid\"\n",
+		"import \"github.com/shopspring/decimal\"\n",
+		"import yaml \"gopkg.in/yaml.v2\"\n",
+		"// DecimalQueue is a queue of decimal.Decimal values.",
+		"items []decimal.Decimal",
+		"func (q *DecimalQueue) Put(v decimal.Decimal) {",
+		"func (q *YAMLQueue) Put(v *yaml.Node) {",
+		"func (q *UUIDsQueue) Put(v []uuid.UUID) {",
+		"func (q *UUIDSliceQueue) Put(v []uuid.UUID) {",
+		"func (q *StringUUIDMapQueue) Put(v map[string]uuid.UUID) {",
+	} {
+		assert.Contains(t, string(output), want)
+	}
+	assert.NotContains(t, string(output), "github.com/shopspring/decimal.Decimal")
+	assert.Equal(t, 1, strings.Count(string(output), "package queue"))
+
+	for _, test := range []struct {
+		typeSets string
+		err      string
+	}{
+		{"ValueType=github.com/shopspring/decimal.Decimal,example.com/decimal.Decimal", "are both named decimal"},
+		{"ValueType=example.com/generic.Type", "are both named generic"},
+	} {
+		typeSets, err := parse.TypeSet(test.typeSets)
+		require.NoError(t, err)
+		_, err = parse.GenericsWithOptions("queue.go", "gen_queue.go", "", strings.NewReader(qualifiedTemplate), typeSets, opts)
+		if assert.Error(t, err, test.typeSets) {
+			assert.Contains(t, err.Error(), test.err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// method is a method declared in a template or in generated code.
+type method struct {
+	Name string
+	// Recv is the name of the receiver's base type.
+	Recv string
+	Pos  token.Position
+}
+
+// methods gets every method declared in the file, in order.
+func methods(fs *token.FileSet, file *ast.File) []method {
+	var ms []method
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) == 0 {
+			continue
+		}
+		recv := fn.Recv.List[0].Type
+		if star, ok := recv.(*ast.StarExpr); ok {
+			recv = star.X
+		}
+		ident, ok := recv.(*ast.Ident)
+		if !ok {
+			continue
+		}
+		ms = append(ms, method{Name: fn.Name.Name, Recv: ident.Name, Pos: fs.Position(fn.Pos())})
+	}
+	return ms
+}
+
+// checkReceivers makes sure that every method the template declares on one
+// of its own types still attaches to the matching generated type once the
+// specific types have been substituted.
+//
+// Methods declared directly on a generic type are always reported since
+// they would end up declared on the specific type.
+func checkReceivers(fs *token.FileSet, file *ast.File, typeSet map[string]string, generated *ast.File) error {
+	declaredInTemplate := declaredTypes(file)
+	declared := declaredTypes(generated)
+	generatedMethods := make(map[method]bool)
+	for _, m := range methods(token.NewFileSet(), generated) {
+		generatedMethods[method{Name: m.Name, Recv: m.Recv}] = true
+	}
+
+	var orphans []orphanedMethod
+	for _, m := range methods(fs, file) {
+		if specificType, isGeneric := typeSet[m.Recv]; isGeneric {
+			orphans = append(orphans, orphanedMethod{Method: m, SpecificRecv: specificTypeOf(specificType)})
+			continue
+		}
+		if !declaredInTemplate[m.Recv] {
+			// declared elsewhere in the package
+			continue
+		}
+		specificRecv := subIntoIdent(m.Recv, typeSet)
+		specific := method{Name: subIntoIdent(m.Name, typeSet), Recv: specificRecv}
+		if !declared[specificRecv] || !generatedMethods[specific] {
+			orphans = append(orphans, orphanedMethod{Method: m, SpecificRecv: specificRecv})
+		}
+	}
+	if len(orphans) > 0 {
+		return &errOrphanedMethods{Methods: orphans}
+	}
+	return nil
+}
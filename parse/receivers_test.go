@@ -0,0 +1,69 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReceivers(t *testing.T) {
+
+	for _, test := range []struct {
+		src     string
+		orphans []string
+	}{
+		{
+			src: `package p
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemList []Item
+
+func (l ItemList) Len() int { return len(l) }
+
+func (l *ItemList) Add(item Item) { *l = append(*l, item) }
+`,
+		},
+		{
+			src: `package p
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+func (i Item) String() string { return "item" }
+`,
+			orphans: []string{"Item.String"},
+		},
+		{
+			src: `package p
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemList []Item
+
+func (l ItemList) Set(v generic.Type) {}
+`,
+			orphans: []string{"ItemList.Set"},
+		},
+	} {
+
+		_, _, err := generateSpecific("template.go", strings.NewReader(test.src), map[string]string{"Item": "int"}, 0, 1, Options{}, noopSpan{})
+		if test.orphans == nil {
+			assert.NoError(t, err)
+			continue
+		}
+		if assert.IsType(t, &errOrphanedMethods{}, err) {
+			for _, orphan := range test.orphans {
+				assert.Contains(t, err.Error(), orphan)
+			}
+		}
+
+	}
+
+}
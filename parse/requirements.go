@@ -0,0 +1,80 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// MissingRequirements gets the import paths of the generated code whose
+// modules the module the output is written into does not require, such as
+// the package of a specific type (github.com/shopspring/decimal.Decimal)
+// that nothing in the module imported before. Without them, the code fails
+// to build with a missing module error. Nothing is missing outside a
+// module, or if the code does not parse.
+func MissingRequirements(outputFilename string, output []byte) ([]string, error) {
+	dir, err := filepath.Abs(filepath.Dir(outputFilename))
+	if err != nil {
+		return nil, err
+	}
+	host, err := findHostModule(dir)
+	if err != nil || host.Path == "" {
+		return nil, nil
+	}
+	generated, err := parser.ParseFile(token.NewFileSet(), outputFilename, output, parser.ImportsOnly)
+	if err != nil {
+		return nil, nil
+	}
+	var missing []string
+	for _, path := range fileImports([]*ast.File{generated}) {
+		if !isStandardImport(path) && !host.provides(path) {
+			missing = append(missing, path)
+		}
+	}
+	return missing, nil
+}
+
+// isStandardImport gets whether the import path is of the standard
+// library, whose paths have no dot in their first element.
+func isStandardImport(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// provides gets whether the package with the import path is in the module
+// or one of the modules it requires.
+func (m *hostModule) provides(path string) bool {
+	modules := []string{m.Path}
+	for _, r := range m.Require {
+		module, _, _ := strings.Cut(r, " ")
+		modules = append(modules, module)
+	}
+	for _, module := range modules {
+		if path == module || strings.HasPrefix(path, module+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Require adds requirements on the modules of the import paths to the
+// module the output is written into, with go get run in its directory with
+// the environment of opts.Loader.
+func Require(outputFilename string, imports []string, opts Options) error {
+	if len(imports) == 0 {
+		return nil
+	}
+	args := append([]string{"get"}, imports...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = filepath.Dir(outputFilename)
+	if opts.Loader != nil {
+		cmd.Env = opts.Loader.Env
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return &errGoCommand{Args: args, Output: string(output), Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package parse_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMissingRequirements(t *testing.T) {
+	dir := t.TempDir()
+	gomod := "module example.com/m\n\ngo 1.20\n\nrequire (\n\tgithub.com/google/uuid v1.3.0 // indirect\n)\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(gomod), 0644))
+	output := []byte(`package m
+
+import (
+	"time"
+
+	"example.com/m/ids"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+`)
+	missing, err := parse.MissingRequirements(filepath.Join(dir, "gen.go"), output)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"github.com/shopspring/decimal"}, missing)
+
+	// outside a module, nothing is required
+	missing, err = parse.MissingRequirements(filepath.Join(t.TempDir(), "gen.go"), output)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
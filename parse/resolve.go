@@ -0,0 +1,263 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cheekybits/genny/diag"
+)
+
+// Resolver finds the import paths of packages that goimports could not,
+// given the names the generated code uses them by, e.g. "decimal". It
+// returns the import path of each name it finds. It may also make the
+// packages available, e.g. by running go get, and return no paths for
+// goimports to find them itself when it is retried.
+type Resolver func(filename string, missing []string) (map[string]string, error)
+
+// ImportMap gets a Resolver that looks the packages up in a file mapping
+// package names to import paths, one per line, as "name path", or just the
+// path for packages named after its last element. Blank lines and lines
+// starting with # are ignored.
+func ImportMap(filename string) (Resolver, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	mapping := make(map[string]string)
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		switch {
+		case len(fields) == 0 || strings.HasPrefix(fields[0], "#"):
+		case len(fields) == 1:
+			mapping[importName(fields[0])] = fields[0]
+		case len(fields) == 2:
+			mapping[fields[0]] = fields[1]
+		default:
+			return nil, &errBadOption{Option: "import map", Value: sc.Text(), Message: filename + ":" + strconv.Itoa(n) + ": name and path expected"}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return func(_ string, missing []string) (map[string]string, error) {
+		found := make(map[string]string)
+		for _, name := range missing {
+			if p, ok := mapping[name]; ok {
+				found[name] = p
+			}
+		}
+		return found, nil
+	}, nil
+}
+
+// CommandResolver gets a Resolver that runs the command line with the
+// system shell, in the directory of the generated file, with the missing
+// package names in GENNY_MISSING, separated by spaces. The command can
+// make the packages available (go get), and print lines in the format of
+// ImportMap for the imports to add.
+func CommandResolver(command string) Resolver {
+	return func(filename string, missing []string) (map[string]string, error) {
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", command)
+		} else {
+			cmd = exec.Command("sh", "-c", command)
+		}
+		cmd.Dir = filepath.Dir(filename)
+		cmd.Env = append(os.Environ(), "GENNY_MISSING="+strings.Join(missing, " "))
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			return nil, &errResolver{Command: command, Err: err, Stderr: strings.TrimSpace(stderr.String())}
+		}
+		found := make(map[string]string)
+		for _, line := range strings.Split(string(output), "\n") {
+			switch fields := strings.Fields(line); len(fields) {
+			case 1:
+				found[importName(fields[0])] = fields[0]
+			case 2:
+				found[fields[0]] = fields[1]
+			}
+		}
+		return found, nil
+	}
+}
+
+// formatWithResolver formats the code like Format and, if packages it uses
+// are left without imports, asks the resolver for them and formats it
+// again with the imports it finds. Packages that are still missing are
+// reported as a strict problem.
+func formatWithResolver(filename string, src []byte, opts Options) ([]byte, error) {
+	output, err := Format(filename, src)
+	if err != nil || opts.Resolver == nil {
+		return output, err
+	}
+	missing := missingPackages(filename, output)
+	if len(missing) == 0 {
+		return output, nil
+	}
+	found, err := opts.Resolver(filename, missing)
+	if err != nil {
+		return nil, err
+	}
+	if output, err = Format(filename, addImports(src, found)); err != nil {
+		return nil, err
+	}
+	if missing = missingPackages(filename, output); len(missing) > 0 {
+		var problems diag.List
+		problems.Add(&errMissingPackages{Filename: filename, Names: missing}, diag.Error)
+		if err := strictError(problems, opts); err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
+}
+
+// missingPackages gets the names of the packages the code, to be written
+// to filename, uses without importing them, in order. Names declared by
+// the other files of the package are not packages.
+func missingPackages(filename string, src []byte) []string {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, 0)
+	if err != nil {
+		return nil
+	}
+	var declared map[string]bool
+	imported := make(map[string]bool)
+	for _, spec := range file.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		if spec.Name != nil {
+			imported[spec.Name.Name] = true
+		} else {
+			imported[importName(p)] = true
+		}
+	}
+	unresolved := make(map[*ast.Ident]bool)
+	for _, ident := range file.Unresolved {
+		unresolved[ident] = true
+	}
+	seen := make(map[string]bool)
+	var missing []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		x, ok := sel.X.(*ast.Ident)
+		if !ok || !unresolved[x] || imported[x.Name] || seen[x.Name] || types.Universe.Lookup(x.Name) != nil {
+			return true
+		}
+		if declared == nil {
+			declared = packageDecls(filename)
+		}
+		if declared[x.Name] {
+			return true
+		}
+		seen[x.Name] = true
+		missing = append(missing, x.Name)
+		return true
+	})
+	sort.Strings(missing)
+	return missing
+}
+
+// packageDecls gets the top level names declared by the Go files in the
+// directory of filename, other than filename itself.
+func packageDecls(filename string) map[string]bool {
+	declared := make(map[string]bool)
+	self, _ := filepath.Abs(filename)
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(filename), "*.go"))
+	for _, match := range matches {
+		if abs, err := filepath.Abs(match); err == nil && abs == self {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), match, nil, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					declared[d.Name.Name] = true
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						declared[s.Name.Name] = true
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							declared[name.Name] = true
+						}
+					}
+				}
+			}
+		}
+	}
+	return declared
+}
+
+// addImports adds an import of each package to the code, after its package
+// clause.
+func addImports(src []byte, imports map[string]string) []byte {
+	if len(imports) == 0 {
+		return src
+	}
+	var names []string
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var decl bytes.Buffer
+	for _, name := range names {
+		p := imports[name]
+		if importName(p) == name {
+			decl.WriteString("import " + strconv.Quote(p) + "\n")
+		} else {
+			decl.WriteString("import " + name + " " + strconv.Quote(p) + "\n")
+		}
+	}
+	var output bytes.Buffer
+	added := false
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for sc.Scan() {
+		output.Write(sc.Bytes())
+		output.WriteByte('\n')
+		if !added && bytes.HasPrefix(sc.Bytes(), packageKeyword) {
+			output.Write(decl.Bytes())
+			added = true
+		}
+	}
+	return output.Bytes()
+}
+
+// importName gets the name a package is usually imported by, as goimports
+// assumes it: the last element of its path, without a major version
+// suffix or a go- prefix, and cut at the first character that cannot be in
+// a name, so that gopkg.in/yaml.v2 is yaml.
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	name = strings.TrimPrefix(name, "go-")
+	if i := strings.IndexFunc(name, func(r rune) bool { return !isAlphaNumeric(r) }); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
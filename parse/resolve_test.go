@@ -0,0 +1,67 @@
+package parse_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolver(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "genny-resolve")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	// registry is declared by the package, so is not a missing package
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "registry.go"), []byte("package money\n\nvar registry = map[string]int{}\n"), 0644))
+	mapFile := filepath.Join(dir, "imports.txt")
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(mapFile), []byte("# packages goimports cannot find\ndecimal example.com/shopspring/decimal\nexample.com/money/v2\n"), 0644))
+	resolver, err := parse.ImportMap(mapFile)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	template := `package money
+
+import "github.com/cheekybits/genny/generic"
+
+type Amount generic.Type
+
+func AmountTotal(amounts []Amount) (total Amount) {
+	registry["Amount"]++
+	return
+}
+`
+	outFile := filepath.Join(dir, "amounts.go")
+	typeSets := []map[string]string{{"Amount": "decimal.Decimal"}, {"Amount": "money.Money"}, {"Amount": "units.Unit"}}
+	var missing []string
+	opts := parse.Options{Resolver: func(filename string, names []string) (map[string]string, error) {
+		missing = names
+		return resolver(filename, names)
+	}}
+	output, err := parse.GenericsWithOptions("amount.go", outFile, "", strings.NewReader(template), typeSets, opts)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"decimal", "money", "units"}, missing)
+	assert.Contains(t, string(output), `"example.com/shopspring/decimal"`)
+	assert.Contains(t, string(output), `"example.com/money/v2"`)
+
+	opts.Strict = true
+	_, err = parse.GenericsWithOptions("amount.go", outFile, "", strings.NewReader(template), typeSets, opts)
+	assert.EqualError(t, err, outFile+": cannot find the packages units to import")
+
+	_, err = parse.GenericsWithOptions("amount.go", outFile, "", strings.NewReader(template), typeSets[2:], parse.Options{Strict: true, Resolver: parse.CommandResolver(`echo "units example.com/units # $GENNY_MISSING"`)})
+	assert.Error(t, err, "the command prints more than a name and path")
+	output, err = parse.GenericsWithOptions("amount.go", outFile, "", strings.NewReader(template), typeSets[2:], parse.Options{Strict: true, Resolver: parse.CommandResolver(`echo "$GENNY_MISSING example.com/units"`)})
+	if assert.NoError(t, err) {
+		assert.Contains(t, string(output), `"example.com/units"`)
+	}
+
+}
@@ -0,0 +1,122 @@
+package parse
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/cheekybits/genny/diag"
+)
+
+// resultKey identifies a generation: the template by its name and content,
+// and what it was generated with.
+type resultKey struct {
+	filename string
+	sum      [sha256.Size]byte
+	// with is the output filename, package name, type sets and options.
+	with string
+}
+
+// result is the output of a generation, with the warnings it found.
+type result struct {
+	key      resultKey
+	output   []byte
+	warnings diag.List
+}
+
+// resultMemo keeps the most recently used generation results of a Cache.
+// It is guarded by the Cache's mutex.
+type resultMemo struct {
+	order   *list.List
+	results map[resultKey]*list.Element
+	hits    int
+	misses  int
+}
+
+// invalidate drops the results generated from the template, or from the
+// templates in it if it is an archive.
+func (m *resultMemo) invalidate(filename string) {
+	for key, e := range m.results {
+		if key.filename == filename || strings.HasPrefix(key.filename, filename+"#") {
+			m.order.Remove(e)
+			delete(m.results, key)
+		}
+	}
+}
+
+// resultKeyOf gets the key of generating the template with the type sets
+// and options, or false if the result cannot be kept.
+func resultKeyOf(filename, outputFilename, pkgName string, in io.ReadSeeker, typeSets []map[string]string, opts Options) (resultKey, bool) {
+	if opts.Validators != nil || opts.Resolver != nil {
+		return resultKey{}, false
+	}
+	in.Seek(0, os.SEEK_SET)
+	src, err := ioutil.ReadAll(in)
+	in.Seek(0, os.SEEK_SET)
+	if err != nil {
+		return resultKey{}, false
+	}
+	var typeSetStrings []string
+	for _, typeSet := range typeSets {
+		typeSetStrings = append(typeSetStrings, typeSetString(typeSet))
+	}
+	// the options that do not change the output are left out, and whether
+	// warnings are collected is kept, as they are only found if they are
+	collects := opts.Warnings != nil
+	opts.Warnings, opts.Cache, opts.Loader, opts.Tracer, opts.CrashReport, opts.Workers, opts.Shuffle, opts.ctx = nil, nil, nil, nil, "", 0, nil, nil
+	with := fmt.Sprintf("%q %q %q %t %#v", outputFilename, pkgName, typeSetStrings, collects, opts)
+	return resultKey{filename: filename, sum: sha256.Sum256(src), with: with}, true
+}
+
+// result gets the kept result, if there is one, adding its warnings to
+// opts.Warnings.
+func (c *Cache) result(key resultKey, opts Options) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.MaxResults < 0 {
+		return nil, false
+	}
+	if c.results == nil {
+		c.results = &resultMemo{order: list.New(), results: make(map[resultKey]*list.Element)}
+	}
+	e, ok := c.results.results[key]
+	if !ok {
+		c.results.misses++
+		return nil, false
+	}
+	c.results.hits++
+	c.results.order.MoveToFront(e)
+	r := e.Value.(*result)
+	addWarnings(r.warnings, opts)
+	return append([]byte(nil), r.output...), true
+}
+
+// putResult keeps the result, dropping the least recently used if the
+// cache holds too many.
+func (c *Cache) putResult(key resultKey, output []byte, warnings diag.List) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	max := c.MaxResults
+	if max == 0 {
+		max = DefaultMaxResults
+	}
+	if max < 0 || c.results == nil {
+		return
+	}
+	r := &result{key: key, output: append([]byte(nil), output...), warnings: append(diag.List(nil), warnings...)}
+	if e, ok := c.results.results[key]; ok {
+		e.Value = r
+		c.results.order.MoveToFront(e)
+		return
+	}
+	c.results.results[key] = c.results.order.PushFront(r)
+	for c.results.order.Len() > max {
+		oldest := c.results.order.Back()
+		c.results.order.Remove(oldest)
+		delete(c.results.results, oldest.Value.(*result).key)
+	}
+}
@@ -0,0 +1,276 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// sandboxModule is the module path of a Sandbox.
+const sandboxModule = "genny.sandbox"
+
+// Sandbox is a temporary module that generated code is built and vetted in,
+// isolated from the module it is generated into. It holds a copy of the
+// output package with the generated code, and a go.mod pinned to the host
+// module: it requires the host module, replaced by its directory, along with
+// the host's requirements and replace directives and its go.sum.
+//
+// The go command runs in the sandbox without a workspace, with the module
+// proxy turned off, so verification never downloads modules or changes the
+// module cache, and gives the same result for the same code and modules.
+// Packages the output package imports from the host module resolve to the
+// host's directory, though its internal packages cannot be imported from
+// the sandbox.
+type Sandbox struct {
+	// Dir is the root directory of the sandbox module, which is the same
+	// for the same output and host module.
+	Dir string
+	// Package is the directory of the copy of the output package.
+	Package string
+	// Env is the environment the go command runs with in the sandbox.
+	Env []string
+
+	buildFlags []string
+}
+
+// NewSandbox makes a Sandbox for the output, to be written to
+// outputFilename. The build flags and environment of opts.Loader, if it
+// is set, are used by the go command. Close removes the sandbox.
+func NewSandbox(outputFilename string, output []byte, opts Options) (*Sandbox, error) {
+	outDir, err := filepath.Abs(filepath.Dir(outputFilename))
+	if err != nil {
+		return nil, err
+	}
+	host, err := findHostModule(outDir)
+	if err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(host.Root, outDir)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.New()
+	fmt.Fprintf(sum, "%s\n%s\n", host.Root, outputFilename)
+	sum.Write(output)
+	s := &Sandbox{Dir: filepath.Join(os.TempDir(), fmt.Sprintf("genny-sandbox-%x", sum.Sum(nil)[:8]))}
+	s.Package = filepath.Join(s.Dir, rel)
+	env := os.Environ()
+	if opts.Loader != nil {
+		s.buildFlags = opts.Loader.BuildFlags
+		if opts.Loader.Env != nil {
+			env = opts.Loader.Env
+		}
+	}
+	s.Env = append(append([]string(nil), env...),
+		"GO111MODULE=on", "GOWORK=off", "GOPROXY=off", "GOSUMDB=off", "GOFLAGS=-mod=mod")
+
+	if err := os.RemoveAll(s.Dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.Package, 0755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(s.Dir, "go.mod"), host.sandboxGoMod(), 0644); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(host.Root, "go.sum")); err == nil {
+		if err := ioutil.WriteFile(filepath.Join(s.Dir, "go.sum"), b, 0644); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	if err := s.copyPackage(outDir, filepath.Base(outputFilename), output); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// copyPackage copies the Go files of the output package, other than its
+// tests and the output file, into the sandbox, and writes the output.
+func (s *Sandbox) copyPackage(dir, outputName string, output []byte) error {
+	names, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		base := filepath.Base(name)
+		if base == outputName || strings.HasSuffix(base, "_test.go") {
+			continue
+		}
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(s.Package, base), b, 0644); err != nil {
+			return err
+		}
+	}
+	return ioutil.WriteFile(filepath.Join(s.Package, outputName), output, 0644)
+}
+
+// Command gets the go command with the arguments, to run in the sandbox.
+func (s *Sandbox) Command(args ...string) *exec.Cmd {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = s.Dir
+	cmd.Env = s.Env
+	return cmd
+}
+
+// Go runs the go command with the arguments in the sandbox, getting its
+// combined output.
+func (s *Sandbox) Go(args ...string) ([]byte, error) {
+	output, err := s.Command(args...).CombinedOutput()
+	if err != nil {
+		return output, &errGoCommand{Args: args, Output: string(output), Err: err}
+	}
+	return output, nil
+}
+
+// Build builds the output package.
+func (s *Sandbox) Build() error {
+	_, err := s.Go(s.packageArgs("build")...)
+	return err
+}
+
+// Vet vets the output package.
+func (s *Sandbox) Vet() error {
+	_, err := s.Go(s.packageArgs("vet")...)
+	return err
+}
+
+// packageArgs gets the arguments to run the go subcommand on the output
+// package with the build flags.
+func (s *Sandbox) packageArgs(command string) []string {
+	rel, _ := filepath.Rel(s.Dir, s.Package)
+	return append(append([]string{command}, s.buildFlags...), "./"+filepath.ToSlash(rel))
+}
+
+// Close removes the sandbox.
+func (s *Sandbox) Close() error {
+	return os.RemoveAll(s.Dir)
+}
+
+// hostModule is the module generated code is written into.
+type hostModule struct {
+	Root      string
+	Path      string
+	GoVersion string
+	Require   []string
+	Replace   []string
+}
+
+// findHostModule finds the module the directory is in, by the go.mod file
+// in it or its closest parent, and reads its requirements.
+func findHostModule(dir string) (*hostModule, error) {
+	for root := dir; ; {
+		b, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+		if err == nil {
+			return parseGoMod(root, b), nil
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			return nil, &errNoModule{Dir: dir}
+		}
+		root = parent
+	}
+}
+
+// parseGoMod reads the module path, go version, requirements and replace
+// directives of the go.mod file in root. Relative replacements are made
+// absolute so that they still apply in the sandbox.
+func parseGoMod(root string, gomod []byte) *hostModule {
+	m := &hostModule{Root: root}
+	var block string
+	scanner := bufio.NewScanner(bytes.NewReader(gomod))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		switch {
+		case fields[0] == "module" && len(fields) == 2:
+			m.Path = strings.Trim(fields[1], `"`)
+		case fields[0] == "go" && len(fields) == 2:
+			m.GoVersion = fields[1]
+		case fields[0] == "require" && len(fields) == 3:
+			m.Require = append(m.Require, fields[1]+" "+fields[2])
+		case fields[0] == "replace":
+			arrow := indexOf(fields, "=>")
+			if arrow < 0 || arrow+1 >= len(fields) {
+				continue
+			}
+			target := fields[arrow+1:]
+			if len(target) == 1 && (strings.HasPrefix(target[0], "./") || strings.HasPrefix(target[0], "../")) {
+				target = []string{filepath.Join(root, filepath.FromSlash(target[0]))}
+			}
+			m.Replace = append(m.Replace, strings.Join(fields[1:arrow], " ")+" => "+strings.Join(target, " "))
+		}
+	}
+	return m
+}
+
+// indexOf gets the index of s in the strings, or -1.
+func indexOf(ss []string, s string) int {
+	for i := range ss {
+		if ss[i] == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// sandboxGoMod gets the go.mod file of a sandbox for the host module.
+func (m *hostModule) sandboxGoMod() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "module %s\n\n", sandboxModule)
+	if m.GoVersion != "" {
+		fmt.Fprintf(&buf, "go %s\n\n", m.GoVersion)
+	}
+	if m.Path != "" {
+		fmt.Fprintf(&buf, "require %s v0.0.0-00010101000000-000000000000\n\n", m.Path)
+	}
+	if len(m.Require) > 0 {
+		buf.WriteString("require (\n")
+		for _, r := range m.Require {
+			fmt.Fprintf(&buf, "\t%s\n", r)
+		}
+		buf.WriteString(")\n\n")
+	}
+	if m.Path != "" {
+		fmt.Fprintf(&buf, "replace %s => %s\n", m.Path, m.Root)
+	}
+	for _, r := range m.Replace {
+		fmt.Fprintf(&buf, "replace %s\n", r)
+	}
+	return buf.Bytes()
+}
+
+// IsNoModule gets whether the error is from NewSandbox finding no module
+// for the output.
+func IsNoModule(err error) bool {
+	_, ok := err.(*errNoModule)
+	return ok
+}
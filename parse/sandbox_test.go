@@ -0,0 +1,88 @@
+package parse_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSandbox(t *testing.T) {
+
+	output, err := ioutil.ReadFile("test/queue/int_queue.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	sandbox, err := parse.NewSandbox("test/queue/int_queue.go", output, parse.Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer sandbox.Close()
+
+	again, err := parse.NewSandbox("test/queue/int_queue.go", output, parse.Options{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, sandbox.Dir, again.Dir, "sandbox should be deterministic")
+	}
+
+	gomod, err := ioutil.ReadFile(filepath.Join(sandbox.Dir, "go.mod"))
+	if assert.NoError(t, err) {
+		root, _ := filepath.Abs("..")
+		assert.Contains(t, string(gomod), "require github.com/cheekybits/genny v0.0.0-00010101000000-000000000000")
+		assert.Contains(t, string(gomod), "replace github.com/cheekybits/genny => "+root)
+		assert.Contains(t, string(gomod), "github.com/stretchr/testify v1.3.0")
+	}
+	assert.Equal(t, filepath.Join(sandbox.Dir, "parse", "test", "queue"), sandbox.Package)
+	_, err = os.Stat(filepath.Join(sandbox.Package, "generic_queue_test.go"))
+	assert.True(t, os.IsNotExist(err), "tests should not be copied")
+	assert.NoError(t, sandbox.Vet())
+
+	broken := strings.Replace(string(output), "return item", `item = item
+	return item`, 1)
+	sandbox, err = parse.NewSandbox("test/queue/int_queue.go", []byte(broken), parse.Options{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer sandbox.Close()
+	assert.NoError(t, sandbox.Build())
+	if err := sandbox.Vet(); assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "go vet ./parse/test/queue failed")
+	}
+
+	_, err = parse.NewSandbox(filepath.Join(os.TempDir(), "genny-no-module", "out.go"), output, parse.Options{})
+	assert.True(t, parse.IsNoModule(err))
+
+}
+
+func TestGenericsVet(t *testing.T) {
+
+	template := `package queue
+
+import (
+	"fmt"
+
+	"github.com/cheekybits/genny/generic"
+)
+
+type Key generic.Type
+
+func KeyLog(k Key) {
+	fmt.Printf("key %d\\n", k)
+}
+`
+	typeSets := []map[string]string{{"Key": "int"}, {"Key": "string"}}
+	_, err := parse.GenericsWithOptions("log.go", "test/queue/key_log.go", "", strings.NewReader(template), typeSets, parse.Options{Vet: true})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "go vet reports problems with the generated code")
+		assert.Contains(t, err.Error(), "log.go:12: fmt.Printf format %d has arg k of wrong type string (with Key=string, at test/queue/key_log.go:")
+		assert.NotContains(t, err.Error(), "Key=int")
+	}
+
+	output, err := parse.GenericsWithOptions("log.go", "test/queue/key_log.go", "", strings.NewReader(template), typeSets[:1], parse.Options{Vet: true})
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "func IntLog(k int) {")
+
+}
@@ -0,0 +1,301 @@
+package parse
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// scopeDirective marks the start and end of a region of the template whose
+// generic types only apply inside it:
+//
+//	//genny:scope begin Keyed
+//	type Key generic.Type
+//	...
+//	//genny:scope end
+//
+// The region is generated with its own type sets, the distinct values the
+// type sets give its generic types, and the rest of the template with
+// those of the generic types declared outside any region. The name after
+// begin is optional, and only used in messages.
+const scopeDirective = metadataPrefix + "scope"
+
+// scope is a region of the template marked with scope directives.
+type scope struct {
+	Name string
+	// Begin and End are the lines of the directives.
+	Begin, End int
+	// Generics are the generic types declared in the region.
+	Generics map[string]bool
+}
+
+// describe gets the scope as it is named in messages.
+func (s *scope) describe() string {
+	if s.Name != "" {
+		return "the scope " + s.Name + " (line " + strconv.Itoa(s.Begin) + ")"
+	}
+	return "the scope begun on line " + strconv.Itoa(s.Begin)
+}
+
+// group is a part of the template that is generated with its own type
+// sets: the template outside any scope, or a scope.
+type group struct {
+	Source   io.ReadSeeker
+	TypeSets []map[string]string
+}
+
+// scopeGroups splits the template into the groups generated with their own
+// type sets, which for a template without scopes is the template itself.
+// Each group's source has the lines of the other groups blanked, except for
+// the package clause and imports, so that line numbers are kept.
+func scopeGroups(filename string, in io.ReadSeeker, typeSets []map[string]string) ([]group, error) {
+	in.Seek(0, os.SEEK_SET)
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	in.Seek(0, os.SEEK_SET)
+	if !bytes.Contains(src, []byte(scopeDirective)) {
+		return nativeGroups(filename, in, src, typeSets)
+	}
+	lines := strings.Split(string(src), "\n")
+	scopes, err := findScopes(filename, lines)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) == 0 {
+		return nativeGroups(filename, in, src, typeSets)
+	}
+
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, src, 0)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	header := headerLines(fs, file)
+	inScope := make(map[int]*scope)
+	for i := range scopes {
+		for n := scopes[i].Begin; n <= scopes[i].End; n++ {
+			inScope[n] = &scopes[i]
+		}
+	}
+	for generic := range genericTypes(file) {
+		if s := inScope[declPos(fs, file, generic).Line]; s != nil {
+			s.Generics[generic] = true
+		}
+	}
+	scoped := make(map[string]bool)
+	for _, s := range scopes {
+		for generic := range s.Generics {
+			scoped[generic] = true
+		}
+	}
+
+	groups := []group{{
+		Source:   derive(lines, header, func(n int) bool { return inScope[n] == nil }),
+		TypeSets: project(typeSets, func(generic string) bool { return !scoped[generic] }),
+	}}
+	for i := range scopes {
+		s := scopes[i]
+		groups = append(groups, group{
+			Source:   derive(lines, header, func(n int) bool { return n > s.Begin && n < s.End }),
+			TypeSets: project(typeSets, func(generic string) bool { return s.Generics[generic] }),
+		})
+	}
+	return groups, nil
+}
+
+// nativeGroups splits a template converted from type parameters by
+// nativeTemplate into groups of the declarations that use the same generic
+// types, so that each declaration is generated once for every distinct
+// combination of the specific types of the type parameters it uses, rather
+// than once per type set: Map[K, V] is generated once for K=string
+// V=float64, whatever T is. Declarations that use none are generated
+// once. Other templates are a group of their own.
+func nativeGroups(filename string, in io.ReadSeeker, src []byte, typeSets []map[string]string) ([]group, error) {
+	whole := []group{{Source: in, TypeSets: typeSets}}
+	if len(typeSets) < 2 || !bytes.Contains(src, []byte(nativeParamPrefix)) {
+		return whole, nil
+	}
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, src, parser.ParseComments)
+	if err != nil {
+		// syntax errors are reported when the template is generated
+		return whole, nil
+	}
+	generics := genericTypes(file)
+	native := false
+	for generic := range generics {
+		native = native || paramName(generic) != generic
+	}
+	if !native {
+		return whole, nil
+	}
+
+	// the generic types each declaration uses, as named by the
+	// identifiers it has, which are named after them, key its group
+	var keys []string
+	uses := make(map[string]map[string]bool)
+	// the group of each line of a declaration
+	owners := make(map[int]string)
+	owned := make(map[int]bool)
+	// the declarations of the generic types go with every group using them
+	declares := make(map[int]string)
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if ok && gd.Tok == token.IMPORT {
+			continue
+		}
+		if ok && len(gd.Specs) == 1 {
+			if ts, ok := gd.Specs[0].(*ast.TypeSpec); ok && declaresGenericType(ts) {
+				for n := fs.PositionFor(start(decl), false).Line; n <= fs.PositionFor(decl.End(), false).Line; n++ {
+					declares[n] = ts.Name.Name
+				}
+				continue
+			}
+		}
+		used := make(map[string]bool)
+		ast.Inspect(decl, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				for generic := range generics {
+					if strings.Contains(id.Name, generic) {
+						used[generic] = true
+					}
+				}
+			}
+			return true
+		})
+		var names []string
+		for generic := range used {
+			names = append(names, generic)
+		}
+		sort.Strings(names)
+		key := strings.Join(names, ",")
+		if uses[key] == nil {
+			keys = append(keys, key)
+			uses[key] = used
+		}
+		for n := fs.PositionFor(start(decl), false).Line; n <= fs.PositionFor(decl.End(), false).Line; n++ {
+			owners[n], owned[n] = key, true
+		}
+	}
+	if len(keys) < 2 {
+		return whole, nil
+	}
+
+	lines := strings.Split(string(src), "\n")
+	header := headerLines(fs, file)
+	var groups []group
+	for i, key := range keys {
+		// the lines of no declaration, such as comments between them, go
+		// with the first group
+		first, key, used := i == 0, key, uses[key]
+		groups = append(groups, group{
+			Source: derive(lines, header, func(n int) bool {
+				switch {
+				case declares[n] != "":
+					return used[declares[n]]
+				case owned[n]:
+					return owners[n] == key
+				}
+				return first
+			}),
+			TypeSets: project(typeSets, func(generic string) bool { return used[generic] }),
+		})
+	}
+	return groups, nil
+}
+
+// headerLines gets the lines of the package clause and the imports of the
+// template, which every group of it keeps.
+func headerLines(fs *token.FileSet, file *ast.File) map[int]bool {
+	header := make(map[int]bool)
+	header[fs.PositionFor(file.Package, false).Line] = true
+	for _, decl := range file.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			for n := fs.PositionFor(gd.Pos(), false).Line; n <= fs.PositionFor(gd.End(), false).Line; n++ {
+				header[n] = true
+			}
+		}
+	}
+	return header
+}
+
+// derive gets the source of a group of the template, with only the lines
+// keep keeps, the header, and the //line directives placing them, so that
+// the lines of the template are kept.
+func derive(lines []string, header map[int]bool, keep func(n int) bool) io.ReadSeeker {
+	derived := make([]string, len(lines))
+	for i, line := range lines {
+		if header[i+1] || keep(i+1) || isLineDirective(line) {
+			derived[i] = line
+		}
+	}
+	return strings.NewReader(strings.Join(derived, "\n"))
+}
+
+// findScopes finds the regions marked by scope directives in the lines of
+// the template. Scopes cannot be nested, and each must be ended.
+func findScopes(filename string, lines []string) ([]scope, error) {
+	var scopes []scope
+	var open *scope
+	for i, line := range lines {
+		n := i + 1
+		text := strings.TrimSpace(line)
+		if !strings.HasPrefix(text, scopeDirective) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(text, scopeDirective))
+		pos := token.Position{Filename: filename, Line: n, Column: strings.Index(line, scopeDirective) + 1}
+		switch {
+		case len(fields) == 0 || (fields[0] != "begin" && fields[0] != "end"):
+			return nil, &errScope{Pos: pos, Reason: "begin or end expected"}
+		case fields[0] == "begin" && len(fields) > 2, fields[0] == "end" && len(fields) > 1:
+			return nil, &errScope{Pos: pos, Reason: "unexpected " + strings.Join(fields[1:], " ")}
+		case fields[0] == "begin" && open != nil:
+			return nil, &errScope{Pos: pos, Reason: "scopes cannot be nested; " + open.describe() + " is not ended"}
+		case fields[0] == "begin":
+			open = &scope{Begin: n, Generics: make(map[string]bool)}
+			if len(fields) == 2 {
+				open.Name = fields[1]
+			}
+		case open == nil:
+			return nil, &errScope{Pos: pos, Reason: "no scope is begun"}
+		default:
+			open.End = n
+			scopes = append(scopes, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		return nil, &errScope{Pos: token.Position{Filename: filename, Line: open.Begin, Column: 1}, Reason: open.describe() + " is never ended"}
+	}
+	return scopes, nil
+}
+
+// project gets the distinct type sets made of the generic types that
+// include includes, in the order they are first given.
+func project(typeSets []map[string]string, include func(generic string) bool) []map[string]string {
+	var projected []map[string]string
+	seen := make(map[string]bool)
+	for _, typeSet := range typeSets {
+		p := make(map[string]string)
+		for generic, specific := range typeSet {
+			if include(generic) {
+				p[generic] = specific
+			}
+		}
+		if key := typeSetString(p); !seen[key] {
+			seen[key] = true
+			projected = append(projected, p)
+		}
+	}
+	return projected
+}
@@ -0,0 +1,60 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+const scopedTemplate = `package store
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type ItemList []Item
+
+//genny:scope begin Keyed
+type Key generic.Type
+
+type KeySet map[Key]struct{}
+
+var KeySetIndex = generic.Index
+//genny:scope end
+`
+
+func TestScopes(t *testing.T) {
+
+	typeSets, err := parse.TypeSet("Item=int,string Key=float64,bool")
+	if !assert.NoError(t, err) {
+		return
+	}
+	output, err := parse.GenericsWithOptions("store.go", "store.go", "", strings.NewReader(scopedTemplate), typeSets, parse.Options{Strict: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	code := string(output)
+	for _, decl := range []string{"type IntList []int", "type StringList []string", "type Float64Set map[float64]struct{}", "type BoolSet map[bool]struct{}"} {
+		assert.Equal(t, 1, strings.Count(code, decl), decl)
+	}
+	assert.True(t, strings.Index(code, "StringList") < strings.Index(code, "Float64Set"), "the scopes are generated after the rest of the template")
+	assert.Contains(t, code, "var Float64SetIndex = 0")
+	assert.Contains(t, code, "var BoolSetIndex = 1")
+	assert.NotContains(t, code, "genny:scope")
+
+	for _, test := range []struct {
+		src string
+		err string
+	}{
+		{"package store\n//genny:scope begin\n", "store.go:2:1: invalid //genny:scope: the scope begun on line 2 is never ended"},
+		{"package store\n//genny:scope begin A\n//genny:scope begin B\n", "store.go:3:1: invalid //genny:scope: scopes cannot be nested; the scope A (line 2) is not ended"},
+		{"package store\n//genny:scope end\n", "store.go:2:1: invalid //genny:scope: no scope is begun"},
+		{"package store\n//genny:scope start\n", "store.go:2:1: invalid //genny:scope: begin or end expected"},
+	} {
+		_, err := parse.GenericsWithOptions("store.go", "store.go", "", strings.NewReader(test.src), nil, parse.Options{})
+		assert.EqualError(t, err, test.err)
+	}
+
+}
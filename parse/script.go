@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"bufio"
+	"bytes"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// scriptConstraint keeps a script out of the package in its directory, so
+// that it is only built when it is named on the command line, as in
+// go run script.go.
+const scriptConstraint = "//go:build ignore"
+
+// mainStub is added to scripts whose template declares no main function.
+const mainStub = `
+// main is a stub for running the generated code as a script.
+func main() {
+}
+`
+
+// scriptPackage gets the package of a script, which must be main.
+func scriptPackage(pkgName string) (string, error) {
+	if pkgName != "" && pkgName != "main" {
+		return "", &errBadOption{Option: "package", Value: pkgName, Message: "scripts are in package main"}
+	}
+	return "main", nil
+}
+
+// makeScript makes the generated code in package main a standalone
+// program: the template's build constraints are replaced by
+// scriptConstraint, and a main stub is added if there is no main function.
+func makeScript(output []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(scriptConstraint + "\n\n")
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	inHeader := true
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(text, "package ") {
+			inHeader = false
+		}
+		if inHeader && (constraint.IsGoBuild(text) || constraint.IsPlusBuild(text)) {
+			continue
+		}
+		buf.Write(scanner.Bytes())
+		buf.WriteByte('\n')
+	}
+	if !declaresMain(buf.Bytes()) {
+		buf.WriteString(mainStub)
+	}
+	return buf.Bytes()
+}
+
+// declaresMain gets whether the Go source declares a main function. Source
+// that does not parse is taken not to.
+func declaresMain(src []byte) bool {
+	file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return false
+	}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
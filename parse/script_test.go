@@ -0,0 +1,17 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeScript(t *testing.T) {
+
+	script := string(makeScript([]byte("//go:build linux\n// +build linux\n\npackage main\n\nfunc main() {}\n")))
+	assert.Equal(t, "//go:build ignore\n\n\npackage main\n\nfunc main() {}\n", script, "the template's constraints are replaced and its main is kept")
+
+	script = string(makeScript([]byte("package main\n\nvar x = 1\n")))
+	assert.Contains(t, script, "func main() {\n}\n")
+
+}
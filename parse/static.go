@@ -0,0 +1,55 @@
+package parse
+
+import "bytes"
+
+// lineSet is a set of line numbers, as a bitmap.
+type lineSet []uint64
+
+func (s *lineSet) add(n int) {
+	for n/64 >= len(*s) {
+		*s = append(*s, 0)
+	}
+	(*s)[n/64] |= 1 << uint(n%64)
+}
+
+func (s lineSet) has(n int) bool {
+	return n/64 < len(s) && s[n/64]&(1<<uint(n%64)) != 0
+}
+
+// staticLines gets the lines of the template, numbered from 1, that are
+// the same for every type set: those without a generic type, a reference
+// to the generic package, a leading comment (which genny may drop with
+// the declaration it documents) or, if todos is set, a TODO or FIXME.
+// They are copied to the output without being scanned.
+func staticLines(src []byte, generics []string, todos bool) lineSet {
+	var static lineSet
+	for n := 1; len(src) > 0; n++ {
+		end := bytes.IndexByte(src, '\n')
+		if end < 0 {
+			end = len(src)
+		}
+		if isStatic(src[:end], generics, todos) {
+			static.add(n)
+		}
+		if end < len(src) {
+			end++
+		}
+		src = src[end:]
+	}
+	return static
+}
+
+func isStatic(line []byte, generics []string, todos bool) bool {
+	if bytes.HasPrefix(bytes.TrimSpace(line), []byte("//")) || bytes.Contains(line, []byte(genericPackage+".")) {
+		return false
+	}
+	if todos && (bytes.Contains(line, []byte("TODO")) || bytes.Contains(line, []byte("FIXME"))) {
+		return false
+	}
+	for _, t := range generics {
+		if bytes.Contains(line, []byte(t)) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,85 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticLines(t *testing.T) {
+	src := []byte(strings.Join([]string{
+		"package p",                     // 1
+		"",                              // 2
+		"type Item generic.Type",        // 3
+		"// Thing is a thing.",          // 4
+		"func Thing() int {",            // 5
+		"\treturn 1 // TODO: not 2",     // 6
+		"}",                             // 7
+		"func ItemThing(v Item) Item {", // 8
+		"\treturn v",                    // 9
+		"}",                             // 10
+	}, "\n"))
+
+	static := staticLines(src, []string{"Item"}, false)
+	var lines []int
+	for n := 1; n <= 11; n++ {
+		if static.has(n) {
+			lines = append(lines, n)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 5, 6, 7, 9, 10}, lines)
+
+	static = staticLines(src, []string{"Item"}, true)
+	assert.False(t, static.has(6), "TODOs are not static when they are stripped or tagged")
+	assert.False(t, static.has(130))
+}
+
+// BenchmarkSubstituteStatic substitutes a template whose lines are mostly
+// without placeholders.
+func BenchmarkSubstituteStatic(b *testing.B) {
+	var template strings.Builder
+	template.WriteString("package p\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\nvar ItemZero Item\n\n")
+	for i := 0; i < 500; i++ {
+		template.WriteString("func init() {\n\tx := 1\n\tfor i := 0; i < 10; i++ {\n\t\tx += i\n\t}\n\t_ = x\n}\n\n")
+	}
+	src := template.String()
+	typeSet := map[string]string{"Item": "int"}
+	fs, file, err := parseSource("template.go", strings.NewReader(src), nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := substitute("template.go", strings.NewReader(src), fs, file, typeSet, 0, 1, Options{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// sharedTemplate declares a table shared by every type set, whose lines
+// without placeholders must still be generated only once.
+const sharedTemplate = `package p
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+var sizes = [generic.Count]int{
+	1,
+	2,
+}
+
+func ItemSize() int { return sizes[generic.Index] }
+`
+
+func TestStaticLinesShared(t *testing.T) {
+	typeSets := []map[string]string{{"Item": "int"}, {"Item": "string"}}
+	output, err := GenericsWithOptions("p.go", "p.go", "", strings.NewReader(sharedTemplate), typeSets, Options{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, strings.Count(string(output), "var sizes = [2]int{"))
+		assert.Equal(t, 1, strings.Count(string(output), "\t1,\n"))
+		assert.Contains(t, string(output), "func StringSize() int { return sizes[1] }")
+	}
+}
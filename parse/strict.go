@@ -0,0 +1,183 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/cheekybits/genny/diag"
+)
+
+// genericTypes gets the names of the generic types declared in the
+// template.
+func genericTypes(file *ast.File) map[string]bool {
+	generics := make(map[string]bool)
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			if sel, ok := ts.Type.(*ast.SelectorExpr); ok {
+				if name, ok := sel.X.(*ast.Ident); ok && name.Name == genericPackage {
+					generics[ts.Name.Name] = true
+				}
+			}
+		}
+	}
+	return generics
+}
+
+// checkParams checks that every type in the type set replaces a generic
+// type of the template, and that every generic type is used by the
+// template. It finds every unknown generic type, or else every unused one.
+func checkParams(fs *token.FileSet, file *ast.File, typeSet map[string]string) diag.List {
+	generics := genericTypes(file)
+	var known, params []string
+	for g := range generics {
+		known = append(known, g)
+	}
+	sort.Strings(known)
+	for t := range typeSet {
+		params = append(params, t)
+	}
+	sort.Strings(params)
+	var problems diag.List
+	for _, t := range params {
+		if !generics[t] {
+			problems.Add(&errUnknownParam{Param: t, Known: known}, diag.Error)
+		}
+	}
+	if len(problems) > 0 {
+		// the uses of the template are moot if the type set is wrong
+		return problems
+	}
+
+	uses := make(map[string]int)
+	ast.Inspect(file, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		for g := range generics {
+			if strings.Contains(ident.Name, g) {
+				uses[g]++
+			}
+		}
+		return true
+	})
+	for _, g := range known {
+		// the declaration itself is the only use
+		if uses[g] <= 1 {
+			problems.Add(&errUnusedPlaceholder{GenericType: g, Pos: declPos(fs, file, g)}, diag.Error)
+		}
+	}
+	return problems
+}
+
+// strictError gets the problems found by a strict check as an error in
+// strict mode. Otherwise they are added to opts.Warnings, if it is set, as
+// warnings, once each.
+func strictError(problems diag.List, opts Options) error {
+	if opts.Strict {
+		return problems.Err()
+	}
+	if opts.Warnings == nil {
+		return nil
+	}
+	for _, d := range problems {
+		warning := diag.FromError(d, diag.Warning)
+		if !opts.Warnings.Has(warning) {
+			*opts.Warnings = append(*opts.Warnings, warning)
+		}
+	}
+	return nil
+}
+
+// declPos gets the position of the declaration of the top level type.
+func declPos(fs *token.FileSet, file *ast.File, name string) token.Position {
+	if obj := file.Scope.Lookup(name); obj != nil {
+		if spec, ok := obj.Decl.(*ast.TypeSpec); ok {
+			return fs.Position(spec.Pos())
+		}
+	}
+	return token.Position{}
+}
+
+// checkCollisions checks that no top level name is declared by more than
+// one type set, e.g. because two specific types wordify to the same name.
+func checkCollisions(filename string, output []byte, origins []origin, typeSets []map[string]string) error {
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, output, 0)
+	if err != nil {
+		// syntax errors are left for goimports to report
+		return nil
+	}
+	declared := make(map[string][]int)
+	var names []string
+	declare := func(name string, pos token.Pos) {
+		if name == "_" || name == "init" {
+			return
+		}
+		line := fs.Position(pos).Line
+		if line < 1 || line > len(origins) {
+			return
+		}
+		if _, ok := declared[name]; !ok {
+			names = append(names, name)
+		}
+		declared[name] = append(declared[name], origins[line-1].TypeSet)
+	}
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				name = recvName(d.Recv.List[0].Type) + "." + name
+			}
+			declare(name, d.Pos())
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					declare(s.Name.Name, s.Pos())
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						declare(n.Name, n.Pos())
+					}
+				}
+			}
+		}
+	}
+	for _, name := range names {
+		sets := declared[name]
+		if len(sets) < 2 {
+			continue
+		}
+		var collision []string
+		for _, i := range sets {
+			if i >= 0 {
+				collision = append(collision, typeSetString(typeSets[i]))
+			}
+		}
+		return &errCollision{Name: name, TypeSets: collision}
+	}
+	return nil
+}
+
+// recvName gets the name of the type of a method receiver.
+func recvName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return recvName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
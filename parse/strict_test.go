@@ -0,0 +1,226 @@
+package parse_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/diag"
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const strictTemplate = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type Unused generic.Type
+
+type ItemQueue struct {
+	items []Item
+}
+`
+
+func TestStrict(t *testing.T) {
+
+	strict := parse.Options{Strict: true}
+	for _, test := range []struct {
+		typeSets []map[string]string
+		err      string
+	}{
+		{
+			typeSets: []map[string]string{{"Item": "int", "Unused": "int", "Other": "string"}},
+			err:      "Unknown generic type 'Other' (the template declares: Item, Unused)",
+		},
+		{
+			typeSets: []map[string]string{{"Item": "int", "Unused": "int"}},
+			err:      "queue.go:7:6: generic type 'Unused' is declared but never used",
+		},
+	} {
+		_, err := parse.GenericsWithOptions("queue.go", "out.go", "", strings.NewReader(strictTemplate), test.typeSets, strict)
+		if assert.Error(t, err) {
+			assert.Equal(t, test.err, err.Error())
+		}
+		// lenient by default
+		_, err = parse.Generics("queue.go", "out.go", "", strings.NewReader(strictTemplate), test.typeSets)
+		assert.NoError(t, err)
+	}
+
+}
+
+func TestStrictWarnings(t *testing.T) {
+
+	typeSets := []map[string]string{{"Item": "int", "Unused": "int"}, {"Item": "string", "Unused": "int"}}
+	var warnings diag.List
+	_, err := parse.GenericsWithOptions("queue.go", "out.go", "", strings.NewReader(strictTemplate), typeSets, parse.Options{Warnings: &warnings})
+	assert.NoError(t, err)
+	if assert.Len(t, warnings, 1, "warnings are only reported once") {
+		assert.Equal(t, "warning: queue.go:7:6: generic type 'Unused' is declared but never used", warnings[0].Error())
+	}
+
+	// every problem is reported at once
+	template := strings.Replace(strictTemplate, "type Unused generic.Type", "type Unused generic.Type\n\ntype Spare generic.Type", 1)
+	typeSets = []map[string]string{{"Item": "int", "Unused": "int", "Spare": "int"}}
+	_, err = parse.GenericsWithOptions("queue.go", "out.go", "", strings.NewReader(template), typeSets, parse.Options{Strict: true})
+	var problems diag.List
+	if assert.True(t, errors.As(err, &problems)) {
+		assert.Len(t, problems, 2)
+	}
+
+}
+
+func TestRequireDocs(t *testing.T) {
+
+	typeSets := []map[string]string{{"Item": "int", "Unused": "int"}}
+	_, err := parse.GenericsWithOptions("queue.go", "out.go", "", strings.NewReader(strictTemplate), typeSets, parse.Options{RequireDocs: true})
+	if assert.Error(t, err) {
+		assert.Equal(t, "queue.go:5:6: generic type 'Item' has no doc comment describing it", err.Error())
+	}
+
+	template, err := ioutil.ReadFile("test/queue/generic_queue.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = parse.GenericsWithOptions("generic_queue.go", "out.go", "", strings.NewReader(string(template)), []map[string]string{{"Something": "int"}}, parse.Options{RequireDocs: true})
+	assert.NoError(t, err)
+
+}
+
+func TestRiskyNames(t *testing.T) {
+
+	template := func(name, directive string) string {
+		return "package a\n\nimport \"github.com/cheekybits/genny/generic\"\n" + directive + "\ntype " + name + " generic.Type\n\nvar Zero" + name + " " + name + "\n"
+	}
+	for _, test := range []struct {
+		name string
+		err  string
+	}{
+		{"len", "a.go:5:6: generic type 'len' is named like the builtin len, so substituting it would also change every identifier, literal and comment containing it; rename it (e.g. LenType) or allow it with //genny:allow len"},
+		{"String", "a.go:5:6: generic type 'String' is named like the builtin string (ignoring case), so substituting it would also change every identifier, literal and comment containing it; rename it (e.g. StringType) or allow it with //genny:allow String"},
+		{"Type", "a.go:5:6: generic type 'Type' is named like generic.Type, so substituting it would also change every identifier, literal and comment containing it; rename it (e.g. ElemType) or allow it with //genny:allow Type"},
+	} {
+		typeSets := []map[string]string{{test.name: "int"}}
+		_, err := parse.Generics("a.go", "b.go", "", strings.NewReader(template(test.name, "")), typeSets)
+		if assert.Error(t, err) {
+			assert.Equal(t, test.err, err.Error())
+		}
+		output, err := parse.Generics("a.go", "b.go", "", strings.NewReader(template(test.name, "//genny:allow "+test.name+"\n")), typeSets)
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(output), "var ZeroInt int")
+			assert.NotContains(t, string(output), "genny:allow")
+		}
+	}
+
+}
+
+func TestStrictCollisions(t *testing.T) {
+
+	template, err := ioutil.ReadFile("test/queue/generic_queue.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	typeSets := []map[string]string{{"Something": "int"}, {"Something": "Int"}}
+	_, err = parse.GenericsWithOptions("generic_queue.go", "out.go", "", strings.NewReader(string(template)), typeSets, parse.Options{Strict: true})
+	if assert.Error(t, err) {
+		assert.Equal(t, "'IntQueue' is generated more than once (by Something=int and Something=Int)", err.Error())
+	}
+
+}
+
+func TestVerify(t *testing.T) {
+
+	output, err := ioutil.ReadFile("test/queue/int_queue.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, parse.Verify("test/queue/int_queue.go", output))
+
+	broken := strings.Replace(string(output), "return item", "return q", 1)
+	err = parse.Verify("test/queue/int_queue.go", []byte(broken))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Generated code does not compile")
+		assert.Contains(t, err.Error(), "cannot use q")
+	}
+
+}
+
+func TestTypeCheck(t *testing.T) {
+
+	template := `package set
+
+import "github.com/cheekybits/genny/generic"
+
+type Key generic.Type
+
+// KeySet is a set of Key.
+type KeySet map[Key]struct{}
+
+func (s KeySet) Add(k Key) {
+	s[k] = struct{}{}
+}
+`
+	out := filepath.Join(t.TempDir(), "gen.go")
+	typeSets, err := parse.TypeSet("Key=string,[]byte|name=Bytes")
+	require.NoError(t, err)
+	_, err = parse.GenericsWithOptions("set.go", out, "", strings.NewReader(template), typeSets, parse.Options{})
+	assert.NoError(t, err)
+
+	_, err = parse.GenericsWithOptions("set.go", out, "", strings.NewReader(template), typeSets, parse.Options{TypeCheck: true})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Generated code does not compile")
+		assert.Contains(t, err.Error(), "set.go:8: invalid map key type []byte (with Key=[]byte|name=Bytes, at "+out+":")
+	}
+
+	output, err := parse.GenericsWithOptions("set.go", out, "", strings.NewReader(template), typeSets[:1], parse.Options{TypeCheck: true})
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "type StringSet map[string]struct{}")
+
+}
+
+func TestVerifyWithLoader(t *testing.T) {
+
+	output := []byte("package tagged\n\nimport \"time\"\n\nvar Timeout time.Duration = time.Second\n\nvar Value Extra = Extra(Timeout)\n\nvar B Base\n")
+
+	err := parse.Verify("test/tagged/gen.go", output)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "undefined: Extra")
+	}
+
+	loader := &parse.Loader{BuildFlags: []string{"-tags=gennytagged"}}
+	opts := parse.Options{Loader: loader}
+	assert.NoError(t, parse.VerifyWithOptions("test/tagged/gen.go", output, opts))
+	// the loader is reused
+	assert.NoError(t, parse.VerifyWithOptions("test/tagged/gen.go", output, opts))
+
+}
+
+func TestImportCycle(t *testing.T) {
+
+	template, err := ioutil.ReadFile("test/queue/generic_queue.go")
+	if !assert.NoError(t, err) {
+		return
+	}
+	typeSets := []map[string]string{{"Something": "a.Widget"}}
+	output, err := parse.Generics("generic_queue.go", "widget_queue.go", "b", strings.NewReader(strings.Replace(string(template), "import \"github.com/cheekybits/genny/generic\"", "import (\n\t\"github.com/cheekybits/genny/generic\"\n\t\"github.com/cheekybits/genny/parse/test/cycle/a\"\n)", 1)), typeSets)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	err = parse.CheckImportCycle("test/cycle/b/widget_queue.go", output, parse.Options{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "Generated code would create an import cycle: github.com/cheekybits/genny/parse/test/cycle/b imports github.com/cheekybits/genny/parse/test/cycle/a imports github.com/cheekybits/genny/parse/test/cycle/b")
+	}
+	err = parse.Verify("test/cycle/b/widget_queue.go", output)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "import cycle")
+	}
+
+	// a does not import the queue package
+	assert.NoError(t, parse.CheckImportCycle("test/queue/widget_queue.go", output, parse.Options{}))
+
+}
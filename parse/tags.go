@@ -0,0 +1,231 @@
+package parse
+
+import (
+	"go/scanner"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Casing controls how specific types are written into struct tag values,
+// and into the identifiers, strings and comments of the template (see
+// PositionCasing).
+type Casing int
+
+const (
+	// CasingKeep follows the casing of the generic type in the tag value:
+	// something becomes int and Something becomes Int.
+	CasingKeep Casing = iota
+	// CasingUpper writes the specific type with an upper case first
+	// letter, e.g. MyType.
+	CasingUpper
+	// CasingLower writes the specific type with a lower case first letter,
+	// e.g. myType.
+	CasingLower
+	// CasingSnake writes the specific type in snake case, e.g. my_type.
+	CasingSnake
+	// CasingVerbatim writes the specific type as it is given, e.g.
+	// *bytes.Buffer. It is only used in strings and comments.
+	CasingVerbatim
+)
+
+var casings = map[string]Casing{
+	"keep":  CasingKeep,
+	"upper": CasingUpper,
+	"lower": CasingLower,
+	"snake": CasingSnake,
+}
+
+// ParseCasing gets the Casing for "keep", "upper", "lower" or "snake".
+func ParseCasing(s string) (Casing, error) {
+	casing, ok := casings[s]
+	if !ok {
+		return CasingKeep, &errBadOption{Option: "casing", Value: s, Message: "keep, upper, lower or snake expected"}
+	}
+	return casing, nil
+}
+
+// AllStructTags is the struct tag key that stands for every key not listed
+// itself, so that the values of all struct tags are substituted into with a
+// casing, e.g. "*:lower" or "*,db:snake".
+const AllStructTags = "*"
+
+// ParseStructTags parses a comma separated list of struct tag keys, each
+// optionally followed by a colon and its casing, e.g. "json:lower,db:snake".
+// Keys without a casing use CasingKeep.
+func ParseStructTags(s string) (map[string]Casing, error) {
+	tags := make(map[string]Casing)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		key, casing := item, CasingKeep
+		if i := strings.Index(item, ":"); i >= 0 {
+			var err error
+			if casing, err = ParseCasing(item[i+1:]); err != nil {
+				return nil, err
+			}
+			key = item[:i]
+		}
+		if !structTagKey.MatchString(key) {
+			return nil, &errBadOption{Option: "struct tag", Value: key, Message: "a struct tag key expected"}
+		}
+		tags[key] = casing
+	}
+	return tags, nil
+}
+
+// structTagKey matches a struct tag key.
+var structTagKey = regexp.MustCompile(`^[^\s:"` + "`" + `]+$`)
+
+// structTag matches a raw string literal in the conventional struct tag
+// format, key:"value" pairs separated by spaces.
+var structTag = regexp.MustCompile("^`(\\s*[^\\s:\"`]+:\"(\\\\.|[^\"\\\\])*\")+\\s*`$")
+
+// structTagPair matches a key:"value" pair of a struct tag.
+var structTagPair = regexp.MustCompile(`([^\s:"` + "`" + `]+):"((\\.|[^"\\])*)"`)
+
+// splitStructTag splits the line around its struct tag. ok is false if it
+// does not have one.
+func splitStructTag(line string) (before, tag, after string, ok bool) {
+	src := []byte(line)
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, src, nil, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return "", "", "", false
+		}
+		if tok == token.STRING && structTag.MatchString(lit) {
+			start := file.Offset(pos)
+			return line[:start], lit, line[start+len(lit):], true
+		}
+	}
+}
+
+// subTypesIntoStructTag substitutes the specific types into the values of
+// the tag keys listed in tags, with their casing, leaving the other keys
+// untouched unless AllStructTags is listed.
+func subTypesIntoStructTag(tag string, generics []string, typeSet map[string]string, tags map[string]Casing) string {
+	return structTagPair.ReplaceAllStringFunc(tag, func(pair string) string {
+		m := structTagPair.FindStringSubmatch(pair)
+		casing, ok := tags[m[1]]
+		if !ok {
+			casing, ok = tags[AllStructTags]
+		}
+		if !ok {
+			return pair
+		}
+		value := m[2]
+		for _, t := range generics {
+			value = subTypeIntoWords(value, t, typeSet[t], casing)
+		}
+		return m[1] + `:"` + value + `"`
+	})
+}
+
+// subTypeIntoWords substitutes the specific type into a struct tag value
+// or a string literal, wherever a word starts with the generic type as it
+// is, with a lower case first letter or in snake case. CasingKeep writes
+// the specific type in the form of the generic type it replaces, and
+// CasingVerbatim as it is given.
+func subTypeIntoWords(value, typeTemplate, specificType string, casing Casing) string {
+	specific := wordify(specificType, true)
+	forms := map[string]Casing{typeTemplate: CasingUpper}
+	if !isExported(typeTemplate) {
+		forms[typeTemplate] = CasingLower
+	}
+	// a one word generic type is the same in lower and snake case, which
+	// is taken as lower case
+	forms[snakeCase(typeTemplate)] = CasingSnake
+	forms[lowerFirst(typeTemplate)] = CasingLower
+	var order []string
+	for form := range forms {
+		order = append(order, form)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		if len(order[i]) != len(order[j]) {
+			return len(order[i]) > len(order[j])
+		}
+		return order[i] < order[j]
+	})
+	var subbed strings.Builder
+	prev := byte(0)
+	for len(value) > 0 {
+		matched := false
+		for _, form := range order {
+			// the generic type must start a word, or a camel case part of one
+			start := !isAlphaNumeric(rune(prev)) || (unicode.IsLower(rune(prev)) && isExported(form))
+			if start && strings.HasPrefix(value, form) {
+				c := casing
+				if c == CasingKeep {
+					c = forms[form]
+				}
+				if c == CasingVerbatim {
+					subbed.WriteString(specificTypeOf(specificType))
+				} else {
+					subbed.WriteString(withCasing(specific, c))
+				}
+				prev = form[len(form)-1]
+				value = value[len(form):]
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			prev = value[0]
+			subbed.WriteByte(value[0])
+			value = value[1:]
+		}
+	}
+	return subbed.String()
+}
+
+// wordForms gets the forms of the generic type that subTypeIntoWords
+// substitutes into: as it is, with a lower case first letter and in snake
+// case.
+func wordForms(typeTemplate string) []string {
+	return []string{typeTemplate, lowerFirst(typeTemplate), snakeCase(typeTemplate)}
+}
+
+// withCasing writes the word with the casing.
+func withCasing(word string, casing Casing) string {
+	switch casing {
+	case CasingLower:
+		return lowerFirst(word)
+	case CasingSnake:
+		return snakeCase(word)
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
+
+// lowerFirst gets the word with a lower case first letter.
+func lowerFirst(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToLower(word[:1]) + word[1:]
+}
+
+// snakeCase gets the camel case word in snake case, keeping initialisms
+// together: MyType is my_type and HTTPServer is http_server.
+func snakeCase(word string) string {
+	runes := []rune(word)
+	var snake strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				snake.WriteByte('_')
+			}
+		}
+		snake.WriteRune(unicode.ToLower(r))
+	}
+	return snake.String()
+}
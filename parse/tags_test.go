@@ -0,0 +1,105 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseStructTags(t *testing.T) {
+
+	tags, err := ParseStructTags("json:lower, db:snake,xml")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Casing{"json": CasingLower, "db": CasingSnake, "xml": CasingKeep}, tags)
+	tags, err = ParseStructTags("*:lower,db:snake")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Casing{AllStructTags: CasingLower, "db": CasingSnake}, tags)
+	_, err = ParseStructTags("json:kebab")
+	assert.Error(t, err)
+	_, err = ParseStructTags(`json":lower`)
+	assert.Error(t, err)
+
+}
+
+func TestSnakeCase(t *testing.T) {
+
+	for word, expected := range map[string]string{
+		"Item":       "item",
+		"MyType":     "my_type",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"int64":      "int64",
+	} {
+		assert.Equal(t, expected, snakeCase(word))
+	}
+
+}
+
+func TestSubTypesIntoStructTag(t *testing.T) {
+
+	tags := map[string]Casing{"json": CasingKeep, "db": CasingSnake}
+	typeSet := map[string]string{"Item": "MyType"}
+	line := "\tKey Item `json:\"item,omitempty\" db:\"Item\" xml:\"item\"` // the Item"
+	before, tag, after, ok := splitStructTag(line)
+	if assert.True(t, ok) {
+		assert.Equal(t, "\tKey Item ", before)
+		assert.Equal(t, " // the Item", after)
+		assert.Equal(t, "`json:\"myType,omitempty\" db:\"my_type\" xml:\"item\"`", subTypesIntoStructTag(tag, []string{"Item"}, typeSet, tags))
+	}
+	all := map[string]Casing{AllStructTags: CasingKeep, "db": CasingSnake}
+	assert.Equal(t, "`json:\"myType,omitempty\" db:\"my_type\" xml:\"myType\"`", subTypesIntoStructTag(tag, []string{"Item"}, typeSet, all))
+	_, _, _, ok = splitStructTag("\tx := `not a tag`")
+	assert.False(t, ok)
+
+}
+
+const stringWordsTemplate = `package p
+
+import "github.com/cheekybits/genny/generic"
+
+type Something generic.Type
+
+// something is not substituted into comments
+func findSomething(somethingID string) error {
+	log("looking for something " + somethingID)
+	return errorf("Something not found (something_id)")
+}
+`
+
+func TestStringWords(t *testing.T) {
+
+	typeSets := []map[string]string{{"Something": "*bytes.Buffer"}}
+	for _, engine := range []Engine{EngineLines, EngineAST} {
+		output, err := GenericsWithOptions("p.go", "p.go", "", strings.NewReader(stringWordsTemplate), typeSets, Options{StringWords: true, Engine: engine, Unformatted: true})
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(output), `log("looking for bytesBuffer " + somethingID)`)
+			assert.Contains(t, string(output), `errorf("BytesBuffer not found (bytesBuffer_id)")`)
+			assert.Contains(t, string(output), "// something is not substituted into comments")
+		}
+		output, err = GenericsWithOptions("p.go", "p.go", "", strings.NewReader(stringWordsTemplate), typeSets, Options{StringWords: true, Engine: engine, Unformatted: true, Casings: map[string]PositionCasing{AllParams: {Strings: CasingVerbatim}}})
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(output), `log("looking for *bytes.Buffer " + somethingID)`)
+		}
+		// by default only the generic type as it is written is substituted
+		output, err = GenericsWithOptions("p.go", "p.go", "", strings.NewReader(stringWordsTemplate), typeSets, Options{Engine: engine, Unformatted: true})
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(output), `errorf("BytesBuffer not found (something_id)")`)
+		}
+	}
+
+}
+
+func TestStructTagsWithoutGenericField(t *testing.T) {
+
+	template := "package p\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\ntype ItemHolder struct {\n\tValue Item `json:\"item\"`\n\tCount int `json:\"item_count\"`\n}\n"
+	typeSets := []map[string]string{{"Item": "int"}}
+	for _, engine := range []Engine{EngineLines, EngineAST} {
+		output, err := GenericsWithOptions("p.go", "p.go", "", strings.NewReader(template), typeSets, Options{StructTags: map[string]Casing{AllStructTags: CasingKeep}, Engine: engine})
+		if assert.NoError(t, err) {
+			assert.Contains(t, string(output), "`json:\"int_count\"`")
+			assert.Contains(t, string(output), "`json:\"int\"`")
+		}
+	}
+
+}
@@ -0,0 +1,46 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+//
+// Template: generic_queue.go (sha256:06c81a3e71ed7104b37176a69ee809c9c1ba1313927d421ac512dd0ab25d9474)
+// Type sets:
+//   - Something=int
+//   - Something=string
+// Parameters:
+//   - Something is a type that does something
+
+package annotate
+
+// IntQueue is a queue of Ints.
+type IntQueue struct {
+	items []int
+}
+
+func NewIntQueue() *IntQueue {
+	return &IntQueue{items: make([]int, 0)}
+}
+func (q *IntQueue) Push(item int) {
+	q.items = append(q.items, item)
+}
+func (q *IntQueue) Pop() int {
+	item := q.items[0]
+	q.items = q.items[1:]
+	return item
+}
+
+// StringQueue is a queue of Strings.
+type StringQueue struct {
+	items []string
+}
+
+func NewStringQueue() *StringQueue {
+	return &StringQueue{items: make([]string, 0)}
+}
+func (q *StringQueue) Push(item string) {
+	q.items = append(q.items, item)
+}
+func (q *StringQueue) Pop() string {
+	item := q.items[0]
+	q.items = q.items[1:]
+	return item
+}
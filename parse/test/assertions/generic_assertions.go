@@ -0,0 +1,24 @@
+package assertions
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+// DescribeItem describes the kind of v.
+func DescribeItem(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case Item:
+		return "item"
+	case []Item:
+		return "slice"
+	}
+	return "unknown"
+}
+
+// AsItem gets v as an Item.
+func AsItem(v interface{}) (Item, bool) {
+	item, ok := v.(Item)
+	return item, ok
+}
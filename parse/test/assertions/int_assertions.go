@@ -0,0 +1,24 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package assertions
+
+// DescribeInt describes the kind of v.
+func DescribeInt(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case int:
+		return "item"
+	case []int:
+		return "slice"
+	}
+	return "unknown"
+}
+
+// AsInt gets v as an Int.
+func AsInt(v interface{}) (int, bool) {
+	item, ok := v.(int)
+	return item, ok
+}
@@ -0,0 +1,20 @@
+package comments
+
+import "github.com/cheekybits/genny/generic"
+
+type ItemType generic.Type
+
+// ItemTypeCounter counts ItemType values.
+type ItemTypeCounter struct {
+	counts map[ItemType]int // how many of each ItemType
+	total  int              // all ItemType values seen
+}
+
+func NewItemTypeCounter() *ItemTypeCounter {
+	return &ItemTypeCounter{counts: make(map[ItemType]int)} // an empty ItemTypeCounter
+}
+
+func (c *ItemTypeCounter) Add(v ItemType) {
+	c.counts[v]++ // count this ItemType
+	c.total++
+}
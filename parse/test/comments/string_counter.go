@@ -0,0 +1,20 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package comments
+
+// StringCounter counts string values.
+type StringCounter struct {
+	counts map[string]int // how many of each string
+	total  int            // all string values seen
+}
+
+func NewStringCounter() *StringCounter {
+	return &StringCounter{counts: make(map[string]int)} // an empty StringCounter
+}
+
+func (c *StringCounter) Add(v string) {
+	c.counts[v]++ // count this string
+	c.total++
+}
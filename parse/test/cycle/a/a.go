@@ -0,0 +1,14 @@
+// Package a declares a type to generate code for, and imports b.
+package a
+
+import "github.com/cheekybits/genny/parse/test/cycle/b"
+
+// Widget is a type from a package that imports b.
+type Widget struct {
+	Name string
+}
+
+// NewWidget makes a Widget named after b.
+func NewWidget() Widget {
+	return Widget{Name: b.Name}
+}
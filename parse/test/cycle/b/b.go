@@ -0,0 +1,6 @@
+// Package b is a package that a imports, so code generated into it must
+// not import a.
+package b
+
+// Name names the package.
+const Name = "b"
@@ -0,0 +1,52 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package embedded
+
+import (
+	"bytes"
+	"io"
+)
+
+// BytesBufferReader reads BytesBuffers.
+type BytesBufferReader interface {
+	ReadBytesBuffer() (*bytes.Buffer, error)
+}
+
+// BytesBufferReadCloser is an BytesBufferReader that can be closed.
+type BytesBufferReadCloser interface {
+	BytesBufferReader
+	io.Closer
+}
+
+// BytesBufferBox embeds an *bytes.Buffer and an BytesBufferHolder.
+type BytesBufferBox struct {
+	*bytes.Buffer
+	*BytesBufferHolder
+}
+
+// NewBytesBufferBox makes an BytesBufferBox holding the BytesBuffer.
+func NewBytesBufferBox(item *bytes.Buffer) *BytesBufferBox {
+	return &BytesBufferBox{Buffer: item, BytesBufferHolder: &BytesBufferHolder{}}
+}
+
+// Unwrap gets the *bytes.Buffer in the box.
+func (b *BytesBufferBox) Unwrap() *bytes.Buffer {
+	return b.Buffer
+}
+
+// BytesBufferHolder holds BytesBuffers.
+type BytesBufferHolder struct {
+	items []*bytes.Buffer
+}
+
+// ReadBytesBuffer reads the first BytesBuffer.
+func (h *BytesBufferHolder) ReadBytesBuffer() (*bytes.Buffer, error) {
+	if len(h.items) == 0 {
+		return nil, io.EOF
+	}
+	return h.items[0], nil
+}
+
+var _ BytesBufferReader = (*BytesBufferHolder)(nil)
@@ -0,0 +1,3 @@
+package embedded
+
+type Thing struct{}
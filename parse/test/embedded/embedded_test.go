@@ -0,0 +1,25 @@
+package embedded
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPromotedFields(t *testing.T) {
+
+	buf := new(bytes.Buffer)
+	box := NewBytesBufferBox(buf)
+	if box.Unwrap() != buf || box.Buffer != buf {
+		t.Error("the box should hold the buffer in its embedded field")
+	}
+	box.WriteString("promoted")
+	if buf.String() != "promoted" {
+		t.Error("the methods of the buffer should be promoted to the box")
+	}
+
+	thing := &Thing{}
+	if NewThingBox(thing).Unwrap() != thing {
+		t.Error("the box should hold the thing in its embedded field")
+	}
+
+}
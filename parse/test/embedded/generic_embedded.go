@@ -0,0 +1,51 @@
+package embedded
+
+import (
+	"io"
+
+	"github.com/cheekybits/genny/generic"
+)
+
+type Item generic.Type
+
+// ItemReader reads Items.
+type ItemReader interface {
+	ReadItem() (Item, error)
+}
+
+// ItemReadCloser is an ItemReader that can be closed.
+type ItemReadCloser interface {
+	ItemReader
+	io.Closer
+}
+
+// ItemBox embeds an Item and an ItemHolder.
+type ItemBox struct {
+	Item
+	*ItemHolder
+}
+
+// NewItemBox makes an ItemBox holding the Item.
+func NewItemBox(item Item) *ItemBox {
+	return &ItemBox{Item: item, ItemHolder: &ItemHolder{}}
+}
+
+// Unwrap gets the Item in the box.
+func (b *ItemBox) Unwrap() Item {
+	return b.Item
+}
+
+// ItemHolder holds Items.
+type ItemHolder struct {
+	items []Item
+}
+
+// ReadItem reads the first Item.
+func (h *ItemHolder) ReadItem() (Item, error) {
+	if len(h.items) == 0 {
+		return nil, io.EOF
+	}
+	return h.items[0], nil
+}
+
+var _ ItemReader = (*ItemHolder)(nil)
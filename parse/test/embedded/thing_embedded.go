@@ -0,0 +1,49 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package embedded
+
+import "io"
+
+// ThingReader reads Things.
+type ThingReader interface {
+	ReadThing() (*Thing, error)
+}
+
+// ThingReadCloser is an ThingReader that can be closed.
+type ThingReadCloser interface {
+	ThingReader
+	io.Closer
+}
+
+// ThingBox embeds an *Thing and an ThingHolder.
+type ThingBox struct {
+	*Thing
+	*ThingHolder
+}
+
+// NewThingBox makes an ThingBox holding the Thing.
+func NewThingBox(item *Thing) *ThingBox {
+	return &ThingBox{Thing: item, ThingHolder: &ThingHolder{}}
+}
+
+// Unwrap gets the *Thing in the box.
+func (b *ThingBox) Unwrap() *Thing {
+	return b.Thing
+}
+
+// ThingHolder holds Things.
+type ThingHolder struct {
+	items []*Thing
+}
+
+// ReadThing reads the first Thing.
+func (h *ThingHolder) ReadThing() (*Thing, error) {
+	if len(h.items) == 0 {
+		return nil, io.EOF
+	}
+	return h.items[0], nil
+}
+
+var _ ThingReader = (*ThingHolder)(nil)
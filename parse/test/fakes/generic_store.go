@@ -0,0 +1,40 @@
+package fakes
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+// ItemStore stores Items by key.
+type ItemStore struct {
+	items map[string]Item
+}
+
+func (s *ItemStore) Put(key string, item Item) {
+	s.items[key] = item
+}
+
+func (s *ItemStore) Get(key string) (item Item, ok bool) {
+	item, ok = s.items[key]
+	return
+}
+
+func (s *ItemStore) PutAll(prefix string, items ...Item) error {
+	for i, item := range items {
+		s.Put(prefix+string(rune('a'+i)), item)
+	}
+	return nil
+}
+
+func (s *ItemStore) Each(fn func(string, Item)) {
+	for key, item := range s.items {
+		fn(key, item)
+	}
+}
+
+func (s ItemStore) Len() int {
+	return len(s.items)
+}
+
+func (s *ItemStore) reset() {
+	s.items = make(map[string]Item)
+}
@@ -0,0 +1,6 @@
+package fakes
+
+var (
+	_ StringStoreInterface = (*StringStore)(nil)
+	_ StringStoreInterface = (*FakeStringStore)(nil)
+)
@@ -0,0 +1,79 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package fakes
+
+// StringStore stores Strings by key.
+type StringStore struct {
+	items map[string]string
+}
+
+func (s *StringStore) Put(key string, item string) {
+	s.items[key] = item
+}
+
+func (s *StringStore) Get(key string) (item string, ok bool) {
+	item, ok = s.items[key]
+	return
+}
+
+func (s *StringStore) PutAll(prefix string, items ...string) error {
+	for i, item := range items {
+		s.Put(prefix+string(rune('a'+i)), item)
+	}
+	return nil
+}
+
+func (s *StringStore) Each(fn func(string, string)) {
+	for key, item := range s.items {
+		fn(key, item)
+	}
+}
+
+func (s StringStore) Len() int {
+	return len(s.items)
+}
+
+func (s *StringStore) reset() {
+	s.items = make(map[string]string)
+}
+
+// StringStoreInterface is the method set of *StringStore.
+type StringStoreInterface interface {
+	Put(key string, item string)
+	Get(key string) (item string, ok bool)
+	PutAll(prefix string, items ...string) error
+	Each(fn func(string, string))
+	Len() int
+}
+
+// FakeStringStore is a fake StringStoreInterface for tests.
+// Each method calls the function in the matching field, which must be set.
+type FakeStringStore struct {
+	PutFunc    func(key string, item string)
+	GetFunc    func(key string) (item string, ok bool)
+	PutAllFunc func(prefix string, items ...string) error
+	EachFunc   func(fn func(string, string))
+	LenFunc    func() int
+}
+
+func (fake *FakeStringStore) Put(key string, item string) {
+	fake.PutFunc(key, item)
+}
+
+func (fake *FakeStringStore) Get(key string) (item string, ok bool) {
+	return fake.GetFunc(key)
+}
+
+func (fake *FakeStringStore) PutAll(prefix string, items ...string) error {
+	return fake.PutAllFunc(prefix, items...)
+}
+
+func (fake *FakeStringStore) Each(fn func(string, string)) {
+	fake.EachFunc(fn)
+}
+
+func (fake *FakeStringStore) Len() int {
+	return fake.LenFunc()
+}
@@ -0,0 +1,27 @@
+package fakes
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeStringStore(t *testing.T) {
+
+	var got []string
+	var store StringStoreInterface = &FakeStringStore{
+		PutAllFunc: func(prefix string, items ...string) error {
+			got = append(got, items...)
+			return nil
+		},
+		GetFunc: func(key string) (string, bool) {
+			return "value of " + key, true
+		},
+	}
+	assert.NoError(t, store.PutAll("p", "a", "b"))
+	assert.Equal(t, []string{"a", "b"}, got)
+	item, ok := store.Get("k")
+	assert.True(t, ok)
+	assert.Equal(t, "value of k", item)
+
+}
@@ -0,0 +1,3 @@
+package funcs
+
+type Thing struct{}
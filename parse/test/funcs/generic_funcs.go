@@ -0,0 +1,53 @@
+package funcs
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+// ItemFunc is a function of an Item.
+type ItemFunc func(Item) Item
+
+// ItemCombiner combines two Items.
+type ItemCombiner func(
+	a Item,
+	b Item,
+) Item
+
+// MapItems applies fn to every Item.
+func MapItems(
+	items []Item,
+	fn func(Item) Item,
+) []Item {
+	out := make([]Item, 0, len(items))
+	for _, item := range items {
+		out = append(out, fn(item))
+	}
+	return out
+}
+
+// AppendItems appends Items to dst.
+func AppendItems(dst []Item, items ...Item) []Item {
+	return append(dst, items...)
+}
+
+// ApplyItem applies every fn to the Item in turn.
+func ApplyItem(item Item, fns ...func(Item) (Item,
+	error)) (Item, error) {
+	var err error
+	for _, fn := range fns {
+		if item, err = fn(item); err != nil {
+			return item, err
+		}
+	}
+	return item, nil
+}
+
+// IdentityItem returns the Item unchanged.
+var IdentityItem = func(item Item) Item {
+	return item
+}
+
+// ToItem converts v to an Item.
+var ToItem = func(v interface{}) Item {
+	return Item(v.(Item))
+}
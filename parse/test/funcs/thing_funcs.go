@@ -0,0 +1,53 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package funcs
+
+// ThingFunc is a function of an Thing.
+type ThingFunc func(*Thing) *Thing
+
+// ThingCombiner combines two Things.
+type ThingCombiner func(
+	a *Thing,
+	b *Thing,
+) *Thing
+
+// MapThings applies fn to every Thing.
+func MapThings(
+	items []*Thing,
+	fn func(*Thing) *Thing,
+) []*Thing {
+	out := make([]*Thing, 0, len(items))
+	for _, item := range items {
+		out = append(out, fn(item))
+	}
+	return out
+}
+
+// AppendThings appends Things to dst.
+func AppendThings(dst []*Thing, items ...*Thing) []*Thing {
+	return append(dst, items...)
+}
+
+// ApplyThing applies every fn to the *Thing in turn.
+func ApplyThing(item *Thing, fns ...func(*Thing) (*Thing,
+	error)) (*Thing, error) {
+	var err error
+	for _, fn := range fns {
+		if item, err = fn(item); err != nil {
+			return item, err
+		}
+	}
+	return item, nil
+}
+
+// IdentityThing returns the *Thing unchanged.
+var IdentityThing = func(item *Thing) *Thing {
+	return item
+}
+
+// ToThing converts v to an Thing.
+var ToThing = func(v interface{}) *Thing {
+	return (*Thing)(v.(*Thing))
+}
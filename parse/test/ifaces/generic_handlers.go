@@ -0,0 +1,30 @@
+package ifaces
+
+import "github.com/cheekybits/genny/generic"
+
+// Handler is the interface the set holds.
+type Handler generic.Interface
+
+//genny:comparable Handler
+
+// HandlerSet is a set of Handlers.
+type HandlerSet struct {
+	handlers map[Handler]bool
+}
+
+// NewHandlerSet makes an empty HandlerSet.
+func NewHandlerSet() *HandlerSet {
+	return &HandlerSet{handlers: make(map[Handler]bool)}
+}
+
+// Add adds the Handler to the set, unless it is nil.
+func (s *HandlerSet) Add(h Handler) {
+	if h != nil {
+		s.handlers[h] = true
+	}
+}
+
+// Has gets whether the Handler is in the set.
+func (s *HandlerSet) Has(h Handler) bool {
+	return s.handlers[h]
+}
@@ -0,0 +1,29 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package ifaces
+
+import "fmt"
+
+// FmtStringerSet is a set of FmtStringers.
+type FmtStringerSet struct {
+	handlers map[fmt.Stringer]bool
+}
+
+// NewFmtStringerSet makes an empty FmtStringerSet.
+func NewFmtStringerSet() *FmtStringerSet {
+	return &FmtStringerSet{handlers: make(map[fmt.Stringer]bool)}
+}
+
+// Add adds the fmt.Stringer to the set, unless it is nil.
+func (s *FmtStringerSet) Add(h fmt.Stringer) {
+	if h != nil {
+		s.handlers[h] = true
+	}
+}
+
+// Has gets whether the fmt.Stringer is in the set.
+func (s *FmtStringerSet) Has(h fmt.Stringer) bool {
+	return s.handlers[h]
+}
@@ -0,0 +1,3 @@
+package interfaces
+
+var _ IntQueueInterface = (*IntQueue)(nil)
@@ -0,0 +1,14 @@
+package ordering
+
+import "github.com/cheekybits/genny/generic"
+
+type ItemKey generic.Type
+
+type Item generic.Type
+
+// ItemKeyToItem indexes Items by ItemKey.
+type ItemKeyToItem map[ItemKey]Item
+
+func NewItemKeyToItem() ItemKeyToItem {
+	return make(ItemKeyToItem)
+}
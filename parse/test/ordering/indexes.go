@@ -0,0 +1,33 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package ordering
+
+// StringToBool indexes Bools by String.
+type StringToBool map[string]bool
+
+func NewStringToBool() StringToBool {
+	return make(StringToBool)
+}
+
+// StringToFloat64 indexes Float64s by String.
+type StringToFloat64 map[string]float64
+
+func NewStringToFloat64() StringToFloat64 {
+	return make(StringToFloat64)
+}
+
+// IntToBool indexes Bools by Int.
+type IntToBool map[int]bool
+
+func NewIntToBool() IntToBool {
+	return make(IntToBool)
+}
+
+// IntToFloat64 indexes Float64s by Int.
+type IntToFloat64 map[int]float64
+
+func NewIntToFloat64() IntToFloat64 {
+	return make(IntToFloat64)
+}
@@ -0,0 +1,19 @@
+// Package owners holds a template owned by a team.
+//
+//genny:owner storage-team, @alice
+package owners
+
+import "github.com/cheekybits/genny/generic"
+
+// Element is the type of the elements of the stack.
+type Element generic.Type
+
+// ElementStack is a last in, first out stack of Elements.
+type ElementStack struct {
+	elements []Element
+}
+
+// Push adds an element to the top of the stack.
+func (s *ElementStack) Push(e Element) {
+	s.elements = append(s.elements, e)
+}
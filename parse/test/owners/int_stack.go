@@ -0,0 +1,19 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+//
+// Owned by template: generic_stack.go
+// Owners: storage-team, @alice
+
+// Package owners holds a template owned by a team.
+package owners
+
+// IntStack is a last in, first out stack of Ints.
+type IntStack struct {
+	elements []int
+}
+
+// Push adds an element to the top of the stack.
+func (s *IntStack) Push(e int) {
+	s.elements = append(s.elements, e)
+}
@@ -0,0 +1,22 @@
+package registry
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+// holders has a constructor for the holder of each type.
+var holders [generic.Count]func() Holder
+
+// ItemHolder holds an Item.
+type ItemHolder struct {
+	value Item
+}
+
+// Name gets the name of the type held.
+func (h *ItemHolder) Name() string {
+	return "Item"
+}
+
+func init() {
+	holders[generic.Index] = func() Holder { return &ItemHolder{} }
+}
@@ -0,0 +1,15 @@
+package holders
+
+// Holder holds a value.
+type Holder interface {
+	Name() string
+}
+
+// Names gets the names of the types of all the holders.
+func Names() []string {
+	var names []string
+	for _, holder := range holders {
+		names = append(names, holder().Name())
+	}
+	return names
+}
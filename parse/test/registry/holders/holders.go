@@ -0,0 +1,36 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package holders
+
+// holders has a constructor for the holder of each type.
+var holders [2]func() Holder
+
+// IntHolder holds an Int.
+type IntHolder struct {
+	value int
+}
+
+// Name gets the name of the type held.
+func (h *IntHolder) Name() string {
+	return "Int"
+}
+
+func init() {
+	holders[0] = func() Holder { return &IntHolder{} }
+}
+
+// StringHolder holds an String.
+type StringHolder struct {
+	value string
+}
+
+// Name gets the name of the type held.
+func (h *StringHolder) Name() string {
+	return "String"
+}
+
+func init() {
+	holders[1] = func() Holder { return &StringHolder{} }
+}
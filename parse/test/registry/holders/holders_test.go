@@ -0,0 +1,12 @@
+package holders
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNames(t *testing.T) {
+	if names := Names(); !reflect.DeepEqual(names, []string{"Int", "String"}) {
+		t.Errorf("Names() = %v, want each type set in order", names)
+	}
+}
@@ -0,0 +1,35 @@
+package sections
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/cheekybits/genny/generic"
+)
+
+type Item generic.Type
+
+// ItemHandle wraps an Item with the file it came from.
+type ItemHandle struct {
+	item Item
+	file *os.File
+}
+
+// Platform gets the platform the handle was built for.
+func (h *ItemHandle) Platform() string {
+	return runtime.GOOS
+}
+
+// Fd gets the descriptor of the file.
+//
+//genny:build !windows
+func (h *ItemHandle) Fd() uintptr {
+	return h.file.Fd()
+}
+
+// Handle gets the handle of the file.
+//
+//genny:build windows
+func (h *ItemHandle) Handle() uintptr {
+	return h.file.Fd()
+}
@@ -0,0 +1,32 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package split
+
+import (
+	"os"
+	"runtime"
+)
+
+// IntHandle wraps an int with the file it came from.
+type IntHandle struct {
+	item int
+	file *os.File
+}
+
+// Platform gets the platform the handle was built for.
+func (h *IntHandle) Platform() string {
+	return runtime.GOOS
+}
+
+// StringHandle wraps an string with the file it came from.
+type StringHandle struct {
+	item string
+	file *os.File
+}
+
+// Platform gets the platform the handle was built for.
+func (h *StringHandle) Platform() string {
+	return runtime.GOOS
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package split
+
+// Fd gets the descriptor of the file.
+func (h *IntHandle) Fd() uintptr {
+	return h.file.Fd()
+}
+
+// Fd gets the descriptor of the file.
+func (h *StringHandle) Fd() uintptr {
+	return h.file.Fd()
+}
@@ -0,0 +1,17 @@
+//go:build windows
+
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package split
+
+// Handle gets the handle of the file.
+func (h *IntHandle) Handle() uintptr {
+	return h.file.Fd()
+}
+
+// Handle gets the handle of the file.
+func (h *StringHandle) Handle() uintptr {
+	return h.file.Fd()
+}
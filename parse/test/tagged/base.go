@@ -0,0 +1,6 @@
+// Package tagged has a type that is only built with the gennytagged build
+// tag, to test that compile verification uses the build flags.
+package tagged
+
+// Base is built with every tag.
+type Base int
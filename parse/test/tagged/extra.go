@@ -0,0 +1,8 @@
+//go:build gennytagged
+
+package tagged
+
+import "time"
+
+// Extra is only built with the gennytagged tag.
+type Extra time.Duration
@@ -0,0 +1,13 @@
+package tags
+
+import "github.com/cheekybits/genny/generic"
+
+// KeyType is the type of the key of the records.
+type KeyType generic.Type
+
+// KeyTypeRecord is a record stored by its KeyType.
+type KeyTypeRecord struct {
+	Key   KeyType `json:"keyType" db:"key_type" xml:"KeyType"`
+	Value string  `json:"value,omitempty"`
+	Count int     `json:"keyTypeCount" db:"key_type_count"`
+}
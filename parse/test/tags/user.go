@@ -0,0 +1,4 @@
+package tags
+
+// UserID identifies a user.
+type UserID string
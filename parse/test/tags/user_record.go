@@ -0,0 +1,12 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package tags
+
+// UserIDRecord is a record stored by its UserID.
+type UserIDRecord struct {
+	Key   UserID `json:"userID" db:"user_id" xml:"KeyType"`
+	Value string `json:"value,omitempty"`
+	Count int    `json:"userIDCount" db:"user_id_count"`
+}
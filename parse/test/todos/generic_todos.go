@@ -0,0 +1,19 @@
+package todos
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+// ItemStack is a stack of Items.
+// TODO: make ItemStack safe for concurrent use,
+// probably with a mutex.
+type ItemStack struct {
+	items []Item
+	count int // FIXME(mat): remove
+}
+
+// Push pushes an Item.
+func (s *ItemStack) Push(item Item) {
+	s.items = append(s.items, item)
+	s.count++ // TODO grow faster
+}
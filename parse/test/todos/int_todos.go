@@ -0,0 +1,17 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package todos
+
+// IntStack is a stack of Ints.
+type IntStack struct {
+	items []int
+	count int
+}
+
+// Push pushes an Int.
+func (s *IntStack) Push(item int) {
+	s.items = append(s.items, item)
+	s.count++
+}
@@ -0,0 +1,19 @@
+// This file was automatically generated by genny.
+// Any changes will be lost if this file is regenerated.
+// see https://github.com/cheekybits/genny
+
+package todos
+
+// StringStack is a stack of Strings.
+// TODO(Item=string): make StringStack safe for concurrent use,
+// probably with a mutex.
+type StringStack struct {
+	items []string
+	count int // FIXME(mat, Item=string): remove
+}
+
+// Push pushes an String.
+func (s *StringStack) Push(item string) {
+	s.items = append(s.items, item)
+	s.count++ // TODO(Item=string) grow faster
+}
@@ -0,0 +1,163 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// testSuffix is the suffix of the names of external test packages and of
+// test files.
+const testSuffix = "_test"
+
+// testPackage is the package a test-only template is generated for: the
+// package under test, whose external test package the code joins.
+type testPackage struct {
+	Name string
+	// Path is the import path of the package, if it is in a module.
+	Path string
+}
+
+// findTestPackage finds the package under test for the output file: the
+// package in its directory, named pkgName if that is given, or after the
+// other files there, or after the template.
+func findTestPackage(outputFilename, pkgName string, in io.ReadSeeker) (*testPackage, error) {
+	if outputFilename == "" {
+		return nil, &errTestPackage{Message: "the output file is needed to find the package under test"}
+	}
+	if !strings.HasSuffix(outputFilename, testSuffix+".go") {
+		return nil, &errTestPackage{Message: filepath.Base(outputFilename) + " is not a test file (" + testSuffix + ".go)"}
+	}
+	dir := filepath.Dir(outputFilename)
+	p := &testPackage{Name: strings.TrimSuffix(pkgName, testSuffix)}
+	if p.Name == "" {
+		p.Name = packageInDir(dir)
+	}
+	if p.Name == "" {
+		in.Seek(0, os.SEEK_SET)
+		file, err := parser.ParseFile(token.NewFileSet(), "", in, parser.PackageClauseOnly)
+		in.Seek(0, os.SEEK_SET)
+		if err != nil {
+			return nil, &errSource{Err: err}
+		}
+		p.Name = strings.TrimSuffix(file.Name.Name, testSuffix)
+	}
+	if m, err := findHostModule(dir); err == nil && m.Path != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			if rel, err := filepath.Rel(m.Root, abs); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				p.Path = strings.TrimSuffix(m.Path+"/"+filepath.ToSlash(rel), "/.")
+			}
+		}
+	}
+	return p, nil
+}
+
+// packageInDir gets the name of the package of the Go files in the
+// directory that are not tests, or "" if there are none.
+func packageInDir(dir string) string {
+	names, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.HasSuffix(name, testSuffix+".go") {
+			continue
+		}
+		src, err := ioutil.ReadFile(name)
+		if err != nil {
+			continue
+		}
+		if file, err := parser.ParseFile(token.NewFileSet(), name, src, parser.PackageClauseOnly); err == nil {
+			return file.Name.Name
+		}
+	}
+	return ""
+}
+
+// testTypeSets gets the type sets with the types declared by the package
+// under test qualified with its name, so that the external test package
+// can use them. They are still known by their own names in identifiers,
+// e.g. ThingTable rather than QueueThingTable for queue.Thing.
+func testTypeSets(typeSets []map[string]string, p *testPackage) []map[string]string {
+	qualified := make([]map[string]string, len(typeSets))
+	for i, typeSet := range typeSets {
+		qualified[i] = make(map[string]string, len(typeSet))
+		for generic, specific := range typeSet {
+			qualified[i][generic] = qualifySpecific(specific, p.Name)
+		}
+	}
+	return qualified
+}
+
+// qualifySpecific qualifies the exported names the specific type uses that
+// are neither built in nor already qualified with the package name.
+func qualifySpecific(specific, pkg string) string {
+	specificType, name := splitTransforms(specific)
+	expr, err := parser.ParseExpr(specificType)
+	if err != nil {
+		return specific
+	}
+	var offsets []int
+	var inspect func(n ast.Node) bool
+	inspect = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			return false
+		case *ast.Field:
+			// only the types of fields and parameters, not their names
+			ast.Inspect(n.Type, inspect)
+			return false
+		case *ast.Ident:
+			if n.IsExported() && types.Universe.Lookup(n.Name) == nil {
+				offsets = append(offsets, int(n.Pos())-1)
+			}
+		}
+		return true
+	}
+	ast.Inspect(expr, inspect)
+	if len(offsets) == 0 {
+		return specific
+	}
+	qualified := specificType
+	for i := len(offsets) - 1; i >= 0; i-- {
+		qualified = qualified[:offsets[i]] + pkg + "." + qualified[offsets[i]:]
+	}
+	if name == "" {
+		if word := wordify(specificType, true); token.IsIdentifier(word) {
+			name = word
+		}
+	}
+	if name == "" {
+		return qualified
+	}
+	// keep any other transformations given with the type
+	if i := strings.Index(specific, transformSep); i >= 0 {
+		return qualified + specific[i:]
+	}
+	return qualified + transformSep + transformName + keyValueSep + name
+}
+
+// importTestPackage adds the import of the package under test to the
+// unformatted output, which goimports drops again if it is not used.
+func importTestPackage(output []byte, p *testPackage) []byte {
+	if p.Path == "" {
+		return output
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "", output, parser.PackageClauseOnly)
+	if err != nil {
+		return output
+	}
+	imp := "\n\nimport " + strconv.Quote(p.Path)
+	if path.Base(p.Path) != p.Name {
+		imp = "\n\nimport " + p.Name + " " + strconv.Quote(p.Path)
+	}
+	end := int(file.Name.End()) - 1
+	return []byte(string(output[:end]) + imp + string(output[end:]))
+}
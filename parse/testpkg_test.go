@@ -0,0 +1,50 @@
+package parse_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const suiteTemplate = `package suite
+
+import (
+	"testing"
+
+	"github.com/cheekybits/genny/generic"
+)
+
+type Item generic.Type
+
+func TestItemTable(t *testing.T) {
+	var zero Item
+	_ = []Item{zero}
+}
+`
+
+func TestGenericsIntoTestPackage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "queue"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "queue", "thing.go"), []byte("package queue\n\ntype Thing struct{}\n"), 0644))
+
+	typeSets, err := parse.TypeSet("Item=Thing,int")
+	require.NoError(t, err)
+	opts := parse.Options{TestPackage: true}
+	output, err := parse.GenericsWithOptions("suite.go", filepath.Join(dir, "queue", "gen_thing_test.go"), "", strings.NewReader(suiteTemplate), typeSets, opts)
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "package queue_test")
+	assert.Contains(t, string(output), "\"example.com/m/queue\"")
+	assert.Contains(t, string(output), "func TestThingTable(t *testing.T) {\n\tvar zero queue.Thing")
+	assert.Contains(t, string(output), "func TestIntTable(t *testing.T) {\n\tvar zero int")
+
+	// the output must be a test file
+	_, err = parse.GenericsWithOptions("suite.go", filepath.Join(dir, "queue", "gen_thing.go"), "", strings.NewReader(suiteTemplate), typeSets, opts)
+	assert.Error(t, err)
+}
@@ -0,0 +1,90 @@
+package parse
+
+import (
+	"go/scanner"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// TodoMode controls what happens to TODO and FIXME comments.
+type TodoMode int
+
+const (
+	// TodoKeep leaves TODO and FIXME comments untouched.
+	TodoKeep TodoMode = iota
+	// TodoStrip removes TODO and FIXME comments from the generated code.
+	TodoStrip
+	// TodoTag tags TODO and FIXME comments with the type set they were
+	// generated for, e.g. TODO(Item=int).
+	TodoTag
+)
+
+var todoModes = map[string]TodoMode{
+	"keep":  TodoKeep,
+	"strip": TodoStrip,
+	"tag":   TodoTag,
+}
+
+// ParseTodoMode gets the TodoMode for "keep", "strip" or "tag".
+func ParseTodoMode(s string) (TodoMode, error) {
+	mode, ok := todoModes[s]
+	if !ok {
+		return TodoKeep, &errBadOption{Option: "todo", Value: s, Message: "keep, strip or tag expected"}
+	}
+	return mode, nil
+}
+
+var todoPattern = regexp.MustCompile(`\b(TODO|FIXME)(\(([^)]*)\))?`)
+
+// todoComment finds the first TODO or FIXME comment in the line, returning
+// the offsets of the comment. ok is false if there is no such comment.
+func todoComment(line string) (start, end int, ok bool) {
+	src := []byte(line)
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	s.Init(file, src, func(token.Position, string) {}, scanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return 0, 0, false
+		}
+		if tok == token.COMMENT && todoPattern.MatchString(lit) {
+			start = file.Offset(pos)
+			return start, start + len(lit), true
+		}
+	}
+}
+
+// isCommentLine gets whether the line only contains a // comment.
+func isCommentLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "//")
+}
+
+// stripTodo removes any TODO or FIXME comment from the line. keep is false
+// if nothing but the comment was on the line.
+func stripTodo(line string) (stripped string, keep bool) {
+	start, end, ok := todoComment(line)
+	if !ok {
+		return line, true
+	}
+	stripped = strings.TrimRight(line[:start]+line[end:], " \t")
+	return stripped, strings.TrimSpace(stripped) != ""
+}
+
+// tagTodo tags any TODO or FIXME comment in the line with the tag.
+func tagTodo(line, tag string) string {
+	start, end, ok := todoComment(line)
+	if !ok {
+		return line
+	}
+	comment := todoPattern.ReplaceAllStringFunc(line[start:end], func(marker string) string {
+		m := todoPattern.FindStringSubmatch(marker)
+		if m[3] != "" {
+			return m[1] + "(" + m[3] + ", " + tag + ")"
+		}
+		return m[1] + "(" + tag + ")"
+	})
+	return line[:start] + comment + line[end:]
+}
@@ -0,0 +1,44 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTodoMode(t *testing.T) {
+
+	for s, mode := range map[string]TodoMode{"keep": TodoKeep, "strip": TodoStrip, "tag": TodoTag} {
+		m, err := ParseTodoMode(s)
+		assert.NoError(t, err)
+		assert.Equal(t, mode, m)
+	}
+	_, err := ParseTodoMode("delete")
+	assert.Error(t, err)
+
+}
+
+func TestStripTodo(t *testing.T) {
+
+	for line, expected := range map[string]string{
+		`x := 1 // TODO: remove`:      `x := 1`,
+		`x := "// TODO" // FIXME now`: `x := "// TODO"`,
+		`x := 1 // nothing to do`:     `x := 1 // nothing to do`,
+		`x := "TODO"`:                 `x := "TODO"`,
+	} {
+		stripped, keep := stripTodo(line)
+		assert.True(t, keep, line)
+		assert.Equal(t, expected, stripped)
+	}
+	_, keep := stripTodo("	// TODO(mat): remove")
+	assert.False(t, keep)
+
+}
+
+func TestTagTodo(t *testing.T) {
+
+	assert.Equal(t, "// TODO(Item=int): remove", tagTodo("// TODO: remove", "Item=int"))
+	assert.Equal(t, "x++ // FIXME(mat, Item=int) now", tagTodo("x++ // FIXME(mat) now", "Item=int"))
+	assert.Equal(t, "// nothing to do", tagTodo("// nothing to do", "Item=int"))
+
+}
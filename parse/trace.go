@@ -0,0 +1,103 @@
+package parse
+
+// Tracer starts spans around the phases of generation so they can be
+// reported to an observability stack. It is deliberately small so that
+// adapting an OpenTelemetry trace.Tracer takes only a few lines:
+//
+//     type otelTracer struct{ ctx context.Context; t trace.Tracer }
+//
+//     func (o otelTracer) StartSpan(name string, attrs map[string]string) parse.Span {
+//         ctx, span := o.t.Start(o.ctx, name)
+//         for k, v := range attrs {
+//             span.SetAttributes(attribute.String(k, v))
+//         }
+//         return otelSpan{otelTracer{ctx, o.t}, span}
+//     }
+//
+// where otelSpan embeds the otelTracer (to start children) and ends the
+// OpenTelemetry span, recording any error, in End.
+type Tracer interface {
+	// StartSpan starts a span with the given name and attributes.
+	StartSpan(name string, attrs map[string]string) Span
+}
+
+// Span is a single traced phase of generation.
+type Span interface {
+	// StartSpan starts a child span of this span.
+	StartSpan(name string, attrs map[string]string) Span
+	// End ends the span. err is the error the phase failed with, if any.
+	End(err error)
+}
+
+// Span names used by the generator. Callers writing the generated code
+// somewhere can use SpanWrite for their own span.
+const (
+	SpanGenerate   = "genny.generate"
+	SpanParse      = "genny.parse"
+	SpanSubstitute = "genny.substitute"
+	SpanFormat     = "genny.format"
+	SpanTypeCheck  = "genny.typecheck"
+	SpanVet        = "genny.vet"
+	SpanWrite      = "genny.write"
+)
+
+// noopSpan is used when no Tracer is configured.
+type noopSpan struct{}
+
+func (noopSpan) StartSpan(string, map[string]string) Span { return noopSpan{} }
+func (noopSpan) End(error)                                 {}
+
+// startSpan starts a root span with the tracer, or a noop span if there
+// is no tracer.
+func startSpan(tracer Tracer, name string, attrs map[string]string) Span {
+	if tracer == nil {
+		return noopSpan{}
+	}
+	return tracer.StartSpan(name, attrs)
+}
+
+// Tracers gets a Tracer that starts its spans with each of the tracers, for
+// reporting generation to more than one place. Nil tracers are left out.
+func Tracers(tracers ...Tracer) Tracer {
+	var ts multiTracer
+	for _, t := range tracers {
+		if t != nil {
+			ts = append(ts, t)
+		}
+	}
+	switch len(ts) {
+	case 0:
+		return nil
+	case 1:
+		return ts[0]
+	}
+	return ts
+}
+
+// multiTracer starts spans with each of its tracers.
+type multiTracer []Tracer
+
+func (m multiTracer) StartSpan(name string, attrs map[string]string) Span {
+	spans := make(multiSpan, len(m))
+	for i, t := range m {
+		spans[i] = t.StartSpan(name, attrs)
+	}
+	return spans
+}
+
+// multiSpan is a span of each tracer of a multiTracer.
+type multiSpan []Span
+
+func (m multiSpan) StartSpan(name string, attrs map[string]string) Span {
+	spans := make(multiSpan, len(m))
+	for i, s := range m {
+		spans[i] = s.StartSpan(name, attrs)
+	}
+	return spans
+}
+
+func (m multiSpan) End(err error) {
+	for _, s := range m {
+		s.End(err)
+	}
+}
@@ -0,0 +1,52 @@
+package parse_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []string
+}
+
+func (r *recordingTracer) StartSpan(name string, attrs map[string]string) parse.Span {
+	return &recordingSpan{tracer: r, name: name}
+}
+
+type recordingSpan struct {
+	tracer *recordingTracer
+	name   string
+}
+
+func (s *recordingSpan) StartSpan(name string, attrs map[string]string) parse.Span {
+	return &recordingSpan{tracer: s.tracer, name: s.name + "/" + name}
+}
+
+func (s *recordingSpan) End(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s.name)
+}
+
+func TestTracer(t *testing.T) {
+
+	tracer := &recordingTracer{}
+	in := contents("test/queue/generic_queue.go")
+	_, err := parse.GenericsWithOptions("generic_queue.go", "", "", strings.NewReader(in), []map[string]string{{"Something": "int"}, {"Something": "string"}}, parse.Options{Tracer: tracer})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{
+			"genny.generate/genny.parse",
+			"genny.generate/genny.substitute",
+			"genny.generate/genny.parse",
+			"genny.generate/genny.substitute",
+			"genny.generate/genny.format",
+			"genny.generate",
+		}, tracer.spans)
+	}
+
+}
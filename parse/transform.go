@@ -0,0 +1,137 @@
+package parse
+
+import (
+	"go/token"
+	"strings"
+)
+
+// transformSep separates a specific type from the transformations given
+// with it, as in Item=time.Time|name=Timestamp.
+const transformSep = "|"
+
+// transformName sets the word the specific type is known by in
+// identifiers, strings and comments, for types whose derived word is
+// unusable or unclear, e.g. Item=func(int) bool|name=Predicate makes
+// PredicateQueue where the type itself is still func(int) bool.
+const transformName = "name"
+
+// nameSep separates a specific type from the name it is known by, as a
+// shorthand for the name transformation: Value=map[string]interface{}:JSONMap
+// is Value=map[string]interface{}|name=JSONMap.
+const nameSep = ":"
+
+// expandName rewrites a specific type of a type set argument given with a
+// name after nameSep as the name transformation. A nameSep within brackets
+// or a string literal, as in a struct tag, is part of the type.
+func expandName(arg, specific string) (string, error) {
+	i := nameIndex(specific)
+	if i < 0 {
+		return specific, nil
+	}
+	specificType, rest := specific[:i], specific[i+len(nameSep):]
+	name, transforms, _ := strings.Cut(rest, transformSep)
+	if _, given := splitTransforms(specificType + transformSep + transforms); given != "" {
+		return "", &errBadTypeArgs{Arg: arg, Message: "the name of " + specificType + " is given both after " + nameSep + " and with " + transformName + "="}
+	}
+	expanded := specificType + transformSep + transformName + keyValueSep + name
+	if transforms != "" {
+		expanded += transformSep + transforms
+	}
+	return expanded, nil
+}
+
+// nameIndex gets the index of the nameSep that follows the specific type,
+// outside any brackets and string literals, or -1 if there is none.
+func nameIndex(specific string) int {
+	depth := 0
+	var quote rune
+	escaped := false
+	for i, r := range specific {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '`':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		case depth == 0 && strings.HasPrefix(specific[i:], transformSep):
+			return -1
+		case depth == 0 && strings.HasPrefix(specific[i:], nameSep):
+			return i
+		}
+	}
+	return -1
+}
+
+// splitTransforms gets the specific type without its transformations, and
+// the name it was given, if any. Transformations are checked by TypeSet.
+func splitTransforms(specific string) (specificType, name string) {
+	i := strings.Index(specific, transformSep)
+	if i < 0 {
+		return specific, ""
+	}
+	specificType = specific[:i]
+	for _, transform := range strings.Split(specific[i+len(transformSep):], transformSep) {
+		if key, value, ok := strings.Cut(transform, keyValueSep); ok && key == transformName {
+			name = value
+		}
+	}
+	return specificType, name
+}
+
+// specificTypeOf gets the specific type without its transformations.
+func specificTypeOf(specific string) string {
+	specificType, _ := splitTransforms(specific)
+	return specificType
+}
+
+// specificTypes gets the type set with its transformations removed, for
+// the checks that look at the types themselves. The type set is returned
+// as it is if none of its types are transformed.
+func specificTypes(typeSet map[string]string) map[string]string {
+	transformed := false
+	for _, specific := range typeSet {
+		if strings.Contains(specific, transformSep) {
+			transformed = true
+			break
+		}
+	}
+	if !transformed {
+		return typeSet
+	}
+	types := make(map[string]string, len(typeSet))
+	for generic, specific := range typeSet {
+		types[generic] = specificTypeOf(specific)
+	}
+	return types
+}
+
+// checkTransforms checks the transformations given with the specific type
+// of a type set argument.
+func checkTransforms(arg, specific string) error {
+	i := strings.Index(specific, transformSep)
+	if i < 0 {
+		return nil
+	}
+	if t := specific[:i]; t == "" || t == builtins || t == numbers {
+		return &errBadTypeArgs{Arg: arg, Message: "a specific type is expected before " + transformSep + " (transformations cannot be given to " + builtins + " or " + numbers + ")"}
+	}
+	for _, transform := range strings.Split(specific[i+len(transformSep):], transformSep) {
+		key, value, _ := strings.Cut(transform, keyValueSep)
+		if key != transformName {
+			return &errBadTypeArgs{Arg: arg, Message: "unknown transformation \"" + transform + "\" (" + transformName + "=Name expected)"}
+		}
+		if !token.IsIdentifier(value) {
+			return &errBadTypeArgs{Arg: arg, Message: "\"" + value + "\" is not a valid name for " + specific[:i]}
+		}
+	}
+	return nil
+}
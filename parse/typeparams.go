@@ -0,0 +1,513 @@
+package parse
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Mode is what is generated from the template.
+type Mode int
+
+const (
+	// ModeCopies generates a copy of the template for each type set.
+	ModeCopies Mode = iota
+	// ModeTypeParams converts the template into one file declaring its
+	// types and functions with Go 1.18 type parameters instead, for
+	// migrating template libraries to Go's generics. The type sets are
+	// not used.
+	ModeTypeParams
+)
+
+var modes = map[string]Mode{
+	"copies":     ModeCopies,
+	"typeparams": ModeTypeParams,
+}
+
+// ParseMode gets the Mode for "copies" or "typeparams".
+func ParseMode(s string) (Mode, error) {
+	mode, ok := modes[s]
+	if !ok {
+		return ModeCopies, &errBadOption{Option: "mode", Value: s, Message: "copies or typeparams expected"}
+	}
+	return mode, nil
+}
+
+// constraintTerms are the constraints the numeric kinds of generic types
+// are converted to, with their terms.
+var constraintTerms = map[string][]string{
+	genericNumber:   {"~int", "~int8", "~int16", "~int32", "~int64", "~uint", "~uint8", "~uint16", "~uint32", "~uint64", "~uintptr", "~float32", "~float64"},
+	genericInteger:  {"~int", "~int8", "~int16", "~int32", "~int64", "~uint", "~uint8", "~uint16", "~uint32", "~uint64", "~uintptr"},
+	genericUnsigned: {"~uint", "~uint8", "~uint16", "~uint32", "~uint64", "~uintptr"},
+	genericFloat:    {"~float32", "~float64"},
+}
+
+// typeParamDecl is a top level declaration of a template converted to
+// type parameters: a type, function, variable or constant.
+type typeParamDecl struct {
+	name *ast.Ident
+	// params are the generic types it uses, directly or through the other
+	// declarations it uses, which become its type parameters.
+	params map[string]bool
+	uses   map[*typeParamDecl]bool
+	// value is whether it is a variable or constant, which cannot have
+	// type parameters.
+	value   bool
+	newName string
+}
+
+// typeParamEdit replaces the source from start to end with text.
+type typeParamEdit struct {
+	start, end int
+	text       string
+}
+
+// typeParams converts the template into one file in which the types and
+// functions that use its generic types take them as type parameters, e.g.
+// ItemQueue becomes Queue[Item any], named without the generic types. The
+// generic types are constrained by their kinds: generic.Type and
+// generic.Interface by any, or comparable if they are map keys or asserted
+// comparable, generic.Comparable by comparable, and the numeric kinds by
+// constraints declared in the file.
+func typeParams(filename, pkgName string, in io.ReadSeeker, opts Options) ([]byte, error) {
+	switch {
+	case opts.Script:
+		return nil, &errBadOption{Option: "mode", Value: "typeparams", Message: "a script cannot be converted to type parameters"}
+	case opts.TestPackage:
+		return nil, &errBadOption{Option: "mode", Value: "typeparams", Message: "the test package is not supported"}
+	case len(opts.Directives) > 0:
+		return nil, &errBadOption{Option: "mode", Value: "typeparams", Message: "directives are not supported"}
+	case opts.Interfaces || opts.Fakes:
+		return nil, &errBadOption{Option: "mode", Value: "typeparams", Message: "interfaces and fakes are not supported"}
+	case opts.LineDirectives:
+		return nil, &errBadOption{Option: "mode", Value: "typeparams", Message: "line directives are not supported"}
+	}
+
+	in.Seek(0, io.SeekStart)
+	src, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	fs := token.NewFileSet()
+	file, err := parser.ParseFile(fs, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, &errSource{Err: err}
+	}
+	offset := func(p token.Pos) int { return fs.Position(p).Offset }
+
+	// the generic types, in the order they are declared
+	kinds := make(map[*ast.TypeSpec]string)
+	var generics []string
+	decls := make(map[interface{}]*typeParamDecl)
+	names := make(map[string]bool)
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					if kind := genericKind(spec); kind != "" {
+						kinds[spec] = kind
+						generics = append(generics, spec.Name.Name)
+						continue
+					}
+					decls[spec] = &typeParamDecl{name: spec.Name}
+					names[spec.Name.Name] = true
+				case *ast.ValueSpec:
+					decls[spec] = &typeParamDecl{name: spec.Names[0], value: true}
+					for _, name := range spec.Names {
+						names[name.Name] = true
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if decl.Recv == nil {
+				decls[decl] = &typeParamDecl{name: decl.Name}
+				names[decl.Name.Name] = true
+			}
+		}
+	}
+	if len(generics) == 0 {
+		return nil, &errTypeParams{Pos: fs.Position(file.Package), Message: "the template declares no generic types"}
+	}
+
+	// what each declaration uses, with methods counted as part of their
+	// receiver types
+	var convErr error
+	inspect := func(n ast.Node, d *typeParamDecl) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if x, ok := sel.X.(*ast.Ident); ok && x.Name == genericPackage && (sel.Sel.Name == genericIndex || sel.Sel.Name == genericCount) && convErr == nil {
+					convErr = &errTypeParams{Pos: fs.Position(sel.Pos()), Message: "generic." + sel.Sel.Name + " has no equivalent with type parameters"}
+				}
+			}
+			id, ok := n.(*ast.Ident)
+			if !ok || id.Obj == nil || d == nil {
+				return true
+			}
+			if spec, ok := id.Obj.Decl.(*ast.TypeSpec); ok && kinds[spec] != "" {
+				d.ensure()
+				d.params[spec.Name.Name] = true
+			} else if used := decls[id.Obj.Decl]; used != nil && used != d {
+				d.ensure()
+				d.uses[used] = true
+			}
+			return true
+		})
+	}
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				inspect(spec, decls[spec])
+			}
+		case *ast.FuncDecl:
+			d := decls[decl]
+			if decl.Recv != nil {
+				if spec := receiverSpec(decl); spec != nil {
+					d = decls[spec]
+				}
+			}
+			inspect(decl, d)
+		}
+	}
+	if convErr != nil {
+		return nil, convErr
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, d := range decls {
+			for used := range d.uses {
+				for p := range used.params {
+					if !d.params[p] {
+						d.ensure()
+						d.params[p] = true
+						changed = true
+					}
+				}
+			}
+		}
+	}
+
+	// the declarations with type parameters are named without the generic
+	// types, unless that makes a name that is taken
+	var converted []*typeParamDecl
+	for _, d := range decls {
+		if len(d.params) > 0 {
+			converted = append(converted, d)
+		}
+	}
+	sort.Slice(converted, func(i, j int) bool { return converted[i].name.Pos() < converted[j].name.Pos() })
+	for _, d := range converted {
+		if d.value {
+			return nil, &errTypeParams{Pos: fs.Position(d.name.Pos()), Message: d.name.Name + " uses a generic type, but variables and constants cannot have type parameters"}
+		}
+	}
+	taken := make(map[string]bool)
+	for name := range names {
+		taken[name] = true
+	}
+	for _, d := range converted {
+		d.newName = d.name.Name
+		if name := nameWithout(d.name.Name, generics); name != d.name.Name && !taken[name] && !isBuiltin(name) && !token.IsKeyword(name) {
+			d.newName = name
+		}
+		taken[d.newName] = true
+	}
+
+	constraints := make(map[string]string)
+	comparables, err := metadata(in, comparableDirective)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range comparables {
+		constraints[name] = "comparable"
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		if m, ok := n.(*ast.MapType); ok {
+			if id, ok := m.Key.(*ast.Ident); ok && id.Obj != nil {
+				if spec, ok := id.Obj.Decl.(*ast.TypeSpec); ok && kinds[spec] != "" {
+					constraints[spec.Name.Name] = "comparable"
+				}
+			}
+		}
+		return true
+	})
+	// the constraints declared for the numeric kinds are named after them,
+	// e.g. number, unless the template uses the name for anything
+	idents := make(map[string]bool)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			idents[id.Name] = true
+		}
+		return true
+	})
+	for _, spec := range file.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		idents[path.Base(importPath)] = true
+	}
+	constraintNames := make(map[string]string)
+	var declared []string
+	for spec, kind := range kinds {
+		name := spec.Name.Name
+		switch {
+		case constraintTerms[kind] != nil:
+			if constraintNames[kind] == "" {
+				constraintNames[kind] = constraintName(kind, idents)
+				declared = append(declared, kind)
+			}
+			constraints[name] = constraintNames[kind]
+		case kind == genericComparable:
+			constraints[name] = "comparable"
+		case constraints[name] == "":
+			constraints[name] = "any"
+		}
+	}
+	sort.Strings(declared)
+
+	// the edits: the declarations of the generic types and genny's
+	// directives are removed, and the declarations with type parameters
+	// renamed and instantiated wherever they are used
+	var edits []typeParamEdit
+	removeLines := func(start, end int) {
+		for start > 0 && src[start-1] != '\n' {
+			start--
+		}
+		for end < len(src) && src[end-1] != '\n' {
+			end++
+		}
+		edits = append(edits, typeParamEdit{start: start, end: end})
+	}
+	for _, decl := range file.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+		all := true
+		for _, spec := range gd.Specs {
+			all = all && kinds[spec.(*ast.TypeSpec)] != ""
+		}
+		if all {
+			removeLines(offset(start(gd)), offset(gd.End()))
+			continue
+		}
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			if kinds[ts] == "" {
+				continue
+			}
+			begin, end := ts.Pos(), ts.End()
+			if ts.Doc != nil {
+				begin = ts.Doc.Pos()
+			}
+			if ts.Comment != nil {
+				end = ts.Comment.End()
+			}
+			removeLines(offset(begin), offset(end))
+		}
+	}
+	for _, group := range file.Comments {
+		for _, c := range group.List {
+			if strings.HasPrefix(c.Text, metadataPrefix) || isUnwanted(c.Text) {
+				removeLines(offset(c.Pos()), offset(c.End()))
+			}
+		}
+	}
+	ast.Inspect(file, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || id.Obj == nil {
+			return true
+		}
+		d := decls[id.Obj.Decl]
+		if d == nil || len(d.params) == 0 {
+			return true
+		}
+		var params []string
+		for _, g := range generics {
+			if !d.params[g] {
+				continue
+			}
+			if id == d.name {
+				params = append(params, g+" "+constraints[g])
+			} else {
+				params = append(params, g)
+			}
+		}
+		edits = append(edits, typeParamEdit{start: offset(id.Pos()), end: offset(id.End()), text: d.newName + "[" + strings.Join(params, ", ") + "]"})
+		return true
+	})
+	// comments name the declarations as they are renamed
+	for _, d := range converted {
+		if d.newName == d.name.Name {
+			continue
+		}
+		word := regexp.MustCompile(`\b` + regexp.QuoteMeta(d.name.Name) + `\b`)
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				for _, loc := range word.FindAllStringIndex(c.Text, -1) {
+					start := offset(c.Pos()) + loc[0]
+					edits = append(edits, typeParamEdit{start: start, end: start + len(d.name.Name), text: d.newName})
+				}
+			}
+		}
+	}
+	if pkgName != "" {
+		edits = append(edits, typeParamEdit{start: offset(file.Name.Pos()), end: offset(file.Name.End()), text: pkgName})
+	}
+
+	var body strings.Builder
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	last := 0
+	for _, e := range edits {
+		if e.start < last {
+			// within a removed declaration
+			continue
+		}
+		body.Write(src[last:e.start])
+		body.WriteString(e.text)
+		last = e.end
+	}
+	body.Write(src[last:])
+	for _, kind := range declared {
+		name := constraintNames[kind]
+		body.WriteString("\n// " + name + " is the constraint of the type parameters that were " + kind + " types.\n")
+		body.WriteString("type " + name + " interface {\n\t" + strings.Join(constraintTerms[kind], " | ") + "\n}\n")
+	}
+
+	// the template's build constraints stay above the header
+	h, err := fileHeader(filename, in, nil, opts)
+	if err != nil {
+		return nil, err
+	}
+	var top, rest []string
+	prelude := true
+	for _, line := range strings.SplitAfter(body.String(), "\n") {
+		text := strings.TrimSpace(line)
+		if strings.HasPrefix(text, string(packageKeyword)+" ") {
+			prelude = false
+		}
+		if prelude && (constraint.IsGoBuild(text) || constraint.IsPlusBuild(text)) {
+			top = append(top, line)
+			continue
+		}
+		rest = append(rest, line)
+	}
+	if len(top) > 0 {
+		top = append(top, "\n")
+	}
+	lines := append(append(top, strings.SplitAfter(string(h), "\n")...), rest...)
+	if opts.Constraint != "" {
+		expr, err := ParseConstraint(opts.Constraint)
+		if err != nil {
+			return nil, err
+		}
+		if lines, _, err = addConstraint(lines, make([]origin, len(lines)), expr); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// ensure makes the maps of the declaration.
+func (d *typeParamDecl) ensure() {
+	if d.params == nil {
+		d.params = make(map[string]bool)
+		d.uses = make(map[*typeParamDecl]bool)
+	}
+}
+
+// genericKind gets the kind of generic type the spec declares, e.g.
+// generic.Type, or "" if it does not declare one.
+func genericKind(ts *ast.TypeSpec) string {
+	sel, ok := ts.Type.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if x, ok := sel.X.(*ast.Ident); !ok || x.Name != genericPackage {
+		return ""
+	}
+	return genericKinds[sel.Sel.Name]
+}
+
+// receiverSpec gets the declaration of the receiver type of the method, or
+// nil if it is not declared in the file.
+func receiverSpec(fn *ast.FuncDecl) *ast.TypeSpec {
+	if len(fn.Recv.List) == 0 {
+		return nil
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	id, ok := recv.(*ast.Ident)
+	if !ok || id.Obj == nil {
+		return nil
+	}
+	spec, _ := id.Obj.Decl.(*ast.TypeSpec)
+	return spec
+}
+
+// constraintName gets the name of the constraint declared for the numeric
+// kind of generic type, e.g. number for generic.Number, or numberConstraint
+// if the template uses number, and so on.
+func constraintName(kind string, idents map[string]bool) string {
+	base := strings.ToLower(strings.TrimPrefix(kind, genericPackage+"."))
+	name := base
+	for n := 1; idents[name] || isBuiltin(name); n++ {
+		name = base + "Constraint"
+		if n > 1 {
+			name += strconv.Itoa(n)
+		}
+	}
+	return name
+}
+
+// nameWithout gets the name without the generic types it names as words,
+// e.g. Queue for ItemQueue, NewQueue for NewItemQueue or Map for
+// KeyValueMap, keeping whether it is exported. Names that are only
+// generic types are kept.
+func nameWithout(name string, generics []string) string {
+	sorted := append([]string(nil), generics...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+	without := name
+	for _, g := range sorted {
+		without = withoutWord(without, g, false)
+		without = withoutWord(without, lowerFirst(g), true)
+	}
+	if without == name || without == "" || !token.IsIdentifier(without) {
+		return name
+	}
+	if ast.IsExported(name) {
+		return withCasing(without, CasingUpper)
+	}
+	return lowerFirst(without)
+}
+
+// withoutWord gets the name without every occurrence of the word that is
+// followed by the start of another word or the end of the name, or only
+// one at its start if first is true.
+func withoutWord(name, word string, first bool) string {
+	for i := 0; i+len(word) <= len(name); {
+		j := strings.Index(name[i:], word)
+		if j < 0 || (first && i+j != 0) {
+			break
+		}
+		start, end := i+j, i+j+len(word)
+		if end < len(name) && !unicode.IsUpper(rune(name[end])) && !unicode.IsDigit(rune(name[end])) && name[end] != '_' {
+			i = start + 1
+			continue
+		}
+		name = name[:start] + name[end:]
+		i = start
+	}
+	return name
+}
@@ -0,0 +1,119 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const typeParamsTemplate = `//go:build !js
+
+// Package queue is a queue.
+package queue
+
+import "github.com/cheekybits/genny/generic"
+
+//go:generate genny -in=$GOFILE gen "Item=int"
+
+// Item is the type of the items.
+type Item generic.Type
+
+type (
+	Key generic.Type
+	// Num is a number.
+	Num generic.Number
+)
+
+// ItemQueue is a queue of Items.
+type ItemQueue struct {
+	items []Item
+	next  *ItemQueue
+}
+
+// NewItemQueue makes an empty ItemQueue.
+func NewItemQueue() *ItemQueue {
+	return &ItemQueue{}
+}
+
+// Push adds an item.
+func (q *ItemQueue) Push(item Item) {
+	q.items = append(q.items, item)
+}
+
+func (q ItemQueue) Index() map[Key]Item { return nil }
+
+func SumNum(nums []Num) Num {
+	var total Num
+	for _, n := range nums {
+		total += n
+	}
+	return total
+}
+
+func helper() int { return 1 }
+`
+
+func TestTypeParams(t *testing.T) {
+	output, err := parse.GenericsWithOptions("queue.go", "queue_generic.go", "", strings.NewReader(typeParamsTemplate), nil, parse.Options{Mode: parse.ModeTypeParams})
+	require.NoError(t, err)
+	code := string(output)
+	assert.True(t, strings.HasPrefix(code, "//go:build !js\n\n// This file was automatically generated by genny."), code)
+	assert.NotContains(t, code, "genny/generic")
+	assert.NotContains(t, code, "go:generate")
+	assert.Contains(t, code, "// Queue is a queue of Items.\ntype Queue[Item any, Key comparable] struct {\n\titems []Item\n\tnext  *Queue[Item, Key]\n}")
+	assert.Contains(t, code, "// NewQueue makes an empty Queue.\nfunc NewQueue[Item any, Key comparable]() *Queue[Item, Key] {\n\treturn &Queue[Item, Key]{}\n}")
+	assert.Contains(t, code, "func (q *Queue[Item, Key]) Push(item Item) {")
+	assert.Contains(t, code, "func (q Queue[Item, Key]) Index() map[Key]Item { return nil }")
+	assert.Contains(t, code, "func Sum[Num number](nums []Num) Num {")
+	assert.Contains(t, code, "func helper() int { return 1 }")
+	assert.Contains(t, code, "type number interface {\n\t~int | ~int8")
+	assert.NotContains(t, code, "Item is the type of the items")
+}
+
+func TestTypeParamsTwoParams(t *testing.T) {
+	// every generic type is taken out of the names, and the constraint is
+	// named so as not to take the name of the template's number
+	src := `package p
+
+import "github.com/cheekybits/genny/generic"
+
+type KeyType generic.Type
+type ValueType generic.Number
+
+// KeyTypeValueTypeMap maps KeyTypes to ValueTypes.
+type KeyTypeValueTypeMap map[KeyType]ValueType
+
+func NewKeyTypeValueTypeMap() KeyTypeValueTypeMap {
+	number := 0
+	_ = number
+	return KeyTypeValueTypeMap{}
+}
+`
+	output, err := parse.GenericsWithOptions("p.go", "", "", strings.NewReader(src), nil, parse.Options{Mode: parse.ModeTypeParams})
+	require.NoError(t, err)
+	code := string(output)
+	assert.Contains(t, code, "// Map maps KeyTypes to ValueTypes.\ntype Map[KeyType comparable, ValueType numberConstraint] map[KeyType]ValueType")
+	assert.Contains(t, code, "func NewMap[KeyType comparable, ValueType numberConstraint]() Map[KeyType, ValueType] {")
+	assert.Contains(t, code, "type numberConstraint interface {")
+	assert.NotContains(t, code, "type number ")
+}
+
+func TestTypeParamsErrors(t *testing.T) {
+	for _, test := range []struct {
+		src, err string
+	}{
+		{"package p\n\nfunc F() {}\n", "the template declares no generic types"},
+		{"package p\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\nvar defaultItem Item\n", "q.go:7:5: cannot convert to type parameters: defaultItem uses a generic type, but variables and constants cannot have type parameters"},
+		{"package p\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n\nfunc ItemIndex(Item) int { return generic.Index }\n", "generic.Index has no equivalent with type parameters"},
+	} {
+		_, err := parse.GenericsWithOptions("q.go", "", "", strings.NewReader(test.src), nil, parse.Options{Mode: parse.ModeTypeParams})
+		if assert.Error(t, err, test.src) {
+			assert.Contains(t, err.Error(), test.err)
+		}
+	}
+	_, err := parse.ParseMode("generics")
+	assert.Error(t, err)
+}
@@ -1,6 +1,9 @@
 package parse
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 const (
 	typeSep     = " "
@@ -20,23 +23,38 @@ const (
 //     Person=man Animal=dog Animal2=cat
 //     Person=man,woman Animal=dog,cat
 //     Person=man,woman,child Animal=dog,cat Place=london,paris
+//     Handler=func(int, string) error,chan error
+//     Item=time.Time|name=Timestamp
+//     Value=map[string]interface{}:JSONMap
+//
+// A specific type may be followed by transformations, each after a |. The
+// only one is name=Name, which sets the word the type is known by in
+// identifiers, strings and comments (TimestampQueue rather than
+// TimeTimeQueue), while the type itself is still substituted for the
+// generic type. Type:Name is a shorthand for Type|name=Name.
 func TypeSet(arg string) ([]map[string]string, error) {
 
 	types := make(map[string][]string)
 	var keys []string
-	for _, pair := range strings.Split(arg, typeSep) {
-		segs := strings.Split(pair, keyValueSep)
+	for _, pair := range splitPairs(arg) {
+		segs := strings.SplitN(pair, keyValueSep, 2)
 		if len(segs) != 2 {
 			return nil, &errBadTypeArgs{Arg: arg, Message: "Generic=Specific expected"}
 		}
 		key := segs[0]
 		keys = append(keys, key)
 		types[key] = make([]string, 0)
-		for _, t := range strings.Split(segs[1], valuesSep) {
+		for _, t := range splitValues(segs[1]) {
+			t, err := expandName(arg, t)
+			if err != nil {
+				return nil, err
+			}
 			if t == builtins {
 				types[key] = append(types[key], Builtins...)
 			} else if t == numbers {
 				types[key] = append(types[key], Numbers...)
+			} else if err := checkTransforms(arg, t); err != nil {
+				return nil, err
 			} else {
 				types[key] = append(types[key], t)
 			}
@@ -63,6 +81,55 @@ func TypeSet(arg string) ([]map[string]string, error) {
 
 }
 
+// typeSetString gets the type set as a Generic=Specific string with the
+// generic types in alphabetical order.
+func typeSetString(typeSet map[string]string) string {
+	var pairs []string
+	for generic, specific := range typeSet {
+		pairs = append(pairs, generic+keyValueSep+specific)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, typeSep)
+}
+
+// splitPairs splits the arg into its Generic=Specific pairs. Specific types
+// may contain spaces (e.g. "func() error"), so any segment without a
+// keyValueSep, or with a transformation before its first keyValueSep (as in
+// "error|name=Fn"), belongs to the pair before it.
+func splitPairs(arg string) []string {
+	var pairs []string
+	for _, seg := range strings.Split(arg, typeSep) {
+		key, _, isPair := strings.Cut(seg, keyValueSep)
+		if len(pairs) > 0 && seg != "" && (!isPair || strings.Contains(key, transformSep)) {
+			pairs[len(pairs)-1] += typeSep + seg
+			continue
+		}
+		pairs = append(pairs, seg)
+	}
+	return pairs
+}
+
+// splitValues splits the specific types, ignoring any valuesSep inside
+// brackets (e.g. "func(int, string) error").
+func splitValues(s string) []string {
+	var values []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		default:
+			if depth == 0 && strings.HasPrefix(s[i:], valuesSep) {
+				values = append(values, s[start:i])
+				start = i + len(valuesSep)
+			}
+		}
+	}
+	return append(values, s[start:])
+}
+
 func buildTypeSet(keys []string, keyI int, cursors map[string]int, types map[string][]string, out chan<- map[string]string) {
 	key := keys[keyI]
 	for cursors[key] < len(types[key]) {
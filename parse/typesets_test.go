@@ -72,3 +72,77 @@ func TestArgsToTypeset(t *testing.T) {
 	}
 
 }
+
+func TestArgsToTypesetWithFuncTypes(t *testing.T) {
+
+	ts, err := parse.TypeSet("Handler=func(int, string) error,chan error Item=int")
+	if assert.NoError(t, err) {
+		if assert.Equal(t, 2, len(ts)) {
+			assert.Equal(t, "func(int, string) error", ts[0]["Handler"])
+			assert.Equal(t, "int", ts[0]["Item"])
+			assert.Equal(t, "chan error", ts[1]["Handler"])
+			assert.Equal(t, "int", ts[1]["Item"])
+		}
+	}
+
+	_, err = parse.TypeSet("func() Item=int")
+	assert.Error(t, err)
+
+}
+
+func TestArgsToTypesetWithNames(t *testing.T) {
+
+	ts, err := parse.TypeSet("Item=time.Time|name=Timestamp,int Handler=func() error|name=Fn")
+	if assert.NoError(t, err) {
+		if assert.Equal(t, 2, len(ts)) {
+			assert.Equal(t, "time.Time|name=Timestamp", ts[0]["Item"])
+			assert.Equal(t, "func() error|name=Fn", ts[0]["Handler"])
+			assert.Equal(t, "int", ts[1]["Item"])
+		}
+	}
+
+	for _, args := range []string{
+		"Item=time.Time|nam=Timestamp",
+		"Item=time.Time|name=Time stamp",
+		"Item=time.Time|name=",
+		"Item=BUILTINS|name=Builtin",
+		"Item=|name=Timestamp",
+	} {
+		_, err := parse.TypeSet(args)
+		assert.Error(t, err, args)
+	}
+
+}
+
+func TestArgsToTypesetWithNameSuffix(t *testing.T) {
+
+	ts, err := parse.TypeSet("Value=map[string]interface{}:JSONMap,int Handler=func(a, b int) error:Fn")
+	if assert.NoError(t, err) {
+		if assert.Equal(t, 2, len(ts)) {
+			assert.Equal(t, "map[string]interface{}|name=JSONMap", ts[0]["Value"])
+			assert.Equal(t, "func(a, b int) error|name=Fn", ts[0]["Handler"])
+			assert.Equal(t, "int", ts[1]["Value"])
+		}
+	}
+
+	// a colon in a struct tag is part of the type
+	ts, err = parse.TypeSet("Item=struct{ A int `json:\"a\"` }:Pair,struct{ A int \"json:\\\"a\\\"\" }")
+	if assert.NoError(t, err) {
+		if assert.Equal(t, 2, len(ts)) {
+			assert.Equal(t, "struct{ A int `json:\"a\"` }|name=Pair", ts[0]["Item"])
+			assert.Equal(t, "struct{ A int \"json:\\\"a\\\"\" }", ts[1]["Item"])
+		}
+	}
+
+	for _, args := range []string{
+		"Item=time.Time:Time stamp",
+		"Item=time.Time:",
+		"Item=BUILTINS:Builtin",
+		"Item=:Timestamp",
+		"Item=time.Time:Timestamp|name=Stamp",
+	} {
+		_, err := parse.TypeSet(args)
+		assert.Error(t, err, args)
+	}
+
+}
@@ -0,0 +1,44 @@
+package parse
+
+import (
+	"sort"
+
+	"github.com/cheekybits/genny/diag"
+)
+
+// Validator checks that a specific type may replace the generic type name,
+// e.g. to only allow the types an organization has approved or to enforce
+// naming rules. It returns an error describing why it may not.
+type Validator func(name, concreteType string) error
+
+// AllParams is the key of Options.Validators for the validators that check
+// every generic type, and of Options.Casings for the casings of every
+// generic type.
+const AllParams = "*"
+
+// validate runs the validators for every generic type of every type set,
+// before anything is generated. It reports every type that is refused, in
+// the order of the type sets and then of the generic types' names.
+func validate(typeSets []map[string]string, validators map[string][]Validator) error {
+	if len(validators) == 0 {
+		return nil
+	}
+	var problems diag.List
+	for _, typeSet := range typeSets {
+		var generics []string
+		for generic := range typeSet {
+			generics = append(generics, generic)
+		}
+		sort.Strings(generics)
+		for _, generic := range generics {
+			specific := specificTypeOf(typeSet[generic])
+			for _, v := range append(validators[AllParams], validators[generic]...) {
+				if err := v(generic, specific); err != nil {
+					problems.Add(&errRefusedType{GenericType: generic, SpecificType: specific, Err: err}, diag.Error)
+					break
+				}
+			}
+		}
+	}
+	return problems.Err()
+}
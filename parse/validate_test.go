@@ -0,0 +1,52 @@
+package parse_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+)
+
+var errNotApproved = errors.New("not an approved type")
+
+func TestValidators(t *testing.T) {
+
+	approved := func(name, concreteType string) error {
+		if concreteType != "int" && concreteType != "string" {
+			return errNotApproved
+		}
+		return nil
+	}
+	var checked []string
+	record := func(name, concreteType string) error {
+		checked = append(checked, name+"="+concreteType)
+		return nil
+	}
+	opts := parse.Options{Validators: map[string][]parse.Validator{
+		parse.AllParams: {record},
+		"Item":          {approved},
+	}}
+
+	_, err := parse.GenericsWithOptions("queue.go", "queue.go", "", strings.NewReader(coverageTemplate), []map[string]string{{"Item": "int"}, {"Item": "string"}}, opts)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Item=int", "Item=string"}, checked)
+
+	// every refused type is reported
+	typeSets := []map[string]string{{"Item": "float64"}, {"Item": "int"}, {"Item": "[]byte"}}
+	_, err = parse.GenericsWithOptions("queue.go", "queue.go", "", strings.NewReader(coverageTemplate), typeSets, opts)
+	assert.EqualError(t, err, "'float64' cannot replace 'Item': not an approved type\n'[]byte' cannot replace 'Item': not an approved type")
+	assert.True(t, errors.Is(err, errNotApproved))
+
+	// naming rules
+	opts.Validators = map[string][]parse.Validator{parse.AllParams: {func(name, concreteType string) error {
+		if strings.Contains(concreteType, "interface{}") {
+			return fmt.Errorf("%s must not be an empty interface", name)
+		}
+		return nil
+	}}}
+	_, err = parse.GenericsWithOptions("queue.go", "queue.go", "", strings.NewReader(coverageTemplate), []map[string]string{{"Item": "interface{}"}}, opts)
+	assert.EqualError(t, err, "'interface{}' cannot replace 'Item': Item must not be an empty interface")
+}
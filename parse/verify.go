@@ -0,0 +1,253 @@
+package parse
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Verify type checks the generated code as it would be compiled when saved
+// to outputFilename, together with the other files of the package in that
+// directory.
+func Verify(outputFilename string, output []byte) error {
+	return VerifyWithOptions(outputFilename, output, Options{})
+}
+
+// VerifyWithOptions is like Verify, but resolves the packages the code
+// imports, and the files of the package, with opts.Loader (or a new Loader
+// if it is not set). It also fails if the code would create an import
+// cycle (see CheckImportCycle). With opts.Script, the code is type checked
+// on its own.
+func VerifyWithOptions(outputFilename string, output []byte, opts Options) error {
+	errs, err := typeErrors(outputFilename, output, opts)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		compileErr := &errCompile{}
+		for _, e := range errs {
+			compileErr.Errors = append(compileErr.Errors, e.Error())
+		}
+		return compileErr
+	}
+	return nil
+}
+
+// typeErrors type checks the generated code as VerifyWithOptions does, and
+// gets the errors go/types finds. The error is for code that cannot be
+// type checked at all.
+func typeErrors(outputFilename string, output []byte, opts Options) ([]types.Error, error) {
+	loader := opts.Loader
+	if loader == nil {
+		loader = &Loader{}
+	}
+	dir := filepath.Dir(outputFilename)
+	fs := loader.fileSet()
+	generated, err := parser.ParseFile(fs, outputFilename, output, 0)
+	if err != nil {
+		return nil, &errCompile{Errors: []string{err.Error()}}
+	}
+
+	// scripts are built on their own
+	files := []*ast.File{generated}
+	var packageFiles []string
+	if !opts.Script {
+		packageFiles = loader.packageFiles(dir)
+	}
+	for _, name := range packageFiles {
+		if filepath.Base(name) == filepath.Base(outputFilename) {
+			continue
+		}
+		file, err := parser.ParseFile(fs, name, nil, 0)
+		if err != nil {
+			return nil, &errCompile{Errors: []string{err.Error()}}
+		}
+		if file.Name.Name == generated.Name.Name {
+			files = append(files, file)
+		}
+	}
+
+	if err := CheckImportCycle(outputFilename, output, Options{Loader: loader, Script: opts.Script, TestPackage: opts.TestPackage}); err != nil {
+		return nil, err
+	}
+
+	importer, err := loader.importer(dir, fileImports(files))
+	if err != nil {
+		return nil, &errCompile{Errors: []string{err.Error()}}
+	}
+	var errs []types.Error
+	conf := types.Config{
+		Importer: importer,
+		Sizes:    types.SizesFor("gc", runtime.GOARCH),
+		Error: func(err error) {
+			if e, ok := err.(types.Error); ok {
+				errs = append(errs, e)
+			}
+		},
+	}
+	conf.Check(generated.Name.Name, fs, files, nil)
+	return errs, nil
+}
+
+// verifyOutput type checks and vets the formatted output as the options
+// ask, for generics, reporting the problems at the positions at gets for
+// the lines of the template. Without origins, as for code converted to
+// type parameters, the problems are reported in the generated code only.
+func verifyOutput(at linePositions, outputFilename string, output, unformatted []byte, origins []origin, typeSets []map[string]string, opts Options, span Span) error {
+	if opts.TypeCheck {
+		checkSpan := span.StartSpan(SpanTypeCheck, nil)
+		err := typeCheck(at, outputFilename, output, unformatted, origins, typeSets, opts)
+		checkSpan.End(err)
+		if err != nil {
+			return err
+		}
+	}
+	if opts.Vet {
+		vetSpan := span.StartSpan(SpanVet, nil)
+		err := vetCheck(at, outputFilename, output, unformatted, origins, typeSets, opts)
+		vetSpan.End(err)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// typeCheck type checks the generated code as VerifyWithOptions does, for
+// Options.TypeCheck. Each error also names the line of the template and the
+// type set it comes from, found through the unformatted code and its
+// origins as generate made them.
+func typeCheck(at linePositions, outputFilename string, output, unformatted []byte, origins []origin, typeSets []map[string]string, opts Options) error {
+	errs, err := typeErrors(outputFilename, output, opts)
+	if err != nil || len(errs) == 0 {
+		return err
+	}
+	lineOrigin := outputOrigins(outputFilename, output, unformatted, origins)
+	compileErr := &errCompile{}
+	for _, e := range errs {
+		compileErr.Errors = append(compileErr.Errors, templateMessage(at, e.Fset.Position(e.Pos), e.Msg, lineOrigin, typeSets))
+	}
+	return compileErr
+}
+
+// vetFinding matches a finding of go vet, or of the compiler it runs, e.g.
+// "./gen.go:9:19: invalid map key type []byte".
+var vetFinding = regexp.MustCompile(`^(?:vet: )?(\S+\.go):(\d+):(\d+): (.*)$`)
+
+// vetCheck runs go vet on the package of the output file with the generated
+// code, in a sandbox module, for Options.Vet. Each finding in the generated
+// code also names the line of the template and the type set it comes from,
+// as with typeCheck.
+func vetCheck(at linePositions, outputFilename string, output, unformatted []byte, origins []origin, typeSets []map[string]string, opts Options) error {
+	sandbox, err := NewSandbox(outputFilename, output, opts)
+	if err != nil {
+		return err
+	}
+	defer sandbox.Close()
+	if opts.Script {
+		// scripts are left out of the package, so are vetted on their own
+		_, err = sandbox.Go("vet", filepath.Join(sandbox.Package, filepath.Base(outputFilename)))
+	} else {
+		err = sandbox.Vet()
+	}
+	goErr, ok := err.(*errGoCommand)
+	if !ok {
+		return err
+	}
+	lineOrigin := outputOrigins(outputFilename, output, unformatted, origins)
+	vetErr := &errVet{}
+	for _, line := range strings.Split(strings.TrimSpace(goErr.Output), "\n") {
+		m := vetFinding.FindStringSubmatch(line)
+		if m == nil || filepath.Base(m[1]) != filepath.Base(outputFilename) {
+			if line != "" && !strings.HasPrefix(line, "#") {
+				vetErr.Findings = append(vetErr.Findings, line)
+			}
+			continue
+		}
+		lineNum, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		pos := token.Position{Filename: outputFilename, Line: lineNum, Column: column}
+		vetErr.Findings = append(vetErr.Findings, templateMessage(at, pos, m[4], lineOrigin, typeSets))
+	}
+	return vetErr
+}
+
+// templateMessage gets the message reported at the position in the
+// generated code as it is reported at the template line and the type set
+// it comes from, followed by the position, or as it is if it does not
+// come from a type set.
+func templateMessage(at linePositions, pos token.Position, msg string, lineOrigin func(token.Position) (origin, bool), typeSets []map[string]string) string {
+	o, ok := lineOrigin(pos)
+	if !ok || o.TypeSet < 0 || o.TypeSet >= len(typeSets) {
+		return pos.String() + ": " + msg
+	}
+	line := at(o.Line)
+	return fmt.Sprintf("%s:%d: %s (with %s, at %s)", line.Filename, line.Line, msg, typeSetString(typeSets[o.TypeSet]), pos)
+}
+
+// outputOrigins gets a function finding the origin of a position in the
+// formatted output. Formatting keeps the order of the declarations, and
+// mostly their lines, so a line is taken to come from where the line as
+// far into the same declaration (from its doc comment) of the unformatted
+// code does.
+func outputOrigins(outputFilename string, output, unformatted []byte, origins []origin) func(token.Position) (origin, bool) {
+	fs := token.NewFileSet()
+	formattedFile, err := parser.ParseFile(fs, outputFilename, output, parser.ParseComments)
+	if err != nil {
+		return func(token.Position) (origin, bool) { return origin{}, false }
+	}
+	unformattedFile, err := parser.ParseFile(fs, outputFilename, unformatted, parser.ParseComments)
+	if err != nil {
+		return func(token.Position) (origin, bool) { return origin{}, false }
+	}
+	formattedDecls, unformattedDecls := codeDecls(formattedFile), codeDecls(unformattedFile)
+	return func(pos token.Position) (origin, bool) {
+		if filepath.Base(pos.Filename) != filepath.Base(outputFilename) {
+			return origin{}, false
+		}
+		for i, decl := range formattedDecls {
+			start, end := fs.Position(declStart(decl)).Line, fs.Position(decl.End()).Line
+			if pos.Line < start || pos.Line > end || i >= len(unformattedDecls) {
+				continue
+			}
+			other := unformattedDecls[i]
+			line := fs.Position(declStart(other)).Line + pos.Line - start
+			if last := fs.Position(other.End()).Line; line > last {
+				line = last
+			}
+			if line < 1 || line > len(origins) {
+				return origin{}, false
+			}
+			return origins[line-1], true
+		}
+		return origin{}, false
+	}
+}
+
+// declStart gets where the declaration starts, with its doc comment.
+func declStart(decl ast.Decl) token.Pos {
+	if doc := declDoc(decl); doc != nil {
+		return doc.Pos()
+	}
+	return decl.Pos()
+}
+
+// codeDecls gets the declarations of the file other than its imports,
+// which formatting rebuilds.
+func codeDecls(file *ast.File) []ast.Decl {
+	var decls []ast.Decl
+	for _, decl := range file.Decls {
+		if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			continue
+		}
+		decls = append(decls, decl)
+	}
+	return decls
+}
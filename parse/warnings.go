@@ -0,0 +1,104 @@
+package parse
+
+import (
+	"go/ast"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cheekybits/genny/diag"
+)
+
+// GenericsWithDiagnostics generates the code like GenericsWithOptions, and
+// also gets the warnings found along the way: what Strict would fail on
+// (unused generic types, names generated by more than one type set, ...)
+// unless it is set, and suspicious substitutions, which never fail. They
+// are returned even if generation fails. If opts.Warnings is set, the
+// warnings are added to it too, and only those it did not hold already are
+// returned.
+func GenericsWithDiagnostics(filename, outputFilename, pkgName string, in io.Reader, typeSets []map[string]string, opts Options) ([]byte, diag.List, error) {
+	if opts.Warnings == nil {
+		opts.Warnings = &diag.List{}
+	}
+	before := len(*opts.Warnings)
+	output, err := GenericsWithOptions(filename, outputFilename, pkgName, in, typeSets, opts)
+	return output, (*opts.Warnings)[before:], err
+}
+
+// addWarnings adds the problems to opts.Warnings, if it is set, as
+// warnings, once each. Unlike strictError, they are warnings in strict mode
+// too.
+func addWarnings(problems diag.List, opts Options) {
+	if opts.Warnings == nil {
+		return
+	}
+	for _, d := range problems {
+		warning := diag.FromError(d, diag.Warning)
+		if !opts.Warnings.Has(warning) {
+			*opts.Warnings = append(*opts.Warnings, warning)
+		}
+	}
+}
+
+// checkLiterals finds the string literals in which a generic type carries
+// on in lower case, such as Key in "Keyboard", which is substituted along with
+// the generic type (IntBoard) although it most likely should not be.
+func checkLiterals(fs *token.FileSet, file *ast.File) diag.List {
+	var generics []string
+	for g := range genericTypes(file) {
+		generics = append(generics, g)
+	}
+	sort.Strings(generics)
+	var problems diag.List
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		for _, g := range generics {
+			if word, ok := wordStartedBy(lit.Value, g); ok {
+				problems.Add(&errSuspiciousLiteral{GenericType: g, Word: word, Pos: fs.Position(lit.Pos())}, diag.Warning)
+			}
+		}
+		return true
+	})
+	return problems
+}
+
+// wordStartedBy gets the first word of the text in which the generic type
+// carries on in lower case, other than a plural, if there is one.
+func wordStartedBy(text, generic string) (string, bool) {
+	for i := 0; ; {
+		j := strings.Index(text[i:], generic)
+		if j < 0 {
+			return "", false
+		}
+		start, end := i+j, i+j+len(generic)
+		i = end
+		if r, _ := utf8.DecodeRuneInString(text[end:]); !unicode.IsLower(r) {
+			continue
+		}
+		for end < len(text) {
+			r, size := utf8.DecodeRuneInString(text[end:])
+			if !isAlphaNumeric(r) {
+				break
+			}
+			end += size
+		}
+		// a plural (Keys) is meant to be substituted
+		if text[i:end] == "s" {
+			continue
+		}
+		for start > 0 {
+			r, size := utf8.DecodeLastRuneInString(text[:start])
+			if !isAlphaNumeric(r) {
+				break
+			}
+			start -= size
+		}
+		return text[start:end], true
+	}
+}
@@ -0,0 +1,58 @@
+package parse_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/diag"
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const keysTemplate = `package keys
+
+import "github.com/cheekybits/genny/generic"
+
+type Key generic.Type
+
+type Value generic.Type
+
+func KeyName(k Key) string {
+	return "Keyboard key, one of the Keys"
+}
+`
+
+func TestGenericsWithDiagnostics(t *testing.T) {
+	typeSets, err := parse.TypeSet("Key=int")
+	require.NoError(t, err)
+	typeSets[0]["Value"] = "string"
+
+	output, warnings, err := parse.GenericsWithDiagnostics("keys.go", "", "", strings.NewReader(keysTemplate), typeSets, parse.Options{})
+	require.NoError(t, err)
+	assert.Contains(t, string(output), "func IntName(k int) string")
+	if assert.Len(t, warnings, 2) {
+		assert.Equal(t, diag.Warning, warnings[0].Severity)
+		assert.Equal(t, 10, warnings[0].Pos.Line)
+		assert.Equal(t, "generic type 'Key' is part of the word 'Keyboard' in a string literal, which is substituted too", warnings[0].Message)
+		assert.Equal(t, diag.Warning, warnings[1].Severity)
+		assert.Equal(t, "generic type 'Value' is declared but never used", warnings[1].Message)
+	}
+
+	// in strict mode, the unused generic type fails, but the suspicious
+	// literal is still only a warning
+	_, warnings, err = parse.GenericsWithDiagnostics("keys.go", "", "", strings.NewReader(keysTemplate), typeSets, parse.Options{Strict: true})
+	assert.Error(t, err)
+	if assert.Len(t, warnings, 1) {
+		assert.Contains(t, warnings[0].Message, "Keyboard")
+	}
+}
+
+func TestGenericsWithDiagnosticsAddsToWarnings(t *testing.T) {
+	typeSets, err := parse.TypeSet("Key=int Value=string")
+	require.NoError(t, err)
+	var collected diag.List
+	_, warnings, err := parse.GenericsWithDiagnostics("keys.go", "", "", strings.NewReader(keysTemplate), typeSets, parse.Options{Warnings: &collected})
+	require.NoError(t, err)
+	assert.Equal(t, warnings, collected)
+}
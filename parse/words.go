@@ -0,0 +1,135 @@
+package parse
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// compositeWord gets the word for a composite specific type, named after
+// what it is made of: ByteSlice for []byte, Byte16Array for [16]byte,
+// StringIntMap for map[string]int, ErrorChan for chan error and
+// IntToBoolFunc for func(int) bool. Types given with the import paths of
+// their packages are named by their names only, as UUIDSlice for
+// []github.com/google/uuid.UUID. It gets false for types that are not
+// composite, or that cannot be parsed, which are named as they always
+// were.
+func compositeWord(specificType string) (string, bool) {
+	specificType = qualifiedType.ReplaceAllStringFunc(specificType, func(match string) string {
+		return match[strings.LastIndex(match, ".")+1:]
+	})
+	expr, err := parser.ParseExpr(specificType)
+	if err != nil {
+		return "", false
+	}
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+			continue
+		case *ast.ParenExpr:
+			expr = e.X
+			continue
+		case *ast.Ident, *ast.SelectorExpr:
+			return "", false
+		}
+		break
+	}
+	return typeWord(expr)
+}
+
+// typeWord gets the word for the type expression, starting with a capital
+// letter, or false if it has a part no word can be made of.
+func typeWord(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return wordify(e.Name, true), true
+	case *ast.SelectorExpr:
+		pkg, ok := e.X.(*ast.Ident)
+		if !ok {
+			return "", false
+		}
+		return wordify(pkg.Name+"."+e.Sel.Name, true), true
+	case *ast.StarExpr:
+		return typeWord(e.X)
+	case *ast.ParenExpr:
+		return typeWord(e.X)
+	case *ast.Ellipsis:
+		return wordsOf("Slice", e.Elt)
+	case *ast.ArrayType:
+		if e.Len == nil {
+			return wordsOf("Slice", e.Elt)
+		}
+		length, ok := e.Len.(*ast.BasicLit)
+		if !ok || length.Kind != token.INT {
+			// a constant or an expression
+			n, ok := typeWord(e.Len)
+			if !ok {
+				return "", false
+			}
+			return wordsOf(n+"Array", e.Elt)
+		}
+		return wordsOf(length.Value+"Array", e.Elt)
+	case *ast.MapType:
+		return wordsOf("Map", e.Key, e.Value)
+	case *ast.ChanType:
+		switch e.Dir {
+		case ast.SEND:
+			return wordsOf("SendChan", e.Value)
+		case ast.RECV:
+			return wordsOf("RecvChan", e.Value)
+		}
+		return wordsOf("Chan", e.Value)
+	case *ast.FuncType:
+		params, ok := wordsOf("", fieldTypes(e.Params)...)
+		if !ok {
+			return "", false
+		}
+		results, ok := wordsOf("", fieldTypes(e.Results)...)
+		if !ok {
+			return "", false
+		}
+		if params != "" && results != "" {
+			return params + "To" + results + "Func", true
+		}
+		return params + results + "Func", true
+	case *ast.InterfaceType:
+		return "Interface", true
+	case *ast.StructType:
+		return "Struct", true
+	case *ast.IndexExpr:
+		return wordsOf("", e.X, e.Index)
+	case *ast.IndexListExpr:
+		return wordsOf("", append([]ast.Expr{e.X}, e.Indices...)...)
+	}
+	return "", false
+}
+
+// wordsOf gets the words of the types, in order, followed by the suffix.
+func wordsOf(suffix string, exprs ...ast.Expr) (string, bool) {
+	var words strings.Builder
+	for _, expr := range exprs {
+		word, ok := typeWord(expr)
+		if !ok {
+			return "", false
+		}
+		words.WriteString(word)
+	}
+	return words.String() + suffix, true
+}
+
+// fieldTypes gets the type of each field of the list, once per name, so
+// that func(a, b int) is named like func(int, int).
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var types []ast.Expr
+	for _, field := range fields.List {
+		for n := 0; n < len(field.Names) || n == 0; n++ {
+			types = append(types, field.Type)
+		}
+	}
+	return types
+}
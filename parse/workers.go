@@ -0,0 +1,142 @@
+package parse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+
+	"github.com/cheekybits/genny/diag"
+)
+
+// specific is the code generated from a template for one type set, with
+// the template line each of its lines came from and the warnings found
+// generating it.
+type specific struct {
+	typeSet  map[string]string
+	output   []byte
+	lines    []int
+	warnings diag.List
+	err      error
+}
+
+// generateSpecifics generates the code of every type set of the groups,
+// up to opts.Workers of them at once and in the order opts.Shuffle gives,
+// and gets it in the order of the groups and their type sets, whatever
+// order it was generated in. The template is parsed once, and shared by
+// the type sets. Like generating the type sets one after another, it fails
+// with the error of the first type set that could not be generated; type
+// sets after it may not be. Once opts.ctx is done, the type sets not yet
+// started fail with its error.
+func generateSpecifics(filename string, groups []group, opts Options, span Span) ([]specific, error) {
+	var (
+		jobs    []specific
+		sources [][]byte
+		indexes []int
+		counts  []int
+	)
+	for _, g := range groups {
+		g.Source.Seek(0, os.SEEK_SET)
+		src, err := ioutil.ReadAll(g.Source)
+		if err != nil {
+			return nil, &errSource{Err: err}
+		}
+		for i, typeSet := range g.TypeSets {
+			jobs = append(jobs, specific{typeSet: typeSet})
+			sources = append(sources, src)
+			indexes = append(indexes, i)
+			counts = append(counts, len(g.TypeSets))
+		}
+	}
+	order := make([]int, len(jobs))
+	for n := range order {
+		order[n] = n
+	}
+	if opts.Shuffle != nil {
+		opts.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	}
+	if opts.Cache == nil {
+		// the template is parsed once for all the type sets
+		opts.Cache = &Cache{MaxResults: -1}
+	}
+
+	run := func(n int) {
+		job := &jobs[n]
+		if opts.ctx != nil {
+			if job.err = opts.ctx.Err(); job.err != nil {
+				return
+			}
+		}
+		jobOpts := opts
+		if opts.Warnings != nil {
+			jobOpts.Warnings = &job.warnings
+		}
+		// panics are reported as internal errors, as they are when the
+		// type sets are generated by the calling goroutine
+		defer func() {
+			if r := recover(); r != nil {
+				job.err = &errInternal{Value: r, Stack: string(debug.Stack()), TypeSet: typeSetString(job.typeSet)}
+			}
+		}()
+		job.output, job.lines, job.err = generateSpecific(filename, bytes.NewReader(sources[n]), job.typeSet, indexes[n], counts[n], jobOpts, span)
+	}
+
+	workers := opts.Workers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+	if workers <= 1 {
+		for _, n := range order {
+			if run(n); jobs[n].err != nil {
+				break
+			}
+		}
+	} else {
+		next := make(chan int)
+		var (
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			failed bool
+		)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for n := range next {
+					run(n)
+					if jobs[n].err != nil {
+						mu.Lock()
+						failed = true
+						mu.Unlock()
+					}
+				}
+			}()
+		}
+		for _, n := range order {
+			mu.Lock()
+			stop := failed
+			mu.Unlock()
+			if stop {
+				break
+			}
+			next <- n
+		}
+		close(next)
+		wg.Wait()
+	}
+
+	// unless they are shuffled, type sets are handed out in order, so every
+	// one before the first that failed was generated
+	for _, job := range jobs {
+		addWarnings(job.warnings, opts)
+		if job.err != nil {
+			return nil, job.err
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,100 @@
+package parse_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/diag"
+	"github.com/cheekybits/genny/parse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const workersTemplate = `package pair
+
+import "github.com/cheekybits/genny/generic"
+
+type Key generic.Type
+type Value generic.Type
+
+type KeyValuePair struct {
+	Key   Key
+	Value Value
+}
+`
+
+func TestGenericsWorkers(t *testing.T) {
+	var typeSets []map[string]string
+	for i := 0; i < 40; i++ {
+		typeSets = append(typeSets, map[string]string{"Key": "int", "Value": fmt.Sprintf("T%02d", i), fmt.Sprintf("Extra%02d", i): "x"})
+	}
+
+	var serialWarnings diag.List
+	serial, err := parse.GenericsWithOptions("pair.go", "gen_pair.go", "", strings.NewReader(workersTemplate), typeSets, parse.Options{Workers: 1, Warnings: &serialWarnings})
+	require.NoError(t, err)
+	assert.Len(t, serialWarnings, 40)
+
+	for _, workers := range []int{0, 4, 64} {
+		var warnings diag.List
+		output, err := parse.GenericsWithOptions("pair.go", "gen_pair.go", "", strings.NewReader(workersTemplate), typeSets, parse.Options{Workers: workers, Warnings: &warnings})
+		require.NoError(t, err)
+		assert.Equal(t, string(serial), string(output), "with %d workers", workers)
+		assert.Equal(t, serialWarnings, warnings, "with %d workers", workers)
+	}
+}
+
+func TestGenericsWorkersError(t *testing.T) {
+	typeSets := []map[string]string{
+		{"Key": "int", "Value": "string"},
+		{"Key": "int"},
+		{"Value": "string"},
+	}
+	for _, workers := range []int{1, 3} {
+		_, err := parse.GenericsWithOptions("pair.go", "gen_pair.go", "", strings.NewReader(workersTemplate), typeSets, parse.Options{Workers: workers})
+		if assert.Error(t, err, "with %d workers", workers) {
+			// the first type set that fails is reported, not the first to
+			// fail
+			assert.Contains(t, err.Error(), "Value", "with %d workers", workers)
+		}
+	}
+}
+
+// typeSetTracer records the type sets substituted, in order.
+type typeSetTracer struct {
+	typeSets []string
+}
+
+func (r *typeSetTracer) StartSpan(name string, attrs map[string]string) parse.Span {
+	if name == parse.SpanSubstitute {
+		r.typeSets = append(r.typeSets, attrs["genny.typeset"])
+	}
+	return r
+}
+
+func (r *typeSetTracer) End(err error) {}
+
+func TestGenericsShuffle(t *testing.T) {
+	typeSets := []map[string]string{
+		{"Key": "int", "Value": "string"},
+		{"Key": "int", "Value": "bool"},
+		{"Key": "string", "Value": "bool"},
+	}
+	inOrder := &typeSetTracer{}
+	expected, err := parse.GenericsWithOptions("pair.go", "gen_pair.go", "", strings.NewReader(workersTemplate), typeSets, parse.Options{Workers: 1, Tracer: inOrder})
+	require.NoError(t, err)
+
+	// the type sets are generated in reverse, but the output is in their
+	// order
+	reversed := &typeSetTracer{}
+	reverse := func(n int, swap func(i, j int)) {
+		for i := 0; i < n/2; i++ {
+			swap(i, n-1-i)
+		}
+	}
+	output, err := parse.GenericsWithOptions("pair.go", "gen_pair.go", "", strings.NewReader(workersTemplate), typeSets, parse.Options{Workers: 1, Tracer: reversed, Shuffle: reverse})
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(output))
+	require.Len(t, reversed.typeSets, 3)
+	assert.Equal(t, []string{inOrder.typeSets[2], inOrder.typeSets[1], inOrder.typeSets[0]}, reversed.typeSets)
+}
@@ -0,0 +1,171 @@
+package paths
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ChecksumsFilename is the name of the file in a template archive listing
+// the SHA-256 checksums of its files, in the format of sha256sum.
+const ChecksumsFilename = "SHA256SUMS"
+
+// archiveExts are the extensions of the template archives genny reads.
+var archiveExts = []string{".tar.gz", ".tgz", ".tar", ".zip"}
+
+// ReadTemplate reads the template at p, relative to dir. p may name a
+// template in an archive (a .tar.gz, .tgz, .tar or .zip file) as
+// archive#member, e.g. templates.tar.gz#queue/generic_queue.go, and may
+// leave out the member if the archive holds a single template. Archives
+// are read in memory. If the archive has a SHA256SUMS file (as bundles do),
+// the template must match its checksum.
+//
+// It gets the name of the template to generate it with: p, with the member
+// it found if p did not name one.
+func ReadTemplate(dir, p string) (string, []byte, error) {
+	archive, member, ok := splitArchive(p)
+	if !ok {
+		b, err := ReadFile("template", dir, p)
+		return p, b, err
+	}
+	resolved := Resolve(dir, archive)
+	files, err := readArchive(resolved)
+	if err != nil {
+		return "", nil, &errPath{What: "template archive", Path: archive, Resolved: resolved, Err: err}
+	}
+	if member == "" {
+		var templates []string
+		for name, src := range files {
+			if strings.HasSuffix(name, ".go") && IsTemplate(src, nil) {
+				templates = append(templates, name)
+			}
+		}
+		sort.Strings(templates)
+		switch len(templates) {
+		case 0:
+			return "", nil, &errPath{What: "template archive", Path: archive, Resolved: resolved, Message: "holds no templates"}
+		case 1:
+			member = templates[0]
+		default:
+			return "", nil, &errPath{What: "template archive", Path: archive, Resolved: resolved,
+				Message: "holds more than one template; name one with " + archive + "#<template> (it holds " + strings.Join(templates, ", ") + ")"}
+		}
+		p = archive + "#" + member
+	}
+	member = path.Clean(member)
+	src, ok := files[member]
+	if !ok {
+		return "", nil, &errPath{What: "template", Path: p, Resolved: resolved + "#" + member, Message: "is not in the archive"}
+	}
+	if sums, ok := files[ChecksumsFilename]; ok {
+		if sum, ok := checksums(sums)[member]; ok && sum != fmt.Sprintf("%x", sha256.Sum256(src)) {
+			return "", nil, &errPath{What: "template", Path: p, Resolved: resolved + "#" + member, Message: "does not match its checksum in " + ChecksumsFilename}
+		}
+	}
+	return p, src, nil
+}
+
+// checksums parses a SHA256SUMS file into the checksums by path.
+func checksums(sums []byte) map[string]string {
+	byPath := make(map[string]string)
+	for _, line := range strings.Split(string(sums), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 {
+			byPath[strings.TrimPrefix(fields[1], "*")] = fields[0]
+		}
+	}
+	return byPath
+}
+
+// splitArchive splits p into the path of an archive and the member in it.
+// ok is false if p is not in an archive.
+func splitArchive(p string) (archive, member string, ok bool) {
+	archive = p
+	if i := strings.LastIndex(p, "#"); i >= 0 {
+		archive, member = p[:i], strings.Replace(p[i+1:], `\`, "/", -1)
+	}
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(strings.ToLower(archive), ext) {
+			return archive, member, true
+		}
+	}
+	return "", "", false
+}
+
+// readArchive reads the regular files in the archive, by their slash
+// separated paths.
+func readArchive(filename string) (map[string][]byte, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasSuffix(strings.ToLower(filename), ".zip") {
+		return readZip(b)
+	}
+	var r io.Reader = bytes.NewReader(b)
+	if !strings.HasSuffix(strings.ToLower(filename), ".tar") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	return readTar(r)
+}
+
+// readZip reads the regular files of a zip archive.
+func readZip(b []byte) (map[string][]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		src, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(f.Name)] = src
+	}
+	return files, nil
+}
+
+// readTar reads the regular files of a tar archive.
+func readTar(r io.Reader) (map[string][]byte, error) {
+	tr := tar.NewReader(r)
+	files := make(map[string][]byte)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		src, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+		files[path.Clean(h.Name)] = src
+	}
+}
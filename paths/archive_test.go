@@ -0,0 +1,89 @@
+package paths_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+const archivedTemplate = "package queue\n\nimport \"github.com/cheekybits/genny/generic\"\n\ntype Item generic.Type\n"
+
+func writeZip(t *testing.T, filename string, files map[string]string) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		assert.NoError(t, err)
+		w.Write([]byte(content))
+	}
+	assert.NoError(t, zw.Close())
+	assert.NoError(t, ioutil.WriteFile(filename, buf.Bytes(), 0644))
+}
+
+func writeTarGz(t *testing.T, filename string, files map[string]string) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		tw.Write([]byte(content))
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, ioutil.WriteFile(filename, buf.Bytes(), 0644))
+}
+
+func TestReadTemplateFromArchive(t *testing.T) {
+	dir := t.TempDir()
+	writeTarGz(t, filepath.Join(dir, "one.tar.gz"), map[string]string{
+		"./lib/queue/generic_queue.go": archivedTemplate,
+		"./lib/README.md":              "templates",
+	})
+	writeZip(t, filepath.Join(dir, "two.zip"), map[string]string{
+		"queue/generic_queue.go": archivedTemplate,
+		"list/generic_list.go":   archivedTemplate,
+	})
+
+	// the only template of the archive
+	name, src, err := paths.ReadTemplate(dir, "one.tar.gz")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "one.tar.gz#lib/queue/generic_queue.go", name)
+		assert.Equal(t, archivedTemplate, string(src))
+	}
+
+	name, src, err = paths.ReadTemplate(dir, `two.zip#list\generic_list.go`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `two.zip#list\generic_list.go`, name)
+		assert.Equal(t, archivedTemplate, string(src))
+	}
+
+	_, _, err = paths.ReadTemplate(dir, "two.zip")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "holds more than one template; name one with two.zip#<template> (it holds list/generic_list.go, queue/generic_queue.go)")
+	}
+	_, _, err = paths.ReadTemplate(dir, "two.zip#missing.go")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "is not in the archive")
+	}
+	_, _, err = paths.ReadTemplate(dir, "three.tgz")
+	assert.Error(t, err)
+}
+
+func TestReadTemplateChecksums(t *testing.T) {
+	dir := t.TempDir()
+	writeTarGz(t, filepath.Join(dir, "bundle.tgz"), map[string]string{
+		"queue/generic_queue.go": archivedTemplate,
+		paths.ChecksumsFilename:  "0000000000000000000000000000000000000000000000000000000000000000  queue/generic_queue.go\n",
+	})
+	_, _, err := paths.ReadTemplate(dir, "bundle.tgz")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "does not match its checksum in SHA256SUMS")
+	}
+}
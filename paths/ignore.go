@@ -0,0 +1,185 @@
+package paths
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// IgnoreFilename is the name of the file listing the paths that commands
+// working on a whole tree (such as unused, fmt and docs) skip.
+const IgnoreFilename = ".gennyignore"
+
+// Ignore holds the gitignore style patterns of an ignore file. A nil
+// Ignore ignores nothing.
+type Ignore struct {
+	// Dir is the directory the patterns are relative to.
+	Dir   string
+	rules []ignoreRule
+}
+
+// ignoreRule is a pattern of an ignore file.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// LoadIgnore reads the ignore file in dir. If there is none, it gets an
+// Ignore that ignores nothing.
+func LoadIgnore(dir string) (*Ignore, error) {
+	filename := filepath.Join(dir, IgnoreFilename)
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return &Ignore{Dir: dir}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ig, err := ParseIgnore(dir, b)
+	if err != nil {
+		return nil, &errPath{What: "ignore file", Path: filename, Err: err}
+	}
+	return ig, nil
+}
+
+// ParseIgnore parses the patterns of an ignore file, relative to dir.
+//
+// The patterns are those of .gitignore: blank lines and lines starting
+// with # are skipped, ! negates a pattern, a trailing / only matches
+// directories, and a pattern with a / anywhere else is relative to dir
+// rather than matched at any depth. *, ? and [...] match within a path
+// element, and ** matches any number of them.
+func ParseIgnore(dir string, src []byte) (*Ignore, error) {
+	ig := &Ignore{Dir: dir}
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r ignoreRule
+		if strings.HasPrefix(line, "!") {
+			r.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		expr := ignorePattern(line)
+		if !anchored {
+			expr = "(.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid pattern %q", n, scanner.Text())
+		}
+		r.re = re
+		ig.rules = append(ig.rules, r)
+	}
+	return ig, scanner.Err()
+}
+
+// ignorePattern turns a gitignore pattern into a regular expression.
+func ignorePattern(pattern string) string {
+	var expr strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
+
+// Match gets whether the path, relative to the working directory, is
+// ignored. isDir says whether it is a directory. Everything in an ignored
+// directory is ignored too, as with git.
+func (ig *Ignore) Match(p string, isDir bool) bool {
+	if ig == nil || len(ig.rules) == 0 {
+		return false
+	}
+	rel, ok := ig.rel(p)
+	if !ok {
+		return false
+	}
+	elems := strings.Split(rel, "/")
+	for i := 1; i <= len(elems); i++ {
+		prefix := strings.Join(elems[:i], "/")
+		if ig.matchOne(prefix, i < len(elems) || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOne gets whether the rules ignore the path itself, the last rule
+// that matches it deciding.
+func (ig *Ignore) matchOne(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range ig.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// rel gets the path relative to the directory of the ignore file, with
+// forward slashes, or false if it is outside it.
+func (ig *Ignore) rel(p string) (string, bool) {
+	base, err := filepath.Abs(ig.Dir)
+	if err != nil {
+		return "", false
+	}
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(base, abs)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
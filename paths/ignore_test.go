@@ -0,0 +1,60 @@
+package paths_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIgnore(t *testing.T) {
+	ig, err := paths.ParseIgnore("root", []byte(`
+# vendored and experimental code
+vendor/
+/third_party
+experimental/**/gen_*.go
+*.tmp.go
+!keep.tmp.go
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, test := range []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"root/vendor", true, true},
+		{"root/vendor/pkg/gen_queue.go", false, true},
+		{"root/a/vendor/gen_queue.go", false, true},
+		{"root/vendor", false, false},
+		{"root/third_party/gen_queue.go", false, true},
+		{"root/a/third_party/gen_queue.go", false, false},
+		{"root/experimental/gen_queue.go", false, true},
+		{"root/experimental/a/b/gen_queue.go", false, true},
+		{"root/experimental/queue.go", false, false},
+		{"root/a/b.tmp.go", false, true},
+		{"root/a/keep.tmp.go", false, false},
+		{"root/queue.go", false, false},
+		{"other/vendor/gen_queue.go", false, false},
+	} {
+		assert.Equal(t, test.ignored, ig.Match(filepath.FromSlash(test.path), test.isDir), test.path)
+	}
+}
+
+func TestLoadIgnore(t *testing.T) {
+	dir := t.TempDir()
+	ig, err := paths.LoadIgnore(dir)
+	assert.NoError(t, err)
+	assert.False(t, ig.Match(filepath.Join(dir, "vendor", "a.go"), false))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, paths.IgnoreFilename), []byte("vendor/\n"), 0644))
+	ig, err = paths.LoadIgnore(dir)
+	assert.NoError(t, err)
+	assert.True(t, ig.Match(filepath.Join(dir, "vendor", "a.go"), false))
+
+	var none *paths.Ignore
+	assert.False(t, none.Match("vendor/a.go", false))
+}
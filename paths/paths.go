@@ -0,0 +1,182 @@
+// Package paths resolves the template and output paths that genny is
+// given in config files, go:generate directives and command line flags, so
+// they mean the same on every platform.
+//
+// Paths may be written with forward slashes or backslashes on any
+// platform. Relative paths are relative to a base directory: the directory
+// of the config file, the directory of the file containing the directive
+// (where go generate runs it), or the working directory for flags.
+package paths
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Resolve gets the path p, relative to dir, in the form of the current
+// platform. An empty dir means the working directory.
+func Resolve(dir, p string) string {
+	if p == "" {
+		return ""
+	}
+	p = filepath.FromSlash(strings.Replace(p, `\`, "/", -1))
+	if filepath.IsAbs(p) || dir == "" {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(Resolve("", dir), p)
+}
+
+// ReadFile reads the file at p, relative to dir. what describes the file
+// in errors, e.g. "template".
+func ReadFile(what, dir, p string) ([]byte, error) {
+	resolved := Resolve(dir, p)
+	b, err := ioutil.ReadFile(resolved)
+	if err != nil {
+		return nil, &errPath{What: what, Path: p, Resolved: resolved, Err: err}
+	}
+	return b, nil
+}
+
+// CheckOutput checks that the resolved output path can be written without
+// harm: it must not be a directory, the template or any other template (a
+// file declaring generic types of the generic package or of
+// genericPackages, see IsTemplate). Unless force is set, which allows
+// overwriting templates but nothing else.
+func CheckOutput(template, out string, force bool, genericPackages []string) error {
+	if out == "" {
+		return &errPath{What: "output", Message: "no path given"}
+	}
+	info, err := os.Stat(out)
+	if err == nil && info.IsDir() {
+		return &errPath{What: "output", Path: out, Message: "is a directory"}
+	}
+	if force {
+		return nil
+	}
+	if template != "" && sameFile(template, out) {
+		return &errPath{What: "output", Path: out, Message: "would overwrite the template", overwrite: true}
+	}
+	if err == nil {
+		if src, err := ioutil.ReadFile(out); err == nil && IsTemplate(src, genericPackages) {
+			return &errPath{What: "output", Path: out, Message: "would overwrite a template, which declares generic types", overwrite: true}
+		}
+	}
+	return nil
+}
+
+// genericDecl is the pattern of the declaration of a generic type of the
+// packages named by %s.
+const genericDecl = `(?m)^\s*(type\s+)?\w+\s+%s\.(Type|Number|Integer|Unsigned|Float|Interface|Comparable)\b`
+
+// templatePattern matches the declaration of a generic type.
+var templatePattern = regexp.MustCompile(fmt.Sprintf(genericDecl, "generic"))
+
+// IsTemplate gets whether the Go source is a template, which declares
+// generic types of the generic package or of genericPackages, other
+// packages given by the name templates use for them or by their import
+// paths, as -generic-packages gives them.
+func IsTemplate(src []byte, genericPackages []string) bool {
+	if templatePattern.Match(src) {
+		return true
+	}
+	var names []string
+	for _, name := range genericPackageNames(src, genericPackages) {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	if len(names) == 0 {
+		return false
+	}
+	return regexp.MustCompile(fmt.Sprintf(genericDecl, "("+strings.Join(names, "|")+")")).Match(src)
+}
+
+// genericPackageNames gets the names the source uses for the generic
+// packages: those given by name, and those of its imports of the ones
+// given by import path.
+func genericPackageNames(src []byte, genericPackages []string) []string {
+	var names []string
+	var imports []*ast.ImportSpec
+	parsed := false
+	for _, pkg := range genericPackages {
+		if token.IsIdentifier(pkg) {
+			names = append(names, pkg)
+			continue
+		}
+		if !parsed {
+			if file, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ImportsOnly); err == nil {
+				imports = file.Imports
+			}
+			parsed = true
+		}
+		for _, imp := range imports {
+			if importPath, err := strconv.Unquote(imp.Path.Value); err != nil || importPath != pkg {
+				continue
+			}
+			name := path.Base(pkg)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			if name != "_" && name != "." {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// IsOverwrite gets whether the error is from CheckOutput refusing to
+// overwrite a template, which it allows when forced.
+func IsOverwrite(err error) bool {
+	e, ok := err.(*errPath)
+	return ok && e.overwrite
+}
+
+// sameFile gets whether the paths are the same file, which need not exist.
+func sameFile(a, b string) bool {
+	if ai, err := os.Stat(a); err == nil {
+		if bi, err := os.Stat(b); err == nil {
+			return os.SameFile(ai, bi)
+		}
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// errPath represents an error with a path, showing where it was resolved
+// to.
+type errPath struct {
+	What     string
+	Path     string
+	Resolved string
+	Message  string
+	Err      error
+
+	overwrite bool
+}
+
+// Error gets a human readable string describing this error.
+func (e errPath) Error() string {
+	msg := e.What
+	if e.Path != "" {
+		msg += " " + e.Path
+	}
+	if e.Resolved != "" && e.Resolved != e.Path {
+		msg += " (resolved to " + e.Resolved + ")"
+	}
+	if e.Message != "" {
+		msg += " " + e.Message
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
@@ -0,0 +1,118 @@
+package paths_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cheekybits/genny/paths"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	dir := filepath.FromSlash("/src/pkg")
+	for _, test := range []struct {
+		dir, p, expected string
+	}{
+		{dir, "", ""},
+		{dir, "queue.go", filepath.FromSlash("/src/pkg/queue.go")},
+		{dir, "gen/queue.go", filepath.FromSlash("/src/pkg/gen/queue.go")},
+		{dir, `gen\queue.go`, filepath.FromSlash("/src/pkg/gen/queue.go")},
+		{dir, "../other/./queue.go", filepath.FromSlash("/src/other/queue.go")},
+		{"", "gen/queue.go", filepath.FromSlash("gen/queue.go")},
+		{"src/pkg", "./queue.go", filepath.FromSlash("src/pkg/queue.go")},
+	} {
+		assert.Equal(t, test.expected, paths.Resolve(test.dir, test.p), test.p)
+	}
+
+	abs, err := filepath.Abs("queue.go")
+	assert.NoError(t, err)
+	assert.Equal(t, abs, paths.Resolve(dir, abs))
+}
+
+func TestReadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-paths")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sub", "queue.go"), []byte("package sub\n"), 0644))
+
+	b, err := paths.ReadFile("template", dir, "sub/queue.go")
+	assert.NoError(t, err)
+	assert.Equal(t, "package sub\n", string(b))
+
+	_, err = paths.ReadFile("template", dir, "sub/missing.go")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "template sub/missing.go (resolved to "+filepath.Join(dir, "sub", "missing.go")+")")
+	}
+}
+
+func TestCheckOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-paths")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	template := filepath.Join(dir, "queue.go")
+
+	assert.NoError(t, paths.CheckOutput(template, filepath.Join(dir, "gen_queue.go"), false, nil))
+	assert.NoError(t, paths.CheckOutput("", filepath.Join(dir, "gen_queue.go"), false, nil))
+	// outputs need not be Go files, e.g. for templates of other languages
+	assert.NoError(t, paths.CheckOutput(template, filepath.Join(dir, "queue.txt"), false, nil))
+	for _, out := range []string{
+		"",
+		template,
+		filepath.Join(dir, ".", "queue.go"),
+	} {
+		assert.Error(t, paths.CheckOutput(template, out, false, nil), out)
+	}
+
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "gen.go"), 0755))
+	err = paths.CheckOutput(template, filepath.Join(dir, "gen.go"), true, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "is a directory")
+	}
+}
+
+func TestCheckOutputTemplates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-paths")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	template := filepath.Join(dir, "queue.go")
+	other := filepath.Join(dir, "list.go")
+	generated := filepath.Join(dir, "gen_queue.go")
+	assert.NoError(t, ioutil.WriteFile(template, []byte("package p\n\ntype Something generic.Type\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(other, []byte("package p\n\ntype (\n\tKey generic.Type\n\tValue generic.Number\n)\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(generated, []byte("package p\n\n// uses generic.Type in a comment\ntype IntQueue []int\n"), 0644))
+
+	for _, out := range []string{template, other} {
+		err := paths.CheckOutput(template, out, false, nil)
+		if assert.Error(t, err, out) {
+			assert.True(t, paths.IsOverwrite(err))
+			assert.Contains(t, err.Error(), "would overwrite")
+		}
+		assert.NoError(t, paths.CheckOutput(template, out, true, nil), out)
+	}
+	assert.NoError(t, paths.CheckOutput(template, generated, false, nil))
+	assert.False(t, paths.IsOverwrite(paths.CheckOutput(template, filepath.Join(dir, "gen.go"), false, nil)))
+}
+
+func TestCheckOutputGenericPackages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "genny-paths")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	named := filepath.Join(dir, "named.go")
+	imported := filepath.Join(dir, "imported.go")
+	assert.NoError(t, ioutil.WriteFile(named, []byte("package p\n\ntype Item genny.Type\n"), 0644))
+	assert.NoError(t, ioutil.WriteFile(imported, []byte("package p\n\nimport tpl \"example.com/lib/generic2\"\n\ntype Item tpl.Number\n"), 0644))
+
+	// templates of other generic packages are only known as templates when
+	// the packages are given, by name or by import path
+	for _, out := range []string{named, imported} {
+		assert.NoError(t, paths.CheckOutput("", out, false, nil), out)
+		err := paths.CheckOutput("", out, false, []string{"genny", "example.com/lib/generic2"})
+		if assert.Error(t, err, out) {
+			assert.True(t, paths.IsOverwrite(err))
+		}
+	}
+	assert.True(t, paths.IsTemplate([]byte("package p\n\ntype Item generic.Type\n"), nil))
+}
@@ -0,0 +1,146 @@
+// Package proptest generates random genny templates and checks that the
+// substitution engine turns them into valid Go.
+//
+// It backs the property tests and fuzz targets of genny itself, and forks
+// of genny can use it to check changes to the engine:
+//
+//	func TestEngine(t *testing.T) {
+//	    if err := proptest.Run(time.Now().UnixNano(), 500); err != nil {
+//	        t.Fatal(err)
+//	    }
+//	}
+//
+// A failure reports the seed that produced it, so it can be reproduced with
+// proptest.Run(seed, 1).
+package proptest
+
+import (
+	"bytes"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/cheekybits/genny/parse"
+)
+
+// Placeholders are the generic types that templates are generated with.
+var Placeholders = []string{"Item", "Key", "Value"}
+
+// Types are the specific types that placeholders are replaced with.
+var Types = append(append([]string{}, parse.Builtins...),
+	"*int",
+	"*string",
+	"time.Duration",
+	"*bytes.Buffer",
+	"interface{}",
+)
+
+// fragments are the declarations templates are made of. $P and $Q are
+// replaced with placeholders and $N with a number that keeps the names
+// unique.
+var fragments = []string{
+	"// $PBox$N holds a $P.\ntype $PBox$N struct {\n\tvalue $P\n}\n",
+	"func New$PBox$N(v $P) *$PBox$N {\n\treturn &$PBox$N{value: v}\n}\n\ntype $PBox$N struct {\n\tvalue $P\n}\n",
+	"type $PList$N []$P\n\nfunc (l $PList$N) Len() int {\n\treturn len(l)\n}\n",
+	"func $PZero$N() $P {\n\tvar zero $P\n\treturn zero\n}\n",
+	"func To$P$N(v $P) $P {\n\treturn $P(v)\n}\n",
+	"func Assert$P$N(v interface{}) ($P, bool) {\n\tp, ok := v.($P)\n\treturn p, ok\n}\n",
+	"var $PCount$N int\n",
+	"func $PSlice$N(vs ...$P) []$P {\n\treturn vs\n}\n",
+	"func $PChan$N() chan $P {\n\treturn make(chan $P)\n}\n",
+	"type $PTo$Q$N map[string]func($P) $Q\n",
+	"func $PFunc$N(fn func($P) ($Q, error)) {\n\t_ = fn\n}\n",
+	"// Each$P$N calls fn for every $P.\nfunc Each$P$N(ps []$P, fn func($P)) {\n\tfor _, p := range ps {\n\t\tfn(p)\n\t}\n}\n",
+	"type $PPair$N struct {\n\tA $P // the first $P\n\tB $Q\n}\n",
+}
+
+// Template generates a random template and a type set for it.
+func Template(r *rand.Rand) ([]byte, map[string]string) {
+	count := 1 + r.Intn(len(Placeholders))
+	placeholders := Placeholders[:count]
+
+	var buf bytes.Buffer
+	buf.WriteString("package template\n\nimport \"github.com/cheekybits/genny/generic\"\n\n")
+	typeSet := make(map[string]string)
+	for _, p := range placeholders {
+		fmt.Fprintf(&buf, "type %s generic.Type\n\n", p)
+		typeSet[p] = Types[r.Intn(len(Types))]
+	}
+	decls := 1 + r.Intn(8)
+	for n := 0; n < decls; n++ {
+		p := placeholders[r.Intn(count)]
+		q := placeholders[r.Intn(count)]
+		fragment := fragments[r.Intn(len(fragments))]
+		fragment = strings.NewReplacer("$P", p, "$Q", q, "$N", fmt.Sprint(n)).Replace(fragment)
+		buf.WriteString(fragment + "\n")
+	}
+	return buf.Bytes(), typeSet
+}
+
+// Check generates the template with the type set and checks that the
+// output is valid Go without any of the placeholders left in it.
+func Check(template []byte, typeSet map[string]string) error {
+	output, err := parse.Generics("template.go", "output.go", "", bytes.NewReader(template), []map[string]string{typeSet})
+	if err != nil {
+		return err
+	}
+
+	var placeholders []string
+	for p := range typeSet {
+		placeholders = append(placeholders, p)
+	}
+	sort.Strings(placeholders)
+
+	var s scanner.Scanner
+	fs := token.NewFileSet()
+	var scanErr error
+	s.Init(fs.AddFile("output.go", fs.Base(), len(output)), output, func(pos token.Position, msg string) {
+		if scanErr == nil {
+			scanErr = fmt.Errorf("%s: %s", pos, msg)
+		}
+	}, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.IDENT {
+			continue
+		}
+		for _, p := range placeholders {
+			if strings.Contains(lit, p) {
+				return fmt.Errorf("%s: placeholder %s left in %s", fs.Position(pos), p, lit)
+			}
+		}
+	}
+	return scanErr
+}
+
+// Failure is a template the engine failed to generate valid code for.
+type Failure struct {
+	// Seed reproduces the failure with Run(Seed, 1).
+	Seed     int64
+	Template []byte
+	TypeSet  map[string]string
+	Err      error
+}
+
+// Error gets a human readable string describing this failure.
+func (f *Failure) Error() string {
+	return fmt.Sprintf("seed %d: %v\ntype set: %v\ntemplate:\n%s", f.Seed, f.Err, f.TypeSet, f.Template)
+}
+
+// Run checks n random templates, the first generated from seed and each
+// following one from the next seed, and returns the first *Failure.
+func Run(seed int64, n int) error {
+	for i := int64(0); i < int64(n); i++ {
+		template, typeSet := Template(rand.New(rand.NewSource(seed + i)))
+		if err := Check(template, typeSet); err != nil {
+			return &Failure{Seed: seed + i, Template: template, TypeSet: typeSet, Err: err}
+		}
+	}
+	return nil
+}
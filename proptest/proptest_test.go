@@ -0,0 +1,51 @@
+package proptest_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/cheekybits/genny/proptest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	if err := proptest.Run(1, 200); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTemplateIsSeeded(t *testing.T) {
+	a, aTypes := proptest.Template(rand.New(rand.NewSource(42)))
+	b, bTypes := proptest.Template(rand.New(rand.NewSource(42)))
+	assert.Equal(t, string(a), string(b))
+	assert.Equal(t, aTypes, bTypes)
+}
+
+func TestCheckReportsLeftoverPlaceholders(t *testing.T) {
+	template := []byte(`package template
+
+import "github.com/cheekybits/genny/generic"
+
+type Item generic.Type
+
+type Box struct {
+	value Item
+}
+`)
+	err := proptest.Check(template, map[string]string{"Item": "ItemHolder"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "placeholder Item left in ItemHolder")
+	}
+	assert.NoError(t, proptest.Check(template, map[string]string{"Item": "int"}))
+}
+
+func FuzzGenerics(f *testing.F) {
+	for _, seed := range []int64{0, 1, 2, 3, 4} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, seed int64) {
+		if err := proptest.Run(seed, 1); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,152 @@
+// Package report writes a local usage report of generation: a record of
+// each template genny generates, with how long it took and whether it
+// succeeded, appended to a file. Nothing is sent anywhere; the report is
+// for build engineers to find the hot spots of generation across a
+// repository. Timings breaks a single run down further, by the phases of
+// each generation, as a JSON document.
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cheekybits/genny/out"
+	"github.com/cheekybits/genny/parse"
+)
+
+// Record is a generation in the report.
+type Record struct {
+	Time     time.Time `json:"time"`
+	Template string    `json:"template"`
+	Out      string    `json:"out,omitempty"`
+	TypeSets int       `json:"typeSets"`
+	Duration float64   `json:"durationMs"`
+	// Outcome is "ok" or "error".
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// csvHeader is the first line of a CSV report.
+var csvHeader = []string{"time", "template", "out", "type_sets", "duration_ms", "outcome", "error"}
+
+// Writer is a parse.Tracer that appends a record of each generation to a
+// file: as CSV if its name ends in .csv, and as JSON lines otherwise. Each
+// record is appended on its own, so the processes of a go generate run can
+// share a report.
+type Writer struct {
+	Filename string
+
+	mu  sync.Mutex
+	err error
+}
+
+// New makes a Writer appending to the file.
+func New(filename string) *Writer {
+	return &Writer{Filename: filename}
+}
+
+// StartSpan starts recording a generation. Only the generation as a whole
+// is recorded, not its phases.
+func (w *Writer) StartSpan(name string, attrs map[string]string) parse.Span {
+	if name != parse.SpanGenerate {
+		return span{}
+	}
+	typeSets, _ := strconv.Atoi(attrs["genny.typesets"])
+	return span{w: w, start: time.Now(), record: Record{
+		Template: attrs["genny.filename"],
+		Out:      attrs["genny.output"],
+		TypeSets: typeSets,
+	}}
+}
+
+// Err gets the first error writing the report, if any.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// write appends the record to the report.
+func (w *Writer) write(r Record) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.append(r); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// append writes the record at the end of the file, in its format, holding
+// the file's lock so that processes reporting at once do not interleave
+// their records or each write the CSV header.
+func (w *Writer) append(r Record) error {
+	unlock, err := out.Lock(w.Filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	f, err := os.OpenFile(w.Filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if !strings.EqualFold(filepath.Ext(w.Filename), ".csv") {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(append(line, '\n'))
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(f)
+	if info.Size() == 0 {
+		cw.Write(csvHeader)
+	}
+	cw.Write([]string{
+		r.Time.Format(time.RFC3339),
+		r.Template,
+		r.Out,
+		strconv.Itoa(r.TypeSets),
+		strconv.FormatFloat(r.Duration, 'f', 3, 64),
+		r.Outcome,
+		r.Error,
+	})
+	cw.Flush()
+	return cw.Error()
+}
+
+// span records a generation when it ends.
+type span struct {
+	w      *Writer
+	start  time.Time
+	record Record
+}
+
+// StartSpan starts a phase of the generation, which is not recorded.
+func (s span) StartSpan(string, map[string]string) parse.Span {
+	return span{}
+}
+
+// End records the generation, if this span is one.
+func (s span) End(err error) {
+	if s.w == nil {
+		return
+	}
+	r := s.record
+	r.Time = s.start.UTC().Truncate(time.Second)
+	r.Duration = float64(time.Since(s.start).Microseconds()) / 1000
+	r.Outcome = "ok"
+	if err != nil {
+		r.Outcome, r.Error = "error", err.Error()
+	}
+	s.w.write(r)
+}
@@ -0,0 +1,80 @@
+package report_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/cheekybits/genny/report"
+	"github.com/stretchr/testify/assert"
+)
+
+const template = `package queue
+
+import "github.com/cheekybits/genny/generic"
+
+type Something generic.Type
+
+type SomethingQueue struct {
+	items []Something
+}
+`
+
+func generate(w *report.Writer, types string) {
+	typeSets, _ := parse.TypeSet(types)
+	parse.GenericsWithOptions("generic_queue.go", "gen_queue.go", "", strings.NewReader(template), typeSets, parse.Options{Tracer: w})
+}
+
+func TestJSONReport(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "genny.jsonl")
+	w := report.New(filename)
+	generate(w, "Something=int,string")
+	generate(w, "Other=int")
+	assert.NoError(t, w.Err())
+
+	f, err := os.Open(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	var records []report.Record
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r report.Record
+		assert.NoError(t, json.Unmarshal(sc.Bytes(), &r))
+		records = append(records, r)
+	}
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, "generic_queue.go", records[0].Template)
+		assert.Equal(t, "gen_queue.go", records[0].Out)
+		assert.Equal(t, 2, records[0].TypeSets)
+		assert.Equal(t, "ok", records[0].Outcome)
+		assert.False(t, records[0].Time.IsZero())
+		assert.Equal(t, "error", records[1].Outcome)
+		assert.NotEmpty(t, records[1].Error)
+	}
+}
+
+func TestCSVReport(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "genny.csv")
+	generate(report.New(filename), "Something=int")
+	// a second run appends without another header
+	generate(report.New(filename), "Something=string")
+
+	f, err := os.Open(filename)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if assert.NoError(t, err) && assert.Len(t, rows, 3) {
+		assert.Equal(t, []string{"time", "template", "out", "type_sets", "duration_ms", "outcome", "error"}, rows[0])
+		assert.Equal(t, []string{"generic_queue.go", "gen_queue.go", "1"}, rows[1][1:4])
+		assert.Equal(t, "ok", rows[2][5])
+	}
+}
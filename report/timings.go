@@ -0,0 +1,179 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cheekybits/genny/parse"
+)
+
+// Run is the timing breakdown of a run of genny, in milliseconds, with the
+// memory it allocated.
+type Run struct {
+	Duration    float64      `json:"durationMs"`
+	AllocBytes  uint64       `json:"allocBytes"`
+	GCs         uint32       `json:"gcs"`
+	Generations []Generation `json:"generations"`
+}
+
+// Generation is the timing breakdown of generating a template, with the
+// time spent in each phase. Parse and Substitute are the totals of its
+// type sets, which are sorted by type set, as several workers generate
+// them in no particular order.
+type Generation struct {
+	Template   string           `json:"template"`
+	Out        string           `json:"out,omitempty"`
+	Duration   float64          `json:"durationMs"`
+	Parse      float64          `json:"parseMs"`
+	Substitute float64          `json:"substituteMs"`
+	Format     float64          `json:"formatMs"`
+	Write      float64          `json:"writeMs"`
+	TypeSets   []TypeSetTimings `json:"typeSets"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// TypeSetTimings is the time spent on a type set of a generation.
+type TypeSetTimings struct {
+	TypeSet    string  `json:"typeSet"`
+	Parse      float64 `json:"parseMs"`
+	Substitute float64 `json:"substituteMs"`
+}
+
+// Timings is a parse.Tracer that records how long each phase of each
+// generation of a run takes, per template and type set, for tracking the
+// performance of generation over time. Writing the output is recorded from
+// the parse.SpanWrite spans callers start for it, matched to the
+// generation by their genny.output attribute. It is safe for concurrent
+// use.
+type Timings struct {
+	mu          sync.Mutex
+	start       time.Time
+	startMem    runtime.MemStats
+	generations []*Generation
+}
+
+// NewTimings makes Timings for a run starting now.
+func NewTimings() *Timings {
+	t := &Timings{start: time.Now()}
+	runtime.ReadMemStats(&t.startMem)
+	return t
+}
+
+// StartSpan starts recording a generation, or the writing of its output.
+func (t *Timings) StartSpan(name string, attrs map[string]string) parse.Span {
+	switch name {
+	case parse.SpanGenerate:
+		g := &Generation{Template: attrs["genny.filename"], Out: attrs["genny.output"], TypeSets: []TypeSetTimings{}}
+		t.mu.Lock()
+		t.generations = append(t.generations, g)
+		t.mu.Unlock()
+		return &timingSpan{t: t, g: g, name: name, start: time.Now()}
+	case parse.SpanWrite:
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for i := len(t.generations) - 1; i >= 0; i-- {
+			if g := t.generations[i]; g.Out == attrs["genny.output"] {
+				return &timingSpan{t: t, g: g, name: name, start: time.Now()}
+			}
+		}
+	}
+	return &timingSpan{}
+}
+
+// Run gets the timings recorded so far.
+func (t *Timings) Run() Run {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := Run{
+		Duration:    milliseconds(time.Since(t.start)),
+		AllocBytes:  mem.TotalAlloc - t.startMem.TotalAlloc,
+		GCs:         mem.NumGC - t.startMem.NumGC,
+		Generations: []Generation{},
+	}
+	for _, g := range t.generations {
+		c := *g
+		c.TypeSets = append([]TypeSetTimings{}, g.TypeSets...)
+		sort.Slice(c.TypeSets, func(i, j int) bool { return c.TypeSets[i].TypeSet < c.TypeSets[j].TypeSet })
+		r.Generations = append(r.Generations, c)
+	}
+	return r
+}
+
+// WriteJSON writes the timings recorded so far as an indented JSON
+// document.
+func (t *Timings) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(t.Run(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// timingSpan adds the time a phase took to its generation when it ends. A
+// span without Timings records nothing.
+type timingSpan struct {
+	t       *Timings
+	g       *Generation
+	typeSet string
+	name    string
+	start   time.Time
+}
+
+// StartSpan starts a phase of the generation.
+func (s *timingSpan) StartSpan(name string, attrs map[string]string) parse.Span {
+	if s.t == nil {
+		return s
+	}
+	return &timingSpan{t: s.t, g: s.g, typeSet: attrs["genny.typeset"], name: name, start: time.Now()}
+}
+
+// End records the phase.
+func (s *timingSpan) End(err error) {
+	if s.t == nil {
+		return
+	}
+	d := milliseconds(time.Since(s.start))
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	switch s.name {
+	case parse.SpanGenerate:
+		s.g.Duration = d
+		if err != nil {
+			s.g.Error = err.Error()
+		}
+	case parse.SpanParse:
+		s.g.Parse += d
+		s.typeSetTimings().Parse += d
+	case parse.SpanSubstitute:
+		s.g.Substitute += d
+		s.typeSetTimings().Substitute += d
+	case parse.SpanFormat:
+		s.g.Format += d
+	case parse.SpanWrite:
+		s.g.Write += d
+	}
+}
+
+// typeSetTimings gets the timings of the span's type set, adding them to
+// the generation if they are not there yet.
+func (s *timingSpan) typeSetTimings() *TypeSetTimings {
+	for i := range s.g.TypeSets {
+		if s.g.TypeSets[i].TypeSet == s.typeSet {
+			return &s.g.TypeSets[i]
+		}
+	}
+	s.g.TypeSets = append(s.g.TypeSets, TypeSetTimings{TypeSet: s.typeSet})
+	return &s.g.TypeSets[len(s.g.TypeSets)-1]
+}
+
+// milliseconds gets the duration in milliseconds, to the microsecond.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,74 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/cheekybits/genny/parse"
+	"github.com/cheekybits/genny/report"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimings(t *testing.T) {
+	tm := report.NewTimings()
+	typeSets, err := parse.TypeSet("Something=int,string")
+	require.NoError(t, err)
+	_, err = parse.GenericsWithOptions("generic_queue.go", "gen_queue.go", "", strings.NewReader(template), typeSets, parse.Options{Tracer: tm})
+	require.NoError(t, err)
+	tm.StartSpan(parse.SpanWrite, map[string]string{"genny.output": "gen_queue.go"}).End(nil)
+	_, err = parse.GenericsWithOptions("generic_queue.go", "", "", strings.NewReader(template), []map[string]string{{"Other": "int"}}, parse.Options{Tracer: tm, Strict: true})
+	require.Error(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, tm.WriteJSON(&buf))
+	var run report.Run
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &run))
+	require.Len(t, run.Generations, 2)
+
+	g := run.Generations[0]
+	assert.Equal(t, "generic_queue.go", g.Template)
+	assert.Equal(t, "gen_queue.go", g.Out)
+	assert.Empty(t, g.Error)
+	if assert.Len(t, g.TypeSets, 2) {
+		assert.Equal(t, "Something=int", g.TypeSets[0].TypeSet)
+		assert.Equal(t, "Something=string", g.TypeSets[1].TypeSet)
+	}
+	assert.True(t, g.Duration >= g.Parse+g.Substitute+g.Format)
+	assert.NotEmpty(t, run.Generations[1].Error)
+	assert.True(t, run.Duration >= g.Duration)
+}
+
+func TestTimingsWorkers(t *testing.T) {
+	// the type sets are in the same order however many workers generate
+	// them
+	typeSets, err := parse.TypeSet("Something=string,int,bool,float64,byte,rune,uint,int64")
+	require.NoError(t, err)
+	var expected []string
+	for _, workers := range []int{1, 8} {
+		tm := report.NewTimings()
+		_, err := parse.GenericsWithOptions("generic_queue.go", "", "", strings.NewReader(template), typeSets, parse.Options{Tracer: tm, Workers: workers})
+		require.NoError(t, err)
+		var got []string
+		for _, ts := range tm.Run().Generations[0].TypeSets {
+			got = append(got, ts.TypeSet)
+		}
+		if expected == nil {
+			expected = got
+		}
+		assert.Equal(t, expected, got, "with %d workers", workers)
+	}
+	assert.Equal(t, "Something=bool", expected[0])
+}
+
+func TestTimingsWithReport(t *testing.T) {
+	tm := report.NewTimings()
+	w := report.New(t.TempDir() + "/genny.jsonl")
+	typeSets, _ := parse.TypeSet("Something=int")
+	_, err := parse.GenericsWithOptions("generic_queue.go", "gen_queue.go", "", strings.NewReader(template), typeSets, parse.Options{Tracer: parse.Tracers(w, nil, tm)})
+	require.NoError(t, err)
+	assert.NoError(t, w.Err())
+	assert.Len(t, tm.Run().Generations, 1)
+}